@@ -3,15 +3,49 @@ package blob
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash/crc32"
 
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 )
 
+const (
+	// FormatVersion is the latest version of the blob format.
+	// Version 1 stores LogEntry in the record body, optionally wrapped in
+	// google.protobuf.Any (see WrapAny).
+	// Version 2 prefixes the version 1 body with the version byte and the
+	// CRC32C (little-endian) of the version 1 body. The version byte never
+	// starts an encoded message as field number 0 is invalid.
+	// Readers supporting version 2 also read version 1, but not vice versa.
+	FormatVersion = 2
+	// DefaultFormatVersion is the version written by default. It stays 1 so
+	// the files remain readable by the deployed readers not supporting
+	// version 2, and version 2 must be opted in explicitly.
+	DefaultFormatVersion = 1
+
+	// anyTag is the first byte of an encoded google.protobuf.Any (field 1, length-delimited).
+	// It never starts an encoded LogEntry as field 1 (nano_ts) is a varint.
+	anyTag = 0x0a
+
+	// checksumVersion is the first version with checksums.
+	checksumVersion = 2
+	// checksumHeaderSize is the size of the version byte and the CRC32C in version 2.
+	checksumHeaderSize = 5
+)
+
 var (
 	// ErrBadRecord indicates a record contains invalid or inconsistent data.
 	ErrBadRecord = errors.New("bad record")
+	// ErrUnknownType indicates an Any-wrapped record body isn't a LogEntry.
+	ErrUnknownType = errors.New("unknown type")
+
+	// LogEntryTypeURL is the type URL of LogEntry wrapped in google.protobuf.Any.
+	LogEntryTypeURL = "type.googleapis.com/" + string((&logspb.LogEntry{}).ProtoReflect().Descriptor().FullName())
+
+	crc32cTable = crc32.MakeTable(crc32.Castagnoli)
 )
 
 // RawRecord is a single record in the file.
@@ -21,21 +55,114 @@ type RawRecord struct {
 	Tail []byte
 }
 
-// RawRecordSize estimate RawRecord size after entry is encoded.
+// RawRecordSize estimate RawRecord size after entry is encoded in DefaultFormatVersion.
 func RawRecordSize(entry *logspb.LogEntry) int {
+	return RawRecordSizeVersion(entry, DefaultFormatVersion)
+}
+
+// RawRecordSizeVersion estimate RawRecord size after entry is encoded in the
+// specified format version.
+func RawRecordSizeVersion(entry *logspb.LogEntry, version int) int {
 	bodySize := proto.Size(entry)
+	if version >= checksumVersion {
+		bodySize += checksumHeaderSize
+	}
 	if rest := bodySize & 3; rest != 0 {
 		bodySize += 4 - rest
 	}
 	return bodySize + 8
 }
 
-// EncodeToRawRecord encodes an entry to a RawRecord.
+// EncodeToRawRecord encodes an entry to a RawRecord in DefaultFormatVersion.
 func EncodeToRawRecord(entry *logspb.LogEntry) (*RawRecord, error) {
+	return EncodeToRawRecordVersion(entry, DefaultFormatVersion, false)
+}
+
+// EncodeToRawRecordAny encodes an entry wrapped in google.protobuf.Any to a
+// RawRecord in DefaultFormatVersion.
+func EncodeToRawRecordAny(entry *logspb.LogEntry) (*RawRecord, error) {
+	return EncodeToRawRecordVersion(entry, DefaultFormatVersion, true)
+}
+
+// EncodeToRawRecordVersion encodes an entry to a RawRecord in the specified
+// format version, optionally wrapped in google.protobuf.Any.
+func EncodeToRawRecordVersion(entry *logspb.LogEntry, version int, wrapAny bool) (*RawRecord, error) {
+	data, err := EncodeBody(entry, version, wrapAny)
+	if err != nil {
+		return nil, err
+	}
+	return rawRecordFromBody(data), nil
+}
+
+// EncodeBody encodes an entry to a record body in the specified format version,
+// optionally wrapped in google.protobuf.Any. It's the reverse of DecodeBody.
+func EncodeBody(entry *logspb.LogEntry, version int, wrapAny bool) ([]byte, error) {
+	var msg proto.Message = entry
+	if wrapAny {
+		var err error
+		if msg, err = WrapAny(entry); err != nil {
+			return nil, err
+		}
+	}
+	switch version {
+	case 1:
+		return proto.Marshal(msg)
+	case checksumVersion:
+		data, err := proto.MarshalOptions{}.MarshalAppend(make([]byte, checksumHeaderSize), msg)
+		if err != nil {
+			return nil, err
+		}
+		data[0] = byte(version)
+		binary.LittleEndian.PutUint32(data[1:], crc32.Checksum(data[checksumHeaderSize:], crc32cTable))
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported format version %d", version)
+	}
+}
+
+// WrapAny wraps a LogEntry in google.protobuf.Any for consumers identifying
+// message types by type URLs, e.g. with schema registries.
+func WrapAny(entry *logspb.LogEntry) (*anypb.Any, error) {
 	data, err := proto.Marshal(entry)
 	if err != nil {
 		return nil, err
 	}
+	return &anypb.Any{TypeUrl: LogEntryTypeURL, Value: data}, nil
+}
+
+// DecodeBody decodes a record body which is either an encoded LogEntry or
+// an encoded google.protobuf.Any wrapping a LogEntry, optionally prefixed by
+// the version byte and the CRC32C in version 2.
+// ErrBadRecord is returned if the checksum doesn't match.
+func DecodeBody(data []byte) (*logspb.LogEntry, error) {
+	if len(data) > 0 && data[0] == checksumVersion {
+		if len(data) < checksumHeaderSize {
+			return nil, fmt.Errorf("body size %d too small for checksum: %w", len(data), ErrBadRecord)
+		}
+		expected := binary.LittleEndian.Uint32(data[1:])
+		data = data[checksumHeaderSize:]
+		if actual := crc32.Checksum(data, crc32cTable); actual != expected {
+			return nil, fmt.Errorf("checksum %08x not match %08x: %w", actual, expected, ErrBadRecord)
+		}
+	}
+	if len(data) > 0 && data[0] == anyTag {
+		var msg anypb.Any
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		if msg.GetTypeUrl() != LogEntryTypeURL {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, msg.GetTypeUrl())
+		}
+		data = msg.GetValue()
+	}
+	var entry logspb.LogEntry
+	if err := proto.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func rawRecordFromBody(data []byte) *RawRecord {
 	bodySize := len(data)
 	rec := &RawRecord{Head: make([]byte, 4), Body: data}
 	binary.LittleEndian.PutUint32(rec.Head, uint32(bodySize))
@@ -46,5 +173,5 @@ func EncodeToRawRecord(entry *logspb.LogEntry) (*RawRecord, error) {
 	} else {
 		rec.Tail = rec.Head
 	}
-	return rec, nil
+	return rec
 }
@@ -0,0 +1,108 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestRecordChecksum(t *testing.T) {
+	entries := gzipTestEntries(3)
+	testCases := []struct {
+		name    string
+		version int
+		wrapAny bool
+		// corrupt is the offset in the body of the second record to flip, negative for intact.
+		corrupt int
+		failed  bool
+	}{
+		{name: "intact", version: 2, corrupt: -1},
+		{name: "corrupt payload", version: 2, corrupt: checksumHeaderSize + 10, failed: true},
+		{name: "corrupt checksum", version: 2, corrupt: 1, failed: true},
+		{name: "corrupt any payload", version: 2, wrapAny: true, corrupt: checksumHeaderSize + 60, failed: true},
+		{name: "version 1", version: 1, corrupt: -1},
+		{name: "version 1 any", version: 1, wrapAny: true, corrupt: -1},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for i, entry := range entries {
+				rec, err := EncodeToRawRecordVersion(entry, tc.version, tc.wrapAny)
+				if err != nil {
+					t.Fatalf("EncodeToRawRecordVersion: %v", err)
+				}
+				if i == 1 && tc.corrupt >= 0 {
+					rec.Body = append([]byte{}, rec.Body...)
+					rec.Body[tc.corrupt] ^= 0x10
+				}
+				buf.Write(rec.Head)
+				buf.Write(rec.Body)
+				buf.Write(rec.Tail)
+			}
+			r := &Reader{R: &buf}
+			var read []*logspb.LogEntry
+			var err error
+			for {
+				var entry *logspb.LogEntry
+				if entry, err = r.Read(); err != nil {
+					break
+				}
+				read = append(read, entry)
+			}
+			if tc.failed {
+				if !errors.Is(err, ErrBadRecord) {
+					t.Fatalf("Expect ErrBadRecord, got %v", err)
+				}
+				if len(read) != 1 || !proto.Equal(read[0], entries[0]) {
+					t.Errorf("Expect only the first entry read before the corrupted one, got %d", len(read))
+				}
+				return
+			}
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Expect EOF, got %v", err)
+			}
+			if len(read) != len(entries) {
+				t.Fatalf("Expect %d entries, got %d", len(entries), len(read))
+			}
+			for i := range entries {
+				if !proto.Equal(read[i], entries[i]) {
+					t.Errorf("Entry %d: expect %v, got %v", i, entries[i], read[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriterVersion(t *testing.T) {
+	entry := gzipTestEntries(1)[0]
+	for _, version := range []int{0, 1, 2} {
+		var buf bytes.Buffer
+		w := &Writer{W: &buf, Version: version}
+		if err := w.WriteLogEntry(entry); err != nil {
+			t.Fatalf("Version %d: WriteLogEntry: %v", version, err)
+		}
+		if version == 0 {
+			version = DefaultFormatVersion
+		}
+		// The first byte of the body is the version byte or the tag of nano_ts.
+		expected := byte(version)
+		if version == 1 {
+			expected = 0x08
+		}
+		if b := buf.Bytes()[4]; b != expected {
+			t.Errorf("Version %d: expect body starting with %02x, got %02x", version, expected, b)
+		}
+		if read, err := (&Reader{R: &buf}).Read(); err != nil || !proto.Equal(read, entry) {
+			t.Errorf("Version %d: Read got %v, %v", version, read, err)
+		}
+	}
+	if _, err := EncodeToRawRecordVersion(entry, 3, false); err == nil {
+		t.Errorf("Expect error on unsupported version")
+	}
+}
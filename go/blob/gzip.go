@@ -0,0 +1,72 @@
+package blob
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// SizedWriter is a writer reporting the size written to the underlying storage,
+// which may differ from the size of data written, e.g. when compressed.
+type SizedWriter interface {
+	io.Writer
+	Size() int64
+}
+
+// GzipWriter compresses the data written to W with gzip.
+type GzipWriter struct {
+	W io.Writer
+
+	gz   *gzip.Writer
+	size int64
+}
+
+type countingWriter struct {
+	w    io.Writer
+	size *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	*w.size += int64(n)
+	return n, err
+}
+
+// NewGzipWriter creates a GzipWriter.
+func NewGzipWriter(w io.Writer) *GzipWriter {
+	gw := &GzipWriter{W: w}
+	gw.gz = gzip.NewWriter(&countingWriter{w: w, size: &gw.size})
+	return gw
+}
+
+// Write implements io.Writer.
+func (w *GzipWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+// Size implements SizedWriter and returns the compressed size written to W,
+// excluding the data buffered in the compressor.
+func (w *GzipWriter) Size() int64 {
+	return w.size
+}
+
+// Sync implements Syncable. It flushes the compressed data and syncs W if supported.
+func (w *GzipWriter) Sync() error {
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	if s, ok := w.W.(Syncable); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close implements io.Closer. It completes the gzip stream and closes W if supported.
+func (w *GzipWriter) Close() error {
+	err := w.gz.Close()
+	if closer, ok := w.W.(io.Closer); ok {
+		if e := closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package blob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func gzipTestEntries(n int) []*logspb.LogEntry {
+	entries := make([]*logspb.LogEntry, n)
+	for i := range entries {
+		entries[i] = &logspb.LogEntry{
+			NanoTs:   int64(i + 1),
+			Level:    logspb.LogEntry_INFO,
+			Location: "gzip_test.go:1",
+			Message:  fmt.Sprintf("message %d: %s", i, strings.Repeat("payload ", 32)),
+		}
+	}
+	return entries
+}
+
+func TestGzipWriter(t *testing.T) {
+	entries := gzipTestEntries(100)
+	var plain, compressed bytes.Buffer
+	pw, gw := &Writer{W: &plain}, &Writer{W: NewGzipWriter(&compressed)}
+	// Write in two gzip streams to cover reopening a file for appending.
+	for i, entry := range entries {
+		if i == len(entries)/2 {
+			if err := gw.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			gw = &Writer{W: NewGzipWriter(&compressed)}
+		}
+		if err := pw.WriteLogEntry(entry); err != nil {
+			t.Fatalf("WriteLogEntry plain: %v", err)
+		}
+		if err := gw.WriteLogEntry(entry); err != nil {
+			t.Fatalf("WriteLogEntry compressed: %v", err)
+		}
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	data, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if !bytes.Equal(data, plain.Bytes()) {
+		t.Errorf("decompressed %d bytes not match uncompressed %d bytes", len(data), plain.Len())
+	}
+
+	gr, err = gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	r := &Reader{R: gr}
+	for i, entry := range entries {
+		decoded, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read entry %d: %v", i, err)
+		}
+		if !proto.Equal(decoded, entry) {
+			t.Errorf("entry %d: decoded %v, expect %v", i, decoded, entry)
+		}
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read after last entry: %v, expect EOF", err)
+	}
+}
+
+func TestGzipWriterSizeLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	gzw := NewGzipWriter(&compressed)
+	w := &Writer{W: gzw, Sync: true, SizeLimit: 1024}
+	var written int
+	for _, entry := range gzipTestEntries(1000) {
+		err := w.WriteLogEntry(entry)
+		if errors.Is(err, ErrSizeLimitExceeded) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("WriteLogEntry: %v", err)
+		}
+		written++
+	}
+	if written == 0 || written == 1000 {
+		t.Fatalf("written %d entries, expect limited by compressed size", written)
+	}
+	if w.WrittenSize <= w.SizeLimit {
+		t.Errorf("uncompressed size %d not exceeding limit %d", w.WrittenSize, w.SizeLimit)
+	}
+	if size := gzw.Size(); size != int64(compressed.Len()) {
+		t.Errorf("Size %d not match compressed size %d", size, compressed.Len())
+	}
+}
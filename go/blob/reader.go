@@ -5,18 +5,36 @@ import (
 	"fmt"
 	"io"
 
-	"google.golang.org/protobuf/proto"
-
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 )
 
+const (
+	// maxResyncRecordSize limits the body size of a record when resyncing.
+	// Larger records are considered corrupted with SkipErrors.
+	maxResyncRecordSize = 1 << 24 // 16M
+)
+
 // Reader reads log entries.
 type Reader struct {
 	R io.Reader
+	// SkipErrors skips bad records (inconsistent sizes, checksum mismatches or
+	// undecodable bodies) by scanning forward to the next valid record instead
+	// of failing, e.g. to recover a partially written file after a crash.
+	// A truncated record at the end is skipped as well.
+	SkipErrors bool
+	// SkippedBytes is the number of bytes skipped with SkipErrors.
+	SkippedBytes int64
+
+	// buf is the data read ahead with SkipErrors.
+	buf []byte
+	eof bool
 }
 
 // Read reads one entry.
 func (r *Reader) Read() (*logspb.LogEntry, error) {
+	if r.SkipErrors {
+		return r.readAndResync()
+	}
 	buf := make([]byte, 4)
 	if _, err := io.ReadFull(r.R, buf); err != nil {
 		return nil, err
@@ -37,11 +55,59 @@ func (r *Reader) Read() (*logspb.LogEntry, error) {
 	if tailSize != size {
 		return nil, fmt.Errorf("tail size %d not match head size %d: %w", tailSize, size, ErrBadRecord)
 	}
-	var entry logspb.LogEntry
-	if err := proto.Unmarshal(buf[:size], &entry); err != nil {
-		return nil, err
+	return DecodeBody(buf[:size])
+}
+
+// readAndResync reads a record from the read-ahead data, and skips one byte
+// at a time until a valid record is found.
+func (r *Reader) readAndResync() (*logspb.LogEntry, error) {
+	for {
+		if err := r.fill(4); err != nil {
+			if err == io.EOF {
+				r.SkippedBytes += int64(len(r.buf))
+				r.buf = nil
+			}
+			return nil, err
+		}
+		if size := int32(binary.LittleEndian.Uint32(r.buf)); size > 0 && size <= maxResyncRecordSize {
+			paddedSize := int(size)
+			if rest := size & 3; rest != 0 {
+				paddedSize += 4 - int(rest)
+			}
+			recSize := paddedSize + 8
+			err := r.fill(recSize)
+			if err == nil && int32(binary.LittleEndian.Uint32(r.buf[recSize-4:])) == size {
+				if entry, err := DecodeBody(r.buf[4 : 4+size]); err == nil {
+					r.buf = r.buf[recSize:]
+					return entry, nil
+				}
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		r.buf = r.buf[1:]
+		r.SkippedBytes++
+	}
+}
+
+// fill reads ahead until at least n bytes are available, or returns io.EOF if
+// the stream ends before that.
+func (r *Reader) fill(n int) error {
+	if len(r.buf) >= n {
+		return nil
+	}
+	if r.eof {
+		return io.EOF
+	}
+	data := make([]byte, n-len(r.buf))
+	read, err := io.ReadFull(r.R, data)
+	r.buf = append(r.buf, data[:read]...)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		r.eof = true
+		return io.EOF
 	}
-	return &entry, nil
+	return err
 }
 
 // Close implements io.Closer.
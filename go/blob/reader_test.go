@@ -0,0 +1,147 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReaderSkipErrors(t *testing.T) {
+	entries := gzipTestEntries(3)
+	recs := make([][]byte, len(entries))
+	for i, entry := range entries {
+		// Corrupted bodies are detected by checksums.
+		rec, err := EncodeToRawRecordVersion(entry, FormatVersion, false)
+		if err != nil {
+			t.Fatalf("EncodeToRawRecordVersion: %v", err)
+		}
+		recs[i] = append(append(append([]byte{}, rec.Head...), rec.Body...), rec.Tail...)
+	}
+	corrupted := func(rec []byte, offset int) []byte {
+		rec = append([]byte{}, rec...)
+		rec[offset] ^= 0x10
+		return rec
+	}
+	hugeHead := make([]byte, 4)
+	binary.LittleEndian.PutUint32(hugeHead, maxResyncRecordSize+1)
+
+	testCases := []struct {
+		name     string
+		data     [][]byte
+		expected []int
+		skipped  int
+	}{
+		{
+			name:     "intact",
+			data:     [][]byte{recs[0], recs[1], recs[2]},
+			expected: []int{0, 1, 2},
+		},
+		{
+			name:     "garbage between records",
+			data:     [][]byte{recs[0], {1, 2, 3}, recs[1], {0, 0, 0, 0, 0}, recs[2]},
+			expected: []int{0, 1, 2},
+			skipped:  8,
+		},
+		{
+			name:     "corrupted body",
+			data:     [][]byte{recs[0], corrupted(recs[1], 20), recs[2]},
+			expected: []int{0, 2},
+			skipped:  len(recs[1]),
+		},
+		{
+			name:     "corrupted head",
+			data:     [][]byte{recs[0], corrupted(recs[1], 1), recs[2]},
+			expected: []int{0, 2},
+			skipped:  len(recs[1]),
+		},
+		{
+			name:     "corrupted tail",
+			data:     [][]byte{recs[0], corrupted(recs[1], len(recs[1])-4), recs[2]},
+			expected: []int{0, 2},
+			skipped:  len(recs[1]),
+		},
+		{
+			name:     "huge size",
+			data:     [][]byte{hugeHead, recs[0], recs[1], recs[2]},
+			expected: []int{0, 1, 2},
+			skipped:  4,
+		},
+		{
+			name:     "truncated",
+			data:     [][]byte{recs[0], recs[1], recs[2][:len(recs[2])/2]},
+			expected: []int{0, 1},
+			skipped:  len(recs[2]) / 2,
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{R: bytes.NewReader(bytes.Join(tc.data, nil)), SkipErrors: true}
+			var read []int
+			for {
+				entry, err := r.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				index := -1
+				for i := range entries {
+					if proto.Equal(entry, entries[i]) {
+						index = i
+					}
+				}
+				read = append(read, index)
+			}
+			if len(read) != len(tc.expected) {
+				t.Fatalf("Expect entries %v, got %v", tc.expected, read)
+			}
+			for i := range read {
+				if read[i] != tc.expected[i] {
+					t.Fatalf("Expect entries %v, got %v", tc.expected, read)
+				}
+			}
+			if r.SkippedBytes != int64(tc.skipped) {
+				t.Errorf("Expect %d bytes skipped, got %d", tc.skipped, r.SkippedBytes)
+			}
+		})
+	}
+}
+
+func TestReaderResyncReadError(t *testing.T) {
+	entries := gzipTestEntries(1)
+	rec, err := EncodeToRawRecord(entries[0])
+	if err != nil {
+		t.Fatalf("EncodeToRawRecord: %v", err)
+	}
+	data := append(append(append([]byte{}, rec.Head...), rec.Body...), rec.Tail...)
+	errRead := errors.New("read error")
+	// The record is split by a read error which should be returned rather
+	// than skipping the partially read record.
+	half := len(data) / 2
+	r := &Reader{R: io.MultiReader(bytes.NewReader(data[:half]), &errReader{err: errRead}), SkipErrors: true}
+	if _, err := r.Read(); !errors.Is(err, errRead) {
+		t.Fatalf("Expect read error, got %v", err)
+	}
+	r.R = bytes.NewReader(data[half:])
+	entry, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !proto.Equal(entry, entries[0]) || r.SkippedBytes != 0 {
+		t.Errorf("Expect entry read without skipping, got skipped %d", r.SkippedBytes)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
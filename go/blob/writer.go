@@ -14,10 +14,19 @@ var (
 
 // Writer is blob writer.
 type Writer struct {
-	W           io.Writer
-	Sync        bool
+	W    io.Writer
+	Sync bool
+	// SizeLimit limits the size written. If W is a SizedWriter (e.g. GzipWriter),
+	// it limits the size reported by W, and entries are rejected once the size
+	// reaches the limit, so the final size may slightly exceed the limit.
 	SizeLimit   int64
 	WrittenSize int64
+	// WrapAny writes entries wrapped in google.protobuf.Any.
+	WrapAny bool
+	// Version is the format version of the records, DefaultFormatVersion if 0.
+	// Version 2 adds checksums, but the files can't be read by readers
+	// not supporting version 2.
+	Version int
 }
 
 // Syncable defines a writer supports Sync().
@@ -40,13 +49,23 @@ func (w *Writer) Close() error {
 
 // WriteLogEntry writes singe log entry.
 func (w *Writer) WriteLogEntry(entry *logspb.LogEntry) error {
-	if w.SizeLimit > 0 && w.WrittenSize+int64(RawRecordSize(entry)) > w.SizeLimit {
-		return ErrSizeLimitExceeded
+	version := w.Version
+	if version == 0 {
+		version = DefaultFormatVersion
 	}
-	rec, err := EncodeToRawRecord(entry)
+	rec, err := EncodeToRawRecordVersion(entry, version, w.WrapAny)
 	if err != nil {
 		return err
 	}
+	if w.SizeLimit > 0 {
+		if sized, ok := w.W.(SizedWriter); ok {
+			if sized.Size() >= w.SizeLimit {
+				return ErrSizeLimitExceeded
+			}
+		} else if w.WrittenSize+int64(len(rec.Head)+len(rec.Body)+len(rec.Tail)) > w.SizeLimit {
+			return ErrSizeLimitExceeded
+		}
+	}
 	if _, err := w.W.Write(rec.Head); err != nil {
 		return err
 	}
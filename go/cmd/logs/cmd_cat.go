@@ -8,18 +8,44 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/evo-cloud/logs/go/emitters/console"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/server"
 	"github.com/evo-cloud/logs/go/source"
 )
 
+// sourceAttr is the attribute of the source file of an entry.
+const sourceAttr = "source"
+
 var (
-	catInput    string
-	catColorful bool
-	fullTraceID bool
+	catInput       string
+	catInputFormat string
+	catColorful    bool
+	fullTraceID    bool
+	catRelTime     string
+	catNoAbsTime   bool
+	catAttrs       []string
+	catAttrOrder   []string
+	catNoAttrs     bool
+	catExpandJSON  bool
+	catRename      []string
+	catSnakeCase   bool
+	catFormat      string
+	catColumns     []string
+	catTemplate    string
+	catStats       bool
+	catTopLocs     int
+	catTraceColor  bool
+	catSanitize    string
+	catLogfmt      bool
+	catSource      bool
+	catRaw         bool
+	catFollow      bool
 
 	maxStrAttrLen = intFromEnv("LOGS_CAT_MAX_STR_ATTR", 80)
 	maxBinAttrLen = intFromEnv("LOGS_CAT_MAX_BIN_ATTR", 8)
@@ -36,7 +62,13 @@ func cmdCat() *cobra.Command {
 		&catInput,
 		"in", "i",
 		"",
-		"Specify the input of logs, filename or - for STDIN.",
+		"Specify the input of logs, filename, directory of log files or - for STDIN.",
+	)
+	cmd.Flags().StringVar(
+		&catInputFormat,
+		"in-format",
+		"",
+		"Specify the format of input: auto (default), stackdriver, hub.",
 	)
 	cmd.Flags().BoolVar(
 		&catColorful,
@@ -68,6 +100,120 @@ func cmdCat() *cobra.Command {
 		false,
 		"Display full trace IDs.",
 	)
+	cmd.Flags().StringVar(
+		&catRelTime,
+		"rel-time",
+		"",
+		"Display relative timestamp: prev (delta from previous line), now (delta from now).",
+	)
+	cmd.Flags().BoolVar(
+		&catNoAbsTime,
+		"no-abs-time",
+		false,
+		"Hide absolute timestamp when relative timestamp is displayed.",
+	)
+	cmd.Flags().StringSliceVar(
+		&catAttrs,
+		"attrs",
+		nil,
+		"Display only the specified attributes (comma separated).",
+	)
+	cmd.Flags().StringSliceVar(
+		&catAttrOrder,
+		"attr-order",
+		nil,
+		"Display the specified attributes (comma separated) first, followed by the others.",
+	)
+	cmd.Flags().BoolVar(
+		&catNoAttrs,
+		"no-attrs",
+		false,
+		"Hide all attributes.",
+	)
+	cmd.Flags().BoolVar(
+		&catExpandJSON,
+		"expand-json",
+		false,
+		"Pretty-print JSON attributes with multiple fields under the log line.",
+	)
+	cmd.Flags().StringArrayVar(
+		&catRename,
+		"rename",
+		nil,
+		"Rename attribute keys on read in the form of OLD=NEW (repeatable).",
+	)
+	cmd.Flags().BoolVar(
+		&catSnakeCase,
+		"snake-case",
+		false,
+		"Normalize attribute keys to snake_case on read.",
+	)
+	cmd.Flags().StringVar(
+		&catFormat,
+		"format",
+		"",
+		"Specify the output format: text (default), json, csv, tsv, gofixture (Go source for test fixtures).",
+	)
+	cmd.Flags().StringSliceVar(
+		&catColumns,
+		"columns",
+		nil,
+		"Attributes (comma separated) as the columns following time, level, location, trace, span, message in csv and tsv formats.",
+	)
+	cmd.Flags().StringVar(
+		&catTemplate,
+		"template",
+		"",
+		"Render each entry with a Go template, e.g. '{{.Level}} {{.Message}} {{attr \"user\"}}', with helpers attr NAME, trace, span, time [FORMAT].",
+	)
+	cmd.Flags().BoolVar(
+		&catStats,
+		"stats",
+		false,
+		"Print the counts per level, location, client and the span durations instead of the entries.",
+	)
+	cmd.Flags().IntVar(
+		&catTopLocs,
+		"top-locations",
+		console.DefaultTopLocations,
+		"Number of the most frequent locations in --stats, all if negative.",
+	)
+	cmd.Flags().BoolVar(
+		&catTraceColor,
+		"trace-color",
+		false,
+		"Color each trace ID differently.",
+	)
+	cmd.Flags().StringVar(
+		&catSanitize,
+		"sanitize",
+		"escape",
+		"Sanitize control characters in text: escape, strip-ansi, none.",
+	)
+	cmd.Flags().BoolVar(
+		&catLogfmt,
+		"logfmt",
+		false,
+		"Extract key=value pairs in messages as attributes (heuristic, for legacy logs).",
+	)
+	cmd.Flags().BoolVar(
+		&catSource,
+		"source",
+		false,
+		"Prefix each line with the source file of the entry (or the \""+sourceAttr+"\" attribute).",
+	)
+	cmd.Flags().BoolVar(
+		&catRaw,
+		"raw",
+		false,
+		"Display entries in full fidelity: no truncation of attributes and paths, floats in full precision, full trace IDs and timestamps in RFC3339 with nanoseconds.",
+	)
+	cmd.Flags().BoolVarP(
+		&catFollow,
+		"follow", "f",
+		false,
+		"Keep reading the input file for new entries like tail -f, and reopen it when rotated.",
+	)
 	return cmd
 }
 
@@ -77,15 +223,76 @@ func runCat(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	var in io.Reader = os.Stdin
-	if catInput != "" && catInput != "-" {
-		f, err := os.Open(catInput)
-		if err != nil {
-			return fmt.Errorf("open %q: %w", catInput, err)
+	var files []string
+	var reader source.Reader
+	if catFollow {
+		if catInput == "" || catInput == "-" {
+			return fmt.Errorf("--follow requires an input file")
+		}
+		if catInputFormat != "" && catInputFormat != "auto" {
+			return fmt.Errorf("input format %s doesn't support --follow", catInputFormat)
+		}
+		if info, err := os.Stat(catInput); err != nil {
+			return err
+		} else if info.IsDir() {
+			return fmt.Errorf("--follow doesn't support directory")
+		}
+		followReader := source.NewFollow(catInput)
+		followReader.SkipErrors = true
+		defer followReader.Close()
+		reader = followReader
+	} else if catInput != "" && catInput != "-" {
+		if info, err := os.Stat(catInput); err == nil && info.IsDir() {
+			if files, err = server.ListLogFiles(catInput); err != nil {
+				return fmt.Errorf("list %q: %w", catInput, err)
+			}
+		} else if catSource && (catInputFormat == "" || catInputFormat == "auto") {
+			files = []string{catInput}
+		} else {
+			f, err := source.OpenFile(catInput)
+			if err != nil {
+				return fmt.Errorf("open %q: %w", catInput, err)
+			}
+			defer f.Close()
+			in = f
 		}
-		defer f.Close()
-		in = f
 	}
-	reader := &source.StreamReader{In: in, SkipErrors: true}
+	switch catInputFormat {
+	case "", "auto":
+		if reader != nil {
+			break
+		}
+		if files != nil {
+			filesReader := source.NewFiles(files...)
+			filesReader.SkipErrors = true
+			if catSource {
+				filesReader.SourceAttr = sourceAttr
+			}
+			defer filesReader.Close()
+			reader = filesReader
+			break
+		}
+		reader = &source.StreamReader{In: in, SkipErrors: true}
+	case "stackdriver":
+		if files != nil {
+			return fmt.Errorf("input format %s doesn't support directory", catInputFormat)
+		}
+		sdReader := source.NewStackdriver(in)
+		sdReader.SkipErrors = true
+		reader = sdReader
+	case "hub":
+		if files != nil {
+			return fmt.Errorf("input format %s doesn't support directory", catInputFormat)
+		}
+		hubReader := source.NewHubStream(in)
+		hubReader.SkipErrors = true
+		reader = hubReader
+	default:
+		return fmt.Errorf("unknown input format: %s", catInputFormat)
+	}
+	if reader, err = transformReader(reader, catRename, catSnakeCase, catLogfmt); err != nil {
+		return err
+	}
 	printer := console.NewPrinter(os.Stdout)
 	printer.MaxStrAttrLen = maxStrAttrLen
 	printer.MaxBinAttrLen = maxBinAttrLen
@@ -93,18 +300,75 @@ func runCat(cmd *cobra.Command, args []string) error {
 	if fullTraceID {
 		printer.ShortenTraceID = false
 	}
+	if catRaw {
+		printer.MaxStrAttrLen, printer.MaxBinAttrLen, printer.MaxPathLen = 0, -1, -1
+		printer.ShortenTraceID, printer.FullPrecision = false, true
+		printer.TimeFormat = time.RFC3339Nano
+	}
+	if printer.RelativeTime, err = console.ParseRelativeTime(catRelTime); err != nil {
+		return err
+	}
+	printer.HideAbsoluteTime = catNoAbsTime
+	printer.Attributes, printer.HideAttributes = catAttrs, catNoAttrs
+	printer.AttrOrder = catAttrOrder
+	printer.ExpandJSON = catExpandJSON
+	printer.ColorByTrace = catTraceColor
+	if catSource {
+		printer.SourceAttribute = sourceAttr
+	}
+	switch catSanitize {
+	case "escape":
+		printer.Sanitizer = console.EscapeControlChars
+	case "strip-ansi":
+		printer.Sanitizer = console.StripANSI
+	case "none":
+		printer.Sanitizer = nil
+	default:
+		return fmt.Errorf("unknown sanitize mode: %s", catSanitize)
+	}
 	if catColorful {
 		if terminal.IsTerminal(int(os.Stdout.Fd())) {
 			printer.UseColor(true)
 		}
 	}
 	printer.DisplaySpanNames()
+	if catTemplate != "" && catFormat != "" && catFormat != "text" {
+		return fmt.Errorf("--template can't be used with output format %s", catFormat)
+	}
+	if catStats && (catTemplate != "" || (catFormat != "" && catFormat != "text")) {
+		return fmt.Errorf("--stats can't be used with --template or --format")
+	}
+	var emitter logs.LogEmitter
+	var stats *console.StatsEmitter
+	switch catFormat {
+	case "", "text":
+		emitter = printer
+		if catTemplate != "" {
+			if emitter, err = console.NewTemplateEmitter(os.Stdout, catTemplate); err != nil {
+				return err
+			}
+		}
+		if catStats {
+			stats = &console.StatsEmitter{Out: os.Stdout, TopLocations: catTopLocs}
+			emitter = stats
+		}
+	case "json":
+		emitter = &console.Emitter{Printer: printer, JSON: true}
+	case "csv":
+		emitter = &console.CSVEmitter{Out: os.Stdout, Columns: catColumns}
+	case "tsv":
+		emitter = &console.CSVEmitter{Out: os.Stdout, Columns: catColumns, Comma: '\t'}
+	case "gofixture":
+		emitter = &console.GoFixtureEmitter{Out: os.Stdout}
+	default:
+		return fmt.Errorf("unknown output format: %s", catFormat)
+	}
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 	for {
 		entry, err := reader.Read(ctx)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
 				break
 			}
 			return err
@@ -114,9 +378,33 @@ func runCat(cmd *cobra.Command, args []string) error {
 		}
 		spanRec := printer.RecordSpanEvent(entry)
 		if filters == nil || filters.FilterLogEntry(entry) {
-			printer.EmitLogEntry(entry)
+			emitter.EmitLogEntry(entry)
 		}
 		spanRec.Done()
 	}
+	if stats != nil {
+		return stats.Report()
+	}
 	return nil
 }
+
+func transformReader(reader source.Reader, renames []string, snakeCase, logfmt bool) (source.Reader, error) {
+	var transformers source.LogEntryTransformers
+	if logfmt {
+		transformers = append(transformers, &source.LogfmtExtractor{})
+	}
+	if snakeCase {
+		transformers = append(transformers, source.SnakeCaseAttributes)
+	}
+	if len(renames) > 0 {
+		rename, err := source.ParseRenameAttributes(renames...)
+		if err != nil {
+			return nil, err
+		}
+		transformers = append(transformers, rename)
+	}
+	if len(transformers) == 0 {
+		return reader, nil
+	}
+	return &source.TransformReader{Reader: reader, Transformer: transformers}, nil
+}
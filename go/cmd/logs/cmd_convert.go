@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/server"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+var (
+	convertOutput    string
+	convertOutFormat string
+	convertGzip      bool
+	convertRename    []string
+	convertSnakeCase bool
+	convertLogfmt    bool
+	convertSource    bool
+	convertSkipErrs  bool
+)
+
+func cmdConvert() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert INPUTS...",
+		Short: "Convert (and merge) log files.",
+		Long: "Convert log files or directories of log files into a single output.\n" +
+			"Gzip compressed inputs are decompressed transparently, and multiple inputs are merged by timestamps.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: runConvert,
+	}
+	cmd.Flags().StringVarP(
+		&convertOutput,
+		"out", "o",
+		"-",
+		"Specify the output filename or - for STDOUT. A .gz suffix enables gzip compression.",
+	)
+	cmd.Flags().StringVar(
+		&convertOutFormat,
+		"out-format",
+		"",
+		"Specify the format of output: blob, json. Detected from the output filename if not specified.",
+	)
+	cmd.Flags().BoolVar(
+		&convertGzip,
+		"gzip",
+		false,
+		"Compress output with gzip.",
+	)
+	cmd.Flags().StringArrayVar(
+		&convertRename,
+		"rename",
+		nil,
+		"Rename attribute keys in the form of OLD=NEW (repeatable).",
+	)
+	cmd.Flags().BoolVar(
+		&convertSnakeCase,
+		"snake-case",
+		false,
+		"Normalize attribute keys to snake_case.",
+	)
+	cmd.Flags().BoolVar(
+		&convertLogfmt,
+		"logfmt",
+		false,
+		"Extract key=value pairs in messages as attributes (heuristic, for legacy logs).",
+	)
+	cmd.Flags().BoolVar(
+		&convertSource,
+		"source",
+		false,
+		"Set the \""+sourceAttr+"\" attribute of each entry to its source file, e.g. for merging logs of multiple instances.",
+	)
+	cmd.Flags().BoolVar(
+		&convertSkipErrs,
+		"skip-errors",
+		true,
+		"Skip corrupted records and unreadable files. Use --skip-errors=false to fail on them instead.",
+	)
+	return cmd
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	readers := make([]source.Reader, 0, len(args))
+	for _, input := range args {
+		reader, err := openInput(input, convertSkipErrs)
+		if err != nil {
+			return err
+		}
+		if convertSource {
+			reader.SourceAttr = sourceAttr
+		}
+		readers = append(readers, reader)
+	}
+	var reader source.Reader = readers[0]
+	if len(readers) > 1 {
+		reader = source.NewMerge(readers...)
+	}
+	defer reader.(io.Closer).Close()
+	reader, err := transformReader(reader, convertRename, convertSnakeCase, convertLogfmt)
+	if err != nil {
+		return err
+	}
+
+	format := convertOutFormat
+	compressed := convertGzip || strings.HasSuffix(convertOutput, ".gz")
+	if format == "" {
+		switch name := strings.TrimSuffix(convertOutput, ".gz"); {
+		case strings.HasSuffix(name, ".blob"):
+			format = "blob"
+		case strings.HasSuffix(name, ".json"), strings.HasSuffix(name, ".jsonl"):
+			format = "json"
+		default:
+			return fmt.Errorf("unable to detect output format from %q, please specify --out-format", convertOutput)
+		}
+	}
+
+	var out io.WriteCloser = os.Stdout
+	if convertOutput != "-" {
+		f, err := os.Create(convertOutput)
+		if err != nil {
+			return fmt.Errorf("create %q: %w", convertOutput, err)
+		}
+		out = f
+	}
+	w := &convertWriter{out: out}
+	if compressed {
+		w.gz = gzip.NewWriter(out)
+		w.buf = bufio.NewWriter(w.gz)
+	} else {
+		w.buf = bufio.NewWriter(out)
+	}
+	var write func(*logspb.LogEntry) error
+	switch format {
+	case "blob":
+		write = (&blob.Writer{W: w.buf}).WriteLogEntry
+	case "json":
+		write = func(entry *logspb.LogEntry) error {
+			data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			w.buf.Write(data)
+			return w.buf.WriteByte('\n')
+		}
+	default:
+		w.Close()
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	for {
+		entry, err := reader.Read(ctx)
+		if err != nil && !errors.Is(err, io.EOF) {
+			w.Close()
+			return err
+		}
+		if entry == nil {
+			break
+		}
+		if err := write(entry); err != nil {
+			w.Close()
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+	return w.Close()
+}
+
+// openInput opens a log file or a directory of log files written by FileStore.
+// Corrupted records and unreadable files are skipped if skipErrors is true.
+func openInput(input string, skipErrors bool) (*source.FilesReader, error) {
+	files := []string{input}
+	if info, err := os.Stat(input); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if files, err = server.ListLogFiles(input); err != nil {
+			return nil, fmt.Errorf("list %q: %w", input, err)
+		}
+	}
+	reader := source.NewFiles(files...)
+	reader.SkipErrors = skipErrors
+	return reader, nil
+}
+
+type convertWriter struct {
+	out io.WriteCloser
+	gz  *gzip.Writer
+	buf *bufio.Writer
+}
+
+// Close flushes all buffered data and closes the output.
+func (w *convertWriter) Close() error {
+	err := w.buf.Flush()
+	if w.gz != nil {
+		if e := w.gz.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	if w.out != os.Stdout {
+		if e := w.out.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/evo-cloud/logs/go/emitters/console"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+const (
+	decorDiffDelete = "\x1b[31m" // fg:red
+	decorDiffInsert = "\x1b[32m" // fg:green
+	decorDiffChange = "\x1b[33m" // fg:yellow
+)
+
+var (
+	diffOnlyChanges bool
+	diffNoAttrs     bool
+	diffIgnoreAttrs []string
+	diffColorful    bool
+	diffMaxEntries  int
+)
+
+func cmdDiff() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff INPUT1 INPUT2 [FILTERS...]",
+		Short: "Compare two log sources.",
+		Long: "Align entries of two log sources (files or directories) by span names, or locations and messages,\n" +
+			"and show the extra/missing entries and the aligned entries with different levels or attributes.\n" +
+			"Both sources are loaded into memory, use filters to narrow down large sources.",
+		Args: cobra.MinimumNArgs(2),
+		RunE: runDiff,
+	}
+	cmd.Flags().BoolVar(
+		&diffOnlyChanges,
+		"only-changes",
+		false,
+		"Only print the divergences.",
+	)
+	cmd.Flags().BoolVar(
+		&diffNoAttrs,
+		"no-attrs",
+		false,
+		"Don't compare attributes.",
+	)
+	cmd.Flags().StringSliceVar(
+		&diffIgnoreAttrs,
+		"ignore-attrs",
+		nil,
+		"Attributes not compared (comma separated), e.g. durations.",
+	)
+	cmd.Flags().BoolVar(
+		&diffColorful,
+		"color",
+		true,
+		"Print with color.",
+	)
+	cmd.Flags().IntVar(
+		&diffMaxEntries,
+		"max-entries",
+		100000,
+		"Maximum number of entries loaded from each source, unlimited if 0.",
+	)
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	filters, err := source.ParseFilters(args[2:]...)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	entriesA, err := readAllEntries(ctx, args[0], filters, diffMaxEntries)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", args[0], err)
+	}
+	entriesB, err := readAllEntries(ctx, args[1], filters, diffMaxEntries)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", args[1], err)
+	}
+
+	var out bytes.Buffer
+	printer := console.NewPrinter(&out)
+	printer.MaxStrAttrLen = maxStrAttrLen
+	printer.MaxBinAttrLen = maxBinAttrLen
+	printer.MaxPathLen = maxPathLen
+	styler := func(text, decor string) string { return text }
+	if diffColorful && terminal.IsTerminal(int(os.Stdout.Fd())) {
+		printer.UseColor(true)
+		styler = func(text, decor string) string { return decor + text + "\x1b[0m" }
+	}
+	format := func(entry *logspb.LogEntry) string {
+		out.Reset()
+		printer.EmitLogEntry(entry)
+		return strings.TrimRight(out.String(), "\r\n")
+	}
+	ignore := make(map[string]bool)
+	for _, name := range diffIgnoreAttrs {
+		ignore[name] = true
+	}
+
+	for _, op := range source.DiffLogEntries(entriesA, entriesB, nil) {
+		switch op.Kind {
+		case source.DiffDelete:
+			fmt.Println(styler("-", decorDiffDelete) + " " + format(op.A))
+		case source.DiffInsert:
+			fmt.Println(styler("+", decorDiffInsert) + " " + format(op.B))
+		default:
+			var changes []string
+			if levelA, levelB := op.A.GetLevel(), op.B.GetLevel(); levelA != levelB {
+				changes = append(changes, fmt.Sprintf("level %s => %s", levelA, levelB))
+			}
+			if !diffNoAttrs {
+				if names := source.AttributeDiffs(op.A, op.B, ignore); len(names) > 0 {
+					changes = append(changes, "attrs "+strings.Join(names, ","))
+				}
+			}
+			if len(changes) == 0 {
+				if !diffOnlyChanges {
+					fmt.Println("  " + format(op.B))
+				}
+				continue
+			}
+			fmt.Println(styler("~", decorDiffChange) + " " + format(op.A))
+			fmt.Println(styler("~", decorDiffChange) + " " + format(op.B))
+			fmt.Println(styler("  ^ "+strings.Join(changes, "; "), decorDiffChange))
+		}
+	}
+	return nil
+}
+
+func readAllEntries(ctx context.Context, input string, filter source.LogEntryFilter, maxEntries int) ([]*logspb.LogEntry, error) {
+	reader, err := openInput(input, true)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var entries []*logspb.LogEntry
+	for {
+		entry, err := reader.Read(ctx)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if entry == nil {
+			return entries, nil
+		}
+		if filter != nil && !filter.FilterLogEntry(entry) {
+			continue
+		}
+		if maxEntries > 0 && len(entries) >= maxEntries {
+			return nil, fmt.Errorf("more than %d entries, narrow down with filters or raise --max-entries", maxEntries)
+		}
+		entries = append(entries, entry)
+	}
+}
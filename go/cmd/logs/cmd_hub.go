@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
@@ -18,7 +24,12 @@ import (
 var (
 	hubServeIngressAddr = ":8000"
 	hubServeListenAddr  = ":8080"
+	hubServeWSAddr      = ""
 	hubServeReplicate   = false
+	hubShutdownTimeout  = 10 * time.Second
+	hubMaxPending       = 1024
+	hubWriteTimeout     time.Duration
+	hubConnectFilters   []string
 )
 
 func hubServe(cmd *cobra.Command, args []string) error {
@@ -30,25 +41,54 @@ func hubServe(cmd *cobra.Command, args []string) error {
 	}
 	ln, err := net.Listen("tcp", hubServeListenAddr)
 	if err != nil {
-		fmt.Errorf("listen egress server %s: %w", hubServeListenAddr, err)
+		return fmt.Errorf("listen egress server %s: %w", hubServeListenAddr, err)
 	}
 	defer ln.Close()
 	defer grpcLn.Close()
 
+	var wsLn net.Listener
+	if hubServeWSAddr != "" {
+		if wsLn, err = net.Listen("tcp", hubServeWSAddr); err != nil {
+			return fmt.Errorf("listen WebSocket server %s: %w", hubServeWSAddr, err)
+		}
+		defer wsLn.Close()
+	}
+
 	logs.Infof("Ingress server on %s", grpcLn.Addr())
 	logs.Infof("Egress server on %s", ln.Addr())
+	if wsLn != nil {
+		logs.Infof("WebSocket egress server on %s", wsLn.Addr())
+	}
 
-	dispatcher := &hub.Dispatcher{}
+	dispatcher := &hub.Dispatcher{MaxPending: hubMaxPending, WriteTimeout: hubWriteTimeout}
 	if hubServeReplicate {
 		dispatcher.Emitter = logs.Default()
 	}
 	ingress := &server.IngressServer{Store: dispatcher}
 	srv := grpc.NewServer()
 	logspb.RegisterIngressServiceServer(srv, ingress)
-	errCh := make(chan error, 2)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	errCh := make(chan error, 3)
 	go func() { errCh <- dispatcher.Serve(ln) }()
 	go func() { errCh <- srv.Serve(grpcLn) }()
-	return <-errCh
+	wsSrv := &http.Server{Handler: dispatcher.WebSocketHandler()}
+	if wsLn != nil {
+		go func() { errCh <- wsSrv.Serve(wsLn) }()
+		defer wsSrv.Close()
+	}
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	logs.Infof("Shutting down")
+	// Stop ingress first so the in-flight batches are dispatched before the
+	// egress connections are closed.
+	srv.GracefulStop()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), hubShutdownTimeout)
+	defer cancelShutdown()
+	return dispatcher.Shutdown(shutdownCtx)
 }
 
 func hubConnect(cmd *cobra.Command, args []string) error {
@@ -60,7 +100,7 @@ func hubConnect(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		addr = args[0]
 	}
-	connector := &hub.Connector{Emitter: emitter}
+	connector := &hub.Connector{Emitter: emitter, Filters: hubConnectFilters}
 	if err := connector.DialAndStream("tcp", addr); err != nil && !errors.Is(err, io.EOF) {
 		return err
 	}
@@ -76,6 +116,10 @@ func cmdHub() *cobra.Command {
 	}
 	hubServeCmd.Flags().StringVarP(&hubServeIngressAddr, "ingress-addr", "i", hubServeIngressAddr, "Logs ingress service (gRPC) address")
 	hubServeCmd.Flags().StringVarP(&hubServeListenAddr, "egress-addr", "e", hubServeListenAddr, "Logs egress (TCP) listening address")
+	hubServeCmd.Flags().StringVar(&hubServeWSAddr, "ws-addr", hubServeWSAddr, "Logs egress (WebSocket) listening address, disabled if empty")
+	hubServeCmd.Flags().DurationVar(&hubShutdownTimeout, "shutdown-timeout", hubShutdownTimeout, "Max time waiting for in-flight logs to be dispatched on shutdown")
+	hubServeCmd.Flags().IntVar(&hubMaxPending, "max-pending", hubMaxPending, "Max entries buffered for a slow egress client before dropping the oldest")
+	hubServeCmd.Flags().DurationVar(&hubWriteTimeout, "write-timeout", hubWriteTimeout, "Close an egress client blocked on writing longer than the timeout (0 to disable)")
 	hubServeCmd.Flags().BoolVar(&hubServeReplicate, "replicate", hubServeReplicate, "Replicate ingress logs to the current logger")
 
 	hubConnectCmd := &cobra.Command{
@@ -84,6 +128,7 @@ func cmdHub() *cobra.Command {
 		Short:   "Connect to hub and stream logs",
 		RunE:    hubConnect,
 	}
+	hubConnectCmd.Flags().StringArrayVar(&hubConnectFilters, "filter", nil, "Only receive logs matching the filter, using the same syntax as cat (repeatable)")
 
 	cmd := &cobra.Command{
 		Use:   "hub",
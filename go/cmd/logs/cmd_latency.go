@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+var (
+	latencyInput   string
+	latencyErrored bool
+	latencySince   string
+	latencyBefore  string
+	latencyFilters []string
+)
+
+func cmdLatency() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "latency [SPAN_NAME]",
+		Short: "Report latency of spans.",
+		Long: "Assemble spans from logs and report count, p50/p90/p99 and max duration of the named span,\n" +
+			"or of all spans grouped by names if SPAN_NAME is not specified.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: runLatency,
+	}
+	cmd.Flags().StringVarP(
+		&latencyInput,
+		"in", "i",
+		"",
+		"Specify the input of logs, filename, directory of log files or - for STDIN.",
+	)
+	cmd.Flags().BoolVar(
+		&latencyErrored,
+		"errored",
+		false,
+		"Only include spans with logs of level ERROR or above.",
+	)
+	cmd.Flags().StringVar(
+		&latencySince,
+		"since",
+		"",
+		"Only include spans started since the time.",
+	)
+	cmd.Flags().StringVar(
+		&latencyBefore,
+		"before",
+		"",
+		"Only include spans started before the time.",
+	)
+	cmd.Flags().StringArrayVar(
+		&latencyFilters,
+		"filter",
+		nil,
+		"Filter spans by their span start entries, using the same syntax as cat (repeatable).",
+	)
+	return cmd
+}
+
+func runLatency(cmd *cobra.Command, args []string) error {
+	var spanName string
+	if len(args) > 0 {
+		spanName = args[0]
+	}
+	filterStrs := append([]string(nil), latencyFilters...)
+	if latencySince != "" {
+		filterStrs = append(filterStrs, "since="+latencySince)
+	}
+	if latencyBefore != "" {
+		filterStrs = append(filterStrs, "before="+latencyBefore)
+	}
+	filters, err := source.ParseFilters(filterStrs...)
+	if err != nil {
+		return err
+	}
+
+	var reader source.Reader
+	if latencyInput == "" || latencyInput == "-" {
+		reader = &source.StreamReader{In: os.Stdin, SkipErrors: true}
+	} else {
+		filesReader, err := openInput(latencyInput, true)
+		if err != nil {
+			return err
+		}
+		defer filesReader.Close()
+		reader = filesReader
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	var assembler logs.SpanAssembler
+	durations := make(map[string][]time.Duration)
+	for {
+		entry, err := reader.Read(ctx)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if entry == nil {
+			break
+		}
+		span := assembler.AddLogEntry(entry)
+		if span == nil || (spanName != "" && span.GetName() != spanName) {
+			continue
+		}
+		if latencyErrored && !spanErrored(span) {
+			continue
+		}
+		if len(filters) > 0 && !filters.FilterLogEntry(span.GetLogs()[0]) {
+			continue
+		}
+		durations[span.GetName()] = append(durations[span.GetName()], time.Duration(span.GetDuration()))
+	}
+
+	names := make([]string, 0, len(durations))
+	for name := range durations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SPAN\tCOUNT\tP50\tP90\tP99\tMAX")
+	for _, name := range names {
+		values := durations[name]
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		fmt.Fprintf(w, "%s\t%d\t%v\t%v\t%v\t%v\n", name, len(values),
+			percentile(values, 50), percentile(values, 90), percentile(values, 99), values[len(values)-1])
+	}
+	return w.Flush()
+}
+
+// spanErrored determines whether any log of the span is at level ERROR or above.
+func spanErrored(span *logspb.Span) bool {
+	for _, entry := range span.GetLogs() {
+		if entry.GetLevel() >= logspb.LogEntry_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+// percentile returns the p-th percentile of sorted values using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
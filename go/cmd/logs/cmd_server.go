@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"github.com/evo-cloud/logs/go/blob"
+	"github.com/evo-cloud/logs/go/config"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/server"
+)
+
+var (
+	serverListenAddr    = ":8000"
+	serverBaseDir       = "logs"
+	serverFileSizeLimit = int64(server.DefaultFileSizeLimit)
+	serverFormatVersion int
+)
+
+func cmdServer() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Run a server persisting logs in files",
+		Long: "Receive logs from the ingress service (gRPC) and persist them in files per client.\n" +
+			"The persisted logs are queried from the egress service (gRPC).\n" +
+			"The current files of all clients are rotated on SIGHUP.",
+		Args: cobra.NoArgs,
+		RunE: runServer,
+	}
+	cmd.Flags().StringVarP(&serverListenAddr, "addr", "a", serverListenAddr, "Listening address of the gRPC services")
+	cmd.Flags().StringVarP(&serverBaseDir, "dir", "d", serverBaseDir, "Base directory of the log files")
+	cmd.Flags().Int64Var(&serverFileSizeLimit, "file-size-limit", serverFileSizeLimit, "Size limit of a log file before it's rotated")
+	cmd.Flags().IntVar(&serverFormatVersion, "format-version", serverFormatVersion, "Blob format version of the log files, 2 writes records with checksums not readable by old readers, 0 means the default (1)")
+	return cmd
+}
+
+func runServer(cmd *cobra.Command, args []string) error {
+	logsConfig.MustSetupDefaultLogger()
+
+	if serverFormatVersion < 0 || serverFormatVersion > blob.FormatVersion {
+		return fmt.Errorf("unsupported format version %d", serverFormatVersion)
+	}
+	if err := os.MkdirAll(serverBaseDir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", serverBaseDir, err)
+	}
+	store := server.NewFileStore(serverBaseDir)
+	store.FileSizeLimit = serverFileSizeLimit
+	store.FormatVersion = serverFormatVersion
+	config.RotateOnSignal(config.RotatorFunc(store.RotateAll), syscall.SIGHUP)
+
+	ln, err := net.Listen("tcp", serverListenAddr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", serverListenAddr, err)
+	}
+	defer ln.Close()
+	logs.Infof("Server on %s, logs in %s", ln.Addr(), serverBaseDir)
+
+	srv := grpc.NewServer()
+	logspb.RegisterIngressServiceServer(srv, &server.IngressServer{Store: store})
+	logspb.RegisterEgressServiceServer(srv, &server.QueryServer{Store: store})
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	logs.Infof("Shutting down")
+	srv.GracefulStop()
+	return nil
+}
@@ -28,6 +28,6 @@ func main() {
 		SilenceUsage: true,
 	}
 	logsConfig.SetupFlagsWith(cmd.PersistentFlags())
-	cmd.AddCommand(cmdCat(), cmdHub(), cmdGen())
+	cmd.AddCommand(cmdCat(), cmdConvert(), cmdDiff(), cmdHub(), cmdGen(), cmdLatency(), cmdServer())
 	cmd.Execute()
 }
@@ -1,20 +1,33 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	blobformat "github.com/evo-cloud/logs/go/blob"
 	"github.com/evo-cloud/logs/go/emitters/blob"
 	"github.com/evo-cloud/logs/go/emitters/console"
 	"github.com/evo-cloud/logs/go/emitters/stackdriver"
 	"github.com/evo-cloud/logs/go/logs"
 	"github.com/evo-cloud/logs/go/streamers/elasticsearch"
 	"github.com/evo-cloud/logs/go/streamers/jaeger"
+	"github.com/evo-cloud/logs/go/streamers/otlp"
 	"github.com/evo-cloud/logs/go/streamers/remote"
 )
 
@@ -23,30 +36,86 @@ type Config struct {
 	ClientName     string
 	ConsolePrinter string
 	Color          bool
+	Theme          string
 
 	// Blob file output.
 	BlobFile      string
 	BlobSync      bool
 	BlobSizeLimit int64
+	BlobRotateHUP bool
+	BlobWrapAny   bool
+	BlobVersion   int
+	BlobRetryMax  int
+	// BlobShardAttr writes entries into files per value of the attribute,
+	// and BlobFile supports {{.Shard}} substitution.
+	BlobShardAttr    string
+	BlobShardMaxOpen int
 
 	// ElasticSearch streamer.
 	ESServerURL  string
 	ESDataStream string
+	// ElasticSearch credentials, ESAPIKey is preferred over ESUsername and ESPassword.
+	ESUsername string
+	ESPassword string
+	ESAPIKey   string
+	// ESDeadLetterFile is the blob filename template for entries permanently
+	// rejected by ElasticSearch.
+	ESDeadLetterFile string
 
 	// Jaeger streamer.
 	JaegerAddr string
+	// JaegerProcessTags are comma separated key=value pairs attached to the Jaeger process.
+	JaegerProcessTags string
+
+	// OTLP streamer.
+	OTLPAddr string
 
 	// Remote streamer.
 	RemoteAddr     string
 	RemoteInsecure bool
+	// TLS configurations of the remote streamer, the system CAs are used if RemoteCACert is empty.
+	// RemoteClientCert and RemoteClientKey specify the client certificate for mutual TLS.
+	RemoteCACert     string
+	RemoteClientCert string
+	RemoteClientKey  string
+	RemoteServerName string
 
 	// Chunked streaming configurations.
 	ChunkedMaxBuffer     int
 	ChunkedMaxBatch      int
 	ChunkedCollectPeriod time.Duration
+	// ChunkedCollectJitter is the percentage of ChunkedCollectPeriod.
+	ChunkedCollectJitter int
+	ChunkedConcurrency   int
+	ChunkedOverrun       string
+	ChunkedBlockTimeout  time.Duration
+
+	// Circuit breaker of streamers, disabled if BreakerThreshold is 0.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// BuildInfo sets the build information (see logs.BuildInfo) as attributes
+	// of the default logger, using ServiceVersion and VCSRevision if specified.
+	BuildInfo      bool
+	ServiceVersion string
+	VCSRevision    string
+
+	// TraceFromEnv continues the trace in logs.TraceParentEnv with the default
+	// logger, so the logs of CLI tools are attached to the trace of the caller.
+	TraceFromEnv bool
+
+	// Sequence sets a sequence number on each entry to detect lost entries.
+	Sequence bool
 
 	// EmitterVerbose allows emitter to write errors using emergent logger.
 	EmitterVerbose bool
+
+	// StatusAddr is the listening address of the HTTP status endpoint,
+	// served by SetupDefaultLogger until Close.
+	StatusAddr string
+
+	// closers are called by Close for clean shutdown.
+	closers []func(context.Context) error
 }
 
 type FlagSet interface {
@@ -63,6 +132,7 @@ func Default() *Config {
 		ChunkedMaxBuffer:     envOrInt("LOGS_CHUNKED_BUFFER_MAX", 1<<20), // 1M
 		ChunkedMaxBatch:      envOrInt("LOGS_CHUNKED_BATCH_MAX", 1<<14),  // 16K
 		ChunkedCollectPeriod: time.Second,
+		ChunkedCollectJitter: 10,
 	}
 }
 
@@ -75,18 +145,47 @@ func (c *Config) SetupFlagsWith(f FlagSet) {
 	f.StringVar(&c.ClientName, "logs-client", os.Getenv("LOGS_CLIENT"), "Logs client name")
 	f.StringVar(&c.ConsolePrinter, "logs-printer", os.Getenv("LOGS_PRINTER"), "Logs console printer")
 	f.BoolVar(&c.Color, "logs-color", c.Color, "Enable color on console printer")
+	f.StringVar(&c.Theme, "logs-theme", os.Getenv("LOGS_THEME"), "Color theme of console printer: dark (default), light, none")
 	f.StringVar(&c.BlobFile, "logs-blob-file", os.Getenv("LOGS_BLOB_FILE"), "Blob filename template for writing binary proto encoded logs to files")
 	f.BoolVar(&c.BlobSync, "logs-blob-sync", c.BlobSync, "Blob file writes with sync")
 	f.Int64Var(&c.BlobSizeLimit, "logs-blob-sizelimit", c.BlobSizeLimit, "Blob file size limit, 0 means no limit")
+	f.BoolVar(&c.BlobRotateHUP, "logs-blob-rotate-hup", c.BlobRotateHUP, "Rotate blob file on SIGHUP")
+	f.IntVar(&c.BlobRetryMax, "logs-blob-retry-max", c.BlobRetryMax, "Blob file max size of entries queued for retrying on write errors, 0 disables retrying")
+	f.StringVar(&c.BlobShardAttr, "logs-blob-shard-attr", os.Getenv("LOGS_BLOB_SHARD_ATTR"), "Blob files are sharded by the value of the attribute, using {{.Shard}} in the filename template")
+	f.IntVar(&c.BlobShardMaxOpen, "logs-blob-shard-max-open", c.BlobShardMaxOpen, "Blob file max number of shard files open concurrently")
+	f.BoolVar(&c.BlobWrapAny, "logs-blob-any", c.BlobWrapAny, "Blob file writes entries wrapped in google.protobuf.Any")
+	f.IntVar(&c.BlobVersion, "logs-blob-version", c.BlobVersion, "Blob file format version, 2 writes records with checksums not readable by old readers, 0 means the default (1)")
 	f.StringVar(&c.ESServerURL, "logs-es-url", os.Getenv("LOGS_ES_URL"), "ElasticSearch server URL")
 	f.StringVar(&c.ESDataStream, "logs-es-datastream", os.Getenv("LOGS_ES_DATASTREAM"), "ElasticSearch data stream")
+	f.StringVar(&c.ESUsername, "logs-es-username", os.Getenv("LOGS_ES_USERNAME"), "ElasticSearch basic auth username")
+	f.StringVar(&c.ESPassword, "logs-es-password", os.Getenv("LOGS_ES_PASSWORD"), "ElasticSearch basic auth password")
+	f.StringVar(&c.ESAPIKey, "logs-es-apikey", os.Getenv("LOGS_ES_APIKEY"), "ElasticSearch API key (base64 encoded), preferred over username and password")
+	f.StringVar(&c.ESDeadLetterFile, "logs-es-dead-letter-file", os.Getenv("LOGS_ES_DEAD_LETTER_FILE"), "Blob filename template for writing entries permanently rejected by ElasticSearch")
 	f.StringVar(&c.JaegerAddr, "logs-jaeger-addr", os.Getenv("LOGS_JAEGER_ADDR"), "Jaeger server address (host:port)")
+	f.StringVar(&c.JaegerProcessTags, "logs-jaeger-tags", os.Getenv("LOGS_JAEGER_TAGS"), "Jaeger process tags as comma separated key=value pairs, e.g. hostname=host1,env=prod")
+	f.StringVar(&c.OTLPAddr, "logs-otlp-addr", os.Getenv("LOGS_OTLP_ADDR"), "OTLP trace collector gRPC address (host:port)")
 	f.StringVar(&c.RemoteAddr, "logs-remote-addr", os.Getenv("LOGS_REMOTE_ADDR"), "Remote server address (host:port)")
 	f.BoolVar(&c.RemoteInsecure, "logs-remote-insecure", false, "Remote server address is insecre")
+	f.StringVar(&c.RemoteCACert, "logs-remote-ca-cert", os.Getenv("LOGS_REMOTE_CA_CERT"), "PEM encoded CA certificates file for verifying the remote server")
+	f.StringVar(&c.RemoteClientCert, "logs-remote-client-cert", os.Getenv("LOGS_REMOTE_CLIENT_CERT"), "PEM encoded client certificate file for mutual TLS with the remote server")
+	f.StringVar(&c.RemoteClientKey, "logs-remote-client-key", os.Getenv("LOGS_REMOTE_CLIENT_KEY"), "PEM encoded client private key file for mutual TLS with the remote server")
+	f.StringVar(&c.RemoteServerName, "logs-remote-server-name", os.Getenv("LOGS_REMOTE_SERVER_NAME"), "Override the server name for verifying the remote server certificate")
 	f.IntVar(&c.ChunkedMaxBuffer, "logs-chunked-buffer-max", c.ChunkedMaxBuffer, "Logs chunked emitter: max buffer of unstreamed logs")
 	f.IntVar(&c.ChunkedMaxBatch, "logs-chunked-batch-max", c.ChunkedMaxBatch, "Logs chunked emitter: max size in one batch")
 	f.DurationVar(&c.ChunkedCollectPeriod, "logs-chunked-collect-period", c.ChunkedCollectPeriod, "Logs chunked emitter: batch period")
+	f.IntVar(&c.ChunkedCollectJitter, "logs-chunked-collect-jitter", c.ChunkedCollectJitter, "Logs chunked emitter: random percentage of batch period to spread flushes, 0 disables jitter")
+	f.StringVar(&c.ChunkedOverrun, "logs-chunked-overrun", c.ChunkedOverrun, "Logs chunked emitter: overrun policy (drop-oldest, drop-newest, block)")
+	f.DurationVar(&c.ChunkedBlockTimeout, "logs-chunked-block-timeout", c.ChunkedBlockTimeout, "Logs chunked emitter: max blocking time with overrun policy block")
+	f.IntVar(&c.ChunkedConcurrency, "logs-chunked-concurrency", c.ChunkedConcurrency, "Logs chunked emitter: max chunks streamed concurrently")
+	f.IntVar(&c.BreakerThreshold, "logs-breaker-threshold", c.BreakerThreshold, "Streamers stop attempting after the number of consecutive failures for a cooldown, 0 disables circuit breaker")
+	f.DurationVar(&c.BreakerCooldown, "logs-breaker-cooldown", c.BreakerCooldown, "Streamers circuit breaker cooldown before probing the backend again")
+	f.BoolVar(&c.BuildInfo, "logs-build-info", envOrBool("LOGS_BUILD_INFO", c.BuildInfo), "Set build version and VCS revision as attributes of the default logger")
+	f.StringVar(&c.ServiceVersion, "logs-service-version", os.Getenv("LOGS_SERVICE_VERSION"), "Override the service version in build info")
+	f.StringVar(&c.VCSRevision, "logs-vcs-revision", os.Getenv("LOGS_VCS_REVISION"), "Override the VCS revision in build info")
+	f.BoolVar(&c.TraceFromEnv, "logs-trace-from-env", envOrBool("LOGS_TRACE_FROM_ENV", c.TraceFromEnv), "Continue the trace in the "+logs.TraceParentEnv+" environment variable (W3C traceparent) with the default logger")
+	f.BoolVar(&c.Sequence, "logs-sequence", envOrBool("LOGS_SEQUENCE", c.Sequence), "Set a sequence number attribute ("+logs.SequenceAttr+") on each entry to detect lost entries")
 	f.BoolVar(&c.EmitterVerbose, "logs-emitter-verbose", c.EmitterVerbose, "Allow emitters write error logs using emergent logger")
+	f.StringVar(&c.StatusAddr, "logs-status-addr", os.Getenv("LOGS_STATUS_ADDR"), "Listening address of HTTP status endpoint (e.g. emit to durable write latency)")
 }
 
 // Emitter creates LogEmitter based on the current configuration.
@@ -94,8 +193,13 @@ func (c *Config) Emitter() (logs.LogEmitter, error) {
 	var emitters logs.MultiEmitter
 	switch c.ConsolePrinter {
 	case "", "default":
+		theme, err := console.ParseTheme(c.Theme)
+		if err != nil {
+			return nil, err
+		}
 		printer := console.NewPrinter(os.Stderr)
 		printer.UseColor(c.Color)
+		printer.SetTheme(theme)
 		emitters = append(emitters, printer)
 	case "json":
 		emitters = append(emitters, &console.Emitter{Printer: console.NewPrinter(os.Stderr), JSON: true})
@@ -124,12 +228,36 @@ func (c *Config) Emitter() (logs.LogEmitter, error) {
 		return nil, fmt.Errorf("unknown console printer: %s", c.ConsolePrinter)
 	}
 
-	if c.BlobFile != "" {
+	if c.BlobFile != "" && (c.BlobVersion < 0 || c.BlobVersion > blobformat.FormatVersion) {
+		return nil, fmt.Errorf("unsupported blob format version %d", c.BlobVersion)
+	}
+	if c.BlobFile != "" && c.BlobShardAttr != "" {
+		fn, err := blob.CreateShardFileWith(c.BlobFile)
+		if err != nil {
+			return nil, fmt.Errorf("blob filename template: %w", err)
+		}
+		shardedEmitter := &blob.ShardedEmitter{
+			Attribute:    c.BlobShardAttr,
+			CreateFile:   fn,
+			Sync:         c.BlobSync,
+			SizeLimit:    c.BlobSizeLimit,
+			WrapAny:      c.BlobWrapAny,
+			Version:      c.BlobVersion,
+			MaxOpenFiles: c.BlobShardMaxOpen,
+		}
+		c.closers = append(c.closers, func(context.Context) error { return shardedEmitter.Close() })
+		emitters = append(emitters, shardedEmitter)
+	} else if c.BlobFile != "" {
 		fn, err := blob.CreateFileWith(c.BlobFile)
 		if err != nil {
 			return nil, fmt.Errorf("blob filename template: %w", err)
 		}
-		emitters = append(emitters, &blob.Emitter{CreateFile: fn, Sync: c.BlobSync, SizeLimit: c.BlobSizeLimit})
+		blobEmitter := &blob.Emitter{CreateFile: fn, Sync: c.BlobSync, SizeLimit: c.BlobSizeLimit, WrapAny: c.BlobWrapAny, Version: c.BlobVersion, RetryQueueSize: c.BlobRetryMax}
+		if c.BlobRotateHUP {
+			RotateOnSignal(blobEmitter, syscall.SIGHUP)
+		}
+		c.closers = append(c.closers, func(context.Context) error { return blobEmitter.Close() })
+		emitters = append(emitters, blobEmitter)
 	}
 
 	if c.ESServerURL != "" {
@@ -141,7 +269,15 @@ func (c *Config) Emitter() (logs.LogEmitter, error) {
 		}
 		s := elasticsearch.NewStreamer(c.ClientName, c.ESDataStream, c.ESServerURL)
 		s.Verbose = c.EmitterVerbose
-		emitters = append(emitters, logs.NewStreamEmitter(s))
+		s.Username, s.Password, s.APIKey = c.ESUsername, c.ESPassword, c.ESAPIKey
+		if c.ESDeadLetterFile != "" {
+			fn, err := blob.CreateFileWith(c.ESDeadLetterFile)
+			if err != nil {
+				return nil, fmt.Errorf("dead letter filename template: %w", err)
+			}
+			s.DeadLetter = &blob.Emitter{CreateFile: fn, Sync: c.BlobSync}
+		}
+		emitters = append(emitters, logs.NewStreamEmitter(c.breakerStreamer("elasticsearch", s)))
 	}
 
 	if c.JaegerAddr != "" {
@@ -152,8 +288,30 @@ func (c *Config) Emitter() (logs.LogEmitter, error) {
 		if err != nil {
 			return nil, fmt.Errorf("streamer Jaeger creation error: %w", err)
 		}
-		chunkedEmitter := logs.NewChunkedEmitter(reporter, c.ChunkedMaxBuffer, c.ChunkedMaxBatch)
-		chunkedEmitter.CollectPeriod = c.ChunkedCollectPeriod
+		tags, err := parseTags(c.JaegerProcessTags)
+		if err != nil {
+			return nil, fmt.Errorf("streamer Jaeger process tags: %w", err)
+		}
+		reporter.SetProcessTags(tags...)
+		chunkedEmitter, err := c.chunkedEmitter("jaeger", reporter)
+		if err != nil {
+			return nil, err
+		}
+		emitters = append(emitters, chunkedEmitter)
+	}
+
+	if c.OTLPAddr != "" {
+		if c.ClientName == "" {
+			return nil, fmt.Errorf("streamer OTLP requires client name")
+		}
+		exporter, err := otlp.New(c.ClientName, c.OTLPAddr, nil)
+		if err != nil {
+			return nil, fmt.Errorf("streamer OTLP creation error: %w", err)
+		}
+		chunkedEmitter, err := c.chunkedEmitter("otlp", exporter)
+		if err != nil {
+			return nil, err
+		}
 		emitters = append(emitters, chunkedEmitter)
 	}
 
@@ -161,22 +319,96 @@ func (c *Config) Emitter() (logs.LogEmitter, error) {
 		if c.ClientName == "" {
 			return nil, fmt.Errorf("streamer Remote requires client name")
 		}
-		var opts []grpc.DialOption
-		if c.RemoteInsecure {
-			opts = append(opts, grpc.WithInsecure())
+		opts, err := c.RemoteDialOptions()
+		if err != nil {
+			return nil, fmt.Errorf("streamer Remote: %w", err)
 		}
 		streamer, err := remote.NewStreamer(c.ClientName, c.RemoteAddr, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("streamer Remote creation error: %w", err)
 		}
 		streamer.Verbose = c.EmitterVerbose
-		emitters = append(emitters, logs.NewStreamEmitter(streamer))
+		emitters = append(emitters, logs.NewStreamEmitter(c.breakerStreamer("remote", streamer)))
 	}
 
+	var emitter logs.LogEmitter = emitters
 	if len(emitters) == 1 {
-		return emitters[0], nil
+		emitter = emitters[0]
+	}
+	if c.Sequence {
+		emitter = logs.NewSequenceEmitter(emitter)
+	}
+	return emitter, nil
+}
+
+// RemoteDialOptions builds the gRPC dial options for the remote streamer.
+func (c *Config) RemoteDialOptions() ([]grpc.DialOption, error) {
+	if c.RemoteInsecure {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+	tlsConfig, err := c.RemoteTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}, nil
+}
+
+// RemoteTLSConfig builds the TLS configuration for the remote streamer.
+func (c *Config) RemoteTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: c.RemoteServerName}
+	if c.RemoteCACert != "" {
+		data, err := os.ReadFile(c.RemoteCACert)
+		if err != nil {
+			return nil, fmt.Errorf("read CA cert: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA cert %q", c.RemoteCACert)
+		}
+	}
+	switch {
+	case c.RemoteClientCert != "" && c.RemoteClientKey != "":
+		cert, err := tls.LoadX509KeyPair(c.RemoteClientCert, c.RemoteClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case c.RemoteClientCert != "" || c.RemoteClientKey != "":
+		return nil, fmt.Errorf("client cert and client key must be specified together")
+	}
+	return tlsConfig, nil
+}
+
+// chunkedEmitter creates a ChunkedEmitter with the chunked streaming configurations.
+func (c *Config) chunkedEmitter(name string, streamer logs.ChunkedStreamer) (*logs.ChunkedEmitter, error) {
+	overrun, err := logs.ParseOverrunPolicy(c.ChunkedOverrun)
+	if err != nil {
+		return nil, err
+	}
+	if c.BreakerThreshold > 0 {
+		streamer = &logs.BreakerChunkedStreamer{Streamer: streamer, Breaker: c.circuitBreaker(name)}
 	}
-	return emitters, nil
+	chunkedEmitter := logs.NewChunkedEmitter(streamer, c.ChunkedMaxBuffer, c.ChunkedMaxBatch)
+	chunkedEmitter.CollectPeriod = c.ChunkedCollectPeriod
+	chunkedEmitter.CollectJitter = float64(c.ChunkedCollectJitter) / 100
+	chunkedEmitter.Concurrency = c.ChunkedConcurrency
+	chunkedEmitter.OverrunPolicy = overrun
+	chunkedEmitter.BlockTimeout = c.ChunkedBlockTimeout
+	c.closers = append(c.closers, chunkedEmitter.Close)
+	return chunkedEmitter, nil
+}
+
+func (c *Config) breakerStreamer(name string, streamer logs.LogStreamer) logs.LogStreamer {
+	if c.BreakerThreshold <= 0 {
+		return streamer
+	}
+	return &logs.BreakerStreamer{Streamer: streamer, Breaker: c.circuitBreaker(name)}
+}
+
+func (c *Config) circuitBreaker(name string) *logs.CircuitBreaker {
+	b := logs.NewCircuitBreaker(name)
+	b.Threshold, b.Cooldown = c.BreakerThreshold, c.BreakerCooldown
+	return b
 }
 
 // SetupDefaultLogger sets up the default logger.
@@ -185,7 +417,22 @@ func (c *Config) SetupDefaultLogger() error {
 	if err != nil {
 		return err
 	}
-	logs.Setup(emitter)
+	if c.StatusAddr != "" {
+		srv, err := ServeStatus(c.StatusAddr)
+		if err != nil {
+			return fmt.Errorf("status endpoint: %w", err)
+		}
+		c.closers = append(c.closers, srv.Shutdown)
+	}
+	logger := logs.Setup(emitter)
+	if c.BuildInfo {
+		logger.SetAttrs(logs.BuildInfo(c.ServiceVersion, c.VCSRevision))
+	}
+	if c.TraceFromEnv {
+		if info, ok := logs.SpanInfoFromEnv(); ok {
+			logger.SetRemoteSpan(info)
+		}
+	}
 	return nil
 }
 
@@ -197,6 +444,92 @@ func (c *Config) MustSetupDefaultLogger() {
 	}
 }
 
+// Close flushes and stops the emitters created by Emitter, and the status
+// endpoint served by SetupDefaultLogger, for clean shutdown,
+// until ctx is done.
+func (c *Config) Close(ctx context.Context) error {
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.closers = nil
+	if len(errs) > 0 {
+		return fmt.Errorf("close emitters: %v", errs)
+	}
+	return nil
+}
+
+// Rotator rotates the files.
+type Rotator interface {
+	Rotate() error
+}
+
+// RotatorFunc is the func form of Rotator.
+type RotatorFunc func() error
+
+// Rotate implements Rotator.
+func (f RotatorFunc) Rotate() error {
+	return f()
+}
+
+// RotateOnSignal starts a goroutine to rotate on receiving the signals.
+func RotateOnSignal(r Rotator, sig ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+	go func() {
+		for range ch {
+			if err := r.Rotate(); err != nil {
+				logs.Emergent().Error(err).PrintErr("Rotate: ")
+			}
+		}
+	}()
+}
+
+// ServeStatus installs a LatencyStats as the latency observer and serves it
+// with the states of circuit breakers on the specified address at /status.
+// The returned server is shut down by the caller.
+func ServeStatus(addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	stats := &logs.LatencyStats{}
+	logs.SetLatencyObserver(stats)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"latency":  stats.Snapshot(),
+			"breakers": logs.BreakerStates(),
+		})
+	})
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logs.Emergent().Error(err).PrintErr("Status: ")
+		}
+	}()
+	return srv, nil
+}
+
+// parseTags parses comma separated key=value pairs into attributes.
+func parseTags(str string) ([]logs.AttributeSetter, error) {
+	var attrs []logs.AttributeSetter
+	for _, item := range strings.Split(str, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(item, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q, expect key=value", item)
+		}
+		attrs = append(attrs, logs.Str(key, strings.TrimSpace(val)))
+	}
+	return attrs, nil
+}
+
 func envOrInt(envVar string, defVal int) int {
 	val := os.Getenv(envVar)
 	if val == "" {
@@ -207,3 +540,10 @@ func envOrInt(envVar string, defVal int) int {
 	}
 	return defVal
 }
+
+func envOrBool(envVar string, defVal bool) bool {
+	if boolVal, err := strconv.ParseBool(os.Getenv(envVar)); err == nil {
+		return boolVal
+	}
+	return defVal
+}
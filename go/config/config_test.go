@@ -0,0 +1,335 @@
+package config
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// writeSelfSignedCert generates a self-signed certificate for both server and
+// client authentication, and writes the PEM encoded cert and key files.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "logs-test"},
+		DNSNames:              []string{"logs.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestRemoteTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(mustParseCert(t, cert))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	c := &Config{
+		RemoteCACert:     certFile,
+		RemoteClientCert: certFile,
+		RemoteClientKey:  keyFile,
+		RemoteServerName: "logs.test",
+	}
+	opts, err := c.RemoteDialOptions()
+	if err != nil {
+		t.Fatalf("RemoteDialOptions: %v", err)
+	}
+	conn, err := grpc.Dial(ln.Addr().String(), opts...)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+}
+
+func mustParseCert(t *testing.T, cert tls.Certificate) *x509.Certificate {
+	t.Helper()
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return parsed
+}
+
+func TestRemoteTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+	testCases := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "missing CA cert", config: Config{RemoteCACert: filepath.Join(dir, "missing.pem")}, expected: "read CA cert"},
+		{name: "invalid CA cert", config: Config{RemoteCACert: keyFile}, expected: "no certificates found"},
+		{name: "invalid client key", config: Config{RemoteClientCert: certFile, RemoteClientKey: certFile}, expected: "load client cert"},
+		{name: "client cert without key", config: Config{RemoteClientCert: certFile}, expected: "must be specified together"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.config.RemoteTLSConfig()
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expect error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	testCases := []struct {
+		str      string
+		expected map[string]string
+		failed   bool
+	}{
+		{str: "", expected: map[string]string{}},
+		{str: "hostname=host1", expected: map[string]string{"hostname": "host1"}},
+		{str: " hostname = host1 , env=prod,", expected: map[string]string{"hostname": "host1", "env": "prod"}},
+		{str: "version=", expected: map[string]string{"version": ""}},
+		{str: "hostname", failed: true},
+		{str: "=host1", failed: true},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.str, func(t *testing.T) {
+			attrs, err := parseTags(tc.str)
+			if failed := err != nil; failed != tc.failed {
+				t.Fatalf("Expect failed %v, got error %v", tc.failed, err)
+			}
+			if tc.failed {
+				return
+			}
+			values := make(map[string]*logspb.Value)
+			logs.AttributeSetters(attrs).SetAttributes(values)
+			tags := make(map[string]string)
+			for key, val := range values {
+				tags[key] = val.GetStrValue()
+			}
+			if !reflect.DeepEqual(tags, tc.expected) {
+				t.Errorf("Expect tags %v, got %v", tc.expected, tags)
+			}
+		})
+	}
+}
+
+type recordingChunkedStreamer struct {
+	lock    sync.Mutex
+	entries []*logspb.LogEntry
+}
+
+func (s *recordingChunkedStreamer) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo) (logs.ChunkedLogStreamer, error) {
+	return s, nil
+}
+
+func (s *recordingChunkedStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingChunkedStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.entries) == 0 {
+		return 0, nil
+	}
+	return s.entries[len(s.entries)-1].GetNanoTs(), nil
+}
+
+func TestConfigClose(t *testing.T) {
+	c := Default()
+	c.ChunkedCollectPeriod = time.Hour
+	streamer := &recordingChunkedStreamer{}
+	emitter, err := c.chunkedEmitter("test", streamer)
+	if err != nil {
+		t.Fatalf("chunkedEmitter: %v", err)
+	}
+	for n := 1; n <= 10; n++ {
+		emitter.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(streamer.entries) != 10 {
+		t.Errorf("Expect 10 entries flushed, got %d", len(streamer.entries))
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("Close again: %v", err)
+	}
+}
+
+func TestStatusEndpoint(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	defaultLogger := logs.Default()
+	t.Cleanup(func() { logs.SetDefault(defaultLogger) })
+
+	c := Default()
+	c.StatusAddr = addr
+	// Creating emitters doesn't serve the status endpoint.
+	for n := 0; n < 2; n++ {
+		if _, err := c.Emitter(); err != nil {
+			t.Fatalf("Emitter: %v", err)
+		}
+	}
+	if err := c.SetupDefaultLogger(); err == nil {
+		t.Fatalf("Expect error serving on an address in use")
+	}
+	ln.Close()
+
+	if err := c.SetupDefaultLogger(); err != nil {
+		t.Fatalf("SetupDefaultLogger: %v", err)
+	}
+	resp, err := http.Get("http://" + addr + "/status")
+	if err != nil {
+		t.Fatalf("Get status: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expect status 200, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// The address is available after Close.
+	if err := c.SetupDefaultLogger(); err != nil {
+		t.Fatalf("SetupDefaultLogger after Close: %v", err)
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestConfigCloseBlobFiles(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name      string
+		shardAttr string
+	}{
+		{name: "single"},
+		{name: "sharded", shardAttr: "tenant"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			c.BlobFile = filepath.Join(dir, tc.name+".blob.gz")
+			c.BlobShardAttr = tc.shardAttr
+			emitter, err := c.Emitter()
+			if err != nil {
+				t.Fatalf("Emitter: %v", err)
+			}
+			for n := 1; n <= 10; n++ {
+				emitter.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+			}
+			if err := c.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			// The gzip stream is complete only if the file is closed.
+			f, err := os.Open(c.BlobFile)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer f.Close()
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("gzip.NewReader: %v", err)
+			}
+			r := &blob.Reader{R: gz}
+			var count int
+			for {
+				if _, err := r.Read(); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				count++
+			}
+			if count != 10 {
+				t.Errorf("Expect 10 entries, got %d", count)
+			}
+		})
+	}
+}
@@ -8,66 +8,204 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/evo-cloud/logs/go/blob"
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
 )
 
+const (
+	gzipSuffix           = ".gz"
+	defaultRetryDelay    = time.Second
+	defaultRetryAttempts = 5
+)
+
 // Emitter emits log entries encoded in binary protos.
 type Emitter struct {
 	CreateFile func() (io.Writer, error)
 	Sync       bool
 	SizeLimit  int64
+	WrapAny    bool
+	// Version is the blob format version, blob.DefaultFormatVersion if 0.
+	Version int
 
-	writerLock sync.RWMutex
+	// RetryQueueSize is the max total size (in bytes) of entries queued for
+	// retrying on write errors. Zero disables retrying.
+	RetryQueueSize int
+	// RetryDelay is the delay before retrying queued entries when no more entries
+	// are emitted.
+	RetryDelay time.Duration
+	// RetryAttempts is the max consecutive failed retries before dropping the queue.
+	RetryAttempts int
+
+	// writerLock serializes the writes, and the rotation of the writer.
+	writerLock sync.Mutex
 	writer     *blob.Writer
+
+	retryLock     sync.Mutex
+	retryQueue    []*logspb.LogEntry
+	retrySize     int
+	retryFailures int
+	retryTimer    *time.Timer
 }
 
 // EmitLogEntry implements LogEmitter.
 func (e *Emitter) EmitLogEntry(entry *logspb.LogEntry) {
-	e.writerLock.RLock()
-	w := e.writer
-	e.writerLock.RUnlock()
+	if e.RetryQueueSize <= 0 {
+		if err := e.writeLogEntry(entry); err != nil {
+			logs.Emergent().Error(err).PrintErr("BlobWriter: ")
+		}
+		return
+	}
+	e.retryLock.Lock()
+	defer e.retryLock.Unlock()
+	if e.flushRetryQueue() {
+		if err := e.writeLogEntry(entry); err == nil {
+			return
+		}
+	}
+	e.enqueueRetry(entry)
+}
+
+func (e *Emitter) writeLogEntry(entry *logspb.LogEntry) error {
+	e.writerLock.Lock()
+	defer e.writerLock.Unlock()
 	for {
-		var err error
-		if w != nil {
-			if err = w.WriteLogEntry(entry); err == nil {
-				return
+		if e.writer != nil {
+			err := e.writer.WriteLogEntry(entry)
+			if err == nil {
+				return nil
 			}
-			e.closeWriter()
+			e.writer.Close()
+			e.writer = nil
 			if !errors.Is(err, blob.ErrSizeLimitExceeded) {
-				logs.Emergent().Error(err).PrintErr("BlobWriter: ")
-				return
+				return err
 			}
 		}
-		if w, err = e.newFile(); err != nil {
-			logs.Emergent().Error(err).PrintErr("BlobWriter CreateFile: ")
-			return
+		w, err := e.newFile()
+		if err != nil {
+			return fmt.Errorf("CreateFile: %w", err)
+		}
+		e.writer = w
+	}
+}
+
+// flushRetryQueue writes the queued entries and returns true if the queue is empty.
+// It must be called with retryLock held.
+func (e *Emitter) flushRetryQueue() bool {
+	for len(e.retryQueue) > 0 {
+		entry := e.retryQueue[0]
+		if err := e.writeLogEntry(entry); err != nil {
+			attempts := e.RetryAttempts
+			if attempts <= 0 {
+				attempts = defaultRetryAttempts
+			}
+			if e.retryFailures++; e.retryFailures >= attempts {
+				logs.Emergent().Error(err).Printf("BlobWriter: drop %d entries after %d retries", len(e.retryQueue), e.retryFailures)
+				e.retryQueue, e.retrySize, e.retryFailures = nil, 0, 0
+				return true
+			}
+			return false
 		}
+		e.retryQueue[0] = nil
+		e.retryQueue = e.retryQueue[1:]
+		e.retrySize -= proto.Size(entry)
 	}
+	e.retryQueue, e.retrySize, e.retryFailures = nil, 0, 0
+	return true
 }
 
-func (e *Emitter) closeWriter() {
+// enqueueRetry queues the entry for retrying. It must be called with retryLock held.
+func (e *Emitter) enqueueRetry(entry *logspb.LogEntry) {
+	size := proto.Size(entry)
+	if e.retrySize+size > e.RetryQueueSize {
+		logs.Emergent().Errorf("BlobWriter: retry queue full, drop entry of %d bytes", size)
+		return
+	}
+	e.retryQueue = append(e.retryQueue, entry)
+	e.retrySize += size
+	e.scheduleRetry()
+}
+
+// scheduleRetry starts a timer to retry the queue. It must be called with retryLock held.
+func (e *Emitter) scheduleRetry() {
+	if e.retryTimer != nil {
+		return
+	}
+	delay := e.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	e.retryTimer = time.AfterFunc(delay, e.retryLater)
+}
+
+func (e *Emitter) retryLater() {
+	e.retryLock.Lock()
+	defer e.retryLock.Unlock()
+	e.retryTimer = nil
+	if !e.flushRetryQueue() {
+		e.scheduleRetry()
+	}
+}
+
+// Rotate starts a new file immediately and closes the current one.
+// If the new file can't be created, the current file is kept.
+func (e *Emitter) Rotate() error {
 	e.writerLock.Lock()
-	if e.writer != nil {
-		e.writer.Close()
-		e.writer = nil
+	w, err := e.newFile()
+	if err != nil {
+		e.writerLock.Unlock()
+		return err
 	}
+	old := e.writer
+	e.writer = w
 	e.writerLock.Unlock()
+	// The old writer is no longer used by any writes once swapped under the lock.
+	if old != nil {
+		return old.Close()
+	}
+	return nil
 }
 
-func (e *Emitter) newFile() (*blob.Writer, error) {
+// Close stops retrying, writes the queued entries if possible, and closes the
+// current file, e.g. to complete the gzip stream.
+func (e *Emitter) Close() error {
+	e.retryLock.Lock()
+	if e.retryTimer != nil {
+		e.retryTimer.Stop()
+		e.retryTimer = nil
+	}
+	if !e.flushRetryQueue() {
+		logs.Emergent().Printf("BlobWriter: drop %d entries on close", len(e.retryQueue))
+		e.retryQueue, e.retrySize, e.retryFailures = nil, 0, 0
+	}
+	e.retryLock.Unlock()
+	return e.closeWriter()
+}
+
+func (e *Emitter) closeWriter() error {
 	e.writerLock.Lock()
 	defer e.writerLock.Unlock()
+	if e.writer == nil {
+		return nil
+	}
+	err := e.writer.Close()
+	e.writer = nil
+	return err
+}
+
+// newFile must be called with writerLock held.
+func (e *Emitter) newFile() (*blob.Writer, error) {
 	f, err := e.CreateFile()
 	if err != nil {
 		return nil, err
 	}
-	e.writer = &blob.Writer{W: f, Sync: e.Sync, SizeLimit: e.SizeLimit}
-	return e.writer, nil
+	return &blob.Writer{W: f, Sync: e.Sync, SizeLimit: e.SizeLimit, WrapAny: e.WrapAny, Version: e.Version}, nil
 }
 
 type filenameTemplateContext struct {
@@ -81,6 +219,7 @@ type filenameTemplateContext struct {
 // - {{.Timestamp}} a timestamp in unix seconds.
 // - {{.Nanos}} the nano seconds part of the timestamp.
 // - {{.Sequence}} auto-incremented sequence, starting from 0.
+// If the filename ends with .gz, the file is compressed using blob.GzipWriter.
 func CreateFileWith(filenameTemplate string) (func() (io.Writer, error), error) {
 	tpl, err := template.New("").Parse(filenameTemplate)
 	if err != nil {
@@ -102,6 +241,9 @@ func CreateFileWith(filenameTemplate string) (func() (io.Writer, error), error)
 			return nil, err
 		}
 		tplCtx.Sequence++
+		if strings.HasSuffix(fn, gzipSuffix) {
+			return blob.NewGzipWriter(f), nil
+		}
 		return f, nil
 	}, nil
 }
@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestEmitterConcurrentRotate(t *testing.T) {
+	dir := t.TempDir()
+	createFile, err := CreateFileWith(filepath.Join(dir, "{{.Sequence}}.blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitter := &Emitter{CreateFile: createFile}
+	const numWriters, numEntries, numRotations = 4, 200, 20
+	var wg sync.WaitGroup
+	for n := 0; n < numWriters; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numEntries; i++ {
+				emitter.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(i + 1), Message: "message"})
+			}
+		}()
+	}
+	rotateErrs := make(chan error, numRotations)
+	for n := 0; n < numRotations; n++ {
+		rotateErrs <- emitter.Rotate()
+	}
+	wg.Wait()
+	emitter.closeWriter()
+	close(rotateErrs)
+	for err := range rotateErrs {
+		if err != nil {
+			t.Errorf("Rotate: %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for _, fn := range files {
+		f, err := os.Open(fn)
+		if err != nil {
+			t.Fatalf("Open %s: %v", fn, err)
+		}
+		r := &blob.Reader{R: f}
+		for {
+			if _, err := r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Read %s: %v", fn, err)
+			}
+			count++
+		}
+		f.Close()
+	}
+	if count != numWriters*numEntries {
+		t.Errorf("Expect %d entries, got %d", numWriters*numEntries, count)
+	}
+}
@@ -0,0 +1,191 @@
+package blob
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	defaultMaxOpenFiles = 64
+	defaultShard        = "default"
+)
+
+// ShardedEmitter emits log entries into different blob files by the value of an attribute,
+// e.g. one file per tenant. At most MaxOpenFiles files are open concurrently, and the least
+// recently used one is closed when a new file needs to be opened.
+type ShardedEmitter struct {
+	// Attribute is the attribute whose value selects the shard.
+	Attribute string
+	// DefaultShard is the shard of entries without the attribute.
+	// If empty, "default" is used.
+	DefaultShard string
+	// CreateFile creates a file for the shard. The file may be reopened after closed
+	// for exceeding MaxOpenFiles, so it should append to an existing file or use a
+	// different name, see CreateShardFileWith.
+	CreateFile func(shard string) (io.Writer, error)
+	Sync       bool
+	// SizeLimit limits the size written to a file since it's opened.
+	SizeLimit int64
+	WrapAny   bool
+	// Version is the blob format version, blob.DefaultFormatVersion if 0.
+	Version int
+	// MaxOpenFiles is the max number of files open concurrently.
+	// If not positive, a default of 64 is used.
+	MaxOpenFiles int
+
+	lock   sync.Mutex
+	shards map[string]*list.Element
+	lru    list.List
+}
+
+type shardEmitter struct {
+	shard   string
+	emitter *Emitter
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *ShardedEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	shard := e.shardOf(entry)
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.emitterOf(shard).EmitLogEntry(entry)
+}
+
+// Close closes all open files, and returns the first error.
+func (e *ShardedEmitter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	var err error
+	for elem := e.lru.Front(); elem != nil; elem = elem.Next() {
+		if closeErr := elem.Value.(*shardEmitter).emitter.closeWriter(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	e.lru.Init()
+	e.shards = nil
+	return err
+}
+
+// emitterOf must be called with lock held.
+func (e *ShardedEmitter) emitterOf(shard string) *Emitter {
+	if elem, ok := e.shards[shard]; ok {
+		e.lru.MoveToFront(elem)
+		return elem.Value.(*shardEmitter).emitter
+	}
+	maxOpen := e.MaxOpenFiles
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenFiles
+	}
+	for e.lru.Len() >= maxOpen {
+		se := e.lru.Remove(e.lru.Back()).(*shardEmitter)
+		se.emitter.closeWriter()
+		delete(e.shards, se.shard)
+	}
+	if e.shards == nil {
+		e.shards = make(map[string]*list.Element)
+	}
+	se := &shardEmitter{
+		shard: shard,
+		emitter: &Emitter{
+			CreateFile: func() (io.Writer, error) { return e.CreateFile(shard) },
+			Sync:       e.Sync,
+			SizeLimit:  e.SizeLimit,
+			WrapAny:    e.WrapAny,
+			Version:    e.Version,
+		},
+	}
+	e.shards[shard] = e.lru.PushFront(se)
+	return se.emitter
+}
+
+func (e *ShardedEmitter) shardOf(entry *logspb.LogEntry) string {
+	var shard string
+	switch v := entry.GetAttributes()[e.Attribute].GetValue().(type) {
+	case *logspb.Value_StrValue:
+		shard = v.StrValue
+	case *logspb.Value_IntValue:
+		shard = strconv.FormatInt(v.IntValue, 10)
+	case *logspb.Value_BoolValue:
+		shard = strconv.FormatBool(v.BoolValue)
+	}
+	if shard = sanitizeShard(shard); shard == "" {
+		if shard = e.DefaultShard; shard == "" {
+			shard = defaultShard
+		}
+	}
+	return shard
+}
+
+// sanitizeShard makes the shard name safe to be used in a filename.
+func sanitizeShard(shard string) string {
+	shard = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, shard)
+	if strings.Trim(shard, ".") == "" {
+		return ""
+	}
+	return shard
+}
+
+type shardFilenameTemplateContext struct {
+	filenameTemplateContext
+	Shard string
+}
+
+// CreateShardFileWith returns a CreateFile func for ShardedEmitter which creates a file using
+// the filenameTemplate. Besides the substitutions supported by CreateFileWith, {{.Shard}} is
+// the name of the shard. The file is opened for appending if it already exists.
+// If the filename ends with .gz, the file is compressed using blob.GzipWriter, and
+// reopened files contain multiple gzip streams which are read as one by gzip readers.
+func CreateShardFileWith(filenameTemplate string) (func(shard string) (io.Writer, error), error) {
+	tpl, err := template.New("").Parse(filenameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	var lock sync.Mutex
+	var sequence int64
+	return func(shard string) (io.Writer, error) {
+		now := time.Now()
+		lock.Lock()
+		tplCtx := &shardFilenameTemplateContext{
+			filenameTemplateContext: filenameTemplateContext{
+				Timestamp: now.Unix(),
+				Nanos:     now.UnixNano() - now.Unix()*1e9,
+				Sequence:  sequence,
+			},
+			Shard: shard,
+		}
+		sequence++
+		lock.Unlock()
+		var out bytes.Buffer
+		if err := tpl.Execute(&out, tplCtx); err != nil {
+			return nil, fmt.Errorf("generate filename: %w", err)
+		}
+		fn := out.String()
+		os.MkdirAll(filepath.Dir(fn), 0755)
+		f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(fn, gzipSuffix) {
+			return blob.NewGzipWriter(f), nil
+		}
+		return f, nil
+	}, nil
+}
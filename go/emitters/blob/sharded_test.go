@@ -0,0 +1,52 @@
+package blob
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evo-cloud/logs/go/blob"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestShardedEmitter(t *testing.T) {
+	dir := t.TempDir()
+	createFile, err := CreateShardFileWith(filepath.Join(dir, "{{.Shard}}.blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emitter := &ShardedEmitter{Attribute: "tenant", CreateFile: createFile, MaxOpenFiles: 2}
+	logger := logs.Root(emitter)
+	for _, tenant := range []string{"a", "b", "c", "a", "../x", "b"} {
+		logger.With(logs.Str("tenant", tenant)).Infof("hello")
+	}
+	logger.Infof("no tenant")
+	if n := emitter.lru.Len(); n != 2 {
+		t.Errorf("Expect 2 open files, got %d", n)
+	}
+	emitter.Close()
+
+	expected := map[string]int{"a.blob": 2, "b.blob": 2, "c.blob": 1, ".._x.blob": 1, "default.blob": 1}
+	for fn, count := range expected {
+		f, err := os.Open(filepath.Join(dir, fn))
+		if err != nil {
+			t.Errorf("Open %s: %v", fn, err)
+			continue
+		}
+		r := &blob.Reader{R: f}
+		var n int
+		for {
+			if _, err := r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Read %s: %v", fn, err)
+			}
+			n++
+		}
+		f.Close()
+		if n != count {
+			t.Errorf("Expect %d entries in %s, got %d", count, fn, n)
+		}
+	}
+}
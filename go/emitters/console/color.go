@@ -0,0 +1,69 @@
+package console
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// traceColor is a color from the xterm 256-color palette.
+type traceColor struct {
+	code int
+	rgb  string
+}
+
+var (
+	// cubeLevels are the RGB levels of the 6x6x6 color cube in xterm 256-color palette.
+	cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+	// traceColors is a stable palette of distinguishable colors readable on both
+	// dark and light backgrounds. The order must not change to keep the mapping stable.
+	traceColors = buildTraceColors()
+)
+
+func buildTraceColors() []traceColor {
+	var colors []traceColor
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				maxLevel, minLevel := r, r
+				for _, level := range []int{g, b} {
+					if level > maxLevel {
+						maxLevel = level
+					}
+					if level < minLevel {
+						minLevel = level
+					}
+				}
+				// Skip greys, too dark and too light colors.
+				if maxLevel == minLevel || maxLevel < 3 || minLevel > 3 {
+					continue
+				}
+				colors = append(colors, traceColor{
+					code: 16 + r*36 + g*6 + b,
+					rgb:  fmt.Sprintf("#%02x%02x%02x", cubeLevels[r], cubeLevels[g], cubeLevels[b]),
+				})
+			}
+		}
+	}
+	return colors
+}
+
+func traceColorOf(traceID string) traceColor {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(traceID)))
+	return traceColors[h.Sum32()%uint32(len(traceColors))]
+}
+
+// TraceColor returns a deterministic color in the form of #rrggbb for a trace ID.
+// The same trace ID always maps to the same color, matching the terminal output
+// of the Printer with ColorByTrace enabled, so other viewers can render consistent colors.
+func TraceColor(traceID string) string {
+	return traceColorOf(traceID).rgb
+}
+
+// TraceColorDecor returns the ANSI escape sequence of the color for a trace ID.
+func TraceColorDecor(traceID string) string {
+	return "\x1b[38;5;" + strconv.Itoa(traceColorOf(traceID).code) + "m"
+}
@@ -0,0 +1,125 @@
+package console
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// CSVColumns are the columns written by CSVEmitter before the attribute columns.
+var CSVColumns = []string{"time", "level", "location", "trace", "span", "message"}
+
+// CSVEmitter writes log entries as CSV rows, with a header row before the first entry.
+// The columns are CSVColumns followed by the specified attributes.
+type CSVEmitter struct {
+	Out io.Writer
+	// Columns are the names of the attributes in the columns. The cell is empty
+	// if the entry doesn't have the attribute.
+	Columns []string
+	// Comma is the field delimiter, ',' if zero, e.g. '\t' for TSV.
+	Comma rune
+	// TimeFormat is the format of the time column, RFC3339 with nanoseconds if empty.
+	TimeFormat string
+
+	lock   sync.Mutex
+	writer *csv.Writer
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *CSVEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.writer == nil {
+		e.writer = csv.NewWriter(e.Out)
+		if e.Comma != 0 {
+			e.writer.Comma = e.Comma
+		}
+		e.writer.Write(append(append([]string{}, CSVColumns...), e.Columns...))
+	}
+	timeFormat := e.TimeFormat
+	if timeFormat == "" {
+		timeFormat = time.RFC3339Nano
+	}
+	row := make([]string, 0, len(CSVColumns)+len(e.Columns))
+	var level string
+	if l := entry.GetLevel(); l != logspb.LogEntry_NONE {
+		level = l.String()
+	}
+	var traceID, spanID string
+	if spanCtx := entry.GetTrace().GetSpanContext(); spanCtx != nil {
+		traceID, spanID = logs.TraceIDStringFrom(spanCtx), logs.SpanIDStringFrom(spanCtx)
+	}
+	row = append(row,
+		time.Unix(0, entry.GetNanoTs()).Format(timeFormat),
+		level,
+		entry.GetLocation(),
+		traceID,
+		spanID,
+		entry.GetMessage(),
+	)
+	for _, name := range e.Columns {
+		var cell string
+		if val, ok := entry.GetAttributes()[name]; ok {
+			cell = plainValue(val)
+		}
+		row = append(row, cell)
+	}
+	e.writer.Write(row)
+	e.writer.Flush()
+}
+
+// plainValue formats an attribute value in plain text without truncation.
+func plainValue(val *logspb.Value) string {
+	switch v := val.GetValue().(type) {
+	case *logspb.Value_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *logspb.Value_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *logspb.Value_FloatValue:
+		return strconv.FormatFloat(float64(v.FloatValue), 'g', -1, 32)
+	case *logspb.Value_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *logspb.Value_StrValue:
+		return v.StrValue
+	case *logspb.Value_Json:
+		return v.Json
+	case *logspb.Value_Bytes:
+		return hex.EncodeToString(v.Bytes)
+	case *logspb.Value_Proto:
+		return hex.EncodeToString(v.Proto)
+	case *logspb.Value_Duration:
+		return time.Duration(v.Duration).String()
+	case *logspb.Value_Time:
+		return time.Unix(0, v.Time).Format(time.RFC3339Nano)
+	case *logspb.Value_Decimal:
+		return v.Decimal
+	case *logspb.Value_MapValue:
+		values := v.MapValue.GetValues()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteByte('{')
+		for n, key := range keys {
+			if n > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(key)
+			sb.WriteByte('=')
+			sb.WriteString(plainValue(values[key]))
+		}
+		sb.WriteByte('}')
+		return sb.String()
+	}
+	return ""
+}
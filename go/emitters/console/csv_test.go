@@ -0,0 +1,66 @@
+package console
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestCSVEmitter(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 890000000, time.UTC)
+	traceID := []byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	entries := []*logspb.LogEntry{
+		{
+			NanoTs:   ts.UnixNano(),
+			Level:    logspb.LogEntry_ERROR,
+			Location: "a.go:1",
+			Message:  `failed, "quoted"` + "\nsecond line",
+			Trace:    &logspb.Trace{SpanContext: &logspb.SpanContext{TraceId: traceID, SpanId: 0xabc}},
+			Attributes: map[string]*logspb.Value{
+				"user":  {Value: &logspb.Value_StrValue{StrValue: "alice"}},
+				"count": {Value: &logspb.Value_IntValue{IntValue: 3}},
+				"ok":    {Value: &logspb.Value_BoolValue{BoolValue: false}},
+			},
+		},
+		{
+			NanoTs:  ts.Add(time.Second).UnixNano(),
+			Message: "no attributes",
+			Attributes: map[string]*logspb.Value{
+				"ratio":   {Value: &logspb.Value_DoubleValue{DoubleValue: 0.5}},
+				"elapsed": {Value: &logspb.Value_Duration{Duration: int64(1200 * time.Millisecond)}},
+				"tags": {Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: map[string]*logspb.Value{
+					"b": {Value: &logspb.Value_IntValue{IntValue: 2}},
+					"a": {Value: &logspb.Value_StrValue{StrValue: "x"}},
+				}}}},
+			},
+		},
+	}
+	columns := []string{"user", "count", "ok", "ratio", "elapsed", "tags", "missing"}
+	expected := [][]string{
+		{"time", "level", "location", "trace", "span", "message", "user", "count", "ok", "ratio", "elapsed", "tags", "missing"},
+		{ts.Local().Format(time.RFC3339Nano), "ERROR", "a.go:1", "0102030405060708090a0b0c0d0e0f10", "0000000000000abc", `failed, "quoted"` + "\nsecond line", "alice", "3", "false", "", "", "", ""},
+		{ts.Add(time.Second).Local().Format(time.RFC3339Nano), "", "", "", "", "no attributes", "", "", "", "0.5", "1.2s", "{a=x b=2}", ""},
+	}
+	for _, comma := range []rune{0, '\t'} {
+		var out strings.Builder
+		emitter := &CSVEmitter{Out: &out, Columns: columns, Comma: comma}
+		for _, entry := range entries {
+			emitter.EmitLogEntry(entry)
+		}
+		r := csv.NewReader(strings.NewReader(out.String()))
+		if comma != 0 {
+			r.Comma = comma
+		}
+		rows, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("Comma %q: ReadAll: %v\n%s", comma, err, out.String())
+		}
+		if !reflect.DeepEqual(rows, expected) {
+			t.Errorf("Comma %q: expect rows\n%q\ngot\n%q", comma, expected, rows)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package console
 
 import (
-	"fmt"
-
 	"google.golang.org/protobuf/encoding/protojson"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
@@ -17,7 +15,7 @@ type Emitter struct {
 // EmitLogEntry implements LogEmitter.
 func (e *Emitter) EmitLogEntry(entry *logspb.LogEntry) {
 	if e.JSON {
-		fmt.Fprintln(e.Printer.Out, protojson.MarshalOptions{Multiline: false, UseProtoNames: true}.Format(entry))
+		e.Printer.write(protojson.MarshalOptions{Multiline: false, UseProtoNames: true}.Format(entry) + "\n")
 		return
 	}
 	e.Printer.EmitLogEntry(entry)
@@ -0,0 +1,231 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// GoFixtureEmitter prints log entries as Go source constructing the equivalent
+// logspb.LogEntry, e.g. for copying captured logs into test fixtures.
+// Each entry is printed as an element of a []*logspb.LogEntry literal.
+type GoFixtureEmitter struct {
+	Out io.Writer
+}
+
+type goWriter struct {
+	sb     strings.Builder
+	indent int
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *GoFixtureEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	var w goWriter
+	w.line("&logspb.LogEntry{")
+	w.indent++
+	if ts := entry.GetNanoTs(); ts != 0 {
+		w.line("NanoTs: %d,", ts)
+	}
+	if tr := entry.GetTrace(); tr != nil {
+		w.trace(tr)
+	}
+	if level := entry.GetLevel(); level != logspb.LogEntry_NONE {
+		w.line("Level: logspb.LogEntry_%s,", level)
+	}
+	if loc := entry.GetLocation(); loc != "" {
+		w.line("Location: %s,", strconv.Quote(loc))
+	}
+	if msg := entry.GetMessage(); msg != "" {
+		w.line("Message: %s,", strconv.Quote(msg))
+	}
+	if attrs := entry.GetAttributes(); len(attrs) > 0 {
+		w.attributes("Attributes", attrs)
+	}
+	if order := entry.GetAttributeOrder(); len(order) > 0 {
+		quoted := make([]string, len(order))
+		for n, key := range order {
+			quoted[n] = strconv.Quote(key)
+		}
+		w.line("AttributeOrder: []string{%s},", strings.Join(quoted, ", "))
+	}
+	w.indent--
+	w.line("},")
+	io.WriteString(e.Out, w.sb.String())
+}
+
+func (w *goWriter) line(format string, args ...interface{}) {
+	w.sb.WriteString(strings.Repeat("\t", w.indent))
+	fmt.Fprintf(&w.sb, format, args...)
+	w.sb.WriteByte('\n')
+}
+
+func (w *goWriter) trace(tr *logspb.Trace) {
+	w.line("Trace: &logspb.Trace{")
+	w.indent++
+	if spanCtx := tr.GetSpanContext(); spanCtx != nil {
+		w.line("SpanContext: %s,", goSpanContext(spanCtx))
+	}
+	switch ev := tr.GetEvent().(type) {
+	case *logspb.Trace_SpanStart_:
+		w.line("Event: &logspb.Trace_SpanStart_{")
+		w.indent++
+		w.line("SpanStart: &logspb.Trace_SpanStart{")
+		w.indent++
+		if name := ev.SpanStart.GetName(); name != "" {
+			w.line("Name: %s,", strconv.Quote(name))
+		}
+		if kind := ev.SpanStart.GetKind(); kind != logspb.Span_UNSPECIFIED {
+			w.line("Kind: logspb.Span_%s,", kind)
+		}
+		if links := ev.SpanStart.GetLinks(); len(links) > 0 {
+			w.line("Links: []*logspb.Link{")
+			w.indent++
+			for _, link := range links {
+				w.link(link)
+			}
+			w.indent--
+			w.line("},")
+		}
+		w.indent--
+		w.line("},")
+		w.indent--
+		w.line("},")
+	case *logspb.Trace_SpanEnd_:
+		w.line("Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}},")
+	case *logspb.Trace_SpanEvent_:
+		w.line("Event: &logspb.Trace_SpanEvent_{SpanEvent: &logspb.Trace_SpanEvent{Name: %s}},", strconv.Quote(ev.SpanEvent.GetName()))
+	}
+	w.indent--
+	w.line("},")
+}
+
+func (w *goWriter) link(link *logspb.Link) {
+	w.line("{")
+	w.indent++
+	if spanCtx := link.GetSpanContext(); spanCtx != nil {
+		w.line("SpanContext: %s,", goSpanContext(spanCtx))
+	}
+	if t := link.GetType(); t != logspb.Link_CHILD_OF {
+		w.line("Type: logspb.Link_%s,", t)
+	}
+	if attrs := link.GetAttributes(); len(attrs) > 0 {
+		w.attributes("Attributes", attrs)
+	}
+	w.indent--
+	w.line("},")
+}
+
+func (w *goWriter) attributes(field string, attrs map[string]*logspb.Value) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	w.line("%s: map[string]*logspb.Value{", field)
+	w.indent++
+	for _, key := range keys {
+		w.line("%s: %s,", strconv.Quote(key), goValue(attrs[key]))
+	}
+	w.indent--
+	w.line("},")
+}
+
+func goSpanContext(spanCtx *logspb.SpanContext) string {
+	var sb strings.Builder
+	sb.WriteString("&logspb.SpanContext{")
+	if id := spanCtx.GetTraceId(); len(id) > 0 {
+		sb.WriteString("TraceId: []byte{")
+		for n, b := range id {
+			if n > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "0x%02x", b)
+		}
+		sb.WriteString("}")
+		if spanCtx.GetSpanId() != 0 || spanCtx.GetFlags() != 0 {
+			sb.WriteString(", ")
+		}
+	}
+	if id := spanCtx.GetSpanId(); id != 0 {
+		fmt.Fprintf(&sb, "SpanId: 0x%016x", id)
+		if spanCtx.GetFlags() != 0 {
+			sb.WriteString(", ")
+		}
+	}
+	if flags := spanCtx.GetFlags(); flags != 0 {
+		fmt.Fprintf(&sb, "Flags: %d", flags)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func goValue(v *logspb.Value) string {
+	switch val := v.GetValue().(type) {
+	case *logspb.Value_MapValue:
+		values := val.MapValue.GetValues()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for n, key := range keys {
+			items[n] = strconv.Quote(key) + ": " + goValue(values[key])
+		}
+		return fmt.Sprintf("{Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: map[string]*logspb.Value{%s}}}}", strings.Join(items, ", "))
+	case *logspb.Value_BoolValue:
+		return fmt.Sprintf("{Value: &logspb.Value_BoolValue{BoolValue: %v}}", val.BoolValue)
+	case *logspb.Value_IntValue:
+		return fmt.Sprintf("{Value: &logspb.Value_IntValue{IntValue: %d}}", val.IntValue)
+	case *logspb.Value_FloatValue:
+		return fmt.Sprintf("{Value: &logspb.Value_FloatValue{FloatValue: %s}}", goFloat(float64(val.FloatValue), 32))
+	case *logspb.Value_DoubleValue:
+		return fmt.Sprintf("{Value: &logspb.Value_DoubleValue{DoubleValue: %s}}", goFloat(val.DoubleValue, 64))
+	case *logspb.Value_StrValue:
+		return fmt.Sprintf("{Value: &logspb.Value_StrValue{StrValue: %s}}", strconv.Quote(val.StrValue))
+	case *logspb.Value_Json:
+		return fmt.Sprintf("{Value: &logspb.Value_Json{Json: %s}}", goString(val.Json))
+	case *logspb.Value_Proto:
+		return fmt.Sprintf("{Value: &logspb.Value_Proto{Proto: []byte(%s)}}", strconv.Quote(string(val.Proto)))
+	case *logspb.Value_Bytes:
+		return fmt.Sprintf("{Value: &logspb.Value_Bytes{Bytes: []byte(%s)}}", strconv.Quote(string(val.Bytes)))
+	case *logspb.Value_Duration:
+		return fmt.Sprintf("{Value: &logspb.Value_Duration{Duration: %d}}", val.Duration)
+	case *logspb.Value_Time:
+		return fmt.Sprintf("{Value: &logspb.Value_Time{Time: %d}}", val.Time)
+	case *logspb.Value_Decimal:
+		return fmt.Sprintf("{Value: &logspb.Value_Decimal{Decimal: %s}}", strconv.Quote(val.Decimal))
+	}
+	return "{}"
+}
+
+func goFloat(f float64, bitSize int) string {
+	var str string
+	switch {
+	case math.IsNaN(f):
+		str = "math.NaN()"
+	case math.IsInf(f, 1):
+		str = "math.Inf(1)"
+	case math.IsInf(f, -1):
+		str = "math.Inf(-1)"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	if bitSize == 32 {
+		return "float32(" + str + ")"
+	}
+	return str
+}
+
+// goString uses raw string literals when possible for readability, e.g. JSON.
+func goString(str string) string {
+	if !strings.ContainsAny(str, "`\r") && strconv.CanBackquote(strings.ReplaceAll(str, "\n", "")) {
+		return "`" + str + "`"
+	}
+	return strconv.Quote(str)
+}
@@ -0,0 +1,148 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Decorations of expanded JSON.
+const (
+	decorJSONKey    = decorKey
+	decorJSONStr    = decorStr
+	decorJSONNumber = decorInt
+	decorJSONNull   = "\x1b[2m" // dim
+)
+
+// jsonIndent is the indentation of each nesting level of expanded JSON.
+const jsonIndent = "  "
+
+// jsonFormatter pretty-prints JSON into lines, preserving the order of keys.
+type jsonFormatter struct {
+	printer *Printer
+	dec     *json.Decoder
+	lines   []string
+	line    strings.Builder
+	depth   int
+	// members counts the fields (or elements) of the top-level value.
+	members int
+}
+
+// expandJSON pretty-prints a JSON value with multiple fields (or elements)
+// into lines without terminators. It returns nil if the value is invalid or
+// has a single field, which is displayed inline.
+func (p *Printer) expandJSON(str string) []string {
+	f := &jsonFormatter{printer: p, dec: json.NewDecoder(strings.NewReader(str))}
+	f.dec.UseNumber()
+	tok, err := f.dec.Token()
+	if err != nil {
+		return nil
+	}
+	if err := f.value(tok); err != nil {
+		return nil
+	}
+	if _, err := f.dec.Token(); err != io.EOF {
+		return nil
+	}
+	if f.members < 2 {
+		return nil
+	}
+	f.newLine()
+	if p.MaxJSONLines > 0 && len(f.lines) > p.MaxJSONLines {
+		return append(f.lines[:p.MaxJSONLines:p.MaxJSONLines], "...")
+	}
+	return f.lines
+}
+
+func (f *jsonFormatter) value(tok json.Token) error {
+	p := f.printer
+	switch v := tok.(type) {
+	case json.Delim:
+		return f.container(v)
+	case string:
+		f.line.WriteString(p.styler(p.sanitize(quoteJSON(p.trimStrAttrValue(v))), p.theme.JSONStr))
+	case json.Number:
+		f.line.WriteString(p.styler(string(v), p.theme.JSONNumber))
+	case bool:
+		if v {
+			f.line.WriteString(p.styler("true", p.theme.True))
+		} else {
+			f.line.WriteString(p.styler("false", p.theme.False))
+		}
+	case nil:
+		f.line.WriteString(p.styler("null", p.theme.JSONNull))
+	}
+	return nil
+}
+
+func (f *jsonFormatter) container(open json.Delim) error {
+	p := f.printer
+	closing := "]"
+	if open == '{' {
+		closing = "}"
+	}
+	if !f.dec.More() {
+		if _, err := f.dec.Token(); err != nil {
+			return err
+		}
+		f.line.WriteString(string(open) + closing)
+		return nil
+	}
+	f.line.WriteString(string(open))
+	f.depth++
+	for f.dec.More() {
+		if f.depth == 1 {
+			f.members++
+		}
+		f.newLine()
+		tok, err := f.dec.Token()
+		if err != nil {
+			return err
+		}
+		if open == '{' {
+			f.line.WriteString(p.styler(p.sanitize(quoteJSON(tok.(string))), p.theme.JSONKey))
+			f.line.WriteString(": ")
+			if tok, err = f.dec.Token(); err != nil {
+				return err
+			}
+		}
+		if err := f.value(tok); err != nil {
+			return err
+		}
+		if f.dec.More() {
+			f.line.WriteByte(',')
+		}
+	}
+	if _, err := f.dec.Token(); err != nil {
+		return err
+	}
+	f.depth--
+	f.newLine()
+	f.line.WriteString(closing)
+	return nil
+}
+
+// jsonPlaceholder is displayed inline for an expanded JSON value by its first line.
+func jsonPlaceholder(first string) string {
+	if first == "[" {
+		return "[...]"
+	}
+	return "{...}"
+}
+
+// newLine completes the current line and starts a new one at the current depth.
+func (f *jsonFormatter) newLine() {
+	f.lines = append(f.lines, f.line.String())
+	f.line.Reset()
+	f.line.WriteString(strings.Repeat(jsonIndent, f.depth))
+}
+
+// quoteJSON encodes a string as JSON, which escapes control characters.
+func quoteJSON(str string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
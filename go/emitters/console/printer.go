@@ -2,11 +2,14 @@ package console
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
@@ -24,44 +27,97 @@ const (
 	decorStr       = "\x1b[94m" // fg:blue-light
 	decorJSON      = "\x1b[33m" // fg:yellow
 	decorProto     = "\x1b[37m" // fg:white
+	decorBytes     = "\x1b[37m" // fg:white
 	decorTraceID   = "\x1b[35m" // fg:magenta
 	decorSpanID    = "\x1b[36m" // fg:cyan
 	decorSpanName  = "\x1b[32m" // fg:green
 	decorSpanStart = "\x1b[92m" // fg:green-light
 	decorSpanEnd   = "\x1b[92m" // fg:green-light
+	decorSpanEvent = "\x1b[92m" // fg:green-light
 	decorLoc       = "\x1b[2m"  // dim
+	decorSource    = "\x1b[95m" // fg:magenta-light
 )
 
 var (
-	levelFmts = map[logspb.LogEntry_Level]*levelFmt{
-		logspb.LogEntry_INFO:     {decor: "\x1b[37m", text: "I"},
-		logspb.LogEntry_WARNING:  {decor: "\x1b[33m", text: "W"},
-		logspb.LogEntry_ERROR:    {decor: "\x1b[31m", text: "E"},
-		logspb.LogEntry_CRITICAL: {decor: "\x1b[31m\x1b[1m", text: "C"},
-		logspb.LogEntry_FATAL:    {decor: "\x1b[31m\x1b[1m\x1b[5m", text: "F"},
+	levelTexts = map[logspb.LogEntry_Level]string{
+		logspb.LogEntry_INFO:     "I",
+		logspb.LogEntry_WARNING:  "W",
+		logspb.LogEntry_ERROR:    "E",
+		logspb.LogEntry_CRITICAL: "C",
+		logspb.LogEntry_FATAL:    "F",
 	}
 )
 
-type levelFmt struct {
-	decor string
-	text  string
-}
+// RelativeTime specifies how the relative timestamp is displayed.
+type RelativeTime int
+
+// Relative timestamp modes.
+const (
+	// RelativeTimeNone doesn't display relative timestamp.
+	RelativeTimeNone RelativeTime = iota
+	// RelativeTimePrev displays the delta from the previous entry.
+	RelativeTimePrev
+	// RelativeTimeNow displays the delta from now.
+	RelativeTimeNow
+)
 
 // Printer prints log entries to console in a human readable format.
+// It's safe for concurrent use once configured: each entry is written to Out
+// in a single write guarded by a lock, so lines never interleave.
 type Printer struct {
 	Out io.Writer
 
-	MaxStrAttrLen  int
-	MaxBinAttrLen  int
-	MaxPathLen     int
+	// MaxStrAttrLen truncates string attributes, 0 means no limit.
+	MaxStrAttrLen int
+	// MaxBinAttrLen truncates binary attributes, 0 means the default (8) and
+	// negative means no limit.
+	MaxBinAttrLen int
+	// MaxPathLen truncates locations, 0 means the base name and negative means no limit.
+	MaxPathLen int
+	// FullPrecision prints floats with the minimal digits representing them
+	// exactly, instead of 8 digits after the decimal point.
+	FullPrecision  bool
 	ShortenTraceID bool
 	DisplayNanoTS  bool
 	TimeFormat     string
+	RelativeTime   RelativeTime
+	// HideAbsoluteTime hides the absolute timestamp when relative timestamp is displayed.
+	HideAbsoluteTime bool
+	// Attributes specifies the attributes to display in order. If empty, all attributes are displayed.
+	Attributes []string
+	// AttrOrder pins the attributes to display first in order, e.g. error, followed
+	// by the others in the order they are added, and then the rest sorted by keys.
+	AttrOrder []string
+	// HideAttributes hides all attributes.
+	HideAttributes bool
+	// ExpandJSON pretty-prints JSON attributes with multiple fields indented
+	// under the log line, instead of inline.
+	ExpandJSON bool
+	// MaxJSONLines truncates expanded JSON attributes, 0 means no limit.
+	MaxJSONLines int
+	// ColorByTrace colors trace IDs by TraceColor instead of a fixed color.
+	ColorByTrace bool
+	// SourceAttribute, if not empty, is the attribute printed as the prefix of
+	// a line instead of a regular attribute, e.g. the source file of the entry.
+	SourceAttribute string
+	// Sanitizer cleans messages, locations, span names, attribute keys and
+	// string values which may contain untrusted control characters.
+	// If nil, the text is printed as is.
+	Sanitizer Sanitizer
 
+	lastNanoTS  int64
 	styler      func(text, decor string) string
+	theme       Theme
 	useSpansMap bool
 	spansLock   sync.RWMutex
-	spans       map[string]*logspb.Trace_SpanStart
+	spans       map[string]*trackedSpan
+	outLock     sync.Mutex
+}
+
+// trackedSpan is a started span with the timestamp of the start.
+type trackedSpan struct {
+	*logspb.Trace_SpanStart
+	startNs int64
 }
 
 // SpanRecorder is used to remove the tracked span event when it ends.
@@ -77,9 +133,12 @@ func NewPrinter(out io.Writer) *Printer {
 		MaxStrAttrLen:  80,
 		MaxBinAttrLen:  8,
 		MaxPathLen:     20,
+		MaxJSONLines:   40,
 		ShortenTraceID: true,
 		TimeFormat:     "0102 15:04:05.000000",
+		Sanitizer:      EscapeControlChars,
 		styler:         noColorStyler,
+		theme:          DarkTheme,
 	}
 }
 
@@ -92,6 +151,11 @@ func (p *Printer) UseColor(colorful bool) {
 	}
 }
 
+// SetTheme sets the colors used when colorful output is enabled.
+func (p *Printer) SetTheme(theme Theme) {
+	p.theme = theme
+}
+
 // DisplaySpanNames enables span event tracking for displaying span names in the
 // related logs.
 func (p *Printer) DisplaySpanNames() {
@@ -114,9 +178,9 @@ func (p *Printer) RecordSpanEvent(entry *logspb.LogEntry) *SpanRecorder {
 		id := logs.IDStringFrom(spanCtx)
 		p.spansLock.Lock()
 		if p.spans == nil {
-			p.spans = make(map[string]*logspb.Trace_SpanStart)
+			p.spans = make(map[string]*trackedSpan)
 		}
-		p.spans[id] = ev.SpanStart
+		p.spans[id] = &trackedSpan{Trace_SpanStart: ev.SpanStart, startNs: entry.GetNanoTs()}
 		p.spansLock.Unlock()
 	case *logspb.Trace_SpanEnd_:
 		return &SpanRecorder{printer: p, endSpanID: logs.IDStringFrom(spanCtx)}
@@ -140,79 +204,79 @@ func (r *SpanRecorder) Done() {
 func (p *Printer) EmitLogEntry(entry *logspb.LogEntry) {
 	var sb strings.Builder
 	var levelDecor string
-	if f := levelFmts[entry.GetLevel()]; f != nil {
-		levelDecor = f.decor
-		sb.WriteString(p.styler(f.text, f.decor))
+	if p.SourceAttribute != "" {
+		if val, ok := entry.GetAttributes()[p.SourceAttribute]; ok {
+			sb.WriteString(p.styler("["+p.sanitize(val.GetStrValue())+"]", p.theme.Source))
+			sb.WriteByte(' ')
+		}
+	}
+	if text, ok := levelTexts[entry.GetLevel()]; ok {
+		levelDecor = p.theme.Levels[entry.GetLevel()]
+		sb.WriteString(p.styler(text, levelDecor))
 	} else {
 		sb.WriteString(" ")
 	}
-	if p.DisplayNanoTS {
-		sb.WriteString(strconv.FormatInt(entry.GetNanoTs(), 10))
-	} else {
-		sb.WriteString(time.Unix(0, entry.GetNanoTs()).Format(p.TimeFormat))
+	if p.RelativeTime == RelativeTimeNone || !p.HideAbsoluteTime {
+		if p.DisplayNanoTS {
+			sb.WriteString(strconv.FormatInt(entry.GetNanoTs(), 10))
+		} else {
+			sb.WriteString(time.Unix(0, entry.GetNanoTs()).Format(p.TimeFormat))
+		}
+		sb.WriteByte(' ')
+	}
+	if p.RelativeTime != RelativeTimeNone {
+		sb.WriteString(p.relativeTime(entry.GetNanoTs()))
+		sb.WriteByte(' ')
 	}
-	sb.WriteByte(' ')
 	if loc := entry.GetLocation(); loc != "" {
 		if p.MaxPathLen == 0 {
 			loc = filepath.Base(loc)
 		} else if p.MaxPathLen > 0 && len(loc) > p.MaxPathLen {
 			loc = ".." + loc[len(loc)-p.MaxPathLen:]
 		}
-		sb.WriteString(p.styler(loc, decorLoc))
+		sb.WriteString(p.styler(p.sanitize(loc), p.theme.Location))
 		sb.WriteByte(' ')
 	}
 	tr := entry.GetTrace()
 	if event := tr.GetEvent(); event != nil {
 		switch ev := event.(type) {
 		case *logspb.Trace_SpanStart_:
-			sb.WriteString(p.styler("+ "+ev.SpanStart.GetName(), decorSpanStart))
+			sb.WriteString(p.styler("+ "+p.sanitize(ev.SpanStart.GetName()), p.theme.SpanStart))
 		case *logspb.Trace_SpanEnd_:
 			text := "-"
 			if span := p.lookupSpan(tr.GetSpanContext()); span != nil {
-				text += " " + span.GetName()
+				text += " " + p.sanitize(span.GetName())
+				text += " (" + time.Duration(entry.GetNanoTs()-span.startNs).String() + ")"
 			}
-			sb.WriteString(p.styler(text, decorSpanEnd))
+			sb.WriteString(p.styler(text, p.theme.SpanEnd))
+		case *logspb.Trace_SpanEvent_:
+			sb.WriteString(p.styler("* "+p.sanitize(ev.SpanEvent.GetName()), p.theme.SpanEvent))
 		}
 	} else {
-		sb.WriteString(p.styler(entry.GetMessage(), levelDecor))
+		sb.WriteString(p.styler(p.sanitize(entry.GetMessage()), levelDecor))
 	}
-	for key, val := range entry.GetAttributes() {
+	var expanded []expandedAttr
+	for _, attr := range p.displayAttributes(entry) {
+		key, val := attr.Name, attr.Value
 		sb.WriteByte(' ')
-		sb.WriteString(p.styler(key, decorKey))
+		sb.WriteString(p.styler(p.sanitize(key), p.theme.Key))
 		sb.WriteByte('=')
-		switch v := val.GetValue().(type) {
-		case *logspb.Value_BoolValue:
-			if v.BoolValue {
-				sb.WriteString(p.styler("T", decorTrue))
-			} else {
-				sb.WriteString(p.styler("F", decorFalse))
-			}
-		case *logspb.Value_IntValue:
-			sb.WriteString(p.styler(strconv.FormatInt(v.IntValue, 10), decorInt))
-		case *logspb.Value_FloatValue:
-			sb.WriteString(p.styler(strconv.FormatFloat(float64(v.FloatValue), 'E', 8, 32), decorFloat))
-		case *logspb.Value_DoubleValue:
-			sb.WriteString(p.styler(strconv.FormatFloat(float64(v.DoubleValue), 'E', 8, 64), decorDouble))
-		case *logspb.Value_StrValue:
-			sb.WriteString(p.styler(p.trimStrAttrValue(v.StrValue), decorStr))
-		case *logspb.Value_Json:
-			sb.WriteString(p.styler(p.trimStrAttrValue(v.Json), decorJSON))
-		case *logspb.Value_Proto:
-			maxBinLen := 8
-			if p.MaxBinAttrLen > 0 {
-				maxBinLen = p.MaxBinAttrLen
-			}
-			var str string
-			if len(v.Proto) > maxBinLen {
-				str = hex.EncodeToString(v.Proto[:8]) + "..."
-			} else {
-				str = hex.EncodeToString(v.Proto)
+		if p.ExpandJSON && val.GetJson() != "" {
+			if lines := p.expandJSON(val.GetJson()); lines != nil {
+				// The value is displayed under the line.
+				expanded = append(expanded, expandedAttr{key: key, lines: lines})
+				sb.WriteString(p.styler(jsonPlaceholder(lines[0]), p.theme.JSON))
+				continue
 			}
-			sb.WriteString(p.styler(str, decorProto))
 		}
+		p.writeValue(&sb, val)
 	}
 	if spanCtx := tr.GetSpanContext(); spanCtx != nil {
 		traceID, spanID := logs.TraceIDStringFrom(spanCtx), logs.SpanIDStringFrom(spanCtx)
+		traceDecor := p.theme.TraceID
+		if p.ColorByTrace {
+			traceDecor = TraceColorDecor(traceID)
+		}
 		if p.ShortenTraceID && len(traceID) >= 10 {
 			traceID = traceID[:6] + ".." + traceID[len(traceID)-4:]
 		}
@@ -221,17 +285,223 @@ func (p *Printer) EmitLogEntry(entry *logspb.LogEntry) {
 			spanID = ".." + spanID[len(spanID)-6:]
 		}
 		sb.WriteByte(' ')
-		sb.WriteString(p.styler(traceID, decorTraceID))
+		sb.WriteString(p.styler(traceID, traceDecor))
 		sb.WriteByte('/')
-		sb.WriteString(p.styler(spanID, decorSpanID))
+		sb.WriteString(p.styler(spanID, p.theme.SpanID))
 		if span := p.lookupSpan(spanCtx); span != nil {
 			sb.WriteByte(' ')
-			sb.WriteString(p.styler(span.GetName(), decorSpanName))
+			sb.WriteString(p.styler(p.sanitize(span.GetName()), p.theme.SpanName))
 		}
 	}
 
 	sb.WriteString("\r\n")
-	io.WriteString(p.Out, sb.String())
+	for _, attr := range expanded {
+		sb.WriteString(jsonIndent)
+		sb.WriteString(p.styler(p.sanitize(attr.key), p.theme.Key))
+		sb.WriteString(": ")
+		for n, line := range attr.lines {
+			if n > 0 {
+				sb.WriteString(jsonIndent)
+			}
+			sb.WriteString(line)
+			sb.WriteString("\r\n")
+		}
+	}
+	p.write(sb.String())
+}
+
+// write writes the text to Out in a single write, without interleaving
+// with concurrent writes.
+func (p *Printer) write(text string) {
+	p.outLock.Lock()
+	defer p.outLock.Unlock()
+	io.WriteString(p.Out, text)
+}
+
+type expandedAttr struct {
+	key   string
+	lines []string
+}
+
+func (p *Printer) displayAttributes(entry *logspb.LogEntry) []logs.NamedAttribute {
+	attrs := entry.GetAttributes()
+	if p.HideAttributes {
+		return nil
+	}
+	if len(p.Attributes) > 0 {
+		result := make([]logs.NamedAttribute, 0, len(p.Attributes))
+		for _, key := range p.Attributes {
+			if val, ok := attrs[key]; ok {
+				result = append(result, logs.NamedAttribute{Name: key, Value: val})
+			}
+		}
+		return result
+	}
+	// Attributes pinned by AttrOrder are displayed first, then the ones in the
+	// order they are added, followed by the ones not in the order sorted by keys.
+	keys := make([]string, 0, len(attrs))
+	listed := make(map[string]bool, len(attrs))
+	for _, order := range [][]string{p.AttrOrder, entry.GetAttributeOrder()} {
+		for _, key := range order {
+			if _, ok := attrs[key]; ok && !listed[key] {
+				keys = append(keys, key)
+				listed[key] = true
+			}
+		}
+	}
+	numListed := len(keys)
+	for key := range attrs {
+		if !listed[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys[numListed:])
+	result := make([]logs.NamedAttribute, 0, len(keys))
+	for _, key := range keys {
+		if p.SourceAttribute != "" && key == p.SourceAttribute {
+			continue
+		}
+		result = append(result, logs.NamedAttribute{Name: key, Value: attrs[key]})
+	}
+	return result
+}
+
+// writeValue writes a formatted attribute value.
+func (p *Printer) writeValue(sb *strings.Builder, val *logspb.Value) {
+	switch v := val.GetValue().(type) {
+	case *logspb.Value_BoolValue:
+		if v.BoolValue {
+			sb.WriteString(p.styler("T", p.theme.True))
+		} else {
+			sb.WriteString(p.styler("F", p.theme.False))
+		}
+	case *logspb.Value_IntValue:
+		sb.WriteString(p.styler(strconv.FormatInt(v.IntValue, 10), p.theme.Int))
+	case *logspb.Value_FloatValue:
+		sb.WriteString(p.styler(strconv.FormatFloat(float64(v.FloatValue), 'E', p.floatPrecision(), 32), p.theme.Float))
+	case *logspb.Value_DoubleValue:
+		sb.WriteString(p.styler(strconv.FormatFloat(float64(v.DoubleValue), 'E', p.floatPrecision(), 64), p.theme.Double))
+	case *logspb.Value_StrValue:
+		sb.WriteString(p.styler(p.sanitize(p.trimStrAttrValue(v.StrValue)), p.theme.Str))
+	case *logspb.Value_Json:
+		sb.WriteString(p.styler(p.sanitize(p.trimStrAttrValue(v.Json)), p.theme.JSON))
+	case *logspb.Value_Bytes:
+		sb.WriteString(p.styler(p.bytesPreview(v.Bytes), p.theme.Bytes))
+	case *logspb.Value_Duration:
+		sb.WriteString(p.styler(time.Duration(v.Duration).String(), p.theme.Int))
+	case *logspb.Value_Time:
+		sb.WriteString(p.styler(time.Unix(0, v.Time).Format(time.RFC3339Nano), p.theme.Int))
+	case *logspb.Value_Decimal:
+		sb.WriteString(p.styler(p.sanitize(p.trimStrAttrValue(v.Decimal)), p.theme.Double))
+	case *logspb.Value_MapValue:
+		sb.WriteByte('{')
+		values := v.MapValue.GetValues()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for n, key := range keys {
+			if n > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(p.styler(p.sanitize(key), p.theme.Key))
+			sb.WriteByte('=')
+			p.writeValue(sb, values[key])
+		}
+		sb.WriteByte('}')
+	case *logspb.Value_Proto:
+		maxBinLen := p.maxBinLen(len(v.Proto))
+		var str string
+		if len(v.Proto) > maxBinLen {
+			str = hex.EncodeToString(v.Proto[:maxBinLen]) + "..."
+		} else {
+			str = hex.EncodeToString(v.Proto)
+		}
+		sb.WriteString(p.styler(str, p.theme.Proto))
+	}
+}
+
+// bytesPreview formats binary data as the length followed by the hex of at most
+// MaxBinAttrLen bytes, e.g. [16]0001020304050607...
+func (p *Printer) bytesPreview(data []byte) string {
+	maxBinLen := p.maxBinLen(len(data))
+	str := "[" + strconv.Itoa(len(data)) + "]"
+	if len(data) > maxBinLen {
+		return str + hex.EncodeToString(data[:maxBinLen]) + "..."
+	}
+	return str + hex.EncodeToString(data)
+}
+
+// floatPrecision returns the precision to format floats.
+func (p *Printer) floatPrecision() int {
+	if p.FullPrecision {
+		return -1
+	}
+	return 8
+}
+
+// maxBinLen returns the max number of bytes to print for binary data of size.
+func (p *Printer) maxBinLen(size int) int {
+	switch {
+	case p.MaxBinAttrLen > 0:
+		return p.MaxBinAttrLen
+	case p.MaxBinAttrLen < 0:
+		return size
+	}
+	return 8
+}
+
+func (p *Printer) relativeTime(nanoTS int64) string {
+	var delta time.Duration
+	switch p.RelativeTime {
+	case RelativeTimePrev:
+		if last := atomic.SwapInt64(&p.lastNanoTS, nanoTS); last != 0 {
+			delta = time.Duration(nanoTS - last)
+		}
+	case RelativeTimeNow:
+		delta = time.Duration(nanoTS - time.Now().UnixNano())
+	}
+	return FormatRelativeTime(delta)
+}
+
+// ParseRelativeTime parses the relative timestamp mode: none, prev, now.
+func ParseRelativeTime(str string) (RelativeTime, error) {
+	switch str {
+	case "", "none":
+		return RelativeTimeNone, nil
+	case "prev":
+		return RelativeTimePrev, nil
+	case "now":
+		return RelativeTimeNow, nil
+	}
+	return RelativeTimeNone, fmt.Errorf("unknown relative time mode: %s", str)
+}
+
+// FormatRelativeTime formats a time delta in a short human friendly form, e.g. +12ms, +1.3s.
+func FormatRelativeTime(delta time.Duration) string {
+	sign := "+"
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+	switch {
+	case delta == 0:
+		return sign + "0"
+	case delta < time.Millisecond:
+		return sign + strconv.FormatInt(int64(delta/time.Microsecond), 10) + "us"
+	case delta < time.Second:
+		return sign + strconv.FormatInt(int64(delta/time.Millisecond), 10) + "ms"
+	case delta < time.Minute:
+		return sign + strconv.FormatFloat(delta.Seconds(), 'f', 1, 64) + "s"
+	}
+	return sign + delta.Round(time.Second).String()
+}
+
+func (p *Printer) sanitize(str string) string {
+	if p.Sanitizer == nil {
+		return str
+	}
+	return p.Sanitizer(str)
 }
 
 func (p *Printer) trimStrAttrValue(val string) string {
@@ -241,7 +511,7 @@ func (p *Printer) trimStrAttrValue(val string) string {
 	return val
 }
 
-func (p *Printer) lookupSpan(spanCtx *logspb.SpanContext) *logspb.Trace_SpanStart {
+func (p *Printer) lookupSpan(spanCtx *logspb.SpanContext) *trackedSpan {
 	if spanCtx == nil || !p.useSpansMap {
 		return nil
 	}
@@ -0,0 +1,461 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestPrintDurationAndTime(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 890000000, time.UTC)
+	var emitted *logspb.LogEntry
+	logger := logs.Root(logs.LogEmitterFunc(func(entry *logspb.LogEntry) { emitted = entry }))
+	logger.With(logs.Duration("elapsed", 1200*time.Millisecond), logs.Time("at", ts)).Infof("done")
+
+	var buf bytes.Buffer
+	w := &blob.Writer{W: &buf}
+	if err := w.WriteLogEntry(emitted); err != nil {
+		t.Fatalf("WriteLogEntry: %v", err)
+	}
+	entry, err := (&blob.Reader{R: &buf}).Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if d := entry.GetAttributes()["elapsed"].GetDuration(); d != int64(1200*time.Millisecond) {
+		t.Errorf("Decoded duration %d, expect %d", d, int64(1200*time.Millisecond))
+	}
+	if ns := entry.GetAttributes()["at"].GetTime(); ns != ts.UnixNano() {
+		t.Errorf("Decoded time %d, expect %d", ns, ts.UnixNano())
+	}
+
+	var out strings.Builder
+	NewPrinter(&out).EmitLogEntry(entry)
+	expected := []string{
+		"elapsed=1.2s",
+		"at=" + ts.Local().Format(time.RFC3339Nano),
+	}
+	for _, str := range expected {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("Expect %q in output %q", str, out.String())
+		}
+	}
+}
+
+func TestPrintAttributesDeterministic(t *testing.T) {
+	entry := &logspb.LogEntry{
+		NanoTs:  time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC).UnixNano(),
+		Level:   logspb.LogEntry_INFO,
+		Message: "done",
+		Attributes: map[string]*logspb.Value{
+			"zone":        {Value: &logspb.Value_StrValue{StrValue: "a"}},
+			"error":       {Value: &logspb.Value_StrValue{StrValue: "failed"}},
+			"count":       {Value: &logspb.Value_IntValue{IntValue: 3}},
+			"grpc.status": {Value: &logspb.Value_StrValue{StrValue: "NOT_FOUND"}},
+			"bucket":      {Value: &logspb.Value_StrValue{StrValue: "b"}},
+			"method":      {Value: &logspb.Value_StrValue{StrValue: "Get"}},
+		},
+	}
+	testCases := []struct {
+		name      string
+		attrOrder []string
+		expected  string
+	}{
+		{
+			name:     "sorted",
+			expected: "bucket=b count=3 error=failed grpc.status=NOT_FOUND method=Get zone=a",
+		},
+		{
+			name:      "pinned",
+			attrOrder: []string{"error", "grpc.status", "missing"},
+			expected:  "error=failed grpc.status=NOT_FOUND bucket=b count=3 method=Get zone=a",
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var first string
+			for i := 0; i < 20; i++ {
+				var out strings.Builder
+				printer := NewPrinter(&out)
+				printer.AttrOrder = tc.attrOrder
+				printer.EmitLogEntry(entry)
+				if i == 0 {
+					first = out.String()
+					if !strings.Contains(first, "done "+tc.expected) {
+						t.Fatalf("Expect attributes %q in output %q", tc.expected, first)
+					}
+				} else if out.String() != first {
+					t.Fatalf("Output %q differs from the first %q", out.String(), first)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	entry := func(json string) *logspb.LogEntry {
+		return &logspb.LogEntry{
+			NanoTs:  time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC).UnixNano(),
+			Level:   logspb.LogEntry_INFO,
+			Message: "done",
+			Attributes: map[string]*logspb.Value{
+				"req": {Value: &logspb.Value_Json{Json: json}},
+			},
+		}
+	}
+	testCases := []struct {
+		name          string
+		json          string
+		expand        bool
+		maxStrAttrLen int
+		maxJSONLines  int
+		expected      string
+	}{
+		{
+			name:     "compact",
+			json:     `{"id":1,"name":"a"}`,
+			expected: `done req={"id":1,"name":"a"}` + "\r\n",
+		},
+		{
+			name:          "compact truncated",
+			json:          `{"id":1,"name":"abcdefgh"}`,
+			maxStrAttrLen: 10,
+			expected:      `done req={"id":1,"n...` + "\r\n",
+		},
+		{
+			name:   "expanded",
+			json:   `{"name":"a","id":1.5,"ok":true,"tags":["x",null],"empty":{}}`,
+			expand: true,
+			expected: "done req={...}\r\n" +
+				"  req: {\r\n" +
+				`    "name": "a",` + "\r\n" +
+				`    "id": 1.5,` + "\r\n" +
+				`    "ok": true,` + "\r\n" +
+				`    "tags": [` + "\r\n" +
+				`      "x",` + "\r\n" +
+				`      null` + "\r\n" +
+				`    ],` + "\r\n" +
+				`    "empty": {}` + "\r\n" +
+				"  }\r\n",
+		},
+		{
+			name:     "expanded array",
+			json:     `[1, 2]`,
+			expand:   true,
+			expected: "done req=[...]\r\n  req: [\r\n    1,\r\n    2\r\n  ]\r\n",
+		},
+		{
+			name:     "single field inline",
+			json:     `{"id":1}`,
+			expand:   true,
+			expected: `done req={"id":1}` + "\r\n",
+		},
+		{
+			name:     "invalid inline",
+			json:     `{"id":1,"name":`,
+			expand:   true,
+			expected: `done req={"id":1,"name":` + "\r\n",
+		},
+		{
+			name:          "expanded truncated",
+			json:          `{"name":"abcdefgh\nij","id":1,"more":2}`,
+			expand:        true,
+			maxStrAttrLen: 4,
+			maxJSONLines:  3,
+			expected: "done req={...}\r\n" +
+				"  req: {\r\n" +
+				`    "name": "abcd...",` + "\r\n" +
+				`    "id": 1,` + "\r\n" +
+				"  ...\r\n",
+		},
+		{
+			name:     "escaped",
+			json:     `{"a":"x\u001b[31my","b\n":"line1\nline2"}`,
+			expand:   true,
+			expected: "done req={...}\r\n" + `  req: {` + "\r\n" + `    "a": "x\u001b[31my",` + "\r\n" + `    "b\n": "line1\nline2"` + "\r\n  }\r\n",
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			printer := NewPrinter(&out)
+			printer.ExpandJSON = tc.expand
+			printer.MaxStrAttrLen = tc.maxStrAttrLen
+			printer.MaxJSONLines = tc.maxJSONLines
+			printer.EmitLogEntry(entry(tc.json))
+			if !strings.HasSuffix(out.String(), tc.expected) {
+				t.Errorf("Expect output ends with:\n%q\ngot:\n%q", tc.expected, out.String())
+			}
+			if strings.Contains(strings.ReplaceAll(out.String(), "\r\n", ""), "\n") {
+				t.Errorf("Unterminated line in output %q", out.String())
+			}
+		})
+	}
+}
+
+func TestPrintJSONColors(t *testing.T) {
+	var out strings.Builder
+	printer := NewPrinter(&out)
+	printer.UseColor(true)
+	printer.ExpandJSON = true
+	printer.EmitLogEntry(&logspb.LogEntry{
+		Attributes: map[string]*logspb.Value{
+			"req": {Value: &logspb.Value_Json{Json: `{"id":1,"name":"a"}`}},
+		},
+	})
+	expected := []string{
+		colorfulStyler(`"id"`, decorJSONKey) + ": " + colorfulStyler("1", decorJSONNumber),
+		colorfulStyler(`"name"`, decorJSONKey) + ": " + colorfulStyler(`"a"`, decorJSONStr),
+	}
+	for _, str := range expected {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("Expect %q in output %q", str, out.String())
+		}
+	}
+}
+
+func TestPrinterTheme(t *testing.T) {
+	theme := Theme{
+		Key:     "\x1b[38;5;1m",
+		Int:     "\x1b[38;5;2m",
+		TraceID: "\x1b[38;5;3m",
+		Levels:  map[logspb.LogEntry_Level]string{logspb.LogEntry_WARNING: "\x1b[38;5;4m"},
+	}
+	entry := &logspb.LogEntry{
+		Level:      logspb.LogEntry_WARNING,
+		Message:    "slow",
+		Attributes: map[string]*logspb.Value{"count": {Value: &logspb.Value_IntValue{IntValue: 3}}},
+		Trace:      &logspb.Trace{SpanContext: &logspb.SpanContext{TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, SpanId: 1}},
+	}
+
+	var out strings.Builder
+	printer := NewPrinter(&out)
+	printer.UseColor(true)
+	printer.SetTheme(theme)
+	printer.EmitLogEntry(entry)
+	expected := []string{
+		"\x1b[38;5;4mW\x1b[0m",
+		"\x1b[38;5;4mslow\x1b[0m",
+		"\x1b[38;5;1mcount\x1b[0m=\x1b[38;5;2m3\x1b[0m",
+		"\x1b[38;5;3m100f0e..0201\x1b[0m",
+	}
+	for _, str := range expected {
+		if !strings.Contains(out.String(), str) {
+			t.Errorf("Expect %q in output %q", str, out.String())
+		}
+	}
+	if strings.Contains(out.String(), DarkTheme.Levels[logspb.LogEntry_WARNING]) {
+		t.Errorf("Unexpected default colors in output %q", out.String())
+	}
+
+	out.Reset()
+	printer.SetTheme(NoTheme)
+	printer.EmitLogEntry(entry)
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("Expect no colors with NoTheme, got %q", out.String())
+	}
+}
+
+func TestPrintSpanDuration(t *testing.T) {
+	start := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC).UnixNano()
+	spanEvent := func(ts int64, spanID uint64, name string) *logspb.LogEntry {
+		entry := &logspb.LogEntry{
+			NanoTs: ts,
+			Trace:  &logspb.Trace{SpanContext: &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: spanID}},
+		}
+		if name != "" {
+			entry.Trace.Event = &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: name}}
+		} else {
+			entry.Trace.Event = &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}
+		}
+		return entry
+	}
+	entries := []*logspb.LogEntry{
+		spanEvent(start, 1, "outer"),
+		spanEvent(start+int64(time.Millisecond), 2, "inner"),
+		spanEvent(start+int64(1500*time.Microsecond), 2, ""),
+		spanEvent(start+int64(1200*time.Millisecond), 1, ""),
+		spanEvent(start+int64(2*time.Second), 3, ""),
+	}
+	expected := []string{
+		"+ outer",
+		"+ inner",
+		"- inner (500µs)",
+		"- outer (1.2s)",
+		"- ",
+	}
+
+	var out strings.Builder
+	printer := NewPrinter(&out)
+	printer.DisplaySpanNames()
+	for _, entry := range entries {
+		rec := printer.RecordSpanEvent(entry)
+		printer.EmitLogEntry(entry)
+		rec.Done()
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expect %d lines, got %q", len(expected), out.String())
+	}
+	for n, line := range lines {
+		if !strings.Contains(line, " "+expected[n]) {
+			t.Errorf("Expect %q in line %q", expected[n], line)
+		}
+	}
+	if strings.Contains(lines[4], "(") {
+		t.Errorf("Expect no duration for unknown span in line %q", lines[4])
+	}
+}
+
+func TestPrinterConcurrent(t *testing.T) {
+	const workers, entriesPerWorker = 16, 100
+	var out piecewiseWriter
+	printer := NewPrinter(&out)
+	printer.UseColor(true)
+	printer.RelativeTime = RelativeTimePrev
+	printer.DisplaySpanNames()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			spanCtx := &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: uint64(worker + 1)}
+			for n := 0; n < entriesPerWorker; n++ {
+				entry := &logspb.LogEntry{
+					NanoTs:  time.Now().UnixNano(),
+					Level:   logspb.LogEntry_INFO,
+					Message: fmt.Sprintf("worker=%d seq=%d", worker, n),
+					Trace:   &logspb.Trace{SpanContext: spanCtx},
+				}
+				switch n {
+				case 0:
+					entry.Trace.Event = &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "work"}}
+				case entriesPerWorker - 1:
+					entry.Trace.Event = &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}
+				}
+				rec := printer.RecordSpanEvent(entry)
+				printer.EmitLogEntry(entry)
+				rec.Done()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(string(out.data), "\r\n"), "\r\n")
+	if len(lines) != workers*entriesPerWorker {
+		t.Fatalf("Expect %d lines, got %d", workers*entriesPerWorker, len(lines))
+	}
+	pattern := regexp.MustCompile(`^\x1b\[37mI\x1b\[0m\S+ \S+ \S+ (\x1b\[92m\+ work|\x1b\[92m- work \(\S+\)|\x1b\[37mworker=\d+ seq=\d+)\x1b\[0m \x1b\[35m[0-9a-f.]+\x1b\[0m/\x1b\[36m[0-9a-f.]+\x1b\[0m( \x1b\[32mwork\x1b\[0m)?$`)
+	for _, line := range lines {
+		if !pattern.MatchString(line) {
+			t.Fatalf("Malformed line %q", line)
+		}
+	}
+}
+
+// piecewiseWriter writes the data byte by byte, yielding in between,
+// to expose interleaving of concurrent writes.
+type piecewiseWriter struct {
+	data []byte
+}
+
+func (w *piecewiseWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.data = append(w.data, b)
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func BenchmarkPrinter(b *testing.B) {
+	entry := &logspb.LogEntry{
+		NanoTs:   time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC).UnixNano(),
+		Level:    logspb.LogEntry_INFO,
+		Location: "server/handler.go:123",
+		Message:  "request served",
+		Trace: &logspb.Trace{
+			SpanContext: &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: 0x16e3a5c1d2b4f789},
+		},
+		Attributes: map[string]*logspb.Value{
+			"method":  {Value: &logspb.Value_StrValue{StrValue: "GET"}},
+			"status":  {Value: &logspb.Value_IntValue{IntValue: 200}},
+			"elapsed": {Value: &logspb.Value_Duration{Duration: int64(1200 * time.Millisecond)}},
+			"cached":  {Value: &logspb.Value_BoolValue{BoolValue: true}},
+		},
+	}
+	for _, colorful := range []bool{false, true} {
+		name := "plain"
+		if colorful {
+			name = "color"
+		}
+		b.Run(name, func(b *testing.B) {
+			printer := NewPrinter(io.Discard)
+			printer.UseColor(colorful)
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				printer.EmitLogEntry(entry)
+			}
+		})
+	}
+}
+
+func TestPrintDecimal(t *testing.T) {
+	entry := &logspb.LogEntry{
+		Level:   logspb.LogEntry_INFO,
+		Message: "done",
+		Attributes: map[string]*logspb.Value{
+			"amount": {Value: &logspb.Value_Decimal{Decimal: "12345.678\x1b[31m"}},
+		},
+	}
+	var out strings.Builder
+	printer := NewPrinter(&out)
+	printer.Sanitizer = strings.NewReplacer("\x1b", "\\x1b").Replace
+	printer.MaxStrAttrLen = 12
+	printer.EmitLogEntry(entry)
+	if expected := "amount=12345.678\\x1b[3..."; !strings.Contains(out.String(), expected) {
+		t.Errorf("Expect %q in output %q", expected, out.String())
+	}
+}
+
+func TestPrintFullPrecision(t *testing.T) {
+	entry := &logspb.LogEntry{
+		Level:   logspb.LogEntry_INFO,
+		Message: "done",
+		Attributes: map[string]*logspb.Value{
+			"float":  {Value: &logspb.Value_FloatValue{FloatValue: 0.1}},
+			"double": {Value: &logspb.Value_DoubleValue{DoubleValue: 1.0000000000000002}},
+		},
+	}
+	testCases := []struct {
+		fullPrecision bool
+		expected      []string
+	}{
+		{expected: []string{"float=1.00000001E-01", "double=1.00000000E+00"}},
+		{fullPrecision: true, expected: []string{"float=1E-01", "double=1.0000000000000002E+00"}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(fmt.Sprintf("full=%v", tc.fullPrecision), func(t *testing.T) {
+			var out strings.Builder
+			printer := NewPrinter(&out)
+			printer.FullPrecision = tc.fullPrecision
+			printer.EmitLogEntry(entry)
+			for _, str := range tc.expected {
+				if !strings.Contains(out.String(), str) {
+					t.Errorf("Expect %q in output %q", str, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package console
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ansiEscapeRegexp matches ANSI escape sequences (CSI and OSC sequences and
+	// single-character escapes).
+	ansiEscapeRegexp = regexp.MustCompile("\x1b(?:\\[[0-?]*[ -/]*[@-~]|\\][^\x07\x1b]*(?:\x07|\x1b\\\\)?|[@-Z\\\\-_])")
+)
+
+// Sanitizer cleans untrusted text before it's printed to a terminal.
+type Sanitizer func(string) string
+
+// EscapeControlChars escapes control characters (including ESC which starts
+// ANSI escape sequences) using Go escapes, e.g. \x1b. Newlines and tabs are kept.
+func EscapeControlChars(str string) string {
+	if !hasControlChars(str) {
+		return str
+	}
+	var sb strings.Builder
+	for _, r := range str {
+		if isControlChar(r) {
+			quoted := strconv.QuoteRune(r)
+			sb.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// StripANSI removes ANSI escape sequences and escapes the remaining control characters.
+func StripANSI(str string) string {
+	if !hasControlChars(str) {
+		return str
+	}
+	return EscapeControlChars(ansiEscapeRegexp.ReplaceAllString(str, ""))
+}
+
+func hasControlChars(str string) bool {
+	for n := 0; n < len(str); n++ {
+		if c := str[n]; c < 0x20 || c == 0x7f || c >= utf8.RuneSelf {
+			for _, r := range str[n:] {
+				if isControlChar(r) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func isControlChar(r rune) bool {
+	if r == '\n' || r == '\t' {
+		return false
+	}
+	// C0, DEL and C1 controls.
+	return r < 0x20 || (r >= 0x7f && r < 0xa0)
+}
@@ -0,0 +1,184 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+// DefaultTopLocations is the default number of locations in the stats report.
+const DefaultTopLocations = 10
+
+// StatsEmitter accumulates the counts of log entries per level, location and
+// client (the source.ClientAttr attribute), as well as the durations of spans
+// assembled from the entries, and prints a report instead of the entries.
+type StatsEmitter struct {
+	Out io.Writer
+	// TopLocations is the number of the most frequent locations in the report,
+	// DefaultTopLocations if 0, and all if negative.
+	TopLocations int
+
+	lock      sync.Mutex
+	total     int
+	levels    map[logspb.LogEntry_Level]int
+	locations map[string]int
+	clients   map[string]int
+	spans     map[string]*SpanStats
+	assembler logs.SpanAssembler
+}
+
+// SpanStats is the stats of the spans of the same name.
+type SpanStats struct {
+	Count    int
+	Duration time.Duration
+}
+
+// NamedCount is a count with a name.
+type NamedCount struct {
+	Name  string
+	Count int
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *StatsEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.levels == nil {
+		e.levels = make(map[logspb.LogEntry_Level]int)
+		e.locations = make(map[string]int)
+		e.clients = make(map[string]int)
+		e.spans = make(map[string]*SpanStats)
+	}
+	e.total++
+	e.levels[entry.GetLevel()]++
+	if loc := entry.GetLocation(); loc != "" {
+		e.locations[loc]++
+	}
+	if client := entry.GetAttributes()[source.ClientAttr].GetStrValue(); client != "" {
+		e.clients[client]++
+	}
+	if span := e.assembler.AddLogEntry(entry); span != nil {
+		stats := e.spans[span.GetName()]
+		if stats == nil {
+			stats = &SpanStats{}
+			e.spans[span.GetName()] = stats
+		}
+		stats.Count++
+		stats.Duration += time.Duration(span.GetDuration())
+	}
+}
+
+// Total returns the number of log entries.
+func (e *StatsEmitter) Total() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.total
+}
+
+// Levels returns the counts per level in the order of levels.
+func (e *StatsEmitter) Levels() []NamedCount {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	counts := make([]NamedCount, 0, len(e.levels))
+	for level, count := range e.levels {
+		counts = append(counts, NamedCount{Name: level.String(), Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return logspb.LogEntry_Level_value[counts[i].Name] < logspb.LogEntry_Level_value[counts[j].Name]
+	})
+	return counts
+}
+
+// Locations returns the counts per location, the most frequent first,
+// limited by TopLocations.
+func (e *StatsEmitter) Locations() []NamedCount {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	counts := sortedCounts(e.locations)
+	top := e.TopLocations
+	if top == 0 {
+		top = DefaultTopLocations
+	}
+	if top > 0 && len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
+
+// Clients returns the counts per client, the most frequent first.
+func (e *StatsEmitter) Clients() []NamedCount {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return sortedCounts(e.clients)
+}
+
+// Spans returns the stats of completed spans by name.
+func (e *StatsEmitter) Spans() map[string]SpanStats {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	spans := make(map[string]SpanStats, len(e.spans))
+	for name, stats := range e.spans {
+		spans[name] = *stats
+	}
+	return spans
+}
+
+// Report prints the stats to Out.
+func (e *StatsEmitter) Report() error {
+	w := tabwriter.NewWriter(e.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "TOTAL\t%d\n", e.Total())
+	fmt.Fprintln(w, "\nLEVEL\tCOUNT")
+	for _, c := range e.Levels() {
+		fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Count)
+	}
+	if locations := e.Locations(); len(locations) > 0 {
+		fmt.Fprintln(w, "\nLOCATION\tCOUNT")
+		for _, c := range locations {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Count)
+		}
+	}
+	if clients := e.Clients(); len(clients) > 0 {
+		fmt.Fprintln(w, "\nCLIENT\tCOUNT")
+		for _, c := range clients {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Count)
+		}
+	}
+	spans := e.Spans()
+	if len(spans) > 0 {
+		names := make([]string, 0, len(spans))
+		for name := range spans {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(w, "\nSPAN\tCOUNT\tDURATION")
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%d\t%v\n", name, spans[name].Count, spans[name].Duration)
+		}
+	}
+	if open := e.assembler.NumOpenSpans(); open > 0 {
+		fmt.Fprintf(w, "\nOPEN SPANS\t%d\n", open)
+	}
+	return w.Flush()
+}
+
+// sortedCounts sorts the counts in descending order, by name if equal.
+func sortedCounts(m map[string]int) []NamedCount {
+	counts := make([]NamedCount, 0, len(m))
+	for name, count := range m {
+		counts = append(counts, NamedCount{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
@@ -0,0 +1,102 @@
+package console
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestStatsEmitter(t *testing.T) {
+	traceID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	spanEvent := func(ts time.Duration, spanID uint64, name string) *logspb.LogEntry {
+		entry := &logspb.LogEntry{
+			NanoTs: int64(ts),
+			Trace:  &logspb.Trace{SpanContext: &logspb.SpanContext{TraceId: traceID, SpanId: spanID}},
+		}
+		if name != "" {
+			entry.Trace.Event = &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: name}}
+		} else {
+			entry.Trace.Event = &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}
+		}
+		return entry
+	}
+	client := func(name string) map[string]*logspb.Value {
+		return map[string]*logspb.Value{"client": {Value: &logspb.Value_StrValue{StrValue: name}}}
+	}
+	entries := []*logspb.LogEntry{
+		spanEvent(0, 1, "query"),
+		{NanoTs: 1, Level: logspb.LogEntry_INFO, Location: "a.go:1", Attributes: client("web")},
+		{NanoTs: 2, Level: logspb.LogEntry_INFO, Location: "a.go:1", Attributes: client("web")},
+		{NanoTs: 3, Level: logspb.LogEntry_ERROR, Location: "b.go:2", Attributes: client("api")},
+		{NanoTs: 4, Level: logspb.LogEntry_WARNING, Location: "c.go:3", Attributes: client("web")},
+		spanEvent(3*time.Millisecond, 1, ""),
+		spanEvent(4*time.Millisecond, 2, "query"),
+		spanEvent(6*time.Millisecond, 2, ""),
+		spanEvent(7*time.Millisecond, 3, "write"),
+		spanEvent(8*time.Millisecond, 4, "read"),
+		spanEvent(9*time.Millisecond, 3, ""),
+	}
+	e := &StatsEmitter{TopLocations: 2}
+	for _, entry := range entries {
+		e.EmitLogEntry(entry)
+	}
+
+	if total := e.Total(); total != len(entries) {
+		t.Errorf("Expect total %d, got %d", len(entries), total)
+	}
+	expectedLevels := []NamedCount{{"NONE", 7}, {"INFO", 2}, {"WARNING", 1}, {"ERROR", 1}}
+	if levels := e.Levels(); !reflect.DeepEqual(levels, expectedLevels) {
+		t.Errorf("Expect levels %v, got %v", expectedLevels, levels)
+	}
+	expectedLocations := []NamedCount{{"a.go:1", 2}, {"b.go:2", 1}}
+	if locations := e.Locations(); !reflect.DeepEqual(locations, expectedLocations) {
+		t.Errorf("Expect locations %v, got %v", expectedLocations, locations)
+	}
+	expectedClients := []NamedCount{{"web", 3}, {"api", 1}}
+	if clients := e.Clients(); !reflect.DeepEqual(clients, expectedClients) {
+		t.Errorf("Expect clients %v, got %v", expectedClients, clients)
+	}
+	expectedSpans := map[string]SpanStats{
+		"query": {Count: 2, Duration: 5 * time.Millisecond},
+		"write": {Count: 1, Duration: 2 * time.Millisecond},
+	}
+	if spans := e.Spans(); !reflect.DeepEqual(spans, expectedSpans) {
+		t.Errorf("Expect spans %v, got %v", expectedSpans, spans)
+	}
+
+	var out strings.Builder
+	e.Out = &out
+	if err := e.Report(); err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	expected := strings.Join([]string{
+		"TOTAL  11",
+		"",
+		"LEVEL    COUNT",
+		"NONE     7",
+		"INFO     2",
+		"WARNING  1",
+		"ERROR    1",
+		"",
+		"LOCATION  COUNT",
+		"a.go:1    2",
+		"b.go:2    1",
+		"",
+		"CLIENT  COUNT",
+		"web     3",
+		"api     1",
+		"",
+		"SPAN   COUNT  DURATION",
+		"query  2      5ms",
+		"write  1      2ms",
+		"",
+		"OPEN SPANS  1",
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("Expect report:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
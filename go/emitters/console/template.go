@@ -0,0 +1,95 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"text/template"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// TemplateEmitter renders each log entry with a Go text/template, one line per
+// entry. The template is executed on the LogEntry, e.g. {{.Level}} {{.Message}},
+// with helper functions:
+//   - attr NAME: the attribute in plain text, empty if absent;
+//   - trace: the trace ID in hex, empty if absent;
+//   - span: the span ID in hex, empty if absent;
+//   - time [FORMAT]: the timestamp in FORMAT, RFC3339 with nanoseconds by default.
+type TemplateEmitter struct {
+	Out io.Writer
+
+	lock    sync.Mutex
+	tmpl    *template.Template
+	current *logspb.LogEntry
+	buf     bytes.Buffer
+}
+
+// NewTemplateEmitter parses the template and creates a TemplateEmitter.
+func NewTemplateEmitter(out io.Writer, text string) (*TemplateEmitter, error) {
+	e := &TemplateEmitter{Out: out}
+	tmpl, err := template.New("entry").Funcs(template.FuncMap{
+		"attr":  e.attr,
+		"trace": e.trace,
+		"span":  e.span,
+		"time":  e.time,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	e.tmpl = tmpl
+	return e, nil
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *TemplateEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.current = entry
+	e.buf.Reset()
+	err := e.tmpl.Execute(&e.buf, entry)
+	e.current = nil
+	if err != nil {
+		logs.Emergent().Error(err).PrintErr("Template: ")
+		return
+	}
+	if data := e.buf.Bytes(); len(data) == 0 || data[len(data)-1] != '\n' {
+		e.buf.WriteByte('\n')
+	}
+	e.Out.Write(e.buf.Bytes())
+}
+
+func (e *TemplateEmitter) attr(name string) string {
+	if val, ok := e.current.GetAttributes()[name]; ok {
+		return plainValue(val)
+	}
+	return ""
+}
+
+func (e *TemplateEmitter) trace() string {
+	if spanCtx := e.current.GetTrace().GetSpanContext(); spanCtx != nil {
+		return logs.TraceIDStringFrom(spanCtx)
+	}
+	return ""
+}
+
+func (e *TemplateEmitter) span() string {
+	if spanCtx := e.current.GetTrace().GetSpanContext(); spanCtx != nil {
+		return logs.SpanIDStringFrom(spanCtx)
+	}
+	return ""
+}
+
+func (e *TemplateEmitter) time(format ...string) (string, error) {
+	if len(format) > 1 {
+		return "", fmt.Errorf("time: expect at most 1 format, got %d", len(format))
+	}
+	layout := time.RFC3339Nano
+	if len(format) > 0 {
+		layout = format[0]
+	}
+	return time.Unix(0, e.current.GetNanoTs()).Format(layout), nil
+}
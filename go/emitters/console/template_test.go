@@ -0,0 +1,81 @@
+package console
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestTemplateEmitter(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 890000000, time.UTC)
+	entries := []*logspb.LogEntry{
+		{
+			NanoTs:  ts.UnixNano(),
+			Level:   logspb.LogEntry_INFO,
+			Message: "login",
+			Trace: &logspb.Trace{SpanContext: &logspb.SpanContext{
+				TraceId: []byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+				SpanId:  0xabc,
+			}},
+			Attributes: map[string]*logspb.Value{
+				"user":  {Value: &logspb.Value_StrValue{StrValue: "alice"}},
+				"count": {Value: &logspb.Value_IntValue{IntValue: 3}},
+			},
+		},
+		{
+			NanoTs:  ts.Add(time.Second).UnixNano(),
+			Level:   logspb.LogEntry_ERROR,
+			Message: "failed",
+		},
+	}
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "fields and attributes",
+			text:     `{{.Level}} {{.Message}} user={{attr "user"}} count={{attr "count"}}`,
+			expected: "INFO login user=alice count=3\nERROR failed user= count=\n",
+		},
+		{
+			name:     "trace and span",
+			text:     `{{.Message}} {{trace}}/{{span}}` + "\n",
+			expected: "login 0102030405060708090a0b0c0d0e0f10/0000000000000abc\nfailed /\n",
+		},
+		{
+			name:     "time",
+			text:     `{{time "15:04:05.000"}} {{time}}`,
+			expected: ts.Local().Format("15:04:05.000") + " " + ts.Local().Format(time.RFC3339Nano) + "\n" + ts.Add(time.Second).Local().Format("15:04:05.000") + " " + ts.Add(time.Second).Local().Format(time.RFC3339Nano) + "\n",
+		},
+		{
+			name:     "conditional",
+			text:     `{{with attr "user"}}{{.}}{{else}}anonymous{{end}}: {{.Message}}`,
+			expected: "alice: login\nanonymous: failed\n",
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			e, err := NewTemplateEmitter(&out, tc.text)
+			if err != nil {
+				t.Fatalf("NewTemplateEmitter: %v", err)
+			}
+			for _, entry := range entries {
+				e.EmitLogEntry(entry)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("Expect %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+	if _, err := NewTemplateEmitter(&strings.Builder{}, `{{.Message`); err == nil {
+		t.Errorf("Expect parse error")
+	}
+	if _, err := NewTemplateEmitter(&strings.Builder{}, `{{unknown}}`); err == nil {
+		t.Errorf("Expect error for undefined function")
+	}
+}
@@ -0,0 +1,122 @@
+package console
+
+import (
+	"fmt"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// Theme defines the ANSI escape sequences of the colorful output of Printer.
+// An empty sequence prints the text without decoration.
+type Theme struct {
+	Key       string
+	True      string
+	False     string
+	Int       string
+	Float     string
+	Double    string
+	Str       string
+	JSON      string
+	Proto     string
+	Bytes     string
+	TraceID   string
+	SpanID    string
+	SpanName  string
+	SpanStart string
+	SpanEnd   string
+	SpanEvent string
+	Location  string
+	Source    string
+	// Decorations of expanded JSON.
+	JSONKey    string
+	JSONStr    string
+	JSONNumber string
+	JSONNull   string
+	// Levels are the decorations of the level and the message.
+	Levels map[logspb.LogEntry_Level]string
+}
+
+// Built-in themes.
+var (
+	// DarkTheme is the default theme for terminals with dark backgrounds.
+	DarkTheme = Theme{
+		Key:        decorKey,
+		True:       decorTrue,
+		False:      decorFalse,
+		Int:        decorInt,
+		Float:      decorFloat,
+		Double:     decorDouble,
+		Str:        decorStr,
+		JSON:       decorJSON,
+		Proto:      decorProto,
+		Bytes:      decorBytes,
+		TraceID:    decorTraceID,
+		SpanID:     decorSpanID,
+		SpanName:   decorSpanName,
+		SpanStart:  decorSpanStart,
+		SpanEnd:    decorSpanEnd,
+		SpanEvent:  decorSpanEvent,
+		Location:   decorLoc,
+		Source:     decorSource,
+		JSONKey:    decorJSONKey,
+		JSONStr:    decorJSONStr,
+		JSONNumber: decorJSONNumber,
+		JSONNull:   decorJSONNull,
+		Levels: map[logspb.LogEntry_Level]string{
+			logspb.LogEntry_INFO:     "\x1b[37m",
+			logspb.LogEntry_WARNING:  "\x1b[33m",
+			logspb.LogEntry_ERROR:    "\x1b[31m",
+			logspb.LogEntry_CRITICAL: "\x1b[31m\x1b[1m",
+			logspb.LogEntry_FATAL:    "\x1b[31m\x1b[1m\x1b[5m",
+		},
+	}
+
+	// LightTheme avoids the white and light colors unreadable on light backgrounds.
+	LightTheme = Theme{
+		Key:        "\x1b[34m",       // fg:blue
+		True:       "\x1b[32m",       // fg:green
+		False:      "\x1b[31m",       // fg:red
+		Int:        "\x1b[36m",       // fg:cyan
+		Float:      "\x1b[36m",       // fg:cyan
+		Double:     "\x1b[36m",       // fg:cyan
+		Str:        "\x1b[34m",       // fg:blue
+		JSON:       "\x1b[38;5;130m", // fg:dark-orange
+		Proto:      "\x1b[90m",       // fg:grey
+		Bytes:      "\x1b[90m",       // fg:grey
+		TraceID:    "\x1b[35m",       // fg:magenta
+		SpanID:     "\x1b[36m",       // fg:cyan
+		SpanName:   "\x1b[32m",       // fg:green
+		SpanStart:  "\x1b[32m",       // fg:green
+		SpanEnd:    "\x1b[32m",       // fg:green
+		SpanEvent:  "\x1b[32m",       // fg:green
+		Location:   "\x1b[2m",        // dim
+		Source:     "\x1b[35m",       // fg:magenta
+		JSONKey:    "\x1b[34m",       // fg:blue
+		JSONStr:    "\x1b[34m",       // fg:blue
+		JSONNumber: "\x1b[36m",       // fg:cyan
+		JSONNull:   "\x1b[2m",        // dim
+		Levels: map[logspb.LogEntry_Level]string{
+			logspb.LogEntry_INFO:     "",
+			logspb.LogEntry_WARNING:  "\x1b[38;5;130m",
+			logspb.LogEntry_ERROR:    "\x1b[31m",
+			logspb.LogEntry_CRITICAL: "\x1b[31m\x1b[1m",
+			logspb.LogEntry_FATAL:    "\x1b[31m\x1b[1m\x1b[5m",
+		},
+	}
+
+	// NoTheme prints without colors.
+	NoTheme = Theme{}
+)
+
+// ParseTheme returns the built-in theme by name: dark, light, none.
+func ParseTheme(name string) (Theme, error) {
+	switch name {
+	case "", "dark":
+		return DarkTheme, nil
+	case "light":
+		return LightTheme, nil
+	case "none":
+		return NoTheme, nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q, expect one of dark, light, none", name)
+}
@@ -1,22 +1,27 @@
 package stackdriver
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/compute/metadata"
 	"google.golang.org/protobuf/encoding/protojson"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/stackdriver"
 )
 
 const (
 	DefaultMaxValueSize = 8192 // 8K.
+	// DefaultHTTPRequestAttr is the attribute set by logs.HTTPRequest in the HTTP handler.
+	DefaultHTTPRequestAttr = "http"
 )
 
 var (
@@ -35,9 +40,22 @@ type JSONPayload struct {
 	SourceLocation *SourceLocation        `json:"logging.googleapis.com/sourceLocation,omitempty"`
 	TraceID        string                 `json:"logging.googleapis.com/trace,omitempty"`
 	SpanID         string                 `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool                   `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	HTTPRequest    *HTTPRequest           `json:"httpRequest,omitempty"`
 	Raw            json.RawMessage        `json:"raw"`
 }
 
+// HTTPRequest defines the Stackdriver HTTP request rendered natively.
+type HTTPRequest struct {
+	RequestMethod string `json:"requestMethod,omitempty"`
+	RequestURL    string `json:"requestUrl,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	// ResponseSize is an int64 encoded as a string.
+	ResponseSize string `json:"responseSize,omitempty"`
+	// Latency is a duration in seconds with the suffix "s", e.g. 1.5s.
+	Latency string `json:"latency,omitempty"`
+}
+
 // SourceLocation defines the Stackdriver source location.
 type SourceLocation struct {
 	File string `json:"file"`
@@ -45,10 +63,7 @@ type SourceLocation struct {
 }
 
 // Timestamp defines the timestamp of the log.
-type Timestamp struct {
-	Seconds int64 `json:"seconds"`
-	Nanos   int64 `json:"nanos"`
-}
+type Timestamp = stackdriver.Timestamp
 
 // JSONEmitter is a console emitter printing logs in Stackdriver compatible JSON format.
 type JSONEmitter struct {
@@ -57,6 +72,10 @@ type JSONEmitter struct {
 	MinLevel  logspb.LogEntry_Level
 	// MaxValueSize applies to the value of a single attribute or the message.
 	MaxValueSize int
+	// HTTPRequestAttr, if not empty, is the attribute set by logs.HTTPRequest
+	// mapped into the httpRequest field, together with the attributes
+	// <name>.response set by logs.HTTPResponse and <name>.latency as a duration.
+	HTTPRequestAttr string
 }
 
 // NewJSONEmitter creates a JSONEmitter.
@@ -71,21 +90,29 @@ func NewJSONEmitter(out io.Writer, projectID string) (*JSONEmitter, error) {
 		}
 		projectID = id
 	}
-	return &JSONEmitter{Out: out, ProjectID: projectID, MaxValueSize: DefaultMaxValueSize}, nil
+	return &JSONEmitter{
+		Out:             out,
+		ProjectID:       projectID,
+		MaxValueSize:    DefaultMaxValueSize,
+		HTTPRequestAttr: DefaultHTTPRequestAttr,
+	}, nil
 }
 
 // EmitLogEntry implements LogEmitter.
 func (e *JSONEmitter) EmitLogEntry(entry *logspb.LogEntry) {
-	if entry.GetLevel() < e.MinLevel {
+	if !logs.LevelAllowed(entry, e.MinLevel) {
 		return
 	}
 	payload := &JSONPayload{
-		Timestamp: timestampFromNanos(entry.GetNanoTs()),
-		Severity:  severityFromLevel(entry.GetLevel()),
+		Timestamp: stackdriver.TimestampFromNanos(entry.GetNanoTs()),
+		Severity:  stackdriver.SeverityFromLevel(entry.GetLevel()),
 		Message:   entry.GetMessage(),
 		Labels:    labelsFromAttributes(entry.GetAttributes(), e.MaxValueSize),
 		Raw:       json.RawMessage(protojson.MarshalOptions{UseProtoNames: true}.Format(entry)),
 	}
+	if e.HTTPRequestAttr != "" {
+		payload.HTTPRequest = httpRequestFromAttributes(entry.GetAttributes(), e.HTTPRequestAttr)
+	}
 	if sz := len(payload.Message); e.MaxValueSize > 0 && sz > e.MaxValueSize {
 		payload.Message = payload.Message[:e.MaxValueSize] + "...<truncated>"
 	}
@@ -104,6 +131,7 @@ func (e *JSONEmitter) EmitLogEntry(entry *logspb.LogEntry) {
 			payload.TraceID = "projects/" + e.ProjectID + "/traces/" + traceID
 		}
 		payload.SpanID = spanID
+		payload.TraceSampled = logs.IsSampled(spanCtx)
 	}
 	out, err := json.Marshal(payload)
 	if err != nil {
@@ -113,27 +141,37 @@ func (e *JSONEmitter) EmitLogEntry(entry *logspb.LogEntry) {
 	fmt.Fprintln(e.Out, string(out))
 }
 
-var (
-	severityMap = map[logspb.LogEntry_Level]string{
-		logspb.LogEntry_INFO:     "NOTICE",
-		logspb.LogEntry_WARNING:  "WARNING",
-		logspb.LogEntry_ERROR:    "ERROR",
-		logspb.LogEntry_CRITICAL: "CRITICAL",
-		logspb.LogEntry_FATAL:    "EMERGENCY",
+// httpRequestFromAttributes extracts the HTTP request from the attributes set
+// by the HTTP handler: the request as name, the response as name.response and
+// the latency as name.latency. It returns nil if none found.
+func httpRequestFromAttributes(attrs map[string]*logspb.Value, name string) *HTTPRequest {
+	var req HTTPRequest
+	var found bool
+	if val := attrs[name].GetJson(); val != "" {
+		var reqAttrs logs.HTTPRequestAttrs
+		if err := json.Unmarshal([]byte(val), &reqAttrs); err == nil {
+			req.RequestMethod, req.RequestURL = reqAttrs.Method, reqAttrs.Path
+			found = true
+		}
 	}
-)
-
-func timestampFromNanos(nanos int64) (ts Timestamp) {
-	ts.Seconds = nanos / 1e9
-	ts.Nanos = nanos % 1e9
-	return
-}
-
-func severityFromLevel(level logspb.LogEntry_Level) string {
-	if s, ok := severityMap[level]; ok {
-		return s
+	if val := attrs[name+".response"].GetJson(); val != "" {
+		var respAttrs logs.HTTPResponseAttrs
+		if err := json.Unmarshal([]byte(val), &respAttrs); err == nil {
+			req.Status = respAttrs.StatusCode
+			if respAttrs.ContentLength >= 0 {
+				req.ResponseSize = strconv.FormatInt(respAttrs.ContentLength, 10)
+			}
+			found = true
+		}
+	}
+	if val, ok := attrs[name+".latency"].GetValue().(*logspb.Value_Duration); ok {
+		req.Latency = strconv.FormatFloat(time.Duration(val.Duration).Seconds(), 'f', -1, 64) + "s"
+		found = true
 	}
-	return "DEFAULT"
+	if !found {
+		return nil
+	}
+	return &req
 }
 
 func labelsFromAttributes(attrs map[string]*logspb.Value, maxValueSize int) map[string]interface{} {
@@ -141,7 +179,18 @@ func labelsFromAttributes(attrs map[string]*logspb.Value, maxValueSize int) map[
 		return nil
 	}
 	labels := make(map[string]interface{})
+	addLabels(labels, "", attrs, maxValueSize)
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// addLabels adds attributes into labels, and nested attributes are flattened
+// using dotted keys.
+func addLabels(labels map[string]interface{}, prefix string, attrs map[string]*logspb.Value, maxValueSize int) {
 	for key, val := range attrs {
+		key = prefix + key
 		switch v := val.GetValue().(type) {
 		case *logspb.Value_BoolValue:
 			labels[key] = v.BoolValue
@@ -165,12 +214,20 @@ func labelsFromAttributes(attrs map[string]*logspb.Value, maxValueSize int) map[
 			} else {
 				labels[key] = v.Proto
 			}
-		default:
-			continue
+		case *logspb.Value_Bytes:
+			if sz := len(v.Bytes); maxValueSize > 0 && sz*2 > maxValueSize {
+				labels[key] = "bytes:<too long...>"
+			} else {
+				labels[key] = hex.EncodeToString(v.Bytes)
+			}
+		case *logspb.Value_MapValue:
+			addLabels(labels, key+".", v.MapValue.GetValues(), maxValueSize)
+		case *logspb.Value_Duration:
+			labels[key] = time.Duration(v.Duration).String()
+		case *logspb.Value_Time:
+			labels[key] = time.Unix(0, v.Time).UTC().Format(time.RFC3339Nano)
+		case *logspb.Value_Decimal:
+			labels[key] = v.Decimal
 		}
 	}
-	if len(labels) == 0 {
-		return nil
-	}
-	return labels
 }
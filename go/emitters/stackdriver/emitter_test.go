@@ -0,0 +1,145 @@
+package stackdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	loghttp "github.com/evo-cloud/logs/go/http"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestHTTPRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/api/items?id=1", nil)
+	resp := &http.Response{Status: "404 Not Found", StatusCode: http.StatusNotFound, ContentLength: 128, Header: make(http.Header)}
+	testCases := []struct {
+		name     string
+		attrs    logs.AttributeSetters
+		expected *HTTPRequest
+	}{
+		{
+			name: "request",
+			attrs: logs.AttributeSetters{
+				logs.HTTPRequest("http", req),
+			},
+			expected: &HTTPRequest{RequestMethod: "POST", RequestURL: "/api/items"},
+		},
+		{
+			name: "request and response",
+			attrs: logs.AttributeSetters{
+				logs.HTTPRequest("http", req),
+				logs.HTTPResponse("http.response", resp),
+				logs.Duration("http.latency", 1500*time.Millisecond),
+			},
+			expected: &HTTPRequest{
+				RequestMethod: "POST",
+				RequestURL:    "/api/items",
+				Status:        http.StatusNotFound,
+				ResponseSize:  "128",
+				Latency:       "1.5s",
+			},
+		},
+		{
+			name: "other name",
+			attrs: logs.AttributeSetters{
+				logs.HTTPRequest("http-request", req),
+			},
+		},
+		{
+			name: "invalid",
+			attrs: logs.AttributeSetters{
+				logs.Str("http", "POST /api/items"),
+				logs.Int("http.latency", 1500),
+			},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			attrs := make(map[string]*logspb.Value)
+			tc.attrs.SetAttributes(attrs)
+			var out bytes.Buffer
+			emitter := &JSONEmitter{Out: &out, ProjectID: "project", HTTPRequestAttr: DefaultHTTPRequestAttr}
+			emitter.EmitLogEntry(&logspb.LogEntry{
+				NanoTs:     time.Now().UnixNano(),
+				Level:      logspb.LogEntry_INFO,
+				Message:    "served",
+				Attributes: attrs,
+			})
+			var payload JSONPayload
+			if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+				t.Fatalf("Unmarshal %q: %v", out.String(), err)
+			}
+			if !reflect.DeepEqual(payload.HTTPRequest, tc.expected) {
+				t.Errorf("Expect httpRequest %+v, got %+v", tc.expected, payload.HTTPRequest)
+			}
+		})
+	}
+}
+
+func TestMinLevelWithLevel(t *testing.T) {
+	var out bytes.Buffer
+	emitter := &JSONEmitter{Out: &out, ProjectID: "project", MinLevel: logspb.LogEntry_WARNING}
+	ctx := logs.Root(emitter).NewContext(context.Background())
+	logs.CtxInfof(ctx, "filtered")
+	logs.CtxInfof(logs.WithLevel(ctx, logspb.LogEntry_INFO), "verbose")
+	logs.CtxWarningf(ctx, "warning")
+
+	var messages []string
+	for dec := json.NewDecoder(&out); dec.More(); {
+		var payload JSONPayload
+		if err := dec.Decode(&payload); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		messages = append(messages, payload.Message)
+	}
+	if expected := []string{"verbose", "warning"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expect messages %q, got %q", expected, messages)
+	}
+}
+
+func TestHTTPRequestFromHandler(t *testing.T) {
+	var out bytes.Buffer
+	emitter, err := NewJSONEmitter(&out, "project")
+	if err != nil {
+		t.Fatalf("NewJSONEmitter: %v", err)
+	}
+	handler := loghttp.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/api/items?id=1", nil)
+	req = req.WithContext(logs.Root(emitter).NewContext(req.Context()))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var spanEnd *JSONPayload
+	for dec := json.NewDecoder(&out); dec.More(); {
+		var payload JSONPayload
+		if err := dec.Decode(&payload); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if strings.HasPrefix(payload.Message, "SPAN_END") {
+			spanEnd = &payload
+		}
+	}
+	if spanEnd == nil {
+		t.Fatalf("No span end in %q", out.String())
+	}
+	httpReq := spanEnd.HTTPRequest
+	if httpReq == nil {
+		t.Fatalf("No httpRequest in span end %+v", spanEnd)
+	}
+	if httpReq.RequestMethod != http.MethodPost || httpReq.RequestURL != "/api/items" || httpReq.Status != http.StatusNotFound || httpReq.ResponseSize != "9" {
+		t.Errorf("Unexpected httpRequest %+v", httpReq)
+	}
+	if !strings.HasSuffix(httpReq.Latency, "s") {
+		t.Errorf("Expect latency in seconds, got %q", httpReq.Latency)
+	}
+}
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// AttributesBuilders combines multiple AttributesBuilder.
+type AttributesBuilders []AttributesBuilder
+
+// BuildAttributes implements AttributesBuilder.
+func (b AttributesBuilders) BuildAttributes(ctx context.Context, md metadata.MD, info *stats.RPCTagInfo) logs.AttributeSetter {
+	setters := make(logs.AttributeSetters, 0, len(b))
+	for _, builder := range b {
+		if setter := builder.BuildAttributes(ctx, md, info); setter != nil {
+			setters = append(setters, setter)
+		}
+	}
+	return setters
+}
+
+// PeerAttributes builds attribute grpc.peer with the address of the remote peer.
+var PeerAttributes AttributesBuilder = AttributesBuilderFunc(buildPeerAttributes)
+
+// MethodAttributes builds attribute grpc.method with the full method name.
+var MethodAttributes AttributesBuilder = AttributesBuilderFunc(buildMethodAttributes)
+
+// DeadlineAttributes builds attributes grpc.deadline and grpc.timeout_ms if
+// the RPC has a deadline.
+var DeadlineAttributes AttributesBuilder = AttributesBuilderFunc(buildDeadlineAttributes)
+
+// MetadataAttributes builds attributes grpc.md.KEY from the values of incoming
+// metadata in the allowlist of keys. Multiple values are joined by comma.
+func MetadataAttributes(keys ...string) AttributesBuilder {
+	allowed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		allowed = append(allowed, strings.ToLower(key))
+	}
+	return AttributesBuilderFunc(func(ctx context.Context, md metadata.MD, info *stats.RPCTagInfo) logs.AttributeSetter {
+		var setters logs.AttributeSetters
+		for _, key := range allowed {
+			if vals := md.Get(key); len(vals) > 0 {
+				setters = append(setters, logs.Str("grpc.md."+key, strings.Join(vals, ",")))
+			}
+		}
+		return setters
+	})
+}
+
+// DefaultAttributesBuilder creates an AttributesBuilder with peer, method, deadline
+// and the incoming metadata from the allowlist of keys.
+func DefaultAttributesBuilder(mdKeys ...string) AttributesBuilder {
+	builders := AttributesBuilders{PeerAttributes, MethodAttributes, DeadlineAttributes}
+	if len(mdKeys) > 0 {
+		builders = append(builders, MetadataAttributes(mdKeys...))
+	}
+	return builders
+}
+
+func buildPeerAttributes(ctx context.Context, md metadata.MD, info *stats.RPCTagInfo) logs.AttributeSetter {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return logs.Str("grpc.peer", p.Addr.String())
+	}
+	return nil
+}
+
+func buildMethodAttributes(ctx context.Context, md metadata.MD, info *stats.RPCTagInfo) logs.AttributeSetter {
+	if info == nil || info.FullMethodName == "" {
+		return nil
+	}
+	return logs.Str("grpc.method", info.FullMethodName)
+}
+
+func buildDeadlineAttributes(ctx context.Context, md metadata.MD, info *stats.RPCTagInfo) logs.AttributeSetter {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	return logs.AttributeSetters{
+		logs.Str("grpc.deadline", deadline.UTC().Format(time.RFC3339Nano)),
+		logs.Int("grpc.timeout_ms", int64(time.Until(deadline)/time.Millisecond)),
+	}
+}
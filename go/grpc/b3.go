@@ -12,6 +12,8 @@ import (
 const (
 	B3TraceIDKey = "x-b3-traceid"
 	B3SpanIDKey  = "x-b3-spanid"
+	B3SampledKey = "x-b3-sampled"
+	B3FlagsKey   = "x-b3-flags"
 )
 
 // B3 extracts B3 span info.
@@ -22,6 +24,9 @@ type B3 struct {
 func (x *B3) ExtractSpanInfo(md metadata.MD, _ *stats.RPCTagInfo) logs.SpanInfo {
 	info := logs.BuildSpanInfoFrom(mdValue(md, B3TraceIDKey), "", mdValue(md, B3SpanIDKey))
 	info.Kind = logspb.Span_SERVER
+	if info.Parent != nil {
+		info.Parent.SpanContext.Flags = logs.ParseB3Flags(mdValue(md, B3SampledKey), mdValue(md, B3FlagsKey))
+	}
 	return info
 }
 
@@ -34,6 +39,12 @@ func (x *B3) InjectSpanInfo(info logs.SpanInfo, md metadata.MD) metadata.MD {
 	if spanID != "" {
 		md.Append(B3SpanIDKey, spanID)
 	}
+	if traceID != "" && spanID != "" {
+		md.Append(B3SampledKey, logs.FormatB3Sampled(info.Context))
+		if logs.IsDebug(info.Context) {
+			md.Append(B3FlagsKey, "1")
+		}
+	}
 	return md
 }
 
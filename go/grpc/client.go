@@ -22,8 +22,9 @@ type ClientStatsHandler struct {
 }
 
 // NewClientStatsHandler creates a ClientStatsHandler.
+// It injects both B3 and W3C trace context.
 func NewClientStatsHandler() *ClientStatsHandler {
-	return &ClientStatsHandler{SpanInfoInjector: &B3{}}
+	return &ClientStatsHandler{SpanInfoInjector: SpanInfoInjectors{&B3{}, &TraceContext{}}}
 }
 
 // TagRPC implements stats.Handler.
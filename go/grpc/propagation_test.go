@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+type spanRecorder struct {
+	lock   sync.Mutex
+	starts []*logspb.LogEntry
+}
+
+func (r *spanRecorder) EmitLogEntry(entry *logspb.LogEntry) {
+	if entry.GetTrace().GetSpanStart() == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.starts = append(r.starts, entry)
+}
+
+func (r *spanRecorder) spanStarts() []*logspb.LogEntry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*logspb.LogEntry(nil), r.starts...)
+}
+
+func TestClientServerSpanLinkage(t *testing.T) {
+	serverRecorder, clientRecorder := &spanRecorder{}, &spanRecorder{}
+	saved := logs.Default()
+	logs.Setup(serverRecorder)
+	t.Cleanup(func() { logs.SetDefault(saved) })
+
+	ln := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(grpc.StatsHandler(NewServerStatsHandler()))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(NewClientStatsHandler()),
+	)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx := logs.Root(clientRecorder).NewContext(context.Background())
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	srv.GracefulStop()
+
+	clientStarts, serverStarts := clientRecorder.spanStarts(), serverRecorder.spanStarts()
+	if len(clientStarts) != 1 || len(serverStarts) != 1 {
+		t.Fatalf("Expect 1 client and 1 server span, got %d and %d", len(clientStarts), len(serverStarts))
+	}
+	clientCtx, serverCtx := clientStarts[0].GetTrace().GetSpanContext(), serverStarts[0].GetTrace().GetSpanContext()
+	if clientTraceID, serverTraceID := logs.TraceIDStringFrom(clientCtx), logs.TraceIDStringFrom(serverCtx); clientTraceID != serverTraceID {
+		t.Errorf("Expect same trace ID, client %s, server %s", clientTraceID, serverTraceID)
+	}
+	if serverCtx.GetSpanId() == clientCtx.GetSpanId() {
+		t.Errorf("Expect server span ID different from client span ID %x", clientCtx.GetSpanId())
+	}
+	serverStart := serverStarts[0].GetTrace().GetSpanStart()
+	if kind := serverStart.GetKind(); kind != logspb.Span_SERVER {
+		t.Errorf("Expect server span kind SERVER, got %v", kind)
+	}
+	var parent *logspb.Link
+	for _, link := range serverStart.GetLinks() {
+		if link.GetType() == logspb.Link_CHILD_OF {
+			parent = link
+		}
+	}
+	if parent == nil {
+		t.Fatalf("Server span has no parent link: %v", serverStart.GetLinks())
+	}
+	if parentID := parent.GetSpanContext().GetSpanId(); parentID != clientCtx.GetSpanId() {
+		t.Errorf("Expect parent span ID %x, got %x", clientCtx.GetSpanId(), parentID)
+	}
+	if parentTraceID, traceID := logs.TraceIDStringFrom(parent.GetSpanContext()), logs.TraceIDStringFrom(clientCtx); parentTraceID != traceID {
+		t.Errorf("Expect parent trace ID %s, got %s", traceID, parentTraceID)
+	}
+}
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// DefaultRequestIDKeys are the metadata keys of the request ID used by NewServerStatsHandler.
+var DefaultRequestIDKeys = []string{"x-request-id", "x-correlation-id"}
+
+// EchoRequestID returns a unary server interceptor sending the request ID in
+// the context back in the response header with the key. It's needed as stats
+// handlers can't set response headers.
+func EchoRequestID(key string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if id := logs.RequestIDFrom(ctx); id != "" {
+			grpc.SetHeader(ctx, metadata.Pairs(key, id))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// EchoRequestIDStream is the stream server interceptor version of EchoRequestID.
+func EchoRequestIDStream(key string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if id := logs.RequestIDFrom(ss.Context()); id != "" {
+			ss.SetHeader(metadata.Pairs(key, id))
+		}
+		return handler(srv, ss)
+	}
+}
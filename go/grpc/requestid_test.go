@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestServerStatsHandlerRequestID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		md       metadata.MD
+		generate bool
+		expected string
+	}{
+		{name: "request id", md: metadata.Pairs("x-request-id", "req-1"), expected: "req-1"},
+		{name: "correlation id", md: metadata.Pairs("x-correlation-id", "corr-1"), generate: true, expected: "corr-1"},
+		{name: "absent"},
+		{name: "generated", generate: true},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &spanRecorder{}
+			ctx := logs.Root(recorder).NewContext(context.Background())
+			ctx = metadata.NewIncomingContext(ctx, tc.md)
+			h := NewServerStatsHandler().WithGenerateRequestID(tc.generate)
+			ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/svc/Method"})
+			id := logs.RequestIDFrom(ctx)
+			if tc.expected != "" && id != tc.expected {
+				t.Errorf("request ID %q, expect %q", id, tc.expected)
+			}
+			if tc.expected == "" && (id != "") != tc.generate {
+				t.Errorf("request ID %q, expect generated %v", id, tc.generate)
+			}
+			starts := recorder.spanStarts()
+			if len(starts) != 1 {
+				t.Fatalf("expect 1 span start, got %d", len(starts))
+			}
+			if attr, ok := starts[0].GetAttributes()[logs.RequestIDAttr]; attr.GetStrValue() != id || ok != (id != "") {
+				t.Errorf("span attribute %s=%v, expect %q", logs.RequestIDAttr, attr, id)
+			}
+		})
+	}
+}
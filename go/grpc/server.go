@@ -34,11 +34,34 @@ func (f AttributesBuilderFunc) BuildAttributes(ctx context.Context, md metadata.
 type ServerStatsHandler struct {
 	SpanInfoExtractor SpanInfoExtractor
 	AttributesBuilder AttributesBuilder
+	// RequestIDKeys are the metadata keys tried in order for the request ID,
+	// which is set as logs.RequestIDAttr on the span and in the context
+	// (see logs.RequestIDFrom). Empty disables request IDs.
+	// Use EchoRequestID to send it back.
+	RequestIDKeys []string
+	// GenerateRequestID generates a request ID if absent in the metadata.
+	GenerateRequestID bool
 }
 
 // NewServerStatsHandler creates a ServerStatsHandler.
+// It extracts W3C trace context and falls back to B3.
 func NewServerStatsHandler() *ServerStatsHandler {
-	return &ServerStatsHandler{SpanInfoExtractor: &B3{}}
+	return &ServerStatsHandler{
+		SpanInfoExtractor: SpanInfoExtractors{&TraceContext{}, &B3{}},
+		RequestIDKeys:     DefaultRequestIDKeys,
+	}
+}
+
+// WithRequestIDKeys sets RequestIDKeys.
+func (h *ServerStatsHandler) WithRequestIDKeys(keys ...string) *ServerStatsHandler {
+	h.RequestIDKeys = keys
+	return h
+}
+
+// WithGenerateRequestID sets GenerateRequestID.
+func (h *ServerStatsHandler) WithGenerateRequestID(generate bool) *ServerStatsHandler {
+	h.GenerateRequestID = generate
+	return h
 }
 
 // WithAttributesBuilder sets AttributesBuilder.
@@ -51,9 +74,24 @@ func (h *ServerStatsHandler) WithAttributesBuilder(b AttributesBuilder) *ServerS
 func (h *ServerStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
 	md, _ := metadata.FromIncomingContext(ctx)
 	spanInfo := h.SpanInfoExtractor.ExtractSpanInfo(md, info)
-	var attrs logs.AttributeSetter
+	var attrs logs.AttributeSetters
 	if b := h.AttributesBuilder; b != nil {
-		attrs = b.BuildAttributes(ctx, md, info)
+		attrs = append(attrs, b.BuildAttributes(ctx, md, info))
+	}
+	if len(h.RequestIDKeys) > 0 {
+		id := ""
+		for _, key := range h.RequestIDKeys {
+			if id = mdValue(md, key); id != "" {
+				break
+			}
+		}
+		if id == "" && h.GenerateRequestID {
+			id = logs.NewRequestID()
+		}
+		if id != "" {
+			attrs = append(attrs, logs.Str(logs.RequestIDAttr, id))
+			ctx = logs.WithRequestID(ctx, id)
+		}
 	}
 	spanInfo.Name = rpcSpanName(info)
 	ctx, _ = logs.StartSpanWith(ctx, 0, spanInfo, attrs)
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// TraceParentKey is the W3C trace context metadata key for SpanInfo.
+const TraceParentKey = "traceparent"
+
+// TraceContext extracts and injects W3C trace context (traceparent).
+// The vendor specific tracestate is not propagated.
+type TraceContext struct {
+}
+
+// ExtractSpanInfo implements SpanInfoExtractor.
+func (x *TraceContext) ExtractSpanInfo(md metadata.MD, _ *stats.RPCTagInfo) logs.SpanInfo {
+	info := logs.SpanInfoFromTraceParent(mdValue(md, TraceParentKey))
+	info.Kind = logspb.Span_SERVER
+	return info
+}
+
+// InjectSpanInfo implements SpanInfoInjector.
+func (x *TraceContext) InjectSpanInfo(info logs.SpanInfo, md metadata.MD) metadata.MD {
+	if value := logs.FormatTraceParent(info.Context); value != "" {
+		md.Set(TraceParentKey, value)
+	}
+	return md
+}
+
+// SpanInfoExtractors tries the extractors in order, and returns the first
+// SpanInfo with a span context.
+type SpanInfoExtractors []SpanInfoExtractor
+
+// ExtractSpanInfo implements SpanInfoExtractor.
+func (x SpanInfoExtractors) ExtractSpanInfo(md metadata.MD, tagInfo *stats.RPCTagInfo) (info logs.SpanInfo) {
+	for _, extractor := range x {
+		if info = extractor.ExtractSpanInfo(md, tagInfo); info.Context != nil {
+			return
+		}
+	}
+	return
+}
+
+// SpanInfoInjectors injects SpanInfo using all the injectors.
+type SpanInfoInjectors []SpanInfoInjector
+
+// InjectSpanInfo implements SpanInfoInjector.
+func (x SpanInfoInjectors) InjectSpanInfo(info logs.SpanInfo, md metadata.MD) metadata.MD {
+	for _, injector := range x {
+		md = injector.InjectSpanInfo(info, md)
+	}
+	return md
+}
@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestTraceContext(t *testing.T) {
+	const (
+		traceID  = "0102030405060708090a0b0c0d0e0f10"
+		spanID   = "00f067aa0ba902b7"
+		traceVal = "00-" + traceID + "-" + spanID + "-01"
+	)
+	info := (&TraceContext{}).ExtractSpanInfo(metadata.Pairs(TraceParentKey, traceVal), nil)
+	if info.Context == nil || info.Parent == nil {
+		t.Fatalf("no span info extracted from %q", traceVal)
+	}
+	// Trace IDs are stored little-endian, in the reversed order of the string form.
+	if id := info.Context.GetTraceId(); id[0] != 0x10 || id[15] != 0x01 {
+		t.Errorf("trace ID bytes %x not in reversed order of %s", id, traceID)
+	}
+	if got := info.TraceID(); got != traceID {
+		t.Errorf("trace ID %s, expect %s", got, traceID)
+	}
+	if got := logs.SpanIDStringFrom(info.Parent.GetSpanContext()); got != spanID {
+		t.Errorf("parent span ID %s, expect %s", got, spanID)
+	}
+
+	// Same as B3 with the same IDs.
+	b3Info := (&B3{}).ExtractSpanInfo(metadata.Pairs(B3TraceIDKey, traceID, B3SpanIDKey, spanID), nil)
+	if b3Info.TraceID() != info.TraceID() || b3Info.Parent.GetSpanContext().GetSpanId() != info.Parent.GetSpanContext().GetSpanId() {
+		t.Errorf("B3 span info %v, expect %v", b3Info.String(), info.String())
+	}
+
+	// Injecting the remote span formats back to the same value.
+	remote := logs.SpanInfo{Context: info.Parent.GetSpanContext()}
+	md := (&TraceContext{}).InjectSpanInfo(remote, metadata.MD{})
+	if got := mdValue(md, TraceParentKey); got != traceVal {
+		t.Errorf("injected %q, expect %q", got, traceVal)
+	}
+}
+
+func TestB3Flags(t *testing.T) {
+	const (
+		traceID = "0102030405060708090a0b0c0d0e0f10"
+		spanID  = "00f067aa0ba902b7"
+	)
+	testCases := []struct {
+		name    string
+		md      metadata.MD
+		sampled bool
+		debug   bool
+	}{
+		{name: "deferred", md: metadata.Pairs(B3TraceIDKey, traceID, B3SpanIDKey, spanID), sampled: true},
+		{name: "not sampled", md: metadata.Pairs(B3TraceIDKey, traceID, B3SpanIDKey, spanID, B3SampledKey, "0")},
+		{name: "debug", md: metadata.Pairs(B3TraceIDKey, traceID, B3SpanIDKey, spanID, B3FlagsKey, "1"), sampled: true, debug: true},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			info := (&B3{}).ExtractSpanInfo(tc.md, nil)
+			remote := info.Parent.GetSpanContext()
+			if logs.IsSampled(remote) != tc.sampled || logs.IsDebug(remote) != tc.debug {
+				t.Fatalf("Extracted flags %d, expect sampled=%v debug=%v", remote.GetFlags(), tc.sampled, tc.debug)
+			}
+			md := (&B3{}).InjectSpanInfo(logs.SpanInfo{Context: remote}, metadata.MD{})
+			injected := (&B3{}).ExtractSpanInfo(md, nil)
+			if flags := injected.Parent.GetSpanContext().GetFlags(); flags != remote.GetFlags() {
+				t.Errorf("Injected flags %d, expect %d", flags, remote.GetFlags())
+			}
+		})
+	}
+}
+
+func TestSpanInfoExtractors(t *testing.T) {
+	const (
+		w3cTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		b3TraceID  = "80f198ee56343ba864fe8b2a57d3eff7"
+	)
+	extractor := SpanInfoExtractors{&TraceContext{}, &B3{}}
+	testCases := []struct {
+		name    string
+		md      metadata.MD
+		traceID string
+	}{
+		{
+			name:    "w3c",
+			md:      metadata.Pairs(TraceParentKey, "00-"+w3cTraceID+"-00f067aa0ba902b7-01"),
+			traceID: w3cTraceID,
+		},
+		{
+			name:    "b3",
+			md:      metadata.Pairs(B3TraceIDKey, b3TraceID, B3SpanIDKey, "e457b5a2e4d86bd1"),
+			traceID: b3TraceID,
+		},
+		{
+			name: "w3c first",
+			md: metadata.Pairs(
+				TraceParentKey, "00-"+w3cTraceID+"-00f067aa0ba902b7-01",
+				B3TraceIDKey, b3TraceID, B3SpanIDKey, "e457b5a2e4d86bd1",
+			),
+			traceID: w3cTraceID,
+		},
+		{
+			name:    "invalid w3c",
+			md:      metadata.Pairs(TraceParentKey, "00-"+w3cTraceID+"-0000000000000000-01", B3TraceIDKey, b3TraceID),
+			traceID: b3TraceID,
+		},
+		{
+			name: "none",
+			md:   metadata.MD{},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			info := extractor.ExtractSpanInfo(tc.md, nil)
+			if got := info.TraceID(); got != tc.traceID {
+				t.Errorf("trace ID %q, expect %q", got, tc.traceID)
+			}
+		})
+	}
+}
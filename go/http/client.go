@@ -12,7 +12,7 @@ func UpdateRequest(r *http.Request) {
 	UpdateHeader(r.Context(), r.Header)
 }
 
-// UpdateHeader updates HTTP header.
+// UpdateHeader updates HTTP header with B3 and W3C trace context headers.
 func UpdateHeader(ctx context.Context, header http.Header) {
 	logger := logs.Use(ctx)
 	spanInfo := logger.SpanInfo()
@@ -22,4 +22,13 @@ func UpdateHeader(ctx context.Context, header http.Header) {
 	if spanID := spanInfo.SpanID(); spanID != "" {
 		header.Add(B3SpanIDHeader, spanID)
 	}
+	if spanInfo.Context != nil {
+		header.Set(B3SampledHeader, logs.FormatB3Sampled(spanInfo.Context))
+		if logs.IsDebug(spanInfo.Context) {
+			header.Set(B3FlagsHeader, "1")
+		}
+	}
+	if traceParent := logs.FormatTraceParent(spanInfo.Context); traceParent != "" {
+		header.Set(TraceParentHeader, traceParent)
+	}
 }
@@ -1,7 +1,11 @@
 package http
 
 import (
+	"bufio"
+	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
@@ -11,13 +15,29 @@ import (
 const (
 	B3TraceIDHeader = "X-B3-TraceId"
 	B3SpanIDHeader  = "X-B3-SpanId"
+	B3SampledHeader = "X-B3-Sampled"
+	B3FlagsHeader   = "X-B3-Flags"
 )
 
+// TraceParentHeader is the W3C trace context HTTP header.
+const TraceParentHeader = "traceparent"
+
+// DefaultRequestIDHeaders are the headers of the request ID used by NewHandler.
+var DefaultRequestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
 // SpanInfoExtractor extracts SpanInfo from RPC.
 type SpanInfoExtractor interface {
 	ExtractSpanInfo(r *http.Request) logs.SpanInfo
 }
 
+// SpanInfoExtractorFunc is the func form of SpanInfoExtractor.
+type SpanInfoExtractorFunc func(r *http.Request) logs.SpanInfo
+
+// ExtractSpanInfo implements SpanInfoExtractor.
+func (f SpanInfoExtractorFunc) ExtractSpanInfo(r *http.Request) logs.SpanInfo {
+	return f(r)
+}
+
 // AttributesBuilder injects attributes into context.
 type AttributesBuilder interface {
 	BuildAttributes(r *http.Request) logs.AttributeSetter
@@ -35,16 +55,66 @@ func (f AttributesBuilderFunc) BuildAttributes(r *http.Request) logs.AttributeSe
 type B3Extractor struct {
 }
 
+// TraceContextExtractor extracts W3C trace context (traceparent) span info.
+type TraceContextExtractor struct {
+}
+
+// SpanInfoExtractors tries the extractors in order, and returns the first
+// SpanInfo with a span context.
+type SpanInfoExtractors []SpanInfoExtractor
+
 // Handler implements http.Handler to inject span into context.
+// The request is set as the "http" attribute (see logs.HTTPRequest), and when
+// the request is served, the response as "http.response" (see logs.HTTPResponse)
+// and the latency as "http.latency" are set on the span end.
 type Handler struct {
 	SpanInfoExtractor SpanInfoExtractor
 	AttributesBuilder AttributesBuilder
-	Next              http.Handler
+	// RequestIDHeaders are the headers tried in order for the request ID, which
+	// is set as logs.RequestIDAttr on the span and in the context
+	// (see logs.RequestIDFrom). Empty disables request IDs.
+	RequestIDHeaders []string
+	// GenerateRequestID generates a request ID if absent in the request.
+	GenerateRequestID bool
+	// EchoRequestID sets the request ID in the response using the header it's
+	// received from, or the first of RequestIDHeaders if generated.
+	EchoRequestID bool
+	Next          http.Handler
 }
 
 // NewHandler creates a Handler.
+// It extracts W3C trace context and falls back to B3.
 func NewHandler(next http.Handler) *Handler {
-	return &Handler{SpanInfoExtractor: &B3Extractor{}, Next: next}
+	return &Handler{
+		SpanInfoExtractor: SpanInfoExtractors{&TraceContextExtractor{}, &B3Extractor{}},
+		RequestIDHeaders:  DefaultRequestIDHeaders,
+		Next:              next,
+	}
+}
+
+// WithRequestIDHeaders sets RequestIDHeaders.
+func (h *Handler) WithRequestIDHeaders(headers ...string) *Handler {
+	h.RequestIDHeaders = headers
+	return h
+}
+
+// WithGenerateRequestID sets GenerateRequestID.
+func (h *Handler) WithGenerateRequestID(generate bool) *Handler {
+	h.GenerateRequestID = generate
+	return h
+}
+
+// WithEchoRequestID sets EchoRequestID.
+func (h *Handler) WithEchoRequestID(echo bool) *Handler {
+	h.EchoRequestID = echo
+	return h
+}
+
+// WithSpanInfoExtractors sets the extractors tried in order to understand
+// different propagation formats, and the first span info found is used.
+func (h *Handler) WithSpanInfoExtractors(extractors ...SpanInfoExtractor) *Handler {
+	h.SpanInfoExtractor = SpanInfoExtractors(extractors)
+	return h
 }
 
 // WithAttributesBuilder sets AttributesBuilder.
@@ -63,18 +133,124 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		attrs = append(attrs, b.BuildAttributes(r))
 	}
 	attrs = append(attrs, logs.HTTPRequest("http", r))
+	if len(h.RequestIDHeaders) > 0 {
+		header, id := h.RequestIDHeaders[0], ""
+		for _, name := range h.RequestIDHeaders {
+			if id = r.Header.Get(name); id != "" {
+				header = name
+				break
+			}
+		}
+		if id == "" && h.GenerateRequestID {
+			id = logs.NewRequestID()
+		}
+		if id != "" {
+			if h.EchoRequestID {
+				w.Header().Set(header, id)
+			}
+			attrs = append(attrs, logs.Str(logs.RequestIDAttr, id))
+			ctx = logs.WithRequestID(ctx, id)
+		}
+	}
 	ctx, span := logs.StartSpanWith(ctx, 0, spanInfo, attrs)
 	defer span.End()
-	h.Next.ServeHTTP(w, r.WithContext(ctx))
+	defer span.Recover()
+	start := time.Now()
+	rw := &responseRecorder{ResponseWriter: w}
+	h.Next.ServeHTTP(rw, r.WithContext(ctx))
+	span.SetAttrs(logs.HTTPResponse("http.response", rw.response()), logs.Duration("http.latency", time.Since(start)))
+}
+
+// responseRecorder records the status code and the size of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseRecorder) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("hijack not supported")
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// response returns the recorded response. The status is 200 if not written,
+// as it's written by the server when the handler returns.
+func (w *responseRecorder) response() *http.Response {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		ContentLength: w.size,
+		Header:        w.Header(),
+	}
 }
 
 // ExtractSpanInfo implements SpanInfoExtractor.
 func (x *B3Extractor) ExtractSpanInfo(r *http.Request) logs.SpanInfo {
 	info := logs.BuildSpanInfoFrom(r.Header.Get(B3TraceIDHeader), "", r.Header.Get(B3SpanIDHeader))
 	info.Kind = logspb.Span_SERVER
+	if info.Parent != nil {
+		info.Parent.SpanContext.Flags = logs.ParseB3Flags(r.Header.Get(B3SampledHeader), r.Header.Get(B3FlagsHeader))
+	}
 	return info
 }
 
+// ExtractSpanInfo implements SpanInfoExtractor.
+func (x *TraceContextExtractor) ExtractSpanInfo(r *http.Request) logs.SpanInfo {
+	info := logs.SpanInfoFromTraceParent(r.Header.Get(TraceParentHeader))
+	info.Kind = logspb.Span_SERVER
+	return info
+}
+
+// ExtractSpanInfo implements SpanInfoExtractor.
+func (x SpanInfoExtractors) ExtractSpanInfo(r *http.Request) (info logs.SpanInfo) {
+	for _, extractor := range x {
+		if info = extractor.ExtractSpanInfo(r); info.Context != nil {
+			return
+		}
+	}
+	return
+}
+
 func requestSpanName(r *http.Request) string {
 	return r.URL.Path
 }
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+type recordingEmitter struct {
+	lock    sync.Mutex
+	entries []*logspb.LogEntry
+}
+
+func (e *recordingEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.entries = append(e.entries, entry)
+}
+
+func TestHandlerRecover(t *testing.T) {
+	emitter := &recordingEmitter{}
+	handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r = r.WithContext(logs.Root(emitter).NewContext(r.Context()))
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	if recovered != "boom" {
+		t.Errorf("recovered %v, expect re-panic with boom", recovered)
+	}
+
+	var panicEntry, spanEnd *logspb.LogEntry
+	for _, entry := range emitter.entries {
+		switch {
+		case entry.GetLevel() == logspb.LogEntry_CRITICAL:
+			panicEntry = entry
+		case entry.GetTrace().GetSpanEnd() != nil:
+			spanEnd = entry
+		}
+	}
+	if panicEntry == nil {
+		t.Fatalf("no CRITICAL entry in %v", emitter.entries)
+	}
+	if msg := panicEntry.GetMessage(); msg != "panic: boom" {
+		t.Errorf("message %q, expect %q", msg, "panic: boom")
+	}
+	if errMsg := panicEntry.GetAttributes()[logs.ErrorAttr].GetStrValue(); errMsg != "boom" {
+		t.Errorf("error attribute %q, expect %q", errMsg, "boom")
+	}
+	if stack := panicEntry.GetAttributes()[logs.StackAttr].GetStrValue(); !strings.Contains(stack, "handler_test.go") {
+		t.Errorf("stack attribute doesn't contain the panic site: %s", stack)
+	}
+	if spanEnd == nil {
+		t.Fatalf("no span end entry in %v", emitter.entries)
+	}
+	if !spanEnd.GetAttributes()[logs.PanicAttr].GetBoolValue() {
+		t.Errorf("span end attributes %v, expect %s=true", spanEnd.GetAttributes(), logs.PanicAttr)
+	}
+	if _, ok := spanEnd.GetAttributes()[logs.ErrorAttr]; ok {
+		t.Errorf("span end attributes %v, unexpected %s", spanEnd.GetAttributes(), logs.ErrorAttr)
+	}
+}
+
+func TestHandlerRecoverAbort(t *testing.T) {
+	emitter := &recordingEmitter{}
+	handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/abort", nil)
+	r = r.WithContext(logs.Root(emitter).NewContext(r.Context()))
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	if recovered != http.ErrAbortHandler {
+		t.Errorf("recovered %v, expect re-panic with %v", recovered, http.ErrAbortHandler)
+	}
+	for _, entry := range emitter.entries {
+		if entry.GetLevel() == logspb.LogEntry_CRITICAL {
+			t.Errorf("unexpected CRITICAL entry %v", entry)
+		}
+		if _, ok := entry.GetAttributes()[logs.PanicAttr]; ok {
+			t.Errorf("unexpected %s in %v", logs.PanicAttr, entry)
+		}
+	}
+}
+
+func TestHandlerSpanInfoExtractors(t *testing.T) {
+	const (
+		b3TraceID  = "80f198ee56343ba864fe8b2a57d3eff7"
+		b3SpanID   = "e457b5a2e4d86bd1"
+		w3cTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		w3cSpanID  = "00f067aa0ba902b7"
+		cTraceID   = "0102030405060708090a0b0c0d0e0f10"
+		cSpanID    = "1112131415161718"
+		customHdr  = "X-Custom-Trace"
+	)
+	custom := SpanInfoExtractorFunc(func(r *http.Request) logs.SpanInfo {
+		ids := strings.SplitN(r.Header.Get(customHdr), "/", 2)
+		if len(ids) != 2 {
+			return logs.SpanInfo{}
+		}
+		return logs.BuildSpanInfoFrom(ids[0], "", ids[1])
+	})
+	b3 := map[string]string{B3TraceIDHeader: b3TraceID, B3SpanIDHeader: b3SpanID}
+	w3c := map[string]string{TraceParentHeader: "00-" + w3cTraceID + "-" + w3cSpanID + "-01"}
+	testCases := []struct {
+		name     string
+		headers  []map[string]string
+		traceID  string
+		parentID string
+	}{
+		{name: "b3", headers: []map[string]string{b3}, traceID: b3TraceID, parentID: b3SpanID},
+		{name: "w3c", headers: []map[string]string{w3c}, traceID: w3cTraceID, parentID: w3cSpanID},
+		{name: "custom", headers: []map[string]string{{customHdr: cTraceID + "/" + cSpanID}}, traceID: cTraceID, parentID: cSpanID},
+		{name: "first match", headers: []map[string]string{w3c, b3}, traceID: b3TraceID, parentID: b3SpanID},
+		{name: "none"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var info logs.SpanInfo
+			handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				info = logs.Use(r.Context()).SpanInfo()
+			})).WithSpanInfoExtractors(&B3Extractor{}, &TraceContextExtractor{}, custom)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, headers := range tc.headers {
+				for key, val := range headers {
+					r.Header.Set(key, val)
+				}
+			}
+			r = r.WithContext(logs.Root(&recordingEmitter{}).NewContext(r.Context()))
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if tc.traceID == "" {
+				if info.Context == nil || info.Parent != nil {
+					t.Errorf("expect a new trace without parent, got %s", info.String())
+				}
+				return
+			}
+			if traceID := info.TraceID(); traceID != tc.traceID {
+				t.Errorf("trace ID %s, expect %s", traceID, tc.traceID)
+			}
+			if parentID := logs.SpanIDStringFrom(info.Parent.GetSpanContext()); parentID != tc.parentID {
+				t.Errorf("parent span ID %s, expect %s", parentID, tc.parentID)
+			}
+		})
+	}
+}
+
+func TestHandlerRequestID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		id       string
+		generate bool
+		echo     bool
+	}{
+		{name: "request id", header: "X-Request-Id", id: "req-1", echo: true},
+		{name: "correlation id", header: "X-Correlation-Id", id: "corr-1", echo: true},
+		{name: "generated", header: "X-Request-Id", generate: true, echo: true},
+		{name: "not echoed", header: "X-Request-Id", id: "req-1"},
+		{name: "generated not echoed", header: "X-Request-Id", generate: true},
+		{name: "absent", header: "X-Request-Id", echo: true},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			emitter := &recordingEmitter{}
+			var ctxID string
+			handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = logs.RequestIDFrom(r.Context())
+			})).WithGenerateRequestID(tc.generate).WithEchoRequestID(tc.echo)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.id != "" {
+				r.Header.Set(tc.header, tc.id)
+			}
+			r = r.WithContext(logs.Root(emitter).NewContext(r.Context()))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if tc.id != "" && ctxID != tc.id {
+				t.Errorf("request ID in context %q, expect %q", ctxID, tc.id)
+			}
+			if tc.id == "" && (ctxID != "") != tc.generate {
+				t.Errorf("request ID in context %q, expect generated %v", ctxID, tc.generate)
+			}
+			echoed := w.Header().Get(tc.header)
+			if tc.echo && echoed != ctxID || !tc.echo && echoed != "" {
+				t.Errorf("response header %s=%q, expect echoed %v of %q", tc.header, echoed, tc.echo, ctxID)
+			}
+			if len(emitter.entries) == 0 || emitter.entries[0].GetTrace().GetSpanStart() == nil {
+				t.Fatalf("no span start entry in %v", emitter.entries)
+			}
+			if attr, ok := emitter.entries[0].GetAttributes()[logs.RequestIDAttr]; attr.GetStrValue() != ctxID || ok != (ctxID != "") {
+				t.Errorf("span attribute %s=%v, expect %q", logs.RequestIDAttr, attr, ctxID)
+			}
+		})
+	}
+}
@@ -1,17 +1,46 @@
 package logs
 
 import (
+	"container/list"
+	"sort"
 	"sync"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 )
 
+// IncompleteSpanAttr is the attribute set to true on spans evicted by
+// SpanAssembler before they end.
+const IncompleteSpanAttr = "span.incomplete"
+
 // SpanAssembler assembles spans from a stream of log entries.
 // The log entries must be added in the time order.
+// Open spans are evicted if MaxOpenSpans or TTL is exceeded, to bound the
+// memory on streams with spans never ending, e.g. from crashed producers.
 type SpanAssembler struct {
-	spans sync.Map
+	// MaxOpenSpans limits the number of open spans, 0 means no limit.
+	// The least recently active span is evicted when exceeded.
+	MaxOpenSpans int
+	// TTL evicts the open spans without any log entries for the duration,
+	// measured by the timestamps of the log entries. 0 means no limit.
+	TTL time.Duration
+	// OnEvict, if not nil, receives the evicted spans, which are partial:
+	// the duration is up to the last log entry, a span end is synthesized,
+	// and IncompleteSpanAttr is set.
+	OnEvict func(*logspb.Span)
+
+	lock  sync.Mutex
+	spans map[string]*list.Element
+	// lru is the list of *openSpan, the most recently active at the back.
+	lru list.List
+}
+
+type openSpan struct {
+	id     string
+	span   *logspb.Span
+	lastNs int64
 }
 
 // AddLogEntry add a log entry for assembling.
@@ -21,18 +50,34 @@ func (a *SpanAssembler) AddLogEntry(entry *logspb.LogEntry) *logspb.Span {
 		return nil
 	}
 	var completedSpan *logspb.Span
+	a.lock.Lock()
 	switch entry.GetTrace().GetEvent().(type) {
 	case *logspb.Trace_SpanStart_:
-		completedSpan = a.spanStart(id, entry)
+		a.spanStart(id, entry)
 	case *logspb.Trace_SpanEnd_:
 		completedSpan = a.spanEnd(id, entry)
 	default:
+		// Span events are collected into the logs of the span as regular logs.
 		a.regularLog(id, entry)
 	}
+	evicted := a.evict(entry.GetNanoTs())
+	a.lock.Unlock()
+	if a.OnEvict != nil {
+		for _, span := range evicted {
+			a.OnEvict(span)
+		}
+	}
 	return completedSpan
 }
 
-func (a *SpanAssembler) spanStart(id string, entry *logspb.LogEntry) *logspb.Span {
+// NumOpenSpans returns the number of open spans.
+func (a *SpanAssembler) NumOpenSpans() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return len(a.spans)
+}
+
+func (a *SpanAssembler) spanStart(id string, entry *logspb.LogEntry) {
 	event := entry.GetTrace().GetSpanStart()
 	span := &logspb.Span{
 		Context:    proto.Clone(entry.GetTrace().GetSpanContext()).(*logspb.SpanContext),
@@ -43,26 +88,133 @@ func (a *SpanAssembler) spanStart(id string, entry *logspb.LogEntry) *logspb.Spa
 		Links:      event.Links,
 	}
 	span.Logs = append(span.Logs, entry)
-	a.spans.Store(id, span)
-	return nil
+	if a.spans == nil {
+		a.spans = make(map[string]*list.Element)
+	}
+	if elem, ok := a.spans[id]; ok {
+		a.lru.Remove(elem)
+	}
+	a.spans[id] = a.lru.PushBack(&openSpan{id: id, span: span, lastNs: entry.GetNanoTs()})
 }
 
 func (a *SpanAssembler) spanEnd(id string, entry *logspb.LogEntry) *logspb.Span {
-	val, ok := a.spans.LoadAndDelete(id)
+	elem, ok := a.spans[id]
 	if !ok {
 		return nil
 	}
-	span := val.(*logspb.Span)
+	delete(a.spans, id)
+	span := a.lru.Remove(elem).(*openSpan).span
 	span.Logs = append(span.Logs, entry)
 	span.Duration = entry.NanoTs - span.StartNs
+	// Attributes set during the span are carried by the span end entry.
+	// Merge them into a new map as the original one is shared with the span start entry.
+	if len(entry.GetAttributes()) > 0 {
+		span.Attributes = mergeAttributes(span.Attributes, entry.Attributes)
+	}
 	return span
 }
 
 func (a *SpanAssembler) regularLog(id string, entry *logspb.LogEntry) {
-	val, ok := a.spans.Load(id)
+	elem, ok := a.spans[id]
 	if !ok {
 		return
 	}
-	span := val.(*logspb.Span)
-	span.Logs = append(span.Logs, entry)
+	open := elem.Value.(*openSpan)
+	open.span.Logs = append(open.span.Logs, entry)
+	open.lastNs = entry.GetNanoTs()
+	a.lru.MoveToBack(elem)
+}
+
+// evict removes the spans exceeding the limits, and returns them as partial spans.
+func (a *SpanAssembler) evict(nowNs int64) (evicted []*logspb.Span) {
+	for elem := a.lru.Front(); elem != nil; elem = a.lru.Front() {
+		open := elem.Value.(*openSpan)
+		if (a.MaxOpenSpans <= 0 || a.lru.Len() <= a.MaxOpenSpans) &&
+			(a.TTL <= 0 || nowNs-open.lastNs <= int64(a.TTL)) {
+			break
+		}
+		a.lru.Remove(elem)
+		delete(a.spans, open.id)
+		evicted = append(evicted, open.partialSpan())
+	}
+	return
+}
+
+// SweepOlderThan removes the open spans started before the cutoff, and returns
+// them as partial spans (see OnEvict) in the order of start time.
+// It's for the streamers to flush the orphan spans periodically, regardless
+// of the log entries being added. OnEvict is not invoked.
+func (a *SpanAssembler) SweepOlderThan(cutoffNanoTS int64) []*logspb.Span {
+	a.lock.Lock()
+	var swept []*openSpan
+	for elem := a.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if open := elem.Value.(*openSpan); open.span.GetStartNs() < cutoffNanoTS {
+			a.lru.Remove(elem)
+			delete(a.spans, open.id)
+			swept = append(swept, open)
+		}
+		elem = next
+	}
+	a.lock.Unlock()
+	sort.SliceStable(swept, func(i, j int) bool {
+		return swept[i].span.GetStartNs() < swept[j].span.GetStartNs()
+	})
+	spans := make([]*logspb.Span, 0, len(swept))
+	for _, open := range swept {
+		spans = append(spans, open.partialSpan())
+	}
+	return spans
+}
+
+// EvictedSpans keeps the spans evicted by a SpanAssembler until taken, e.g. for
+// the streamers to export them in the current batch. Add can be used as OnEvict.
+type EvictedSpans struct {
+	lock  sync.Mutex
+	spans []*logspb.Span
+}
+
+// Add keeps an evicted span.
+func (s *EvictedSpans) Add(span *logspb.Span) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.spans = append(s.spans, span)
+}
+
+// Take returns the kept spans and clears them.
+func (s *EvictedSpans) Take() []*logspb.Span {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	spans := s.spans
+	s.spans = nil
+	return spans
+}
+
+// partialSpan ends the open span at the last log entry with a synthesized span end.
+func (s *openSpan) partialSpan() *logspb.Span {
+	span := s.span
+	span.Logs = append(span.Logs, &logspb.LogEntry{
+		NanoTs: s.lastNs,
+		Trace: &logspb.Trace{
+			SpanContext: span.Context,
+			Event:       &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}},
+		},
+	})
+	span.Duration = s.lastNs - span.StartNs
+	span.Attributes = mergeAttributes(span.Attributes, map[string]*logspb.Value{
+		IncompleteSpanAttr: {Value: &logspb.Value_BoolValue{BoolValue: true}},
+	})
+	return span
+}
+
+// mergeAttributes merges attributes into a new map, the latter overwrites the former.
+func mergeAttributes(attrs, more map[string]*logspb.Value) map[string]*logspb.Value {
+	merged := make(map[string]*logspb.Value, len(attrs)+len(more))
+	for key, val := range attrs {
+		merged[key] = val
+	}
+	for key, val := range more {
+		merged[key] = val
+	}
+	return merged
 }
@@ -0,0 +1,248 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestSpanEndCarriesAttributesSetDuringSpan(t *testing.T) {
+	var assembler SpanAssembler
+	var spans []*logspb.Span
+	logger := Root(LogEmitterFunc(func(entry *logspb.LogEntry) {
+		if span := assembler.AddLogEntry(entry); span != nil {
+			spans = append(spans, span)
+		}
+	}))
+	spanLogger := logger.StartSpan(SpanInfo{Name: "test"}, Str("initial", "value"))
+	spanLogger.Infof("in span")
+	spanLogger.SetAttrs(Int("status_code", 404))
+	spanLogger.EndSpan()
+
+	if len(spans) != 1 {
+		t.Fatalf("Expect 1 assembled span, got %d", len(spans))
+	}
+	span := spans[0]
+	if val := span.GetAttributes()["initial"].GetStrValue(); val != "value" {
+		t.Errorf("Expect attribute initial=value, got %q", val)
+	}
+	if val, ok := span.GetAttributes()["status_code"]; !ok || val.GetIntValue() != 404 {
+		t.Errorf("Expect attribute status_code=404, got %v", val)
+	}
+	if n := len(span.GetLogs()); n != 3 {
+		t.Fatalf("Expect 3 logs in span, got %d", n)
+	}
+	if _, ok := span.GetLogs()[0].GetAttributes()["status_code"]; ok {
+		t.Errorf("Span start entry should not be modified")
+	}
+}
+
+func TestSpanEventsAssembled(t *testing.T) {
+	var assembler SpanAssembler
+	var spans []*logspb.Span
+	logger := Root(LogEmitterFunc(func(entry *logspb.LogEntry) {
+		if span := assembler.AddLogEntry(entry); span != nil {
+			spans = append(spans, span)
+		}
+	}))
+	spanLogger := logger.StartSpan(SpanInfo{Name: "test"}, Str("initial", "value"))
+	spanLogger.AddSpanEvent("cache-miss", Str("key", "k1"))
+	spanLogger.Infof("in span")
+	spanLogger.AddSpanEvent("retry", Int("attempt", 2))
+	spanLogger.EndSpan()
+
+	if len(spans) != 1 {
+		t.Fatalf("Expect 1 assembled span, got %d", len(spans))
+	}
+	var events []*logspb.LogEntry
+	for _, entry := range spans[0].GetLogs() {
+		if entry.GetTrace().GetSpanEvent() != nil {
+			events = append(events, entry)
+		}
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expect 2 span events, got %d", len(events))
+	}
+	if name := events[0].GetTrace().GetSpanEvent().GetName(); name != "cache-miss" {
+		t.Errorf("Expect event cache-miss, got %q", name)
+	}
+	if val := events[0].GetAttributes()["key"].GetStrValue(); val != "k1" {
+		t.Errorf("Expect event attribute key=k1, got %q", val)
+	}
+	if val := events[0].GetAttributes()["initial"].GetStrValue(); val != "value" {
+		t.Errorf("Expect span attribute initial=value on event, got %q", val)
+	}
+	if name := events[1].GetTrace().GetSpanEvent().GetName(); name != "retry" {
+		t.Errorf("Expect event retry, got %q", name)
+	}
+	if val := events[1].GetAttributes()["attempt"].GetIntValue(); val != 2 {
+		t.Errorf("Expect event attribute attempt=2, got %d", val)
+	}
+	if ctx := events[1].GetTrace().GetSpanContext(); ctx.GetSpanId() != spans[0].GetContext().GetSpanId() {
+		t.Errorf("Expect event in span %x, got %x", spans[0].GetContext().GetSpanId(), ctx.GetSpanId())
+	}
+}
+
+func TestSpanAssemblerEviction(t *testing.T) {
+	spanCtx := func(id uint64) *logspb.SpanContext {
+		return &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: id}
+	}
+	start := func(id uint64, ts int64) *logspb.LogEntry {
+		return &logspb.LogEntry{NanoTs: ts, Trace: &logspb.Trace{SpanContext: spanCtx(id), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span"}}}}
+	}
+	log := func(id uint64, ts int64) *logspb.LogEntry {
+		return &logspb.LogEntry{NanoTs: ts, Trace: &logspb.Trace{SpanContext: spanCtx(id)}}
+	}
+	end := func(id uint64, ts int64) *logspb.LogEntry {
+		return &logspb.LogEntry{NanoTs: ts, Trace: &logspb.Trace{SpanContext: spanCtx(id), Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}}
+	}
+	testCases := []struct {
+		name         string
+		maxOpenSpans int
+		ttl          time.Duration
+		entries      []*logspb.LogEntry
+		evicted      []uint64
+		completed    []uint64
+	}{
+		{
+			name:         "max open spans",
+			maxOpenSpans: 2,
+			entries:      []*logspb.LogEntry{start(1, 1), start(2, 2), log(1, 3), start(3, 4), end(1, 5), end(3, 6)},
+			evicted:      []uint64{2},
+			completed:    []uint64{1, 3},
+		},
+		{
+			name:      "ttl",
+			ttl:       10,
+			entries:   []*logspb.LogEntry{start(1, 1), start(2, 2), log(2, 10), start(3, 12), log(2, 21), end(3, 32)},
+			evicted:   []uint64{1, 2},
+			completed: []uint64{3},
+		},
+		{
+			name:      "unlimited",
+			entries:   []*logspb.LogEntry{start(1, 1), start(2, 2), start(3, 1000), end(2, 2000)},
+			completed: []uint64{2},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var evicted, completed []*logspb.Span
+			assembler := &SpanAssembler{
+				MaxOpenSpans: tc.maxOpenSpans,
+				TTL:          tc.ttl,
+				OnEvict:      func(span *logspb.Span) { evicted = append(evicted, span) },
+			}
+			for _, entry := range tc.entries {
+				if span := assembler.AddLogEntry(entry); span != nil {
+					completed = append(completed, span)
+				}
+			}
+			if len(evicted) != len(tc.evicted) {
+				t.Fatalf("Expect %d evicted spans, got %d", len(tc.evicted), len(evicted))
+			}
+			for i, span := range evicted {
+				if id := span.GetContext().GetSpanId(); id != tc.evicted[i] {
+					t.Errorf("Evicted span %d: expect %d, got %d", i, tc.evicted[i], id)
+				}
+				if !span.GetAttributes()[IncompleteSpanAttr].GetBoolValue() {
+					t.Errorf("Evicted span %d not marked incomplete", span.GetContext().GetSpanId())
+				}
+			}
+			if len(completed) != len(tc.completed) {
+				t.Fatalf("Expect %d completed spans, got %d", len(tc.completed), len(completed))
+			}
+			for i, span := range completed {
+				if id := span.GetContext().GetSpanId(); id != tc.completed[i] {
+					t.Errorf("Completed span %d: expect %d, got %d", i, tc.completed[i], id)
+				}
+				if _, ok := span.GetAttributes()[IncompleteSpanAttr]; ok {
+					t.Errorf("Completed span %d marked incomplete", span.GetContext().GetSpanId())
+				}
+			}
+			if open := assembler.NumOpenSpans(); open != len(tc.entries)-len(tc.evicted)-2*len(tc.completed)-numRegularLogs(tc.entries) {
+				t.Errorf("Unexpected open spans %d", open)
+			}
+		})
+	}
+}
+
+func TestSpanAssemblerSweep(t *testing.T) {
+	spanCtx := func(id uint64) *logspb.SpanContext {
+		return &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: id}
+	}
+	var assembler SpanAssembler
+	for _, entry := range []*logspb.LogEntry{
+		{NanoTs: 1, Trace: &logspb.Trace{SpanContext: spanCtx(1), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span1"}}}},
+		{NanoTs: 5, Trace: &logspb.Trace{SpanContext: spanCtx(2), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span2"}}}},
+		{NanoTs: 3, Trace: &logspb.Trace{SpanContext: spanCtx(3), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span3"}}}},
+		{NanoTs: 10, Trace: &logspb.Trace{SpanContext: spanCtx(1)}, Message: "active"},
+	} {
+		assembler.AddLogEntry(entry)
+	}
+
+	spans := assembler.SweepOlderThan(4)
+	expected := []struct {
+		id       uint64
+		duration int64
+		numLogs  int
+	}{
+		{id: 1, duration: 9, numLogs: 3},
+		{id: 3, duration: 0, numLogs: 2},
+	}
+	if len(spans) != len(expected) {
+		t.Fatalf("Expect %d swept spans, got %d", len(expected), len(spans))
+	}
+	for i, span := range spans {
+		if id := span.GetContext().GetSpanId(); id != expected[i].id {
+			t.Errorf("Swept span %d: expect %d, got %d", i, expected[i].id, id)
+		}
+		if span.GetDuration() != expected[i].duration {
+			t.Errorf("Swept span %d: expect duration %d, got %d", i, expected[i].duration, span.GetDuration())
+		}
+		if !span.GetAttributes()[IncompleteSpanAttr].GetBoolValue() {
+			t.Errorf("Swept span %d not marked incomplete", i)
+		}
+		logs := span.GetLogs()
+		if len(logs) != expected[i].numLogs {
+			t.Fatalf("Swept span %d: expect %d logs, got %d", i, expected[i].numLogs, len(logs))
+		}
+		if end := logs[len(logs)-1]; end.GetTrace().GetSpanEnd() == nil || end.GetNanoTs() != span.GetStartNs()+span.GetDuration() {
+			t.Errorf("Swept span %d: expect synthesized span end, got %v", i, end)
+		}
+	}
+	if open := assembler.NumOpenSpans(); open != 1 {
+		t.Errorf("Expect 1 open span, got %d", open)
+	}
+	if spans := assembler.SweepOlderThan(4); len(spans) != 0 {
+		t.Errorf("Expect no spans swept again, got %d", len(spans))
+	}
+}
+
+func numRegularLogs(entries []*logspb.LogEntry) (n int) {
+	for _, entry := range entries {
+		if entry.GetTrace().GetEvent() == nil {
+			n++
+		}
+	}
+	return
+}
+
+func TestEvictedSpans(t *testing.T) {
+	var evicted EvictedSpans
+	assembler := &SpanAssembler{MaxOpenSpans: 1, OnEvict: evicted.Add}
+	for id := uint64(1); id <= 3; id++ {
+		assembler.AddLogEntry(&logspb.LogEntry{NanoTs: int64(id), Trace: &logspb.Trace{
+			SpanContext: &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: id},
+			Event:       &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span"}},
+		}})
+	}
+	spans := evicted.Take()
+	if len(spans) != 2 || spans[0].GetContext().GetSpanId() != 1 || spans[1].GetContext().GetSpanId() != 2 {
+		t.Fatalf("Expect spans 1 and 2 evicted, got %v", spans)
+	}
+	if spans := evicted.Take(); len(spans) != 0 {
+		t.Errorf("Expect no spans after taken, got %v", spans)
+	}
+}
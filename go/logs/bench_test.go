@@ -0,0 +1,152 @@
+package logs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// discardChunkedStreamer acknowledges and discards all streamed log entries.
+type discardChunkedStreamer struct{}
+
+type discardChunkedLogStreamer struct {
+	lastNanoTS int64
+}
+
+func (discardChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	return &discardChunkedLogStreamer{}, nil
+}
+
+func (s *discardChunkedLogStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	s.lastNanoTS = entry.GetNanoTs()
+	return nil
+}
+
+func (s *discardChunkedLogStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	return s.lastNanoTS, nil
+}
+
+func benchEntry() *logspb.LogEntry {
+	logger := newLogger(&DummyEmitter{}).StartSpan(SpanInfo{Name: "bench"})
+	entry := logger.SetAttrs(
+		Str("method", "GET"),
+		Int("status", 200),
+		Duration("elapsed", 1200*time.Millisecond),
+		Bool("cached", true),
+	).makeEntry(0)
+	entry.Level, entry.Message = logspb.LogEntry_INFO, "request served"
+	return entry
+}
+
+func BenchmarkLoggerInfof(b *testing.B) {
+	b.Run("plain", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{})
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.Infof("value %d", n)
+		}
+	})
+	b.Run("attrs", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{}).SetAttrs(Str("method", "GET"), Int("status", 200))
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.Infof("value %d", n)
+		}
+	})
+	b.Run("span", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{}).StartSpan(SpanInfo{Name: "bench"})
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.Infof("value %d", n)
+		}
+	})
+	b.Run("inline attrs", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{})
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.With(Int("n", int64(n)), Str("method", "GET")).Infof("value")
+		}
+	})
+}
+
+func BenchmarkMakeEntry(b *testing.B) {
+	b.Run("root", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{})
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.makeEntry(0)
+		}
+	})
+	b.Run("span", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{}).StartSpan(SpanInfo{Name: "bench"}).SetAttrs(Str("method", "GET"))
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.makeEntry(0)
+		}
+	})
+}
+
+func BenchmarkAttributes(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		attrs := make(map[string]*logspb.Value, 6)
+		AttributeSetters{
+			Str("method", "GET"),
+			Int("status", 200),
+			Float("ratio", 0.5),
+			Bool("cached", true),
+			Duration("elapsed", time.Second),
+			JSON("req", map[string]int{"id": 1}),
+		}.SetAttributes(attrs)
+	}
+}
+
+func BenchmarkProtoSize(b *testing.B) {
+	entry := benchEntry()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		proto.Size(entry)
+	}
+}
+
+func BenchmarkChunkedEmitter(b *testing.B) {
+	entry := benchEntry()
+	emitter := NewChunkedEmitter(discardChunkedStreamer{}, 1<<24, 1<<16)
+	defer emitter.Close(context.Background())
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		emitter.EmitLogEntry(entry)
+	}
+}
+
+// TestHotPathAllocs guards against allocation regressions on the hot path.
+// Lower the limits when an optimization lands.
+func TestHotPathAllocs(t *testing.T) {
+	root := newLogger(&DummyEmitter{})
+	span := root.StartSpan(SpanInfo{Name: "bench"}).SetAttrs(Str("method", "GET"))
+	filtered := newLogger(&DummyEmitter{}).SetMinLevel(logspb.LogEntry_WARNING)
+	entry := benchEntry()
+	testCases := []struct {
+		name      string
+		fn        func()
+		maxAllocs float64
+	}{
+		{name: "filtered", fn: func() { filtered.Infof("value") }, maxAllocs: 0},
+		{name: "infof", fn: func() { root.Infof("value") }, maxAllocs: 8},
+		{name: "infof in span", fn: func() { span.Infof("value") }, maxAllocs: 11},
+		{name: "make entry", fn: func() { root.makeEntry(0) }, maxAllocs: 6},
+		{name: "proto size", fn: func() { proto.Size(entry) }, maxAllocs: 4},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tc.fn); allocs > tc.maxAllocs {
+				t.Errorf("Allocations %v exceed %v", allocs, tc.maxAllocs)
+			}
+		})
+	}
+}
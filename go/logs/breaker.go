@@ -0,0 +1,255 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	defaultBreakerPending   = 10000
+)
+
+// ErrCircuitOpen indicates the call is not attempted as the circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+// Breaker states.
+const (
+	// BreakerClosed allows all attempts.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all attempts until the cooldown expires.
+	BreakerOpen
+	// BreakerHalfOpen allows a single probing attempt.
+	BreakerHalfOpen
+)
+
+// String implements fmt.Stringer.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker stops attempting a failing backend for a while.
+// After Threshold consecutive failures, the circuit opens and rejects all
+// attempts. Once Cooldown expires, it's half-open and allows a single probing
+// attempt: the circuit closes if it succeeds, otherwise it opens again.
+type CircuitBreaker struct {
+	Name string
+	// Threshold is the number of consecutive failures to open the circuit.
+	// If not positive, a default of 5 is used.
+	Threshold int
+	// Cooldown is the duration the circuit stays open.
+	// If not positive, a default of 30 seconds is used.
+	Cooldown time.Duration
+
+	lock     sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+var (
+	breakersLock sync.Mutex
+	breakers     = make(map[string]*CircuitBreaker)
+)
+
+// NewCircuitBreaker creates a CircuitBreaker and registers it by name
+// so its state is reported by BreakerStates.
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	b := &CircuitBreaker{Name: name}
+	breakersLock.Lock()
+	breakers[name] = b
+	breakersLock.Unlock()
+	return b
+}
+
+// BreakerStates returns the states of all registered circuit breakers.
+func BreakerStates() map[string]string {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	states := make(map[string]string, len(breakers))
+	for name, b := range breakers {
+		states[name] = b.State().String()
+	}
+	return states
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// Allow determines whether an attempt can be made.
+// If true is returned, the result must be reported using Report.
+func (b *CircuitBreaker) Allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		cooldown := b.Cooldown
+		if cooldown <= 0 {
+			cooldown = defaultBreakerCooldown
+		}
+		if time.Since(b.openedAt) < cooldown {
+			return false
+		}
+		b.state, b.probing = BreakerHalfOpen, true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Report reports the result of an attempt.
+func (b *CircuitBreaker) Report(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+	if err == nil {
+		if b.state != BreakerClosed {
+			Emergent().Infof("Circuit %s closed", b.Name)
+		}
+		b.state, b.failures = BreakerClosed, 0
+		return
+	}
+	b.failures++
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if b.state == BreakerHalfOpen || b.failures >= threshold {
+		if b.state == BreakerClosed {
+			Emergent().Error(err).PrintErrf("Circuit %s open after %d failures: ", b.Name, b.failures)
+		}
+		b.state, b.openedAt = BreakerOpen, time.Now()
+	}
+}
+
+// BreakerStreamer wraps a LogStreamer with a CircuitBreaker.
+// While the circuit is open, entries are buffered (bounded by MaxPending)
+// and streamed together with the next batch once an attempt is allowed.
+// Entries of a failed batch are buffered again, so the underlying streamer
+// may receive duplicated entries if a batch partially failed.
+type BreakerStreamer struct {
+	Streamer LogStreamer
+	Breaker  *CircuitBreaker
+	// MaxPending is the max number of buffered entries, the oldest are dropped.
+	// If not positive, a default of 10000 is used.
+	MaxPending int
+
+	lock    sync.Mutex
+	pending []*logspb.LogEntry
+}
+
+// StreamLogEntries implements LogStreamer.
+func (s *BreakerStreamer) StreamLogEntries(ctx context.Context, entries []*logspb.LogEntry) error {
+	s.lock.Lock()
+	s.addPending(entries)
+	if !s.Breaker.Allow() {
+		s.lock.Unlock()
+		return nil
+	}
+	batch := s.pending
+	s.pending = nil
+	s.lock.Unlock()
+
+	err := s.Streamer.StreamLogEntries(ctx, batch)
+	s.Breaker.Report(err)
+	if err != nil {
+		s.lock.Lock()
+		pending := s.pending
+		s.pending = batch
+		s.addPending(pending)
+		s.lock.Unlock()
+	}
+	return err
+}
+
+// Pending returns the number of buffered entries.
+func (s *BreakerStreamer) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pending)
+}
+
+// addPending must be called with lock held.
+func (s *BreakerStreamer) addPending(entries []*logspb.LogEntry) {
+	maxPending := s.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultBreakerPending
+	}
+	pending := append(s.pending, entries...)
+	if dropped := len(pending) - maxPending; dropped > 0 {
+		Emergent().Errorf("Circuit %s dropped %d entries", s.Breaker.Name, dropped)
+		pending = append([]*logspb.LogEntry(nil), pending[dropped:]...)
+	}
+	s.pending = pending
+}
+
+// BreakerChunkedStreamer wraps a ChunkedStreamer with a CircuitBreaker.
+// While the circuit is open, ErrCircuitOpen is returned without attempting
+// and the chunks stay in the (bounded) buffer of ChunkedEmitter.
+type BreakerChunkedStreamer struct {
+	Streamer ChunkedStreamer
+	Breaker  *CircuitBreaker
+}
+
+type breakerChunkStreamer struct {
+	ChunkedLogStreamer
+	breaker *CircuitBreaker
+	err     error
+}
+
+// StartStreamInChunk implements ChunkedStreamer.
+func (s *BreakerChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	if !s.Breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+	cs, err := s.Streamer.StartStreamInChunk(ctx, info)
+	if err != nil {
+		s.Breaker.Report(err)
+		return nil, err
+	}
+	return &breakerChunkStreamer{ChunkedLogStreamer: cs, breaker: s.Breaker}, nil
+}
+
+// StreamLogEntry implements ChunkedLogStreamer.
+func (s *breakerChunkStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	err := s.ChunkedLogStreamer.StreamLogEntry(ctx, entry)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return err
+}
+
+// StreamEnd implements ChunkedLogStreamer.
+func (s *breakerChunkStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	lastTS, err := s.ChunkedLogStreamer.StreamEnd(ctx)
+	if s.err == nil {
+		s.err = err
+	}
+	s.breaker.Report(s.err)
+	return lastTS, err
+}
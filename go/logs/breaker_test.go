@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestBreakerStreamer(t *testing.T) {
+	streamer := &recordingStreamer{err: errors.New("failure")}
+	breaker := &CircuitBreaker{Name: "test", Threshold: 2, Cooldown: time.Hour}
+	bs := &BreakerStreamer{Streamer: streamer, Breaker: breaker, MaxPending: 3}
+	emitter := NewStreamEmitter(bs)
+	emitter.DisableWorker = true
+	logger := Root(emitter)
+	ctx := context.Background()
+
+	for n := 0; n < 2; n++ {
+		logger.Infof("failed")
+		if err := emitter.DrainOnce(ctx); !errors.Is(err, streamer.err) {
+			t.Fatalf("Expect error %v, got %v", streamer.err, err)
+		}
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("Expect state open, got %v", state)
+	}
+	for n := 0; n < 3; n++ {
+		logger.Infof("buffered")
+		if err := emitter.DrainOnce(ctx); err != nil {
+			t.Fatalf("Expect no error with open circuit, got %v", err)
+		}
+	}
+	if n := len(streamer.batches); n != 2 {
+		t.Errorf("Expect no attempts with open circuit, got %d attempts", n)
+	}
+	if n := bs.Pending(); n != 3 {
+		t.Errorf("Expect 3 pending entries, got %d", n)
+	}
+
+	// Expire the cooldown to probe.
+	breaker.Cooldown = time.Nanosecond
+	streamer.err = nil
+	logger.Infof("probe")
+	if err := emitter.DrainOnce(ctx); err != nil {
+		t.Fatalf("DrainOnce: %v", err)
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("Expect state closed, got %v", state)
+	}
+	if batch := streamer.batches[len(streamer.batches)-1]; len(batch) != 3 || batch[2].GetMessage() != "probe" {
+		t.Errorf("Expect the last 3 entries streamed, got %v", batch)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	breaker := &CircuitBreaker{Name: "test", Threshold: 1, Cooldown: time.Nanosecond}
+	breaker.Report(errors.New("failure"))
+	time.Sleep(time.Millisecond)
+	if !breaker.Allow() {
+		t.Fatal("Expect probing allowed after cooldown")
+	}
+	if breaker.Allow() {
+		t.Error("Expect only a single probe when half-open")
+	}
+	breaker.Report(errors.New("failure"))
+	if state := breaker.State(); state != BreakerOpen {
+		t.Errorf("Expect state open after failed probe, got %v", state)
+	}
+}
+
+func TestBreakerChunkedStreamer(t *testing.T) {
+	streamer := &recordingChunkedStreamer{endErr: errors.New("failure")}
+	breaker := &CircuitBreaker{Name: "test", Threshold: 2, Cooldown: time.Hour}
+	e := NewChunkedEmitter(&BreakerChunkedStreamer{Streamer: streamer, Breaker: breaker}, 1<<16, 1<<10)
+	// Prevent the background worker from streaming.
+	e.workers = 1
+	ctx := context.Background()
+
+	for n := 1; n <= 2; n++ {
+		e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "failed"})
+		if err := e.emitChunks(ctx); err != nil {
+			t.Fatalf("emitChunks: %v", err)
+		}
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("Expect state open, got %v", state)
+	}
+
+	// Chunks are kept while the circuit is open.
+	streamer.endErr = nil
+	e.EmitLogEntry(&logspb.LogEntry{NanoTs: 3, Message: "buffered"})
+	if err := e.emitChunks(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expect ErrCircuitOpen, got %v", err)
+	}
+	if n := len(streamer.entries); n != 2 {
+		t.Errorf("Expect no attempts with open circuit, got %d entries streamed", n)
+	}
+	if stats := e.Stats(); stats.NumRecords != 1 {
+		t.Errorf("Expect 1 buffered entry, got %d", stats.NumRecords)
+	}
+
+	// Expire the cooldown to probe.
+	breaker.Cooldown = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if err := e.emitChunks(ctx); err != nil {
+		t.Fatalf("emitChunks: %v", err)
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("Expect state closed, got %v", state)
+	}
+	if n := len(streamer.entries); n != 3 || streamer.entries[2].GetMessage() != "buffered" {
+		t.Errorf("Expect the buffered entry streamed, got %v", streamer.entries)
+	}
+}
@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"runtime/debug"
+)
+
+const (
+	// ServiceVersionAttr is the attribute name of the version of the binary.
+	ServiceVersionAttr = "service.version"
+	// VCSRevisionAttr is the attribute name of the VCS revision the binary is built from.
+	VCSRevisionAttr = "vcs.revision"
+)
+
+// BuildInfo creates attributes of the build information embedded in the binary:
+// the module version as ServiceVersionAttr and the VCS revision as VCSRevisionAttr
+// (suffixed with "-dirty" if built with local modifications).
+// Non-empty version or revision overrides the embedded values, e.g. for builds
+// without VCS information. An attribute is omitted if the value is unknown.
+func BuildInfo(version, revision string) AttributeSetter {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if version == "" && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+		if revision == "" {
+			var modified bool
+			for _, setting := range info.Settings {
+				switch setting.Key {
+				case "vcs.revision":
+					revision = setting.Value
+				case "vcs.modified":
+					modified = setting.Value == "true"
+				}
+			}
+			if revision != "" && modified {
+				revision += "-dirty"
+			}
+		}
+	}
+	var attrs AttributeSetters
+	if version != "" {
+		attrs = append(attrs, Str(ServiceVersionAttr, version))
+	}
+	if revision != "" {
+		attrs = append(attrs, Str(VCSRevisionAttr, revision))
+	}
+	return attrs
+}
@@ -2,6 +2,10 @@ package logs
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,8 +17,37 @@ import (
 
 const (
 	defaultCollectPeriod = time.Second
+	defaultCollectJitter = 0.1
+	defaultBlockTimeout  = time.Second
 )
 
+// OverrunPolicy defines the behavior of ChunkedEmitter when the buffer is full.
+type OverrunPolicy int
+
+// Overrun policies.
+const (
+	// OverrunDropOldest discards the oldest records to make room for the new one.
+	OverrunDropOldest OverrunPolicy = iota
+	// OverrunDropNewest discards the new record.
+	OverrunDropNewest
+	// OverrunBlock blocks the producer until there's room, BlockTimeout expires,
+	// or the emitter is closed. On timeout or close, the oldest records are discarded.
+	OverrunBlock
+)
+
+// ParseOverrunPolicy parses a string to OverrunPolicy.
+func ParseOverrunPolicy(str string) (OverrunPolicy, error) {
+	switch strings.ToLower(str) {
+	case "", "drop-oldest", "oldest":
+		return OverrunDropOldest, nil
+	case "drop-newest", "newest":
+		return OverrunDropNewest, nil
+	case "block":
+		return OverrunBlock, nil
+	}
+	return OverrunDropOldest, fmt.Errorf("unknown overrun policy: %s", str)
+}
+
 // ChunkedStreamer streams log entries in chunks.
 type ChunkedStreamer interface {
 	StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error)
@@ -44,14 +77,57 @@ type ChunkedEmitter struct {
 	MaxSize       int
 	ChunkSize     int
 	CollectPeriod time.Duration
+	// CollectJitter randomizes each collect period by the fraction of CollectPeriod
+	// in both directions, so emitters across a fleet don't flush at the same time.
+	// With jitter, the first collect period is also randomized between 0 and
+	// CollectPeriod to stagger the start.
+	// It's 0.1 by default with NewChunkedEmitter. Zero disables the jitter.
+	CollectJitter float64
+	// Concurrency specifies the max number of chunks being streamed concurrently.
+	// With concurrency more than 1, chunks may arrive out-of-order at the backend.
+	// Values less than 2 stream chunks serially.
+	Concurrency int
+	// OverrunPolicy specifies what to do when the buffer is full.
+	OverrunPolicy OverrunPolicy
+	// BlockTimeout is the max duration a producer is blocked with OverrunBlock.
+	// If not positive, a default of 1 second is used.
+	BlockTimeout time.Duration
+
+	emitCh    chan struct{}
+	workers   int32
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	initOnce  sync.Once
+	closeOnce sync.Once
 
-	emitCh  chan struct{}
-	workers int32
+	lock       sync.Mutex
+	first      *record
+	last       *record
+	totalSize  int
+	numRecords int
+	spaceCh    chan struct{}
+
+	droppedBytes   int64
+	droppedRecords int64
+	lastStreamed   int64
+	streamedBytes  int64
+}
 
-	lock      sync.Mutex
-	first     *record
-	last      *record
-	totalSize int
+// ChunkedEmitterStats provides the statistics of the buffer of ChunkedEmitter.
+type ChunkedEmitterStats struct {
+	// TotalSize is the size of buffered records.
+	TotalSize int
+	// MaxSize is the configured max size of the buffer.
+	MaxSize int
+	// NumRecords is the number of buffered records.
+	NumRecords int
+	// DroppedBytes is the cumulative size of records dropped on overruns.
+	DroppedBytes int64
+	// DroppedRecords is the cumulative number of records dropped on overruns.
+	DroppedRecords int64
+	// LastStreamed is the time the last chunk was successfully streamed.
+	// It's zero if no chunk has been streamed.
+	LastStreamed time.Time
 }
 
 type record struct {
@@ -67,18 +143,48 @@ func NewChunkedEmitter(streamer ChunkedStreamer, maxSize, chunkSize int) *Chunke
 		MaxSize:       maxSize,
 		ChunkSize:     chunkSize,
 		CollectPeriod: defaultCollectPeriod,
+		CollectJitter: defaultCollectJitter,
 		emitCh:        make(chan struct{}, 1),
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
 	}
 }
 
+// init creates the channels not created by NewChunkedEmitter,
+// e.g. when ChunkedEmitter is constructed as a struct literal.
+func (e *ChunkedEmitter) init() {
+	e.initOnce.Do(func() {
+		if e.emitCh == nil {
+			e.emitCh = make(chan struct{}, 1)
+		}
+		if e.stopCh == nil {
+			e.stopCh = make(chan struct{})
+		}
+		if e.doneCh == nil {
+			e.doneCh = make(chan struct{})
+		}
+	})
+}
+
 // EmitLogEntry implements LogEmitter.
 func (e *ChunkedEmitter) EmitLogEntry(entry *logspb.LogEntry) {
-	if atomic.LoadInt32(&e.workers) == 0 {
+	e.init()
+	if atomic.CompareAndSwapInt32(&e.workers, 0, 1) {
 		go e.runWorker(context.Background())
 	}
 	rec := &record{entry: entry, size: proto.Size(entry)}
 	e.lock.Lock()
 	defer e.lock.Unlock()
+	switch e.OverrunPolicy {
+	case OverrunDropNewest:
+		if e.totalSize+rec.size > e.MaxSize {
+			e.addDropped(rec.size, 1)
+			Emergent().Errorf("Overrun %d bytes of records", rec.size)
+			return
+		}
+	case OverrunBlock:
+		e.waitForSpace(rec.size)
+	}
 	if e.last == nil {
 		e.first, e.last = rec, rec
 	} else {
@@ -86,16 +192,20 @@ func (e *ChunkedEmitter) EmitLogEntry(entry *logspb.LogEntry) {
 		e.last = rec
 	}
 	e.totalSize += rec.size
-	var lostSize int
+	e.numRecords++
+	var lostSize, lostRecords int
 	for e.totalSize > e.MaxSize && e.first != nil {
 		e.totalSize -= e.first.size
 		lostSize += e.first.size
+		lostRecords++
 		e.first = e.first.next
 	}
 	if e.first == nil {
 		e.last = nil
 	}
+	e.numRecords -= lostRecords
 	if lostSize > 0 {
+		e.addDropped(lostSize, lostRecords)
 		Emergent().Errorf("Overrun %d bytes of records", lostSize)
 	}
 	select {
@@ -104,73 +214,265 @@ func (e *ChunkedEmitter) EmitLogEntry(entry *logspb.LogEntry) {
 	}
 }
 
-func (e *ChunkedEmitter) runWorker(ctx context.Context) {
-	defer func() {
-		atomic.AddInt32(&e.workers, -1)
-	}()
-	if atomic.AddInt32(&e.workers, 1) > 1 {
-		return
+// Stats returns the current statistics.
+func (e *ChunkedEmitter) Stats() ChunkedEmitterStats {
+	e.lock.Lock()
+	stats := ChunkedEmitterStats{
+		TotalSize:  e.totalSize,
+		MaxSize:    e.MaxSize,
+		NumRecords: e.numRecords,
+	}
+	e.lock.Unlock()
+	stats.DroppedBytes = atomic.LoadInt64(&e.droppedBytes)
+	stats.DroppedRecords = atomic.LoadInt64(&e.droppedRecords)
+	if ts := atomic.LoadInt64(&e.lastStreamed); ts != 0 {
+		stats.LastStreamed = time.Unix(0, ts)
+	}
+	return stats
+}
+
+func (e *ChunkedEmitter) addDropped(size, count int) {
+	atomic.AddInt64(&e.droppedBytes, int64(size))
+	atomic.AddInt64(&e.droppedRecords, int64(count))
+}
+
+// waitForSpace must be called with lock held.
+func (e *ChunkedEmitter) waitForSpace(size int) {
+	timeout := e.BlockTimeout
+	if timeout <= 0 {
+		timeout = defaultBlockTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for e.totalSize > 0 && e.totalSize+size > e.MaxSize {
+		if e.spaceCh == nil {
+			e.spaceCh = make(chan struct{})
+		}
+		spaceCh := e.spaceCh
+		e.lock.Unlock()
+		select {
+		case e.emitCh <- struct{}{}:
+		default:
+		}
+		var stopped bool
+		select {
+		case <-spaceCh:
+		case <-timer.C:
+			stopped = true
+		case <-e.stopCh:
+			stopped = true
+		}
+		e.lock.Lock()
+		if stopped {
+			return
+		}
+	}
+}
+
+// notifySpace must be called with lock held.
+func (e *ChunkedEmitter) notifySpace() {
+	if e.spaceCh != nil {
+		close(e.spaceCh)
+		e.spaceCh = nil
+	}
+}
+
+// Flush streams the records buffered at the time of the call immediately and
+// blocks until they are streamed or ctx is done. Records emitted meanwhile may
+// be streamed but are not waited for. An error is returned if any records failed
+// to stream.
+func (e *ChunkedEmitter) Flush(ctx context.Context) error {
+	e.lock.Lock()
+	target := atomic.LoadInt64(&e.streamedBytes) + int64(e.totalSize)
+	e.lock.Unlock()
+	for {
+		streamed := atomic.LoadInt64(&e.streamedBytes)
+		if streamed >= target {
+			return nil
+		}
+		e.lock.Lock()
+		remaining := e.totalSize
+		e.lock.Unlock()
+		if remaining == 0 {
+			// The rest is in-flight in the background worker, or dropped.
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.emitChunksConcurrently(ctx); err != nil {
+			return err
+		}
+		if atomic.LoadInt64(&e.streamedBytes) == streamed {
+			return fmt.Errorf("unable to stream %d bytes of records", remaining)
+		}
+	}
+}
+
+// Close stops the background worker and flushes the buffered records.
+// It waits for the in-flight chunks and the flush until ctx is done.
+// The emitter must not be used after Close.
+func (e *ChunkedEmitter) Close(ctx context.Context) error {
+	e.init()
+	e.closeOnce.Do(func() {
+		close(e.stopCh)
+	})
+	// The worker never starts if it's not started yet.
+	if atomic.CompareAndSwapInt32(&e.workers, 0, 1) {
+		close(e.doneCh)
 	}
+	select {
+	case <-e.doneCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.Flush(ctx)
+}
+
+func (e *ChunkedEmitter) runWorker(ctx context.Context) {
+	e.init()
+	defer close(e.doneCh)
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	period := e.firstCollectPeriod(rnd)
 	for {
-		e.emitChunks(ctx)
+		e.emitChunksConcurrently(ctx)
 		select {
 		case <-ctx.Done():
 			return
+		case <-e.stopCh:
+			return
 		case <-e.emitCh:
-		case <-time.After(e.CollectPeriod):
+		case <-time.After(period):
+		}
+		period = e.collectPeriod(rnd)
+	}
+}
+
+// firstCollectPeriod staggers the start by a random period up to CollectPeriod
+// if jitter is enabled, so emitters started together don't flush in phase.
+func (e *ChunkedEmitter) firstCollectPeriod(rnd *rand.Rand) time.Duration {
+	if e.CollectJitter <= 0 || e.CollectPeriod <= 0 {
+		return e.CollectPeriod
+	}
+	return time.Duration(rnd.Int63n(int64(e.CollectPeriod))) + 1
+}
+
+func (e *ChunkedEmitter) collectPeriod(rnd *rand.Rand) time.Duration {
+	jitter := e.CollectJitter
+	if jitter <= 0 {
+		return e.CollectPeriod
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return e.CollectPeriod + time.Duration(float64(e.CollectPeriod)*jitter*(2*rnd.Float64()-1))
+}
+
+func (e *ChunkedEmitter) emitChunksConcurrently(ctx context.Context) error {
+	if e.Concurrency < 2 {
+		return e.emitChunks(ctx)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, e.Concurrency)
+	for n := 0; n < e.Concurrency; n++ {
+		head, tail, info := e.fetchChunk()
+		if info.NumEntries == 0 {
+			break
+		}
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			errs[n] = e.emitChunk(ctx, head, tail, info)
+		}(n)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func (e *ChunkedEmitter) emitChunks(ctx context.Context) {
+func (e *ChunkedEmitter) emitChunks(ctx context.Context) error {
 	head, tail, info := e.fetchChunk()
 	if info.NumEntries == 0 {
-		return
+		return nil
 	}
+	return e.emitChunk(ctx, head, tail, info)
+}
+
+// emitChunk returns an error if not all records are received.
+func (e *ChunkedEmitter) emitChunk(ctx context.Context, head, tail *record, info *ChunkInfo) error {
 	var lastTS int64
 	rs, err := e.Streamer.StartStreamInChunk(ctx, *info)
+	streamErr := err
+	circuitOpen := errors.Is(err, ErrCircuitOpen)
 	if err != nil {
-		Emergent().Error(err).PrintErr("StartStreamChunk: ")
+		if !circuitOpen {
+			Emergent().Error(err).PrintErr("StartStreamChunk: ")
+		}
 	} else {
 		for rec := head; rec != nil; rec = rec.next {
 			if err := rs.StreamLogEntry(ctx, rec.entry); err != nil {
 				Emergent().Error(err).PrintErrf("StreamRecord(%v): ", rec.entry.GetNanoTs())
+				streamErr = err
 				break
 			}
 		}
 		lastTS, err = rs.StreamEnd(ctx)
 		if err != nil {
 			Emergent().Error(err).PrintErr("StreamEnd: ")
+			streamErr = err
 		}
 	}
 
 	// Discard received records.
+	var receivedSize int
 	for head != nil && head.entry.GetNanoTs() <= lastTS {
-		info.TotalSize -= head.size
+		receivedSize += head.size
+		info.NumEntries--
 		head = head.next
 	}
+	info.TotalSize -= receivedSize
+	atomic.AddInt64(&e.streamedBytes, int64(receivedSize))
 
 	if head == nil {
-		return
+		atomic.StoreInt64(&e.lastStreamed, time.Now().UnixNano())
+		return nil
+	}
+	if streamErr == nil {
+		streamErr = fmt.Errorf("records after %d not received", lastTS)
 	}
 
 	// Not all records received, requeue the rest of records.
 	e.lock.Lock()
 	defer e.lock.Unlock()
 	totalSize := info.TotalSize + e.totalSize
-	var lostSize, returnedSize int
+	var lostSize, returnedSize, lostRecords int
 	for head != nil && totalSize > e.MaxSize {
 		totalSize -= head.size
 		lostSize += head.size
+		lostRecords++
 		head = head.next
 	}
+	if lostSize > 0 {
+		e.addDropped(lostSize, lostRecords)
+	}
 	if head != nil {
+		e.numRecords += info.NumEntries - lostRecords
 		tail.next = e.first
 		e.first = head
+		if e.last == nil {
+			e.last = tail
+		}
 		returnedSize = totalSize - e.totalSize
 		e.totalSize = totalSize
 	}
-	Emergent().Errorf("Returned %d bytes, discarded %d bytes", returnedSize, lostSize)
+	if !circuitOpen || lostSize > 0 {
+		Emergent().Errorf("Returned %d bytes, discarded %d bytes", returnedSize, lostSize)
+	}
+	return streamErr
 }
 
 func (e *ChunkedEmitter) fetchChunk() (*record, *record, *ChunkInfo) {
@@ -196,6 +498,9 @@ func (e *ChunkedEmitter) fetchChunk() (*record, *record, *ChunkInfo) {
 	}
 	if tail != nil {
 		tail.next = nil
+		e.totalSize -= info.TotalSize
+		e.numRecords -= info.NumEntries
+		e.notifySpace()
 	}
 	return head, tail, &info
 }
@@ -0,0 +1,356 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+type recordingChunkedStreamer struct {
+	entries []*logspb.LogEntry
+	lastTS  int64
+	endErr  error
+}
+
+func (s *recordingChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	return s, nil
+}
+
+func (s *recordingChunkedStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	s.entries = append(s.entries, entry)
+	s.lastTS = entry.GetNanoTs()
+	return nil
+}
+
+func (s *recordingChunkedStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	return s.lastTS, s.endErr
+}
+
+func TestChunkedEmitterStats(t *testing.T) {
+	entrySize := proto.Size(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+	testCases := []struct {
+		name    string
+		overrun OverrunPolicy
+	}{
+		{name: "drop-oldest", overrun: OverrunDropOldest},
+		{name: "drop-newest", overrun: OverrunDropNewest},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			streamer := &recordingChunkedStreamer{}
+			e := NewChunkedEmitter(streamer, entrySize*10, entrySize*4)
+			e.OverrunPolicy = tc.overrun
+			// Prevent the background worker from streaming.
+			e.workers = 1
+			for n := 1; n <= 15; n++ {
+				e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+			}
+			stats := e.Stats()
+			if stats.NumRecords != 10 || stats.TotalSize != entrySize*10 || stats.MaxSize != entrySize*10 {
+				t.Errorf("unexpected buffer stats: %+v", stats)
+			}
+			if stats.DroppedRecords != 5 || stats.DroppedBytes != int64(entrySize*5) {
+				t.Errorf("unexpected dropped stats: %+v", stats)
+			}
+			if !stats.LastStreamed.IsZero() {
+				t.Errorf("LastStreamed is set before streaming: %v", stats.LastStreamed)
+			}
+
+			if err := e.Flush(context.Background()); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			stats = e.Stats()
+			if stats.NumRecords != 0 || stats.TotalSize != 0 || stats.LastStreamed.IsZero() {
+				t.Errorf("unexpected stats after Flush: %+v", stats)
+			}
+			if len(streamer.entries) != 10 {
+				t.Errorf("streamed %d entries, expect 10", len(streamer.entries))
+			}
+		})
+	}
+}
+
+// producingChunkedStreamer emits more entries to the emitter on every chunk,
+// like producers which keep logging during a flush.
+type producingChunkedStreamer struct {
+	recordingChunkedStreamer
+	emitter *ChunkedEmitter
+}
+
+func (s *producingChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	return s, nil
+}
+
+func (s *producingChunkedStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	for n := 0; n < 2; n++ {
+		s.emitter.EmitLogEntry(&logspb.LogEntry{NanoTs: s.lastTS*10 + int64(n), Message: "produced"})
+	}
+	return s.lastTS, nil
+}
+
+// failingChunkedStreamer fails every chunk without receiving any entries.
+type failingChunkedStreamer struct {
+	err error
+}
+
+func (s *failingChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	return s, nil
+}
+
+func (s *failingChunkedStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	return nil
+}
+
+func (s *failingChunkedStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	return 0, s.err
+}
+
+func TestChunkedEmitterFlush(t *testing.T) {
+	entrySize := proto.Size(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+
+	t.Run("producing", func(t *testing.T) {
+		streamer := &producingChunkedStreamer{}
+		e := NewChunkedEmitter(streamer, entrySize*100, entrySize)
+		streamer.emitter = e
+		// Prevent the background worker from streaming.
+		e.workers = 1
+		for n := 1; n <= 3; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+		}
+		if err := e.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		if len(streamer.entries) != 3 {
+			t.Errorf("Expect 3 entries streamed, got %d", len(streamer.entries))
+		}
+		if stats := e.Stats(); stats.NumRecords != 6 {
+			t.Errorf("Expect 6 entries produced during Flush, got %d", stats.NumRecords)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		streamer := &failingChunkedStreamer{err: errors.New("failure")}
+		e := NewChunkedEmitter(streamer, entrySize*100, entrySize*2)
+		e.workers = 1
+		for n := 1; n <= 3; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+		}
+		// The failed chunk is returned to the buffer.
+		if err := e.Flush(context.Background()); !errors.Is(err, streamer.err) {
+			t.Errorf("Expect %v, got %v", streamer.err, err)
+		}
+		if stats := e.Stats(); stats.NumRecords != 3 {
+			t.Errorf("Expect 3 entries retained, got %d", stats.NumRecords)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		e := NewChunkedEmitter(&recordingChunkedStreamer{}, entrySize*100, entrySize)
+		e.workers = 1
+		e.EmitLogEntry(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := e.Flush(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expect %v, got %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestChunkedEmitterClose(t *testing.T) {
+	entrySize := proto.Size(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+	testCases := []struct {
+		name       string
+		numEntries int
+	}{
+		{name: "not started"},
+		{name: "started", numEntries: 5},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			streamer := &recordingChunkedStreamer{}
+			// Constructed without NewChunkedEmitter.
+			e := &ChunkedEmitter{
+				Streamer:      streamer,
+				MaxSize:       entrySize * 100,
+				ChunkSize:     entrySize * 2,
+				CollectPeriod: time.Hour,
+			}
+			for n := 1; n <= tc.numEntries; n++ {
+				e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := e.Close(ctx); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if len(streamer.entries) != tc.numEntries {
+				t.Errorf("Expect %d entries streamed, got %d", tc.numEntries, len(streamer.entries))
+			}
+			if err := e.Close(ctx); err != nil {
+				t.Errorf("Close again: %v", err)
+			}
+		})
+	}
+}
+
+// blockingChunkedStreamer blocks streaming chunks until released.
+type blockingChunkedStreamer struct {
+	recordingChunkedStreamer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingChunkedStreamer) StartStreamInChunk(ctx context.Context, info ChunkInfo) (ChunkedLogStreamer, error) {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s, nil
+}
+
+func TestChunkedEmitterOverrunBlock(t *testing.T) {
+	entrySize := proto.Size(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+	emitAsync := func(e *ChunkedEmitter, ts int64) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: ts, Message: "message"})
+		}()
+		return done
+	}
+	expectBlocked := func(t *testing.T, done chan struct{}) {
+		select {
+		case <-done:
+			t.Fatalf("EmitLogEntry is not blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	expectDone := func(t *testing.T, done chan struct{}) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("EmitLogEntry is still blocked")
+		}
+	}
+
+	t.Run("space freed", func(t *testing.T) {
+		e := NewChunkedEmitter(&recordingChunkedStreamer{}, entrySize*2, entrySize)
+		e.OverrunPolicy = OverrunBlock
+		e.BlockTimeout = time.Hour
+		// Prevent the background worker from streaming.
+		e.workers = 1
+		for n := 1; n <= 2; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+		}
+		done := emitAsync(e, 3)
+		expectBlocked(t, done)
+		if err := e.emitChunks(context.Background()); err != nil {
+			t.Fatalf("emitChunks: %v", err)
+		}
+		expectDone(t, done)
+		if stats := e.Stats(); stats.NumRecords != 2 || stats.DroppedRecords != 0 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		e := NewChunkedEmitter(&recordingChunkedStreamer{}, entrySize*2, entrySize)
+		e.OverrunPolicy = OverrunBlock
+		e.BlockTimeout = 20 * time.Millisecond
+		e.workers = 1
+		for n := 1; n <= 2; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+		}
+		start := time.Now()
+		e.EmitLogEntry(&logspb.LogEntry{NanoTs: 3, Message: "message"})
+		if elapsed := time.Since(start); elapsed < e.BlockTimeout {
+			t.Errorf("EmitLogEntry returned in %v before timeout %v", elapsed, e.BlockTimeout)
+		}
+		if stats := e.Stats(); stats.NumRecords != 2 || stats.DroppedRecords != 1 {
+			t.Errorf("Expect the oldest dropped, got stats: %+v", stats)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		streamer := &blockingChunkedStreamer{started: make(chan struct{}, 1), release: make(chan struct{})}
+		e := NewChunkedEmitter(streamer, entrySize*2, entrySize)
+		e.OverrunPolicy = OverrunBlock
+		e.BlockTimeout = time.Hour
+		e.CollectPeriod = time.Hour
+		// The worker is blocked streaming the first entry.
+		e.EmitLogEntry(&logspb.LogEntry{NanoTs: 1, Message: "message"})
+		<-streamer.started
+		for n := 2; n <= 3; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{NanoTs: int64(n), Message: "message"})
+		}
+		done := emitAsync(e, 4)
+		expectBlocked(t, done)
+
+		closed := make(chan error, 1)
+		go func() { closed <- e.Close(context.Background()) }()
+		expectDone(t, done)
+		close(streamer.release)
+		if err := <-closed; err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if stats := e.Stats(); stats.DroppedRecords != 1 {
+			t.Errorf("Expect 1 dropped, got stats: %+v", stats)
+		}
+		if n := len(streamer.entries); n != 3 {
+			t.Errorf("Expect 3 entries streamed, got %d", n)
+		}
+	})
+}
+
+func TestChunkedEmitterCollectPeriod(t *testing.T) {
+	const period = time.Second
+	testCases := []struct {
+		name     string
+		jitter   float64
+		min, max time.Duration
+	}{
+		{name: "no jitter", jitter: 0, min: period, max: period},
+		{name: "default", jitter: defaultCollectJitter, min: 900 * time.Millisecond, max: 1100 * time.Millisecond},
+		{name: "capped", jitter: 2, min: 0, max: 2 * period},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			e := &ChunkedEmitter{CollectPeriod: period, CollectJitter: tc.jitter}
+			rnd := rand.New(rand.NewSource(1))
+			var minSeen, maxSeen time.Duration
+			for i := 0; i < 1000; i++ {
+				d := e.collectPeriod(rnd)
+				if d < tc.min || d > tc.max {
+					t.Fatalf("collect period %v out of [%v, %v]", d, tc.min, tc.max)
+				}
+				if i == 0 || d < minSeen {
+					minSeen = d
+				}
+				if d > maxSeen {
+					maxSeen = d
+				}
+				first := e.firstCollectPeriod(rnd)
+				if tc.jitter == 0 && first != period {
+					t.Fatalf("first collect period %v, expect %v", first, period)
+				}
+				if first <= 0 || first > period {
+					t.Fatalf("first collect period %v out of (0, %v]", first, period)
+				}
+			}
+			// The jitter spreads over most of the range.
+			if spread := tc.max - tc.min; spread > 0 && maxSeen-minSeen < spread*8/10 {
+				t.Errorf("collect periods spread [%v, %v], expect most of [%v, %v]", minSeen, maxSeen, tc.min, tc.max)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package logs
 
 import (
+	"context"
+
 	"google.golang.org/protobuf/proto"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
@@ -13,72 +15,102 @@ func With(attrs ...AttributeSetter) *LogPrinter {
 
 // Info is a shortcut.
 func Info() *LogPrinter {
-	return Default().Printer(1).Info()
+	return Default().printerAt(1, logspb.LogEntry_INFO).Info()
 }
 
 // Warning is a shortcut.
 func Warning(err error) *LogPrinter {
-	return Default().Printer(1).Warning(err)
+	return Default().printerAt(1, logspb.LogEntry_WARNING).Warning(err)
 }
 
 // Error is a shortcut.
 func Error(err error) *LogPrinter {
-	return Default().Printer(1).Error(err)
+	return Default().printerAt(1, logspb.LogEntry_ERROR).Error(err)
 }
 
 // Critical is a shortcut.
 func Critical(err error) *LogPrinter {
-	return Default().Printer(1).Critical(err)
+	return Default().printerAt(1, logspb.LogEntry_CRITICAL).Critical(err)
 }
 
 // Fatal is a shortcut.
 func Fatal(err error) *LogPrinter {
-	return Default().Printer(1).Fatal(err)
+	return Default().printerAt(1, logspb.LogEntry_FATAL).Fatal(err)
 }
 
 // Print is a shortcut.
 func Print(message string) {
-	Default().Printer(1).Print(message)
+	Default().printerAt(1, logspb.LogEntry_NONE).Print(message)
 }
 
 // Printf is a shortcut.
 func Printf(format string, args ...interface{}) {
-	Default().Printer(1).Printf(format, args...)
+	Default().printerAt(1, logspb.LogEntry_NONE).Printf(format, args...)
 }
 
 // Infof is a shortcut.
 func Infof(format string, args ...interface{}) {
-	Default().Printer(1).Infof(format, args...)
+	Default().printerAt(1, logspb.LogEntry_INFO).Infof(format, args...)
 }
 
 // Warningf is a shortcut.
 func Warningf(format string, args ...interface{}) error {
-	return Default().Printer(1).Warningf(format, args...)
+	return Default().printerAt(1, logspb.LogEntry_WARNING).Warningf(format, args...)
 }
 
 // Errorf is a shortcut.
 func Errorf(format string, args ...interface{}) error {
-	return Default().Printer(1).Errorf(format, args...)
+	return Default().printerAt(1, logspb.LogEntry_ERROR).Errorf(format, args...)
 }
 
 // Criticalf is a shortcut.
 func Criticalf(format string, args ...interface{}) error {
-	return Default().Printer(1).Criticalf(format, args...)
+	return Default().printerAt(1, logspb.LogEntry_CRITICAL).Criticalf(format, args...)
 }
 
 // Fatalf is a shortcut.
 func Fatalf(format string, args ...interface{}) {
-	Default().Printer(1).Fatalf(format, args...)
+	Default().printerAt(1, logspb.LogEntry_FATAL).Fatalf(format, args...)
+}
+
+// CtxPrintf is a shortcut of Use(ctx).Printf.
+func CtxPrintf(ctx context.Context, format string, args ...interface{}) {
+	Use(ctx).printerAt(1, logspb.LogEntry_NONE).Printf(format, args...)
+}
+
+// CtxInfof is a shortcut of Use(ctx).Infof.
+func CtxInfof(ctx context.Context, format string, args ...interface{}) {
+	Use(ctx).printerAt(1, logspb.LogEntry_INFO).Infof(format, args...)
+}
+
+// CtxWarningf is a shortcut of Use(ctx).Warningf.
+func CtxWarningf(ctx context.Context, format string, args ...interface{}) error {
+	return Use(ctx).printerAt(1, logspb.LogEntry_WARNING).Warningf(format, args...)
+}
+
+// CtxErrorf is a shortcut of Use(ctx).Errorf.
+func CtxErrorf(ctx context.Context, format string, args ...interface{}) error {
+	return Use(ctx).printerAt(1, logspb.LogEntry_ERROR).Errorf(format, args...)
+}
+
+// CtxCriticalf is a shortcut of Use(ctx).Criticalf.
+func CtxCriticalf(ctx context.Context, format string, args ...interface{}) error {
+	return Use(ctx).printerAt(1, logspb.LogEntry_CRITICAL).Criticalf(format, args...)
+}
+
+// CtxFatalf is a shortcut of Use(ctx).Fatalf.
+func CtxFatalf(ctx context.Context, format string, args ...interface{}) {
+	Use(ctx).printerAt(1, logspb.LogEntry_FATAL).Fatalf(format, args...)
 }
 
 // PrintProto is a shortcut.
 func PrintProto(prefix string, msg proto.Message) {
-	Default().Printer(1).PrintProto(prefix, msg)
+	Default().printerAt(1, logspb.LogEntry_NONE).PrintProto(prefix, msg)
 }
 
 // PrintJSON is a shortcut.
 func PrintJSON(prefix string, obj interface{}) {
-	Default().Printer(1).PrintJSON(prefix, obj)
+	Default().printerAt(1, logspb.LogEntry_NONE).PrintJSON(prefix, obj)
 }
 
 // EmitLogEntry is a shortcut.
@@ -1,6 +1,10 @@
 package logs
 
-import logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+import (
+	"sort"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
 
 // MultiEmitter emits log entry to multiple emitters.
 type MultiEmitter []LogEmitter
@@ -11,3 +15,46 @@ func (e MultiEmitter) EmitLogEntry(entry *logspb.LogEntry) {
 		emitter.EmitLogEntry(entry)
 	}
 }
+
+// CopyLogEntry returns a shallow copy of the entry with the attributes replaced
+// by attrs. Emitters use it to alter an entry which may be shared with other
+// emitters. The attribute order is kept for the attributes in attrs, and the
+// attributes not in the entry are appended, sorted by names.
+func CopyLogEntry(entry *logspb.LogEntry, attrs map[string]*logspb.Value) *logspb.LogEntry {
+	var order []string
+	if entryOrder := entry.GetAttributeOrder(); len(entryOrder) > 0 {
+		order = make([]string, 0, len(attrs))
+		for _, key := range entryOrder {
+			if _, ok := attrs[key]; ok {
+				order = append(order, key)
+			}
+		}
+		var added []string
+		for key := range attrs {
+			if _, ok := entry.GetAttributes()[key]; !ok {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		order = append(order, added...)
+	}
+	return &logspb.LogEntry{
+		NanoTs:         entry.GetNanoTs(),
+		Trace:          entry.GetTrace(),
+		Level:          entry.GetLevel(),
+		Location:       entry.GetLocation(),
+		Message:        entry.GetMessage(),
+		Attributes:     attrs,
+		AttributeOrder: order,
+	}
+}
+
+// CopyLogEntryWith returns a shallow copy of the entry with the attribute set.
+func CopyLogEntryWith(entry *logspb.LogEntry, name string, val *logspb.Value) *logspb.LogEntry {
+	attrs := make(map[string]*logspb.Value, len(entry.GetAttributes())+1)
+	for key, val := range entry.GetAttributes() {
+		attrs[key] = val
+	}
+	attrs[name] = val
+	return CopyLogEntry(entry, attrs)
+}
@@ -0,0 +1,52 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestCopyLogEntryWith(t *testing.T) {
+	val := func(str string) *logspb.Value { return &logspb.Value{Value: &logspb.Value_StrValue{StrValue: str}} }
+	testCases := []struct {
+		name     string
+		entry    *logspb.LogEntry
+		attr     string
+		expected []string
+	}{
+		{
+			name:     "added",
+			entry:    &logspb.LogEntry{Attributes: map[string]*logspb.Value{"b": val("1"), "a": val("2")}, AttributeOrder: []string{"b", "a"}},
+			attr:     "c",
+			expected: []string{"b", "a", "c"},
+		},
+		{
+			name:     "overwritten",
+			entry:    &logspb.LogEntry{Attributes: map[string]*logspb.Value{"b": val("1"), "a": val("2")}, AttributeOrder: []string{"b", "a"}},
+			attr:     "b",
+			expected: []string{"b", "a"},
+		},
+		{
+			name:  "no order",
+			entry: &logspb.LogEntry{Attributes: map[string]*logspb.Value{"a": val("1")}},
+			attr:  "c",
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			numAttrs := len(tc.entry.Attributes)
+			copied := CopyLogEntryWith(tc.entry, tc.attr, val("new"))
+			if !reflect.DeepEqual(copied.GetAttributeOrder(), tc.expected) {
+				t.Errorf("Expect order %v, got %v", tc.expected, copied.GetAttributeOrder())
+			}
+			if v := copied.GetAttributes()[tc.attr].GetStrValue(); v != "new" {
+				t.Errorf("Expect %s=new, got %q", tc.attr, v)
+			}
+			if len(tc.entry.Attributes) != numAttrs || tc.entry.Attributes[tc.attr].GetStrValue() == "new" {
+				t.Errorf("Entry is modified: %v", tc.entry.Attributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+const (
+	// ErrorAttr is the attribute name of the error message.
+	ErrorAttr = "error"
+	// ErrorLocationAttr is the attribute name of the origin of an error.
+	ErrorLocationAttr = "error.location"
+	// ErrorChainAttr is the attribute name of the unwrapped error chain in JSON.
+	ErrorChainAttr = "error.chain"
+)
+
+// ErrorChainItem is an error in the unwrapped error chain.
+type ErrorChainItem struct {
+	// Type is the concrete type name, e.g. *fmt.wrapError.
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// Sentinels are the well-known sentinel errors, e.g. io.EOF, matched by
+	// this error but not the errors it wraps.
+	Sentinels []string `json:"sentinels,omitempty"`
+}
+
+// sentinelErrors are the well-known sentinel errors identified in the error chain.
+var sentinelErrors = []struct {
+	name string
+	err  error
+}{
+	{"context.Canceled", context.Canceled},
+	{"context.DeadlineExceeded", context.DeadlineExceeded},
+	{"io.EOF", io.EOF},
+	{"io.ErrUnexpectedEOF", io.ErrUnexpectedEOF},
+	{"io.ErrClosedPipe", io.ErrClosedPipe},
+	{"fs.ErrNotExist", fs.ErrNotExist},
+	{"fs.ErrExist", fs.ErrExist},
+	{"fs.ErrPermission", fs.ErrPermission},
+	{"fs.ErrClosed", fs.ErrClosed},
+}
+
+// UnwrapErrorChain returns the chain of errors unwrapped by errors.Unwrap,
+// from the outermost one.
+func UnwrapErrorChain(err error) []ErrorChainItem {
+	var chain []ErrorChainItem
+	for ; err != nil; err = errors.Unwrap(err) {
+		item := ErrorChainItem{Type: fmt.Sprintf("%T", err), Message: err.Error()}
+		next := errors.Unwrap(err)
+		for _, sentinel := range sentinelErrors {
+			if errors.Is(err, sentinel.err) && (next == nil || !errors.Is(next, sentinel.err)) {
+				item.Sentinels = append(item.Sentinels, sentinel.name)
+			}
+		}
+		chain = append(chain, item)
+	}
+	return chain
+}
+
+// ErrorChain creates an attribute of the unwrapped error chain in JSON.
+// It's a no-op if err is nil.
+func ErrorChain(err error) AttributeSetter {
+	if err == nil {
+		return AttributeSetters(nil)
+	}
+	return JSON(ErrorChainAttr, UnwrapErrorChain(err))
+}
+
+// errorCallers is implemented by errors carrying program counters of the stack.
+type errorCallers interface {
+	Callers() []uintptr
+}
+
+// ErrorLocation returns the location (file:line) where the error was created
+// if the error or any error it wraps carries a stack trace, e.g. errors from
+// github.com/pkg/errors (StackTrace method) or errors with a Callers method.
+// The innermost error with a stack trace is used as it's closest to the origin.
+// An empty string is returned if no stack trace is found.
+func ErrorLocation(err error) string {
+	var pcs []uintptr
+	// Methods of a typed nil error (e.g. a nil *MyError) may panic.
+	for ; err != nil && !isNilPointer(err); err = errors.Unwrap(err) {
+		if stack := errorStack(err); len(stack) > 0 {
+			pcs = stack
+		}
+	}
+	if len(pcs) == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	if frame.File == "" {
+		return ""
+	}
+	return frame.File + ":" + strconv.Itoa(frame.Line)
+}
+
+// ErrorOrigin creates an attribute of the error origin location.
+// It's a no-op if the location can't be determined.
+func ErrorOrigin(err error) AttributeSetter {
+	loc := ErrorLocation(err)
+	if loc == "" {
+		return AttributeSetters(nil)
+	}
+	return Str(ErrorLocationAttr, loc)
+}
+
+// stackTraceMethods caches the index of the StackTrace method per error type,
+// or -1 if the type doesn't have one, so the method is looked up once per type.
+var stackTraceMethods sync.Map
+
+func errorStack(err error) []uintptr {
+	if c, ok := err.(errorCallers); ok {
+		return c.Callers()
+	}
+	// The StackTrace method from github.com/pkg/errors returns a slice of Frame
+	// which is defined as uintptr holding the values from runtime.Callers.
+	// Use reflection to avoid the dependency.
+	val := reflect.ValueOf(err)
+	index := stackTraceMethodIndex(val.Type())
+	if index < 0 {
+		return nil
+	}
+	frames := val.Method(index).Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for n := range pcs {
+		pcs[n] = uintptr(frames.Index(n).Uint())
+	}
+	return pcs
+}
+
+func stackTraceMethodIndex(t reflect.Type) int {
+	if index, ok := stackTraceMethods.Load(t); ok {
+		return index.(int)
+	}
+	index := -1
+	if method, ok := t.MethodByName("StackTrace"); ok {
+		// The receiver is the first input of method.Type.
+		mt := method.Type
+		if mt.NumIn() == 1 && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Slice && mt.Out(0).Elem().Kind() == reflect.Uintptr {
+			index = method.Index
+		}
+	}
+	stackTraceMethods.Store(t, index)
+	return index
+}
+
+// isNilPointer determines whether err is a typed nil pointer.
+func isNilPointer(err error) bool {
+	val := reflect.ValueOf(err)
+	return val.Kind() == reflect.Ptr && val.IsNil()
+}
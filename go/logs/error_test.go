@@ -0,0 +1,183 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+
+func (timeoutError) Is(target error) bool { return target == context.DeadlineExceeded }
+
+func TestUnwrapErrorChain(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "a.conf", Err: fs.ErrNotExist}
+	testCases := []struct {
+		name     string
+		err      error
+		expected []ErrorChainItem
+	}{
+		{
+			name: "wrapped sentinel",
+			err:  fmt.Errorf("load config: %w", fmt.Errorf("read: %w", pathErr)),
+			expected: []ErrorChainItem{
+				{Type: "*fmt.wrapError", Message: "load config: read: open a.conf: file does not exist"},
+				{Type: "*fmt.wrapError", Message: "read: open a.conf: file does not exist"},
+				{Type: "*fs.PathError", Message: "open a.conf: file does not exist"},
+				{Type: "*errors.errorString", Message: "file does not exist", Sentinels: []string{"fs.ErrNotExist"}},
+			},
+		},
+		{
+			name: "sentinel by Is",
+			err:  fmt.Errorf("query: %w", timeoutError{}),
+			expected: []ErrorChainItem{
+				{Type: "*fmt.wrapError", Message: "query: timeout"},
+				{Type: "logs.timeoutError", Message: "timeout", Sentinels: []string{"context.DeadlineExceeded"}},
+			},
+		},
+		{
+			name: "unwrapped",
+			err:  io.EOF,
+			expected: []ErrorChainItem{
+				{Type: "*errors.errorString", Message: "EOF", Sentinels: []string{"io.EOF"}},
+			},
+		},
+		{
+			name: "not wrapped with %w",
+			err:  fmt.Errorf("read: %v", io.EOF),
+			expected: []ErrorChainItem{
+				{Type: "*errors.errorString", Message: "read: EOF"},
+			},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if chain := UnwrapErrorChain(tc.err); !reflect.DeepEqual(chain, tc.expected) {
+				t.Errorf("Expect %+v, got %+v", tc.expected, chain)
+			}
+		})
+	}
+}
+
+func TestLogPrinterErrorChain(t *testing.T) {
+	emitter := &recordingEmitter{}
+	logger := newLogger(emitter)
+	err := fmt.Errorf("stream: %w", io.ErrUnexpectedEOF)
+	logger.Error(err).ErrorChain(err).PrintErr("failed: ")
+	logger.Info().ErrorChain(nil).Print("no error")
+	if len(emitter.entries) != 2 {
+		t.Fatalf("Expect 2 entries, got %d", len(emitter.entries))
+	}
+
+	attrs := emitter.entries[0].GetAttributes()
+	if msg := attrs[ErrorAttr].GetStrValue(); msg != err.Error() {
+		t.Errorf("Expect %s=%q, got %q", ErrorAttr, err.Error(), msg)
+	}
+	var chain []ErrorChainItem
+	if err := json.Unmarshal([]byte(attrs[ErrorChainAttr].GetJson()), &chain); err != nil {
+		t.Fatalf("Decode %s %q: %v", ErrorChainAttr, attrs[ErrorChainAttr].GetJson(), err)
+	}
+	expected := []ErrorChainItem{
+		{Type: "*fmt.wrapError", Message: "stream: unexpected EOF"},
+		{Type: "*errors.errorString", Message: "unexpected EOF", Sentinels: []string{"io.ErrUnexpectedEOF"}},
+	}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("Expect %+v, got %+v", expected, chain)
+	}
+
+	if _, ok := emitter.entries[1].GetAttributes()[ErrorChainAttr]; ok {
+		t.Errorf("Unexpected %s for nil error", ErrorChainAttr)
+	}
+}
+
+// stackError carries the stack like errors from github.com/pkg/errors.
+type stackError struct {
+	pcs []uintptr
+}
+
+type stackFrame uintptr
+
+type stackTrace []stackFrame
+
+func (e *stackError) Error() string { return "stack" }
+
+func (e *stackError) StackTrace() stackTrace {
+	frames := make(stackTrace, len(e.pcs))
+	for n, pc := range e.pcs {
+		frames[n] = stackFrame(pc)
+	}
+	return frames
+}
+
+// wrappingStackError carries the stack and wraps another error.
+type wrappingStackError struct {
+	stackError
+	err error
+}
+
+func (e *wrappingStackError) Unwrap() error { return e.err }
+
+// callersError carries the stack with a Callers method.
+type callersError struct {
+	pcs []uintptr
+}
+
+func (e *callersError) Error() string { return "callers" }
+
+func (e *callersError) Callers() []uintptr { return e.pcs }
+
+func callers() ([]uintptr, string) {
+	pcs := make([]uintptr, 8)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return pcs, frame.File + ":" + strconv.Itoa(frame.Line)
+}
+
+func TestErrorLocation(t *testing.T) {
+	stackPCs, stackLoc := callers()
+	callersPCs, callersLoc := callers()
+	var nilErr *callersError
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "StackTrace", err: &stackError{pcs: stackPCs}, expected: stackLoc},
+		{name: "Callers", err: &callersError{pcs: callersPCs}, expected: callersLoc},
+		{name: "wrapped", err: fmt.Errorf("read: %w", &stackError{pcs: stackPCs}), expected: stackLoc},
+		{name: "innermost", err: &wrappingStackError{stackError: stackError{pcs: stackPCs}, err: &callersError{pcs: callersPCs}}, expected: callersLoc},
+		{name: "no stack", err: io.EOF},
+		{name: "nil", err: nil},
+		{name: "typed nil", err: nilErr},
+		{name: "wrapped typed nil", err: fmt.Errorf("read: %w", nilErr)},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if loc := ErrorLocation(tc.err); loc != tc.expected {
+				t.Errorf("Expect location %q, got %q", tc.expected, loc)
+			}
+		})
+	}
+}
+
+func TestErrorOriginTypedNil(t *testing.T) {
+	emitter := &recordingEmitter{}
+	var err *callersError
+	newLogger(emitter).Error(err).PrintErr("failed: ")
+	if len(emitter.entries) != 1 {
+		t.Fatalf("Expect 1 entry, got %d", len(emitter.entries))
+	}
+	if _, ok := emitter.entries[0].GetAttributes()[ErrorLocationAttr]; ok {
+		t.Errorf("Unexpected %s for typed nil error", ErrorLocationAttr)
+	}
+}
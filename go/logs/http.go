@@ -7,21 +7,33 @@ import (
 
 // HTTPRequestAttrs extracts information from HTTP request as attributes.
 type HTTPRequestAttrs struct {
-	Method  string            `json:"method"`
-	Path    string            `json:"path"`
-	Headers map[string]string `json:"headers"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	ContentLength int64             `json:"content-length"`
+	ContentType   string            `json:"content-type,omitempty"`
+	Headers       map[string]string `json:"headers"`
 }
 
 // HTTPResponseAttrs extracts information from HTTP response as attributes.
 type HTTPResponseAttrs struct {
-	Status     string            `json:"status"`
-	StatusCode int               `json:"status-code"`
-	Headers    map[string]string `json:"headers"`
+	Status        string            `json:"status"`
+	StatusCode    int               `json:"status-code"`
+	ContentLength int64             `json:"content-length"`
+	ContentType   string            `json:"content-type,omitempty"`
+	Headers       map[string]string `json:"headers"`
 }
 
 // HTTPRequest creates an Attribute from an HTTP request.
+// Besides the JSON attribute, content type and length are also set as
+// separate attributes <name>.content_type and <name>.content_length.
 func HTTPRequest(name string, r *http.Request) AttributeSetter {
-	attrs := &HTTPRequestAttrs{Method: r.Method, Path: r.URL.Path, Headers: make(map[string]string)}
+	attrs := &HTTPRequestAttrs{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		ContentLength: r.ContentLength,
+		ContentType:   r.Header.Get("Content-Type"),
+		Headers:       make(map[string]string),
+	}
 	attrs.Headers["Host"] = r.Host
 	for name, vals := range r.Header {
 		if strings.ToLower(name) == "authorization" {
@@ -34,14 +46,32 @@ func HTTPRequest(name string, r *http.Request) AttributeSetter {
 		}
 		attrs.Headers[name] = strings.Join(vals, "; ")
 	}
-	return JSON(name, attrs)
+	return AttributeSetters{JSON(name, attrs), contentAttrs(name, attrs.ContentType, attrs.ContentLength)}
 }
 
 // HTTPResponse creates an Attribute from an HTTP response.
+// Similar to HTTPRequest, <name>.content_type and <name>.content_length are set.
 func HTTPResponse(name string, r *http.Response) AttributeSetter {
-	attrs := &HTTPResponseAttrs{Status: r.Status, StatusCode: r.StatusCode, Headers: make(map[string]string)}
+	attrs := &HTTPResponseAttrs{
+		Status:        r.Status,
+		StatusCode:    r.StatusCode,
+		ContentLength: r.ContentLength,
+		ContentType:   r.Header.Get("Content-Type"),
+		Headers:       make(map[string]string),
+	}
 	for name, vals := range r.Header {
 		attrs.Headers[name] = strings.Join(vals, "; ")
 	}
-	return JSON(name, attrs)
+	return AttributeSetters{JSON(name, attrs), contentAttrs(name, attrs.ContentType, attrs.ContentLength)}
+}
+
+func contentAttrs(name, contentType string, contentLength int64) AttributeSetter {
+	var setters AttributeSetters
+	if contentType != "" {
+		setters = append(setters, Str(name+".content_type", contentType))
+	}
+	if contentLength >= 0 {
+		setters = append(setters, Int(name+".content_length", contentLength))
+	}
+	return setters
 }
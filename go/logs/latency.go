@@ -0,0 +1,97 @@
+package logs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// LatencyObserver observes the end-to-end latency from emitting a log entry
+// to writing it into a durable sink.
+type LatencyObserver interface {
+	ObserveLatency(sink string, latency time.Duration)
+}
+
+// LatencyStats is a LatencyObserver keeping simple statistics per sink.
+// It also serves the statistics as JSON over HTTP as a status endpoint.
+type LatencyStats struct {
+	lock  sync.Mutex
+	sinks map[string]*LatencySummary
+}
+
+// LatencySummary summarizes the observed latencies of a sink.
+type LatencySummary struct {
+	Count int64         `json:"count"`
+	Last  time.Duration `json:"last"`
+	Max   time.Duration `json:"max"`
+	Total time.Duration `json:"total"`
+}
+
+type latencyObserverHolder struct {
+	observer LatencyObserver
+}
+
+var latencyObserver atomic.Value
+
+// SetLatencyObserver installs the global LatencyObserver used by durable sinks.
+// Pass nil to disable the observation.
+func SetLatencyObserver(observer LatencyObserver) {
+	latencyObserver.Store(latencyObserverHolder{observer: observer})
+}
+
+// ObserveDurable reports the latency of entries which are durably written to the sink.
+// The latency is measured from the timestamp of an entry which is stamped when the
+// entry is emitted. It's a no-op if no LatencyObserver is installed.
+func ObserveDurable(sink string, entries ...*logspb.LogEntry) {
+	holder, _ := latencyObserver.Load().(latencyObserverHolder)
+	if holder.observer == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	for _, entry := range entries {
+		if ts := entry.GetNanoTs(); ts > 0 {
+			holder.observer.ObserveLatency(sink, time.Duration(now-ts))
+		}
+	}
+}
+
+// ObserveLatency implements LatencyObserver.
+func (s *LatencyStats) ObserveLatency(sink string, latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sinks == nil {
+		s.sinks = make(map[string]*LatencySummary)
+	}
+	summary := s.sinks[sink]
+	if summary == nil {
+		summary = &LatencySummary{}
+		s.sinks[sink] = summary
+	}
+	summary.Count++
+	summary.Last = latency
+	summary.Total += latency
+	if latency > summary.Max {
+		summary.Max = latency
+	}
+}
+
+// Snapshot returns a copy of current statistics.
+func (s *LatencyStats) Snapshot() map[string]LatencySummary {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make(map[string]LatencySummary, len(s.sinks))
+	for sink, summary := range s.sinks {
+		result[sink] = *summary
+	}
+	return result
+}
+
+// Average returns the average latency.
+func (s LatencySummary) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
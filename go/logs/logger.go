@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -20,8 +21,16 @@ import (
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 )
 
+// ContextKey is the type of the key associating a logger with a context.
+// The package is imported as github.com/evo-cloud/logs/go/logs only. A vendored
+// or forked copy of this package defines a distinct ContextKey type, and a logger
+// stored by one copy isn't visible to the other.
+type ContextKey struct{}
+
 var (
-	contextKey = &Logger{}
+	// LoggerContextKey is the key of the logger in a context. It can be used with
+	// context.Value directly and the value is a *Logger.
+	LoggerContextKey = ContextKey{}
 
 	idgenLock sync.Mutex
 	idgenRand = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -45,21 +54,41 @@ func (f LogEmitterFunc) EmitLogEntry(entry *logspb.LogEntry) {
 // It won't be used if log level is FATAL.
 type ErrorFilter func(err error) bool
 
+// MaxLoggerDepth is the max length of the parent chain of a logger.
+// A logger created beyond it is flagged via Emergent and detached from its parents,
+// so EndSpan on it returns the default logger.
+var MaxLoggerDepth = 1024
+
 // Logger is the API for emitting logs.
 type Logger struct {
 	ErrorFilter ErrorFilter
+	// MinLevel discards logs below the level before building the log entries.
+	// Span start/end events are always emitted regardless of the level.
+	MinLevel logspb.LogEntry_Level
+	// Sampler decides the trace flags of root spans. If nil, all traces are sampled.
+	Sampler Sampler
 
 	emitter LogEmitter
 	parent  *Logger
+	depth   int
 	span    *SpanInfo
 	attrs   map[string]*logspb.Value
+	order   []string
+	// deferred are the DeferredAttributes in attrs.
+	deferred []*DeferredAttribute
+	// local are the attributes set on logs printed by this logger (and loggers
+	// created using New), but not on span events and child spans.
+	local AttributeSetters
+	// verbose marks the entries as LogEntry.Verbose, see WithLevel.
+	verbose bool
 }
 
 // LogPrinter prepares and prints a single log message.
 type LogPrinter struct {
-	logger *Logger
-	entry  *logspb.LogEntry
-	err    error
+	logger   *Logger
+	entry    *logspb.LogEntry
+	err      error
+	deferred []*DeferredAttribute
 }
 
 // SpanInfo provides detailed information of a span.
@@ -105,16 +134,148 @@ func (a NamedAttribute) SetAttributes(attrs map[string]*logspb.Value) {
 	attrs[a.Name] = a.Value
 }
 
+// DeferredAttribute is an attribute whose value is evaluated only when a log
+// entry is emitted, see Lazy.
+type DeferredAttribute struct {
+	name string
+	fn   func() *logspb.Value
+	// placeholder is the value in the attributes until evaluated.
+	placeholder *logspb.Value
+}
+
+// Lazy creates an attribute with the value evaluated only when a log entry is
+// emitted, e.g. expensive dumps which are discarded when the entry is filtered
+// by level. If fn returns nil, the attribute is omitted.
+// The value is evaluated for each emitted entry, including the ones from child
+// loggers inheriting the attribute. If the attribute is set outside of a
+// Logger or LogPrinter, e.g. nested in Map, the value is evaluated immediately.
+func Lazy(name string, fn func() *logspb.Value) *DeferredAttribute {
+	return &DeferredAttribute{name: name, fn: fn, placeholder: &logspb.Value{}}
+}
+
+// SetAttributes implements AttributeSetter.
+func (a *DeferredAttribute) SetAttributes(attrs map[string]*logspb.Value) {
+	if val := a.fn(); val != nil {
+		attrs[a.name] = val
+	}
+}
+
+// resolveDeferred evaluates the deferred attributes which are not overwritten,
+// and removes the ones evaluated to nil from the attributes and the order.
+func resolveDeferred(attrs map[string]*logspb.Value, order *[]string, deferred []*DeferredAttribute) {
+	for _, a := range deferred {
+		if attrs[a.name] != a.placeholder {
+			continue
+		}
+		if val := a.fn(); val != nil {
+			attrs[a.name] = val
+			continue
+		}
+		delete(attrs, a.name)
+		for n, name := range *order {
+			if name == a.name {
+				*order = append((*order)[:n], (*order)[n+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// setAttributes applies the setter and appends the keys of newly added
+// attributes to the order. Overwritten attributes keep their positions.
+// DeferredAttributes are set as placeholders and appended to deferred.
+func setAttributes(attrs map[string]*logspb.Value, order *[]string, deferred *[]*DeferredAttribute, setter AttributeSetter) {
+	switch a := setter.(type) {
+	case *DeferredAttribute:
+		if _, exists := attrs[a.name]; !exists {
+			*order = append(*order, a.name)
+		}
+		attrs[a.name] = a.placeholder
+		*deferred = append(*deferred, a)
+		return
+	case *NamedAttribute:
+		if _, exists := attrs[a.Name]; !exists {
+			*order = append(*order, a.Name)
+		}
+		attrs[a.Name] = a.Value
+		return
+	case NamedAttribute:
+		if _, exists := attrs[a.Name]; !exists {
+			*order = append(*order, a.Name)
+		}
+		attrs[a.Name] = a.Value
+		return
+	case AttributeSetters:
+		for _, item := range a {
+			if item != nil {
+				setAttributes(attrs, order, deferred, item)
+			}
+		}
+		return
+	}
+	numAttrs := len(attrs)
+	setter.SetAttributes(attrs)
+	if len(attrs) <= numAttrs {
+		return
+	}
+	ordered := make(map[string]bool, len(*order))
+	for _, key := range *order {
+		ordered[key] = true
+	}
+	var added []string
+	for key := range attrs {
+		if !ordered[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	*order = append(*order, added...)
+}
+
 // Use returns the logger associated with the context.
 // The returned logger is mutable.
 func Use(ctx context.Context) *Logger {
-	logger, ok := ctx.Value(contextKey).(*Logger)
+	logger, ok := FromContext(ctx)
 	if !ok {
 		return Default()
 	}
 	return logger
 }
 
+// FromContext returns the logger associated with the context.
+// Unlike Use, it reports false if no logger is associated.
+func FromContext(ctx context.Context) (*Logger, bool) {
+	logger, ok := ctx.Value(LoggerContextKey).(*Logger)
+	return logger, ok && logger != nil
+}
+
+// WithLocalAttrs returns a context with a child logger of the context logger
+// having the local attributes, see SetLocalAttrs.
+func WithLocalAttrs(ctx context.Context, attrs ...AttributeSetter) context.Context {
+	return Use(ctx).New().SetLocalAttrs(attrs...).NewContext(ctx)
+}
+
+// WithLevel returns a context with a child logger of the context logger emitting
+// logs at or above the level, e.g. lowering the threshold to enable verbose logs
+// for a single request. Loggers derived from the context inherit the level.
+// There's no debug level, use LogEntry_NONE (logs printed by Printf) for the
+// most verbose logs.
+// The entries are marked as LogEntry.Verbose so emitters filtering by levels,
+// see LevelAllowed, let them pass too.
+func WithLevel(ctx context.Context, level logspb.LogEntry_Level) context.Context {
+	logger := Use(ctx).New().SetMinLevel(level)
+	logger.verbose = true
+	return logger.NewContext(ctx)
+}
+
+// Derive creates a child logger of the context logger with the attributes
+// without starting a span, and returns the context with the child logger.
+// The attributes are set on all logs in the scope, including child spans.
+func Derive(ctx context.Context, attrs ...AttributeSetter) (context.Context, *Logger) {
+	logger := Use(ctx).New(attrs...)
+	return logger.NewContext(ctx), logger
+}
+
 // Span starts a new span from current context.
 func Span(ctx context.Context, name string, attrs ...AttributeSetter) (context.Context, *Logger) {
 	logger := Use(ctx).StartSpanDepth(1, SpanInfo{Name: name}, attrs...)
@@ -127,6 +288,18 @@ func StartSpan(ctx context.Context, name string, attrs ...AttributeSetter) (cont
 	return logger.NewContext(ctx), logger
 }
 
+// UseOrStartSpan returns the logger associated with the context if it's already in a span.
+// Otherwise, it starts a new span and returns the context with the new logger.
+// The returned bool indicates whether a new span is started, and only in that case the
+// caller should end the span.
+func UseOrStartSpan(ctx context.Context, name string, attrs ...AttributeSetter) (context.Context, *Logger, bool) {
+	if logger := Use(ctx); logger.span != nil {
+		return ctx, logger, false
+	}
+	logger := Use(ctx).StartSpanDepth(1, SpanInfo{Name: name}, attrs...)
+	return logger.NewContext(ctx), logger, true
+}
+
 // StartSpanWith starts a span with detailed SpanInfo.
 func StartSpanWith(ctx context.Context, depth int, info SpanInfo, attrs ...AttributeSetter) (context.Context, *Logger) {
 	logger := Use(ctx).StartSpanDepth(depth+1, info, attrs...)
@@ -163,11 +336,48 @@ func Double(name string, val float64) AttributeSetter {
 	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_DoubleValue{DoubleValue: val}}}
 }
 
+// Decimal creates an attribute of an exact decimal number, e.g. monetary values,
+// which shouldn't be logged as Float/Double to avoid binary rounding.
+// The value is kept as is (e.g. "12.50"), and a string attribute is created
+// if it's not a valid decimal (see ParseDecimal).
+func Decimal(name, val string) AttributeSetter {
+	if _, err := ParseDecimal(val); err != nil {
+		return Str(name, val)
+	}
+	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_Decimal{Decimal: val}}}
+}
+
 // Str creates a string attribute.
 func Str(name, val string) AttributeSetter {
 	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_StrValue{StrValue: val}}}
 }
 
+// Duration creates a duration attribute.
+func Duration(name string, d time.Duration) AttributeSetter {
+	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_Duration{Duration: int64(d)}}}
+}
+
+// Time creates a timestamp attribute.
+func Time(name string, t time.Time) AttributeSetter {
+	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_Time{Time: t.UnixNano()}}}
+}
+
+// Bytes creates an attribute with arbitrary binary data.
+func Bytes(name string, data []byte) AttributeSetter {
+	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_Bytes{Bytes: data}}}
+}
+
+// Map creates an attribute with nested attributes.
+func Map(name string, attrs ...AttributeSetter) AttributeSetter {
+	values := make(map[string]*logspb.Value)
+	for _, attr := range attrs {
+		if attr != nil {
+			attr.SetAttributes(values)
+		}
+	}
+	return &NamedAttribute{Name: name, Value: &logspb.Value{Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: values}}}}
+}
+
 // Proto creates an attribute with encoded proto.
 func Proto(name string, msg proto.Message) AttributeSetter {
 	encoded, err := proto.Marshal(msg)
@@ -348,14 +558,27 @@ func (l *Logger) SpanInfo() SpanInfo {
 func (l *Logger) New(attrs ...AttributeSetter) *Logger {
 	c := &Logger{
 		ErrorFilter: l.ErrorFilter,
+		MinLevel:    l.MinLevel,
+		Sampler:     l.Sampler,
 		emitter:     l.emitter,
 		parent:      l,
+		depth:       l.depth + 1,
 		span:        l.span,
 		attrs:       make(map[string]*logspb.Value),
+		order:       append([]string(nil), l.order...),
+		deferred:    append([]*DeferredAttribute(nil), l.deferred...),
+		local:       l.local[:len(l.local):len(l.local)],
+		verbose:     l.verbose,
 	}
 	for k, v := range l.attrs {
 		c.attrs[k] = v
 	}
+	if c.depth > MaxLoggerDepth {
+		// Usually spans are started in a loop without being ended.
+		// The chain is cut so the ancestors can be released.
+		Emergent().With(Int("depth", int64(c.depth))).Errorf("Logger parent chain exceeds %d, span %q may not be ended, detached from parents", MaxLoggerDepth, l.SpanInfo().Name)
+		c.parent, c.depth = nil, 0
+	}
 	return c.SetAttrs(attrs...)
 }
 
@@ -363,7 +586,21 @@ func (l *Logger) New(attrs ...AttributeSetter) *Logger {
 func (l *Logger) SetAttrs(attrs ...AttributeSetter) *Logger {
 	for _, attr := range attrs {
 		if attr != nil {
-			attr.SetAttributes(l.attrs)
+			setAttributes(l.attrs, &l.order, &l.deferred, attr)
+		}
+	}
+	return l
+}
+
+// SetLocalAttrs adds local attributes into the current logger.
+// Unlike SetAttrs, local attributes are only set on logs printed by the logger
+// and loggers created using New, not on span start/end events or logs in child
+// spans, e.g. request-scoped details which shouldn't leak into trace exports.
+// Local attributes overwrite the ones with the same names set by SetAttrs.
+func (l *Logger) SetLocalAttrs(attrs ...AttributeSetter) *Logger {
+	for _, attr := range attrs {
+		if attr != nil {
+			l.local = append(l.local, attr)
 		}
 	}
 	return l
@@ -372,6 +609,7 @@ func (l *Logger) SetAttrs(attrs ...AttributeSetter) *Logger {
 // StartSpanDepth creates a logger for a new span with specified call stack depth.
 func (l *Logger) StartSpanDepth(depth int, info SpanInfo, attrs ...AttributeSetter) *Logger {
 	c := l.New(attrs...)
+	c.local = nil
 	c.span = &SpanInfo{
 		Name:    info.Name,
 		Kind:    info.Kind,
@@ -398,6 +636,7 @@ func (l *Logger) StartSpanDepth(depth int, info SpanInfo, attrs ...AttributeSett
 	if c.span.Context.GetSpanId() == 0 {
 		c.span.Context.SpanId = NewSpanID()
 	}
+	c.span.Context.Flags = c.traceFlags(c.span)
 	entry := c.makeEntry(depth + 1)
 	entry.Trace.Event = &logspb.Trace_SpanStart_{
 		SpanStart: &logspb.Trace_SpanStart{
@@ -407,11 +646,13 @@ func (l *Logger) StartSpanDepth(depth int, info SpanInfo, attrs ...AttributeSett
 		},
 	}
 	entry.Message = fmt.Sprintf("SPAN_START %s", c.span)
-	c.emit(entry, nil)
+	c.emit(entry, nil, c.deferred)
 	return c
 }
 
 // EndSpanDepth ends a span and returns the parent logger.
+// The span end entry carries all attributes of the logger, including the ones
+// set (using SetAttrs) after the span started, e.g. final status codes.
 func (l *Logger) EndSpanDepth(depth int) *Logger {
 	if l.span == nil {
 		return l
@@ -421,13 +662,35 @@ func (l *Logger) EndSpanDepth(depth int) *Logger {
 		SpanEnd: &logspb.Trace_SpanEnd{},
 	}
 	entry.Message = fmt.Sprintf("SPAN_END %s", l.span)
-	l.emit(entry, nil)
+	l.emit(entry, nil, l.deferred)
 	if l.parent == nil {
 		return Default()
 	}
 	return l.parent
 }
 
+// AddSpanEvent emits a timestamped event within the current span with the
+// attributes, e.g. "cache-miss", like span events in OpenTelemetry.
+// Without a span, it's emitted as a regular log.
+func (l *Logger) AddSpanEvent(name string, attrs ...AttributeSetter) {
+	entry := l.makeEntry(1)
+	deferred := l.deferred[:len(l.deferred):len(l.deferred)]
+	for _, attr := range attrs {
+		if attr != nil {
+			setAttributes(entry.Attributes, &entry.AttributeOrder, &deferred, attr)
+		}
+	}
+	if entry.Trace != nil {
+		entry.Trace.Event = &logspb.Trace_SpanEvent_{
+			SpanEvent: &logspb.Trace_SpanEvent{Name: name},
+		}
+		entry.Message = fmt.Sprintf("SPAN_EVENT %s %s", l.span, name)
+	} else {
+		entry.Message = fmt.Sprintf("SPAN_EVENT %s", name)
+	}
+	l.emit(entry, nil, deferred)
+}
+
 // StartSpan starts a new span.
 func (l *Logger) StartSpan(info SpanInfo, attrs ...AttributeSetter) *Logger {
 	return l.StartSpanDepth(1, info, attrs...)
@@ -445,12 +708,32 @@ func (l *Logger) End() {
 
 // NewContext creates a context with current logger.
 func (l *Logger) NewContext(ctx context.Context) context.Context {
-	return context.WithValue(ctx, contextKey, l)
+	return context.WithValue(ctx, LoggerContextKey, l)
+}
+
+// SetMinLevel sets MinLevel of the logger.
+func (l *Logger) SetMinLevel(level logspb.LogEntry_Level) *Logger {
+	l.MinLevel = level
+	return l
 }
 
 // Printer starts printing a log.
 func (l *Logger) Printer(depth int) *LogPrinter {
-	return &LogPrinter{logger: l, entry: l.makeEntry(depth + 1)}
+	// The capacity is limited so With never appends into the logger's slice.
+	p := &LogPrinter{logger: l, entry: l.makeEntry(depth + 1), deferred: l.deferred[:len(l.deferred):len(l.deferred)]}
+	if len(l.local) > 0 {
+		setAttributes(p.entry.Attributes, &p.entry.AttributeOrder, &p.deferred, l.local)
+	}
+	return p
+}
+
+// printerAt returns a no-op printer without building the entry if the level is
+// below MinLevel. The no-op printer still keeps the error for PrintErr/PrintErrf.
+func (l *Logger) printerAt(depth int, level logspb.LogEntry_Level) *LogPrinter {
+	if level < l.MinLevel {
+		return &LogPrinter{logger: l}
+	}
+	return l.Printer(depth + 1)
 }
 
 // With is a shortcut.
@@ -460,92 +743,92 @@ func (l *Logger) With(attrs ...AttributeSetter) *LogPrinter {
 
 // Info is a shortcut.
 func (l *Logger) Info() *LogPrinter {
-	return l.Printer(1).Info()
+	return l.printerAt(1, logspb.LogEntry_INFO).Info()
 }
 
 // Warning is a shortcut.
 func (l *Logger) Warning(err error) *LogPrinter {
-	return l.Printer(1).Warning(err)
+	return l.printerAt(1, logspb.LogEntry_WARNING).Warning(err)
 }
 
 // Error is a shortcut.
 func (l *Logger) Error(err error) *LogPrinter {
-	return l.Printer(1).Error(err)
+	return l.printerAt(1, logspb.LogEntry_ERROR).Error(err)
 }
 
 // Critical is a shortcut.
 func (l *Logger) Critical(err error) *LogPrinter {
-	return l.Printer(1).Critical(err)
+	return l.printerAt(1, logspb.LogEntry_CRITICAL).Critical(err)
 }
 
 // Fatal is a shortcut.
 func (l *Logger) Fatal(err error) *LogPrinter {
-	return l.Printer(1).Fatal(err)
+	return l.printerAt(1, logspb.LogEntry_FATAL).Fatal(err)
 }
 
 // Print is a shortcut.
 func (l *Logger) Print(message string) {
-	l.Printer(1).Print(message)
+	l.printerAt(1, logspb.LogEntry_NONE).Print(message)
 }
 
 // Printf is a shortcut.
 func (l *Logger) Printf(format string, args ...interface{}) {
-	l.Printer(1).Printf(format, args...)
+	l.printerAt(1, logspb.LogEntry_NONE).Printf(format, args...)
 }
 
 // Infof is a shortcut.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	l.Printer(1).Infof(format, args...)
+	l.printerAt(1, logspb.LogEntry_INFO).Infof(format, args...)
 }
 
 // Warningf is a shortcut.
 func (l *Logger) Warningf(format string, args ...interface{}) error {
-	return l.Printer(1).Warningf(format, args...)
+	return l.printerAt(1, logspb.LogEntry_WARNING).Warningf(format, args...)
 }
 
 // Errorf is a shortcut.
 func (l *Logger) Errorf(format string, args ...interface{}) error {
-	return l.Printer(1).Errorf(format, args...)
+	return l.printerAt(1, logspb.LogEntry_ERROR).Errorf(format, args...)
 }
 
 // Criticalf is a shortcut.
 func (l *Logger) Criticalf(format string, args ...interface{}) error {
-	return l.Printer(1).Criticalf(format, args...)
+	return l.printerAt(1, logspb.LogEntry_CRITICAL).Criticalf(format, args...)
 }
 
 // Fatalf is a shortcut.
 func (l *Logger) Fatalf(format string, args ...interface{}) {
-	l.Printer(1).Fatalf(format, args...)
+	l.printerAt(1, logspb.LogEntry_FATAL).Fatalf(format, args...)
 }
 
 // PrintProtoCompact is a shortcut.
 func (l *Logger) PrintProtoCompact(prefix string, msg proto.Message) {
-	l.Printer(1).PrintProtoCompact(prefix, msg)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintProtoCompact(prefix, msg)
 }
 
 // PrintProto is a shortcut.
 func (l *Logger) PrintProto(prefix string, msg proto.Message) {
-	l.Printer(1).PrintProto(prefix, msg)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintProto(prefix, msg)
 }
 
 // PrintJSONCompact is a shortcut.
 func (l *Logger) PrintJSONCompact(prefix string, obj interface{}) {
-	l.Printer(1).PrintJSONCompact(prefix, obj)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintJSONCompact(prefix, obj)
 }
 
 // PrintJSON is a shortcut.
 func (l *Logger) PrintJSON(prefix string, obj interface{}) {
-	l.Printer(1).PrintJSON(prefix, obj)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintJSON(prefix, obj)
 }
 
 // PrintProtoJSONCompact is a shortcut.
 func (l *Logger) PrintProtoJSONCompact(prefix string, msg proto.Message) {
-	l.Printer(1).PrintProtoJSONCompact(prefix, msg)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintProtoJSONCompact(prefix, msg)
 }
 
 // PrintProtoJSON is a shortcut.
 func (l *Logger) PrintProtoJSON(prefix string, msg proto.Message) {
-	l.Printer(1).PrintProtoJSON(prefix, msg)
+	l.printerAt(1, logspb.LogEntry_NONE).PrintProtoJSON(prefix, msg)
 }
 
 // EmitLogEntry implements LogEmitter and simply passthrough the log entry to the current emitter.
@@ -557,6 +840,7 @@ func (l *Logger) makeEntry(depth int) *logspb.LogEntry {
 	entry := &logspb.LogEntry{
 		NanoTs:     time.Now().UnixNano(),
 		Attributes: make(map[string]*logspb.Value),
+		Verbose:    l.verbose,
 	}
 	if l.span != nil {
 		entry.Trace = &logspb.Trace{SpanContext: l.span.Context}
@@ -567,13 +851,24 @@ func (l *Logger) makeEntry(depth int) *logspb.LogEntry {
 	for k, v := range l.attrs {
 		entry.Attributes[k] = v
 	}
+	if len(l.order) > 0 {
+		entry.AttributeOrder = append(make([]string, 0, len(l.order)+2), l.order...)
+	}
 	return entry
 }
 
-func (l *Logger) emit(entry *logspb.LogEntry, err error) {
+func (l *Logger) emit(entry *logspb.LogEntry, err error, deferred []*DeferredAttribute) {
 	if err != nil && entry.Level != logspb.LogEntry_FATAL && l.ErrorFilter != nil && !l.ErrorFilter(err) {
 		return
 	}
+	if entry.Level < l.MinLevel && entry.Level != logspb.LogEntry_FATAL && entry.GetTrace().GetEvent() == nil {
+		return
+	}
+	resolveDeferred(entry.Attributes, &entry.AttributeOrder, deferred)
+	if len(entry.Attributes) < 2 {
+		// The order is meaningless.
+		entry.AttributeOrder = nil
+	}
 	l.emitter.EmitLogEntry(entry)
 	if entry.Level == logspb.LogEntry_FATAL {
 		os.Exit(1)
@@ -582,15 +877,20 @@ func (l *Logger) emit(entry *logspb.LogEntry, err error) {
 
 // With sets attributes.
 func (p *LogPrinter) With(attrs ...AttributeSetter) *LogPrinter {
+	if p.entry == nil {
+		return p
+	}
 	for _, attr := range attrs {
-		attr.SetAttributes(p.entry.Attributes)
+		setAttributes(p.entry.Attributes, &p.entry.AttributeOrder, &p.deferred, attr)
 	}
 	return p
 }
 
 // Info sets info level.
 func (p *LogPrinter) Info() *LogPrinter {
-	p.entry.Level = logspb.LogEntry_INFO
+	if p.entry != nil {
+		p.entry.Level = logspb.LogEntry_INFO
+	}
 	return p
 }
 
@@ -618,16 +918,28 @@ func (p *LogPrinter) Fatal(err error) *LogPrinter {
 	return p
 }
 
+// ErrorChain records the unwrapped chain of err in ErrorChainAttr, in addition
+// to the error message set by Warning, Error, etc.
+func (p *LogPrinter) ErrorChain(err error) *LogPrinter {
+	return p.With(ErrorChain(err))
+}
+
 // Print prints a message.
 func (p *LogPrinter) Print(message string) {
+	if p.entry == nil {
+		return
+	}
 	p.entry.Message = message
-	p.logger.emit(p.entry, p.err)
+	p.logger.emit(p.entry, p.err, p.deferred)
 }
 
 // Printf formats a message and print.
 func (p *LogPrinter) Printf(format string, args ...interface{}) {
+	if p.entry == nil {
+		return
+	}
 	p.entry.Message = fmt.Sprintf(format, args...)
-	p.logger.emit(p.entry, p.err)
+	p.logger.emit(p.entry, p.err, p.deferred)
 }
 
 // Infof sets info level, formats and prints the message.
@@ -714,8 +1026,8 @@ func (p *LogPrinter) PrintProtoJSON(prefix string, msg proto.Message) {
 // PrintErr prints the error specified by Warning/Error/Critical/Fatal and returns the error.
 // It doesn't print and returns nil if error is not set.
 func (p *LogPrinter) PrintErr(prefix string) error {
-	if p.err == nil {
-		return nil
+	if p.err == nil || p.entry == nil {
+		return p.err
 	}
 	p.Print(prefix + p.err.Error())
 	return p.err
@@ -723,17 +1035,21 @@ func (p *LogPrinter) PrintErr(prefix string) error {
 
 // PrintErrf is similar to PrintErr with prefix formatted.
 func (p *LogPrinter) PrintErrf(prefixFormat string, args ...interface{}) error {
-	if p.err == nil {
-		return nil
+	if p.err == nil || p.entry == nil {
+		return p.err
 	}
 	p.Print(fmt.Sprintf(prefixFormat, args...) + p.err.Error())
 	return p.err
 }
 
 func (p *LogPrinter) setError(level logspb.LogEntry_Level, err error) {
+	if p.entry == nil {
+		p.err = err
+		return
+	}
 	p.entry.Level = level
 	if err != nil {
-		p.With(Str("error", err.Error()))
+		p.With(Str(ErrorAttr, err.Error()), ErrorOrigin(err))
 		p.err = err
 	}
 }
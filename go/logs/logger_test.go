@@ -0,0 +1,330 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+type recordingEmitter struct {
+	entries []*logspb.LogEntry
+}
+
+func (e *recordingEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.entries = append(e.entries, entry)
+}
+
+func TestMinLevel(t *testing.T) {
+	emitter := &recordingEmitter{}
+	logger := newLogger(emitter).SetMinLevel(logspb.LogEntry_WARNING)
+	logger.Infof("info")
+	logger.Printf("none")
+	logger.With(Str("a", "b")).Info().Print("info with attrs")
+	if err := logger.Info().PrintErr("no error: "); err != nil {
+		t.Errorf("PrintErr returned %v", err)
+	}
+	if err := logger.Warningf("warning"); err == nil || err.Error() != "warning" {
+		t.Errorf("Warningf returned %v", err)
+	}
+	logger.Error(errors.New("error")).PrintErr("failed: ")
+	span := logger.StartSpan(SpanInfo{Name: "span"})
+	span.Infof("info in span")
+	span.EndSpan()
+
+	var messages []string
+	for _, entry := range emitter.entries {
+		messages = append(messages, entry.GetMessage())
+	}
+	if len(messages) != 4 {
+		t.Fatalf("emitted %q", messages)
+	}
+	if messages[0] != "warning" || messages[1] != "failed: error" {
+		t.Errorf("emitted %q", messages)
+	}
+	if emitter.entries[2].GetTrace().GetSpanStart() == nil || emitter.entries[3].GetTrace().GetSpanEnd() == nil {
+		t.Errorf("span events not emitted: %q", messages)
+	}
+}
+
+func TestLazy(t *testing.T) {
+	emitter := &recordingEmitter{}
+	logger := newLogger(emitter).SetMinLevel(logspb.LogEntry_INFO)
+	var calls int
+	lazy := Lazy("dump", func() *logspb.Value {
+		calls++
+		return &logspb.Value{Value: &logspb.Value_StrValue{StrValue: "expensive"}}
+	})
+
+	logger.With(lazy).Printf("filtered")
+	child := logger.New(lazy)
+	child.Printf("filtered in child")
+	if calls != 0 {
+		t.Fatalf("Lazy func called %d times for filtered entries", calls)
+	}
+
+	child.Infof("emitted")
+	child.New(Str("dump", "overwritten")).Infof("overwritten")
+	logger.With(Lazy("none", func() *logspb.Value { return nil })).Infof("omitted")
+	if calls != 1 {
+		t.Errorf("Lazy func called %d times, expect 1", calls)
+	}
+	if len(emitter.entries) != 3 {
+		t.Fatalf("emitted %d entries", len(emitter.entries))
+	}
+	if val := emitter.entries[0].GetAttributes()["dump"].GetStrValue(); val != "expensive" {
+		t.Errorf("emitted dump=%q", val)
+	}
+	if val := emitter.entries[1].GetAttributes()["dump"].GetStrValue(); val != "overwritten" {
+		t.Errorf("overwritten dump=%q", val)
+	}
+	if _, ok := emitter.entries[2].GetAttributes()["none"]; ok {
+		t.Errorf("nil lazy value not omitted")
+	}
+}
+
+func TestCtxPrintf(t *testing.T) {
+	emitter := &recordingEmitter{}
+	ctx := newLogger(emitter).New(Str("key", "value")).NewContext(context.Background())
+	CtxInfof(ctx, "info")
+	if err := CtxErrorf(ctx, "error %d", 1); err == nil || err.Error() != "error 1" {
+		t.Errorf("CtxErrorf returned %v", err)
+	}
+	if len(emitter.entries) != 2 {
+		t.Fatalf("emitted %d entries", len(emitter.entries))
+	}
+	for _, entry := range emitter.entries {
+		if !strings.Contains(entry.GetLocation(), "logger_test.go:") {
+			t.Errorf("%q: location %q", entry.GetMessage(), entry.GetLocation())
+		}
+		if entry.GetAttributes()["key"].GetStrValue() != "value" {
+			t.Errorf("%q: attributes of context logger not set", entry.GetMessage())
+		}
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	emitter := &recordingEmitter{}
+	ctx := newLogger(emitter).SetMinLevel(logspb.LogEntry_WARNING).NewContext(context.Background())
+	verboseCtx := WithLevel(ctx, logspb.LogEntry_NONE)
+	CtxInfof(ctx, "filtered")
+	CtxInfof(verboseCtx, "verbose")
+	spanCtx, span := StartSpan(verboseCtx, "span")
+	CtxPrintf(spanCtx, "verbose in span")
+	span.EndSpan()
+	CtxInfof(ctx, "filtered again")
+
+	var messages []string
+	for _, entry := range emitter.entries {
+		if entry.GetTrace().GetEvent() == nil {
+			messages = append(messages, entry.GetMessage())
+			if !IsVerbose(entry) {
+				t.Errorf("entry %q not marked verbose", entry.GetMessage())
+			}
+			if len(entry.GetAttributes()) != 0 {
+				t.Errorf("entry %q has attributes %v", entry.GetMessage(), entry.GetAttributes())
+			}
+		}
+	}
+	if len(messages) != 2 || messages[0] != "verbose" || messages[1] != "verbose in span" {
+		t.Errorf("emitted %q", messages)
+	}
+}
+
+func TestAttributeScopes(t *testing.T) {
+	emitter := &recordingEmitter{}
+	ctx := newLogger(emitter).New(Str("logger", "l")).NewContext(context.Background())
+	ctx = WithLocalAttrs(ctx, Str("local", "c"))
+	CtxInfof(ctx, "direct")
+	CtxInfof(WithLevel(ctx, logspb.LogEntry_NONE), "new")
+	spanCtx, span := StartSpan(ctx, "span", Str("span", "s"))
+	CtxInfof(spanCtx, "in span")
+	CtxInfof(WithLocalAttrs(spanCtx, Str("local", "c1")), "local in span")
+	span.EndSpan()
+
+	testCases := []struct {
+		message string
+		attrs   map[string]string
+	}{
+		{message: "direct", attrs: map[string]string{"logger": "l", "local": "c"}},
+		{message: "new", attrs: map[string]string{"logger": "l", "local": "c"}},
+		{message: "SPAN_START", attrs: map[string]string{"logger": "l", "span": "s"}},
+		{message: "in span", attrs: map[string]string{"logger": "l", "span": "s"}},
+		{message: "local in span", attrs: map[string]string{"logger": "l", "span": "s", "local": "c1"}},
+		{message: "SPAN_END", attrs: map[string]string{"logger": "l", "span": "s"}},
+	}
+	if len(emitter.entries) != len(testCases) {
+		t.Fatalf("emitted %d entries, expect %d", len(emitter.entries), len(testCases))
+	}
+	for n, tc := range testCases {
+		entry := emitter.entries[n]
+		if !strings.HasPrefix(entry.GetMessage(), tc.message) {
+			t.Errorf("entry %d: message %q, expect %q", n, entry.GetMessage(), tc.message)
+			continue
+		}
+		attrs := make(map[string]string)
+		for key, val := range entry.GetAttributes() {
+			attrs[key] = val.GetStrValue()
+		}
+		if len(attrs) != len(tc.attrs) {
+			t.Errorf("%s: attributes %v, expect %v", tc.message, attrs, tc.attrs)
+			continue
+		}
+		for key, val := range tc.attrs {
+			if attrs[key] != val {
+				t.Errorf("%s: attributes %v, expect %v", tc.message, attrs, tc.attrs)
+				break
+			}
+		}
+	}
+}
+
+func TestDerive(t *testing.T) {
+	emitter := &recordingEmitter{}
+	ctx := newLogger(emitter).New(Str("logger", "l")).NewContext(context.Background())
+	derivedCtx, derived := Derive(ctx, Str("scope", "s"))
+	if logger, _ := FromContext(derivedCtx); logger != derived {
+		t.Fatalf("derived logger not in the context")
+	}
+	derived.Infof("derived")
+	CtxInfof(ctx, "parent")
+	_, span := StartSpan(derivedCtx, "span")
+	span.EndSpan()
+
+	if len(emitter.entries) != 4 {
+		t.Fatalf("emitted %d entries, expect 4", len(emitter.entries))
+	}
+	for n, entry := range emitter.entries {
+		_, scoped := entry.GetAttributes()["scope"]
+		if expected := n != 1; scoped != expected {
+			t.Errorf("entry %d %q: scope attribute %v, expect %v", n, entry.GetMessage(), scoped, expected)
+		}
+		if entry.GetAttributes()["logger"].GetStrValue() != "l" {
+			t.Errorf("entry %d %q: missing logger attribute", n, entry.GetMessage())
+		}
+	}
+	if emitter.entries[0].GetTrace() != nil {
+		t.Errorf("derived logger started a span")
+	}
+}
+
+func TestMaxLoggerDepth(t *testing.T) {
+	saved := MaxLoggerDepth
+	MaxLoggerDepth = 3
+	defer func() { MaxLoggerDepth = saved }()
+
+	logger := newLogger(&DummyEmitter{})
+	for n := 0; n < 5; n++ {
+		logger = logger.StartSpan(SpanInfo{Name: "loop"})
+		if logger.depth > MaxLoggerDepth {
+			t.Fatalf("depth %d exceeds %d", logger.depth, MaxLoggerDepth)
+		}
+	}
+	var length int
+	for l := logger; l.parent != nil; l = l.parent {
+		length++
+	}
+	if length > MaxLoggerDepth {
+		t.Errorf("parent chain length %d exceeds %d", length, MaxLoggerDepth)
+	}
+}
+
+func BenchmarkMinLevel(b *testing.B) {
+	b.Run("filtered", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{}).SetMinLevel(logspb.LogEntry_WARNING)
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.Infof("value %d", n)
+		}
+	})
+	b.Run("emitted", func(b *testing.B) {
+		logger := newLogger(&DummyEmitter{})
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			logger.Infof("value %d", n)
+		}
+	})
+}
+
+func TestAttributeOrder(t *testing.T) {
+	emitter := &recordingEmitter{}
+	logger := newLogger(emitter).New(Str("z", "1"), Str("m", "2"))
+	logger.With(Str("b", "3"), Str("a", "4")).Info().Print("insertion")
+	logger.With(Str("m", "5"), Str("c", "6"), Str("z", "7")).Info().Print("overwrite")
+	logger.New(Str("y", "8")).With(Str("x", "9")).Info().Print("child")
+	logger.Info().Print("unchanged")
+	logger.With(Str("b", "3"), Str("a", "4")).Info().Print("sequence")
+	logger.With(Str("b", "3"), Lazy("none", func() *logspb.Value { return nil }), Str("a", "4")).Info().Print("lazy nil")
+
+	expected := [][]string{
+		{"z", "m", "b", "a"},
+		{"z", "m", "c"},
+		{"z", "m", "y", "x"},
+		{"z", "m"},
+	}
+	if len(emitter.entries) != 6 {
+		t.Fatalf("Expect 6 entries, got %d", len(emitter.entries))
+	}
+	for n, order := range expected {
+		if actual := emitter.entries[n].GetAttributeOrder(); !reflect.DeepEqual(actual, order) {
+			t.Errorf("%s: expect order %v, got %v", emitter.entries[n].GetMessage(), order, actual)
+		}
+	}
+	if val := emitter.entries[1].GetAttributes()["m"].GetStrValue(); val != "5" {
+		t.Errorf("Expect overwritten m=5, got %q", val)
+	}
+	// The lazy attribute evaluated to nil is removed from the order.
+	if order := emitter.entries[5].GetAttributeOrder(); !reflect.DeepEqual(order, expected[0]) {
+		t.Errorf("lazy nil: expect order %v, got %v", expected[0], order)
+	}
+
+	// The order propagates through emitters.
+	recorder := &recordingEmitter{}
+	emitters := MultiEmitter{NewMaskEmitter(recorder, nil, []string{"m"}), NewSequenceEmitter(recorder)}
+	emitters.EmitLogEntry(emitter.entries[4])
+	if len(recorder.entries) != 2 {
+		t.Fatalf("Expect 2 entries, got %d", len(recorder.entries))
+	}
+	if order := recorder.entries[0].GetAttributeOrder(); !reflect.DeepEqual(order, []string{"z", "b", "a"}) {
+		t.Errorf("MaskEmitter: unexpected order %v", order)
+	}
+	if order := recorder.entries[1].GetAttributeOrder(); len(order) < 4 || !reflect.DeepEqual(order[:4], expected[0]) {
+		t.Errorf("SequenceEmitter: unexpected order %v", order)
+	}
+}
+
+func TestUseOrStartSpan(t *testing.T) {
+	emitter := &recordingEmitter{}
+	ctx := newLogger(emitter).NewContext(context.Background())
+
+	spanCtx, spanLogger, started := UseOrStartSpan(ctx, "outer", Str("a", "b"))
+	if !started {
+		t.Fatal("Expect a new span started")
+	}
+	if name := spanLogger.SpanInfo().Name; name != "outer" {
+		t.Errorf("Expect span outer, got %q", name)
+	}
+	if logger, ok := FromContext(spanCtx); !ok || logger != spanLogger {
+		t.Error("Expect the span logger in the returned context")
+	}
+	if len(emitter.entries) != 1 || emitter.entries[0].GetTrace().GetSpanStart().GetName() != "outer" {
+		t.Fatalf("Expect a span start entry, got %v", emitter.entries)
+	}
+	if val := emitter.entries[0].GetAttributes()["a"].GetStrValue(); val != "b" {
+		t.Errorf("Expect attribute a=b on span start, got %q", val)
+	}
+
+	innerCtx, innerLogger, started := UseOrStartSpan(spanCtx, "inner")
+	if started {
+		t.Error("Expect the existing span reused")
+	}
+	if innerCtx != spanCtx || innerLogger != spanLogger {
+		t.Error("Expect the same context and logger returned")
+	}
+	if len(emitter.entries) != 1 {
+		t.Errorf("Expect no more entries emitted, got %d", len(emitter.entries))
+	}
+}
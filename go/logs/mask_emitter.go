@@ -0,0 +1,55 @@
+package logs
+
+import (
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// MaskEmitter filters attributes of log entries before passing them to the next emitter.
+// It allows a single emitting call to feed differently-shaped entries to different emitters.
+type MaskEmitter struct {
+	Next LogEmitter
+	// Include specifies the attributes to keep. If empty, all attributes are kept
+	// unless they are excluded.
+	Include map[string]bool
+	// Exclude specifies the attributes to drop.
+	Exclude map[string]bool
+}
+
+// NewMaskEmitter creates a MaskEmitter.
+func NewMaskEmitter(next LogEmitter, include, exclude []string) *MaskEmitter {
+	e := &MaskEmitter{Next: next}
+	if len(include) > 0 {
+		e.Include = make(map[string]bool)
+		for _, name := range include {
+			e.Include[name] = true
+		}
+	}
+	if len(exclude) > 0 {
+		e.Exclude = make(map[string]bool)
+		for _, name := range exclude {
+			e.Exclude[name] = true
+		}
+	}
+	return e
+}
+
+// EmitLogEntry implements LogEmitter.
+// A copy with masked attributes is emitted if any attribute is masked.
+func (e *MaskEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	attrs := entry.GetAttributes()
+	masked := make(map[string]*logspb.Value, len(attrs))
+	for key, val := range attrs {
+		if e.Include != nil && !e.Include[key] {
+			continue
+		}
+		if e.Exclude[key] {
+			continue
+		}
+		masked[key] = val
+	}
+	if len(masked) == len(attrs) {
+		e.Next.EmitLogEntry(entry)
+		return
+	}
+	e.Next.EmitLogEntry(CopyLogEntry(entry, masked))
+}
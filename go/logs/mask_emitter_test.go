@@ -0,0 +1,33 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskEmitterAttributeOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{name: "unmasked", expected: []string{"c", "a", "b"}},
+		{name: "include", include: []string{"a", "c"}, expected: []string{"c", "a"}},
+		{name: "exclude", exclude: []string{"c"}, expected: []string{"a", "b"}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &recordingEmitter{}
+			logger := newLogger(NewMaskEmitter(recorder, tc.include, tc.exclude))
+			logger.With(Str("c", "1"), Str("a", "2"), Str("b", "3")).Info().Print("masked")
+			if len(recorder.entries) != 1 {
+				t.Fatalf("Expect 1 entry, got %d", len(recorder.entries))
+			}
+			if order := recorder.entries[0].GetAttributeOrder(); !reflect.DeepEqual(order, tc.expected) {
+				t.Errorf("Expect order %v, got %v", tc.expected, order)
+			}
+		})
+	}
+}
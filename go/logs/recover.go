@@ -0,0 +1,39 @@
+package logs
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	// StackAttr is the attribute name of the stack trace of a panic.
+	StackAttr = "error.stack"
+	// PanicAttr is the attribute name marking a span ended by a panic.
+	PanicAttr = "error.panic"
+)
+
+// Recover is intended to be deferred, e.g. `defer log.Recover()`, to capture a
+// panic: it logs the panic as a CRITICAL entry with the stack trace (StackAttr),
+// marks the span as errored by setting PanicAttr to true, and re-panics so the
+// panic is still handled by the callers.
+// http.ErrAbortHandler is re-panicked without logging, as it aborts the
+// handler intentionally.
+func (l *Logger) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+	l.SetAttrs(Bool(PanicAttr, true))
+	l.printerAt(1, logspb.LogEntry_CRITICAL).With(Str(StackAttr, string(debug.Stack()))).Critical(err).Printf("panic: %v", r)
+	panic(r)
+}
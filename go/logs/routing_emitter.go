@@ -0,0 +1,95 @@
+package logs
+
+import (
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// AttributeMatcher matches the value of an attribute.
+type AttributeMatcher func(*logspb.Value) bool
+
+// RouteRule routes the entries with the attribute matched to the emitter.
+type RouteRule struct {
+	Attribute string
+	// Match matches the attribute value. If nil, the rule matches if the attribute is present.
+	Match   AttributeMatcher
+	Emitter LogEmitter
+}
+
+// RoutingEmitter routes entries to emitters based on attributes.
+// The rules are evaluated in order.
+type RoutingEmitter struct {
+	Rules []RouteRule
+	// Default receives the entries not matching any rule. Nil means dropping them.
+	Default LogEmitter
+	// Fanout routes the entry to all matching rules instead of only the first one.
+	Fanout bool
+}
+
+// NewRoutingEmitter creates a RoutingEmitter.
+func NewRoutingEmitter(defaultEmitter LogEmitter, rules ...RouteRule) *RoutingEmitter {
+	return &RoutingEmitter{Rules: rules, Default: defaultEmitter}
+}
+
+// Route adds a rule.
+func (e *RoutingEmitter) Route(attr string, match AttributeMatcher, emitter LogEmitter) *RoutingEmitter {
+	e.Rules = append(e.Rules, RouteRule{Attribute: attr, Match: match, Emitter: emitter})
+	return e
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *RoutingEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	routed := false
+	attrs := entry.GetAttributes()
+	for _, rule := range e.Rules {
+		val, ok := attrs[rule.Attribute]
+		if !ok || (rule.Match != nil && !rule.Match(val)) {
+			continue
+		}
+		rule.Emitter.EmitLogEntry(entry)
+		if !e.Fanout {
+			return
+		}
+		routed = true
+	}
+	if !routed && e.Default != nil {
+		e.Default.EmitLogEntry(entry)
+	}
+}
+
+// MatchValue matches an attribute value by its string form against any of the values.
+// Bool values are matched as "true"/"false" and numbers in decimal.
+func MatchValue(values ...string) AttributeMatcher {
+	set := make(map[string]bool, len(values))
+	for _, val := range values {
+		set[val] = true
+	}
+	return func(v *logspb.Value) bool {
+		switch val := v.GetValue().(type) {
+		case *logspb.Value_StrValue:
+			return set[val.StrValue]
+		case *logspb.Value_BoolValue:
+			return set[strconv.FormatBool(val.BoolValue)]
+		case *logspb.Value_IntValue:
+			return set[strconv.FormatInt(val.IntValue, 10)]
+		case *logspb.Value_FloatValue:
+			return set[strconv.FormatFloat(float64(val.FloatValue), 'g', -1, 32)]
+		case *logspb.Value_DoubleValue:
+			return set[strconv.FormatFloat(val.DoubleValue, 'g', -1, 64)]
+		case *logspb.Value_Json:
+			return set[val.Json]
+		case *logspb.Value_Bytes:
+			return set[hex.EncodeToString(val.Bytes)]
+		case *logspb.Value_Duration:
+			return set[time.Duration(val.Duration).String()]
+		case *logspb.Value_Time:
+			return set[time.Unix(0, val.Time).UTC().Format(time.RFC3339Nano)]
+		case *logspb.Value_Decimal:
+			return set[val.Decimal]
+		}
+		return false
+	}
+}
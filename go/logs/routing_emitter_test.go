@@ -0,0 +1,97 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestRoutingEmitter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fanout   bool
+		attrs    []AttributeSetter
+		expected []string
+	}{
+		{name: "first match", attrs: []AttributeSetter{Str("component", "db"), True("audit")}, expected: []string{"db"}},
+		{name: "second rule", attrs: []AttributeSetter{Str("component", "web"), True("audit")}, expected: []string{"audit"}},
+		{name: "present", attrs: []AttributeSetter{Int("error", 0)}, expected: []string{"error"}},
+		{name: "default", attrs: []AttributeSetter{Str("component", "web"), False("audit")}, expected: []string{"default"}},
+		{name: "no attributes", expected: []string{"default"}},
+		{name: "fanout", fanout: true, attrs: []AttributeSetter{Str("component", "db"), True("audit")}, expected: []string{"db", "audit"}},
+		{name: "fanout default", fanout: true, attrs: []AttributeSetter{Str("component", "web")}, expected: []string{"default"}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var routed []string
+			emitter := func(name string) LogEmitter {
+				return LogEmitterFunc(func(*logspb.LogEntry) { routed = append(routed, name) })
+			}
+			e := NewRoutingEmitter(emitter("default")).
+				Route("component", MatchValue("db", "cache"), emitter("db")).
+				Route("audit", MatchValue("true"), emitter("audit")).
+				Route("error", nil, emitter("error"))
+			e.Fanout = tc.fanout
+			entry := &logspb.LogEntry{Attributes: make(map[string]*logspb.Value)}
+			for _, attr := range tc.attrs {
+				attr.SetAttributes(entry.Attributes)
+			}
+			e.EmitLogEntry(entry)
+			if !reflect.DeepEqual(routed, tc.expected) {
+				t.Errorf("Expect routed to %v, got %v", tc.expected, routed)
+			}
+		})
+	}
+}
+
+func TestRoutingEmitterNoDefault(t *testing.T) {
+	recorder := &recordingEmitter{}
+	e := NewRoutingEmitter(nil).Route("component", MatchValue("db"), recorder)
+	e.EmitLogEntry(&logspb.LogEntry{})
+	e.EmitLogEntry(&logspb.LogEntry{Attributes: map[string]*logspb.Value{
+		"component": {Value: &logspb.Value_StrValue{StrValue: "db"}},
+	}})
+	if len(recorder.entries) != 1 {
+		t.Errorf("Expect 1 entry routed, got %d", len(recorder.entries))
+	}
+}
+
+func TestMatchValue(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	testCases := []struct {
+		name    string
+		attr    AttributeSetter
+		value   string
+		matched bool
+	}{
+		{name: "str", attr: Str("a", "x"), value: "x", matched: true},
+		{name: "str mismatch", attr: Str("a", "y"), value: "x"},
+		{name: "bool", attr: True("a"), value: "true", matched: true},
+		{name: "bool mismatch", attr: False("a"), value: "true"},
+		{name: "int", attr: Int("a", -12), value: "-12", matched: true},
+		{name: "int and str", attr: Str("a", "12"), value: "12", matched: true},
+		{name: "float", attr: Float("a", 0.1), value: "0.1", matched: true},
+		{name: "double", attr: Double("a", 1.5), value: "1.5", matched: true},
+		{name: "integral double", attr: Double("a", 2), value: "2", matched: true},
+		{name: "decimal", attr: Decimal("a", "1.50"), value: "1.50", matched: true},
+		{name: "decimal not normalized", attr: Decimal("a", "1.50"), value: "1.5"},
+		{name: "json", attr: JSON("a", []int{1, 2}), value: "[1,2]", matched: true},
+		{name: "bytes", attr: Bytes("a", []byte{0xab, 0x01}), value: "ab01", matched: true},
+		{name: "duration", attr: Duration("a", 1500*time.Millisecond), value: "1.5s", matched: true},
+		{name: "time", attr: Time("a", ts), value: "2020-01-02T03:04:05.000000006Z", matched: true},
+		{name: "map", attr: Map("a", Str("b", "x")), value: "x"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			attrs := make(map[string]*logspb.Value)
+			tc.attr.SetAttributes(attrs)
+			if matched := MatchValue("other", tc.value)(attrs["a"]); matched != tc.matched {
+				t.Errorf("Match %v with %q: %v, expect %v", attrs["a"], tc.value, matched, tc.matched)
+			}
+		})
+	}
+}
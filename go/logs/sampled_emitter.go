@@ -0,0 +1,155 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	defaultSummaryInterval = 10 * time.Second
+)
+
+// SampledEmitter rate limits log entries per call site (location and level)
+// using token buckets before passing them to the next emitter.
+// Entries exceeding the rate are dropped, and a summary entry with the number
+// of dropped entries is emitted for each call site every SummaryInterval.
+// Span events and verbose entries (see WithLevel) are never dropped.
+type SampledEmitter struct {
+	Next LogEmitter
+	// PerSecond is the rate of entries allowed per call site.
+	PerSecond float64
+	// Burst is the max number of entries allowed at once per call site.
+	// If less than 1, 1 is used, otherwise no entries would be allowed.
+	Burst int
+	// SummaryInterval is the min interval between summaries of dropped entries.
+	// If not positive, a default of 10 seconds is used.
+	SummaryInterval time.Duration
+
+	lock        sync.Mutex
+	buckets     map[sampleKey]*sampleBucket
+	lastSummary time.Time
+	now         func() time.Time
+}
+
+type sampleKey struct {
+	location string
+	level    logspb.LogEntry_Level
+}
+
+type sampleBucket struct {
+	tokens  float64
+	updated time.Time
+	dropped int64
+}
+
+// NewSampledEmitter creates a SampledEmitter.
+func NewSampledEmitter(next LogEmitter, perSecond float64, burst int) *SampledEmitter {
+	return &SampledEmitter{Next: next, PerSecond: perSecond, Burst: burst}
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *SampledEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	if entry.GetTrace().GetEvent() != nil || IsVerbose(entry) {
+		e.Next.EmitLogEntry(entry)
+		return
+	}
+	e.lock.Lock()
+	now := e.currentTime()
+	allowed := e.allow(sampleKey{location: entry.GetLocation(), level: entry.GetLevel()}, now)
+	var summaries []*logspb.LogEntry
+	interval := e.SummaryInterval
+	if interval <= 0 {
+		interval = defaultSummaryInterval
+	}
+	if now.Sub(e.lastSummary) >= interval {
+		summaries = e.summarize(now)
+	}
+	e.lock.Unlock()
+	for _, summary := range summaries {
+		e.Next.EmitLogEntry(summary)
+	}
+	if allowed {
+		e.Next.EmitLogEntry(entry)
+	}
+}
+
+// Flush emits the summaries of dropped entries immediately.
+func (e *SampledEmitter) Flush() {
+	e.lock.Lock()
+	summaries := e.summarize(e.currentTime())
+	e.lock.Unlock()
+	for _, summary := range summaries {
+		e.Next.EmitLogEntry(summary)
+	}
+}
+
+func (e *SampledEmitter) currentTime() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+// allow must be called with lock held.
+func (e *SampledEmitter) allow(key sampleKey, now time.Time) bool {
+	if e.buckets == nil {
+		e.buckets = make(map[sampleKey]*sampleBucket)
+		e.lastSummary = now
+	}
+	burst := float64(e.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	bucket := e.buckets[key]
+	if bucket == nil {
+		bucket = &sampleBucket{tokens: burst, updated: now}
+		e.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * e.PerSecond
+		if bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		bucket.dropped++
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// summarize must be called with lock held.
+func (e *SampledEmitter) summarize(now time.Time) []*logspb.LogEntry {
+	e.lastSummary = now
+	var keys []sampleKey
+	for key, bucket := range e.buckets {
+		if bucket.dropped > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].location != keys[j].location {
+			return keys[i].location < keys[j].location
+		}
+		return keys[i].level < keys[j].level
+	})
+	summaries := make([]*logspb.LogEntry, 0, len(keys))
+	for _, key := range keys {
+		bucket := e.buckets[key]
+		summaries = append(summaries, &logspb.LogEntry{
+			NanoTs:     now.UnixNano(),
+			Level:      logspb.LogEntry_WARNING,
+			Location:   key.location,
+			Message:    fmt.Sprintf("Dropped %d %s entries exceeding the rate", bucket.dropped, key.level),
+			Attributes: map[string]*logspb.Value{"dropped": {Value: &logspb.Value_IntValue{IntValue: bucket.dropped}}},
+		})
+		bucket.dropped = 0
+	}
+	return summaries
+}
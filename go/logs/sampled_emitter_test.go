@@ -0,0 +1,120 @@
+package logs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestSampledEmitter(t *testing.T) {
+	recorder := &recordingEmitter{}
+	now := time.Unix(1000, 0)
+	e := NewSampledEmitter(recorder, 1, 3)
+	e.now = func() time.Time { return now }
+
+	for n := 0; n < 10; n++ {
+		e.EmitLogEntry(&logspb.LogEntry{Location: "a.go:1", Level: logspb.LogEntry_WARNING})
+	}
+	e.EmitLogEntry(&logspb.LogEntry{Location: "a.go:1", Level: logspb.LogEntry_ERROR})
+	e.EmitLogEntry(&logspb.LogEntry{Location: "b.go:1", Level: logspb.LogEntry_WARNING})
+	e.EmitLogEntry(&logspb.LogEntry{
+		Location: "a.go:1",
+		Level:    logspb.LogEntry_WARNING,
+		Trace:    &logspb.Trace{Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}},
+	})
+	if len(recorder.entries) != 6 {
+		t.Fatalf("emitted %d entries, expect 6", len(recorder.entries))
+	}
+
+	// Tokens are refilled and the summary is emitted after the interval.
+	now = now.Add(defaultSummaryInterval)
+	e.EmitLogEntry(&logspb.LogEntry{Location: "a.go:1", Level: logspb.LogEntry_WARNING})
+	if len(recorder.entries) != 8 {
+		t.Fatalf("emitted %d entries, expect 8", len(recorder.entries))
+	}
+	summary := recorder.entries[6]
+	if summary.GetLocation() != "a.go:1" || summary.GetAttributes()["dropped"].GetIntValue() != 7 {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+	if recorder.entries[7].GetNanoTs() != 0 || recorder.entries[7].GetLocation() != "a.go:1" {
+		t.Errorf("unexpected entry after summary: %v", recorder.entries[7])
+	}
+
+	// Nothing to summarize.
+	e.Flush()
+	if len(recorder.entries) != 8 {
+		t.Errorf("emitted %d entries after Flush, expect 8", len(recorder.entries))
+	}
+}
+
+func TestSampledEmitterVerbose(t *testing.T) {
+	recorder := &recordingEmitter{}
+	e := NewSampledEmitter(recorder, 1, 1)
+	ctx := WithLevel(newLogger(e).NewContext(context.Background()), logspb.LogEntry_NONE)
+	for n := 0; n < 3; n++ {
+		CtxInfof(ctx, "verbose")
+	}
+	if len(recorder.entries) != 3 {
+		t.Errorf("emitted %d entries, expect 3", len(recorder.entries))
+	}
+}
+
+func TestSampledEmitterZeroBurst(t *testing.T) {
+	recorder := &recordingEmitter{}
+	now := time.Unix(1000, 0)
+	e := NewSampledEmitter(recorder, 1, 0)
+	e.now = func() time.Time { return now }
+	for n := 0; n < 3; n++ {
+		e.EmitLogEntry(&logspb.LogEntry{Location: "a.go:1", Level: logspb.LogEntry_INFO})
+	}
+	if len(recorder.entries) != 1 {
+		t.Fatalf("emitted %d entries, expect 1", len(recorder.entries))
+	}
+	now = now.Add(time.Second)
+	e.EmitLogEntry(&logspb.LogEntry{Location: "a.go:1", Level: logspb.LogEntry_INFO})
+	if len(recorder.entries) != 2 {
+		t.Errorf("emitted %d entries after refill, expect 2", len(recorder.entries))
+	}
+}
+
+func TestSampledEmitterSummaryOrder(t *testing.T) {
+	recorder := &recordingEmitter{}
+	now := time.Unix(1000, 0)
+	e := NewSampledEmitter(recorder, 1, 1)
+	e.now = func() time.Time { return now }
+	sites := []struct {
+		location string
+		level    logspb.LogEntry_Level
+	}{
+		{"b.go:1", logspb.LogEntry_ERROR},
+		{"a.go:1", logspb.LogEntry_WARNING},
+		{"b.go:1", logspb.LogEntry_INFO},
+		{"a.go:1", logspb.LogEntry_ERROR},
+		{"b.go:1", logspb.LogEntry_WARNING},
+	}
+	for _, site := range sites {
+		for n := 0; n < 2; n++ {
+			e.EmitLogEntry(&logspb.LogEntry{Location: site.location, Level: site.level})
+		}
+	}
+	recorder.entries = nil
+	e.Flush()
+
+	var summaries []string
+	for _, entry := range recorder.entries {
+		summaries = append(summaries, entry.GetLocation()+" "+entry.GetMessage())
+	}
+	expected := []string{
+		"a.go:1 Dropped 1 WARNING entries exceeding the rate",
+		"a.go:1 Dropped 1 ERROR entries exceeding the rate",
+		"b.go:1 Dropped 1 INFO entries exceeding the rate",
+		"b.go:1 Dropped 1 WARNING entries exceeding the rate",
+		"b.go:1 Dropped 1 ERROR entries exceeding the rate",
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("Expect summaries %q, got %q", expected, summaries)
+	}
+}
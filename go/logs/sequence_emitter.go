@@ -0,0 +1,33 @@
+package logs
+
+import (
+	"sync/atomic"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	// SequenceAttr is the attribute of the sequence number set by SequenceEmitter.
+	SequenceAttr = "log.seq"
+)
+
+// SequenceEmitter sets a monotonically increasing sequence number, starting from 1,
+// as SequenceAttr of each entry before passing it to the next emitter, so that
+// consumers can detect lost entries by gaps in sequence numbers.
+// The sequence restarts from 1 when the process restarts.
+type SequenceEmitter struct {
+	Next LogEmitter
+
+	seq uint64
+}
+
+// NewSequenceEmitter creates a SequenceEmitter.
+func NewSequenceEmitter(next LogEmitter) *SequenceEmitter {
+	return &SequenceEmitter{Next: next}
+}
+
+// EmitLogEntry implements LogEmitter.
+func (e *SequenceEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	seq := atomic.AddUint64(&e.seq, 1)
+	e.Next.EmitLogEntry(CopyLogEntryWith(entry, SequenceAttr, &logspb.Value{Value: &logspb.Value_IntValue{IntValue: int64(seq)}}))
+}
@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+type lockedRecordingEmitter struct {
+	lock    sync.Mutex
+	entries []*logspb.LogEntry
+}
+
+func (e *lockedRecordingEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.entries = append(e.entries, entry)
+}
+
+func TestSequenceEmitterConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 100
+	recorder := &lockedRecordingEmitter{}
+	e := NewSequenceEmitter(recorder)
+	var wg sync.WaitGroup
+	for n := 0; n < goroutines; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				e.EmitLogEntry(&logspb.LogEntry{Message: "entry"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	seqs := make([]int64, 0, len(recorder.entries))
+	for _, entry := range recorder.entries {
+		seqs = append(seqs, entry.GetAttributes()[SequenceAttr].GetIntValue())
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	if len(seqs) != goroutines*perGoroutine {
+		t.Fatalf("emitted %d entries, expect %d", len(seqs), goroutines*perGoroutine)
+	}
+	for i, seq := range seqs {
+		if seq != int64(i+1) {
+			t.Fatalf("sequence numbers not contiguous from 1: got %d at %d", seq, i)
+		}
+	}
+}
+
+func TestSequenceEmitterEntry(t *testing.T) {
+	recorder := &recordingEmitter{}
+	e := NewSequenceEmitter(recorder)
+	entry := &logspb.LogEntry{
+		Message: "entry",
+		Attributes: map[string]*logspb.Value{
+			"b": {Value: &logspb.Value_StrValue{StrValue: "1"}},
+			"a": {Value: &logspb.Value_StrValue{StrValue: "2"}},
+		},
+		AttributeOrder: []string{"b", "a"},
+	}
+	e.EmitLogEntry(entry)
+	e.EmitLogEntry(entry)
+
+	if _, ok := entry.GetAttributes()[SequenceAttr]; ok || len(entry.GetAttributes()) != 2 {
+		t.Errorf("input entry mutated: %v", entry.GetAttributes())
+	}
+	if expected := []string{"b", "a"}; !reflect.DeepEqual(entry.GetAttributeOrder(), expected) {
+		t.Errorf("input entry order mutated: %v", entry.GetAttributeOrder())
+	}
+	if len(recorder.entries) != 2 {
+		t.Fatalf("emitted %d entries, expect 2", len(recorder.entries))
+	}
+	for n, emitted := range recorder.entries {
+		if seq := emitted.GetAttributes()[SequenceAttr].GetIntValue(); seq != int64(n+1) {
+			t.Errorf("entry %d: sequence %d, expect %d", n, seq, n+1)
+		}
+		if expected := []string{"b", "a", SequenceAttr}; !reflect.DeepEqual(emitted.GetAttributeOrder(), expected) {
+			t.Errorf("entry %d: attribute order %v, expect %v", n, emitted.GetAttributeOrder(), expected)
+		}
+	}
+}
@@ -36,6 +36,11 @@ func Setup(emitter LogEmitter) *Logger {
 	return l
 }
 
+// SetDefault replaces the default logger, e.g. to restore the one returned by Default.
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
 // Root creates a root logger.
 func Root(emitter LogEmitter) *Logger {
 	return newLogger(emitter)
@@ -17,6 +17,9 @@ type LogStreamer interface {
 // StreamEmitter simply emits collected logs.
 type StreamEmitter struct {
 	Streamer LogStreamer
+	// DisableWorker prevents the background worker from being started on emitting.
+	// The queued entries must be streamed explicitly using DrainOnce.
+	DisableWorker bool
 
 	emitCh  chan struct{}
 	workers int32
@@ -36,7 +39,7 @@ func NewStreamEmitter(streamer LogStreamer) *StreamEmitter {
 
 // EmitLogEntry implements LogEmitter.
 func (e *StreamEmitter) EmitLogEntry(entry *logspb.LogEntry) {
-	if atomic.LoadInt32(&e.workers) == 0 {
+	if !e.DisableWorker && atomic.LoadInt32(&e.workers) == 0 {
 		go e.runWorker(context.Background())
 	}
 	e.lock.Lock()
@@ -56,7 +59,9 @@ func (e *StreamEmitter) runWorker(ctx context.Context) {
 		return
 	}
 	for {
-		e.emitEntries(ctx)
+		if err := e.DrainOnce(ctx); err != nil {
+			Emergent().Error(err).PrintErr("Stream: ")
+		}
 		select {
 		case <-ctx.Done():
 			return
@@ -65,20 +70,25 @@ func (e *StreamEmitter) runWorker(ctx context.Context) {
 	}
 }
 
-func (e *StreamEmitter) emitEntries(ctx context.Context) {
+// Len returns the number of queued entries.
+func (e *StreamEmitter) Len() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.entries.Len()
+}
+
+// DrainOnce streams all queued entries synchronously in the current goroutine.
+func (e *StreamEmitter) DrainOnce(ctx context.Context) error {
 	e.lock.Lock()
 	entryList := e.entries
 	e.entries = list.New()
 	e.lock.Unlock()
 	if entryList.Len() == 0 {
-		return
+		return nil
 	}
 	entries := make([]*logspb.LogEntry, 0, entryList.Len())
 	for elem := entryList.Front(); elem != nil; elem = elem.Next() {
 		entries = append(entries, elem.Value.(*logspb.LogEntry))
 	}
-
-	if err := e.Streamer.StreamLogEntries(ctx, entries); err != nil {
-		Emergent().Error(err).PrintErr("Stream: ")
-	}
+	return e.Streamer.StreamLogEntries(ctx, entries)
 }
@@ -0,0 +1,53 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+type recordingStreamer struct {
+	batches [][]*logspb.LogEntry
+	err     error
+}
+
+func (s *recordingStreamer) StreamLogEntries(ctx context.Context, entries []*logspb.LogEntry) error {
+	s.batches = append(s.batches, entries)
+	return s.err
+}
+
+func TestStreamEmitterDrainOnce(t *testing.T) {
+	streamer := &recordingStreamer{}
+	emitter := NewStreamEmitter(streamer)
+	emitter.DisableWorker = true
+	logger := Root(emitter)
+	logger.Infof("first")
+	logger.Infof("second")
+	if n := emitter.Len(); n != 2 {
+		t.Fatalf("Expect 2 queued entries, got %d", n)
+	}
+	ctx := context.Background()
+	if err := emitter.DrainOnce(ctx); err != nil {
+		t.Fatalf("DrainOnce: %v", err)
+	}
+	if len(streamer.batches) != 1 || len(streamer.batches[0]) != 2 {
+		t.Fatalf("Expect 1 batch of 2 entries, got %v", streamer.batches)
+	}
+	if msg := streamer.batches[0][1].GetMessage(); msg != "second" {
+		t.Errorf("Expect message %q, got %q", "second", msg)
+	}
+	if n := emitter.Len(); n != 0 {
+		t.Errorf("Expect empty queue, got %d", n)
+	}
+	if err := emitter.DrainOnce(ctx); err != nil || len(streamer.batches) != 1 {
+		t.Errorf("Expect no streaming on empty queue, got err=%v, batches=%d", err, len(streamer.batches))
+	}
+
+	streamer.err = errors.New("failure")
+	logger.Infof("third")
+	if err := emitter.DrainOnce(ctx); !errors.Is(err, streamer.err) {
+		t.Errorf("Expect error %v, got %v", streamer.err, err)
+	}
+}
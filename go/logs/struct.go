@@ -0,0 +1,169 @@
+package logs
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	// RedactedValue replaces the values of fields tagged with `log:"redact"`.
+	RedactedValue = "[REDACTED]"
+	// CyclicValue replaces the values referencing themselves.
+	CyclicValue = "[CYCLIC]"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Struct creates an attribute with selected exported fields of a struct in a JSON string.
+// If fields are specified, only those top-level fields (by Go name or JSON name) are included.
+// Fields tagged with `log:"-"` are omitted and `log:"redact"` are masked, including
+// the fields of nested structs, so sensitive values are never logged.
+// Field names follow the `json` tags like JSON.
+// Values referencing themselves are replaced with CyclicValue.
+func Struct(name string, v interface{}, fields ...string) AttributeSetter {
+	var selected map[string]bool
+	if len(fields) > 0 {
+		selected = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			selected[field] = true
+		}
+	}
+	return JSON(name, structVisitor{}.value(reflect.ValueOf(v), selected))
+}
+
+// structVisitor tracks the pointers, maps and slices being visited to detect cycles.
+type structVisitor map[structVisitKey]bool
+
+type structVisitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter marks v being visited, returns false if it's already being visited.
+func (s structVisitor) enter(v reflect.Value) (structVisitKey, bool) {
+	key := structVisitKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if s[key] {
+		return key, false
+	}
+	s[key] = true
+	return key, true
+}
+
+func (s structVisitor) value(v reflect.Value, selected map[string]bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return s.value(v.Elem(), selected)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		key, ok := s.enter(v)
+		if !ok {
+			return CyclicValue
+		}
+		defer delete(s, key)
+		return s.value(v.Elem(), selected)
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		s.fields(v, selected, fields)
+		return fields
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		if v.Kind() == reflect.Slice {
+			key, ok := s.enter(v)
+			if !ok {
+				return CyclicValue
+			}
+			defer delete(s, key)
+		}
+		items := make([]interface{}, v.Len())
+		for n := range items {
+			items[n] = s.value(v.Index(n), nil)
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		key, ok := s.enter(v)
+		if !ok {
+			return CyclicValue
+		}
+		defer delete(s, key)
+		items := make(map[string]interface{}, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			items[iter.Key().String()] = s.value(iter.Value(), nil)
+		}
+		return items
+	}
+	return v.Interface()
+}
+
+func (s structVisitor) fields(v reflect.Value, selected map[string]bool, fields map[string]interface{}) {
+	t := v.Type()
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		logTag := field.Tag.Get("log")
+		if logTag == "-" {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if field.Anonymous && jsonName == "" {
+			fv := v.Field(n)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				if fv.Elem().Kind() == reflect.Struct {
+					// Embedded pointers referencing themselves are omitted.
+					if key, ok := s.enter(fv); ok {
+						s.fields(fv.Elem(), selected, fields)
+						delete(s, key)
+					}
+					continue
+				}
+			} else if fv.Kind() == reflect.Struct {
+				s.fields(fv, selected, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if selected != nil && !selected[field.Name] && !(jsonName != "" && selected[jsonName]) {
+			continue
+		}
+		name := field.Name
+		if jsonName != "" {
+			name = jsonName
+		}
+		if logTag == "redact" {
+			fields[name] = RedactedValue
+			continue
+		}
+		fields[name] = s.value(v.Field(n), nil)
+	}
+}
@@ -0,0 +1,71 @@
+package logs
+
+import (
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+type testCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" log:"redact"`
+}
+
+type testConfig struct {
+	Name    string
+	Addr    string `json:"addr,omitempty"`
+	Token   string `log:"-"`
+	Creds   *testCredentials
+	Backups []testCredentials `json:"backups"`
+	private int
+}
+
+func TestStruct(t *testing.T) {
+	config := &testConfig{
+		Name:    "test",
+		Addr:    "localhost:80",
+		Token:   "secret",
+		Creds:   &testCredentials{User: "admin", Password: "secret"},
+		Backups: []testCredentials{{User: "backup", Password: "secret"}},
+	}
+	testCases := []struct {
+		fields []string
+		json   string
+	}{
+		{
+			json: `{"Creds":{"password":"[REDACTED]","user":"admin"},"Name":"test","addr":"localhost:80","backups":[{"password":"[REDACTED]","user":"backup"}]}`,
+		},
+		{
+			fields: []string{"Name", "addr", "Token"},
+			json:   `{"Name":"test","addr":"localhost:80"}`,
+		},
+	}
+	for _, tc := range testCases {
+		attrs := make(map[string]*logspb.Value)
+		Struct("config", config, tc.fields...).SetAttributes(attrs)
+		if val := attrs["config"].GetJson(); val != tc.json {
+			t.Errorf("Struct(%v) = %s, expect %s", tc.fields, val, tc.json)
+		}
+	}
+}
+
+type testNode struct {
+	Name  string
+	Next  *testNode
+	Items []interface{}
+	*testNode
+}
+
+func TestStructCyclic(t *testing.T) {
+	shared := &testNode{Name: "shared"}
+	node := &testNode{Name: "node", Items: []interface{}{shared, shared, nil}}
+	node.Next = node
+	node.testNode = node
+	node.Items[2] = node.Items
+	attrs := make(map[string]*logspb.Value)
+	Struct("node", node).SetAttributes(attrs)
+	expected := `{"Items":[{"Items":null,"Name":"shared","Next":null},{"Items":null,"Name":"shared","Next":null},"[CYCLIC]"],"Name":"node","Next":"[CYCLIC]"}`
+	if val := attrs["node"].GetJson(); val != expected {
+		t.Errorf("Struct = %s, expect %s", val, expected)
+	}
+}
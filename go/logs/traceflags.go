@@ -0,0 +1,95 @@
+package logs
+
+import (
+	"encoding/binary"
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// Trace flags in SpanContext, compatible with Jaeger flags.
+const (
+	// TraceFlagSampled indicates the trace is sampled.
+	TraceFlagSampled uint32 = 1
+	// TraceFlagDebug indicates the trace is forced to be sampled, e.g. X-B3-Flags: 1.
+	TraceFlagDebug uint32 = 2
+)
+
+// Sampler decides whether a new trace is sampled when its root span starts.
+// The spans with parents inherit the flags from the parents.
+type Sampler func(info SpanInfo) bool
+
+// SampleRatio creates a Sampler sampling the ratio of traces, determined by
+// the trace IDs so the decision is consistent across processes.
+// Only the rightmost 7 bytes of trace IDs are used, which are random in both
+// generated trace IDs and W3C trace IDs.
+func SampleRatio(ratio float64) Sampler {
+	switch {
+	case ratio >= 1:
+		return func(SpanInfo) bool { return true }
+	case ratio <= 0:
+		return func(SpanInfo) bool { return false }
+	}
+	const randomMask = 1<<56 - 1
+	bound := uint64(ratio * randomMask)
+	return func(info SpanInfo) bool {
+		id := info.Context.GetTraceId()
+		if len(id) < 8 {
+			return false
+		}
+		return binary.LittleEndian.Uint64(id[:8])&randomMask < bound
+	}
+}
+
+// IsSampled determines whether the span context is sampled.
+func IsSampled(ctx *logspb.SpanContext) bool {
+	return ctx.GetFlags()&(TraceFlagSampled|TraceFlagDebug) != 0
+}
+
+// IsDebug determines whether the span context has the debug flag.
+func IsDebug(ctx *logspb.SpanContext) bool {
+	return ctx.GetFlags()&TraceFlagDebug != 0
+}
+
+// ParseB3Flags parses the values of B3 X-B3-Sampled and X-B3-Flags into trace flags.
+// Without a sampling decision, the trace is sampled as it's recorded by the receiver.
+func ParseB3Flags(sampled, flags string) uint32 {
+	if flags == "1" || sampled == "d" {
+		return TraceFlagSampled | TraceFlagDebug
+	}
+	switch strings.ToLower(sampled) {
+	case "0", "false":
+		return 0
+	}
+	return TraceFlagSampled
+}
+
+// FormatB3Sampled formats the sampling decision as the value of X-B3-Sampled.
+func FormatB3Sampled(ctx *logspb.SpanContext) string {
+	if IsSampled(ctx) {
+		return "1"
+	}
+	return "0"
+}
+
+// SetSampler sets the Sampler of the logger.
+func (l *Logger) SetSampler(sampler Sampler) *Logger {
+	l.Sampler = sampler
+	return l
+}
+
+// traceFlags determines the flags of a new span. The explicitly set flags
+// are kept, otherwise the flags are inherited from the parent, and the Sampler
+// decides for a root span.
+func (l *Logger) traceFlags(info *SpanInfo) uint32 {
+	if flags := info.Context.GetFlags(); flags != 0 {
+		return flags
+	}
+	if info.Parent != nil {
+		return info.Parent.GetSpanContext().GetFlags()
+	}
+	if l.Sampler == nil || l.Sampler(*info) {
+		return TraceFlagSampled
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package logs
+
+import (
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestTraceFlags(t *testing.T) {
+	never := func(SpanInfo) bool { return false }
+	testCases := []struct {
+		name    string
+		sampler Sampler
+		info    SpanInfo
+		flags   uint32
+	}{
+		{name: "root default", flags: TraceFlagSampled},
+		{name: "root not sampled", sampler: never},
+		{
+			name:    "explicit",
+			sampler: never,
+			info:    SpanInfo{Context: &logspb.SpanContext{Flags: TraceFlagSampled | TraceFlagDebug}},
+			flags:   TraceFlagSampled | TraceFlagDebug,
+		},
+		{
+			name:    "remote sampled",
+			sampler: never,
+			info:    SpanInfoFromTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+			flags:   TraceFlagSampled,
+		},
+		{
+			name: "remote not sampled",
+			info: SpanInfoFromTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"),
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			root := Root(&DummyEmitter{}).SetSampler(tc.sampler)
+			span := root.StartSpan(tc.info)
+			if flags := span.SpanInfo().Context.GetFlags(); flags != tc.flags {
+				t.Fatalf("Expect flags %d, got %d", tc.flags, flags)
+			}
+			child := span.StartSpan(SpanInfo{Name: "child"})
+			if flags := child.SpanInfo().Context.GetFlags(); flags != tc.flags {
+				t.Errorf("Expect child flags %d, got %d", tc.flags, flags)
+			}
+			traceParent := FormatTraceParent(child.SpanInfo().Context)
+			if remote, err := ParseTraceParent(traceParent); err != nil {
+				t.Errorf("ParseTraceParent(%q): %v", traceParent, err)
+			} else if IsSampled(remote.Context) != IsSampled(child.SpanInfo().Context) {
+				t.Errorf("Sampled not propagated in %q", traceParent)
+			}
+		})
+	}
+}
+
+func TestParseB3Flags(t *testing.T) {
+	testCases := []struct {
+		sampled, flags string
+		expected       uint32
+	}{
+		{expected: TraceFlagSampled},
+		{sampled: "1", expected: TraceFlagSampled},
+		{sampled: "true", expected: TraceFlagSampled},
+		{sampled: "0"},
+		{sampled: "false"},
+		{sampled: "d", expected: TraceFlagSampled | TraceFlagDebug},
+		{sampled: "0", flags: "1", expected: TraceFlagSampled | TraceFlagDebug},
+	}
+	for _, tc := range testCases {
+		if flags := ParseB3Flags(tc.sampled, tc.flags); flags != tc.expected {
+			t.Errorf("ParseB3Flags(%q, %q) expect %d, got %d", tc.sampled, tc.flags, tc.expected, flags)
+		}
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	sampler := SampleRatio(0.25)
+	var sampled int
+	for n := 0; n < 1000; n++ {
+		info := SpanInfo{Context: &logspb.SpanContext{TraceId: NewTraceID()}}
+		decision := sampler(info)
+		if sampler(info) != decision {
+			t.Fatalf("Inconsistent decisions for trace %s", info.TraceID())
+		}
+		if decision {
+			sampled++
+		}
+	}
+	if sampled < 150 || sampled > 350 {
+		t.Errorf("Sampled %d of 1000 traces with ratio 0.25", sampled)
+	}
+}
@@ -0,0 +1,121 @@
+package logs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// TraceParentEnv is the conventional environment variable carrying the W3C
+// trace context of the parent process, e.g. set by CI pipelines.
+const TraceParentEnv = "TRACEPARENT"
+
+const (
+	traceParentVersion = "00"
+	traceParentLen     = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	// traceParentSampled is the sampled bit of W3C trace-flags.
+	traceParentSampled = 0x01
+)
+
+// ParseTraceParent parses a W3C traceparent value (version-traceid-spanid-flags)
+// into SpanInfo of the remote span.
+func ParseTraceParent(value string) (SpanInfo, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < traceParentLen || (len(value) > traceParentLen && value[traceParentLen] != '-') {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent: %q", value)
+	}
+	fields := strings.Split(value[:traceParentLen], "-")
+	if len(fields) != 4 || len(fields[0]) != 2 || len(fields[1]) != 32 || len(fields[2]) != 16 || len(fields[3]) != 2 {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent: %q", value)
+	}
+	traceFlags, err := hex.DecodeString(fields[0] + fields[3])
+	if err != nil {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent: %q", value)
+	}
+	if fields[0] == "ff" || (fields[0] == traceParentVersion && len(value) != traceParentLen) {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent version: %q", value)
+	}
+	ctx := &logspb.SpanContext{}
+	if traceFlags[1]&traceParentSampled != 0 {
+		ctx.Flags = TraceFlagSampled
+	}
+	if ctx.TraceId, err = ParseTraceID(fields[1]); err != nil {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent trace ID: %w", err)
+	}
+	if strings.Trim(fields[1], "0") == "" {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent: zero trace ID")
+	}
+	if ctx.SpanId, err = ParseSpanID(fields[2]); err != nil {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent span ID: %w", err)
+	}
+	if ctx.SpanId == 0 {
+		return SpanInfo{}, fmt.Errorf("invalid traceparent: zero span ID")
+	}
+	return SpanInfo{Context: ctx}, nil
+}
+
+// SpanInfoFromTraceParent creates SpanInfo of a new span as a child of the
+// remote span in a W3C traceparent value, like BuildSpanInfoFrom.
+// It returns empty SpanInfo if the value is invalid.
+func SpanInfoFromTraceParent(value string) SpanInfo {
+	remote, err := ParseTraceParent(value)
+	if err != nil {
+		return SpanInfo{}
+	}
+	return SpanInfo{
+		Context: &logspb.SpanContext{TraceId: CopyTraceID(remote.Context.GetTraceId())},
+		Parent: &logspb.Link{
+			SpanContext: remote.Context,
+			Type:        logspb.Link_CHILD_OF,
+		},
+	}
+}
+
+// FormatTraceParent formats the span context as a W3C traceparent value.
+// It returns empty string if the span context is incomplete.
+func FormatTraceParent(ctx *logspb.SpanContext) string {
+	traceID, spanID := TraceIDStringFrom(ctx), SpanIDStringFrom(ctx)
+	if traceID == "" || spanID == "" {
+		return ""
+	}
+	flags := "00"
+	if IsSampled(ctx) {
+		flags = "01"
+	}
+	return traceParentVersion + "-" + traceID + "-" + spanID + "-" + flags
+}
+
+// SpanInfoFromEnv parses the trace context in TraceParentEnv.
+// It returns false if the environment variable is not set or invalid.
+func SpanInfoFromEnv() (SpanInfo, bool) {
+	value := os.Getenv(TraceParentEnv)
+	if value == "" {
+		return SpanInfo{}, false
+	}
+	info, err := ParseTraceParent(value)
+	if err != nil {
+		Emergent().Error(err).PrintErr("SpanInfoFromEnv: ")
+		return SpanInfo{}, false
+	}
+	return info, true
+}
+
+// SetRemoteSpan makes the logger continue a span from another process,
+// e.g. from SpanInfoFromEnv, without emitting span events. The logs are
+// associated with the remote span, and spans started from the logger become
+// its children. It's intended for root loggers.
+func (l *Logger) SetRemoteSpan(info SpanInfo) *Logger {
+	if info.Context != nil {
+		l.span = &SpanInfo{
+			Name:    info.Name,
+			Kind:    info.Kind,
+			Context: info.Context,
+			Parent:  info.Parent,
+			Links:   info.Links,
+		}
+	}
+	return l
+}
@@ -0,0 +1,39 @@
+package logs
+
+import (
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	testCases := []struct {
+		value string
+		valid bool
+	}{
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true},
+		{value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", valid: true},
+		{value: ""},
+		{value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e473-600f067aa0ba902b7-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz"},
+	}
+	for _, tc := range testCases {
+		info, err := ParseTraceParent(tc.value)
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("ParseTraceParent(%q) expect error", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTraceParent(%q): %v", tc.value, err)
+			continue
+		}
+		if got := FormatTraceParent(info.Context); got[3:52] != tc.value[3:52] {
+			t.Errorf("FormatTraceParent %q not match %q", got, tc.value)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// Value kinds used for hashing.
+const (
+	valueHashNil byte = iota
+	valueHashBool
+	valueHashInt
+	valueHashFloat
+	valueHashStr
+	valueHashJSON
+	valueHashProto
+	valueHashDuration
+	valueHashTime
+	valueHashBytes
+	valueHashMap
+	valueHashDecimal
+)
+
+// ValueEqual compares two values.
+// Numeric values are compared across types by their numeric values:
+// an int equals a float/double only if the latter is integral and exactly the
+// same number, and a float is converted to double exactly (so float 0.1 doesn't
+// equal double 0.1). NaN doesn't equal anything, including itself.
+// Str and JSON values are never equal to each other even with the same content,
+// neither are durations and timestamps equal to numeric values.
+// Decimals are compared exactly by their numeric values with other decimals only,
+// e.g. "1.50" equals "1.5".
+// Two nil values (or values with no variant set) are equal.
+func ValueEqual(a, b *logspb.Value) bool {
+	switch va := a.GetValue().(type) {
+	case nil:
+		return b.GetValue() == nil
+	case *logspb.Value_BoolValue:
+		vb, ok := b.GetValue().(*logspb.Value_BoolValue)
+		return ok && va.BoolValue == vb.BoolValue
+	case *logspb.Value_StrValue:
+		vb, ok := b.GetValue().(*logspb.Value_StrValue)
+		return ok && va.StrValue == vb.StrValue
+	case *logspb.Value_Json:
+		vb, ok := b.GetValue().(*logspb.Value_Json)
+		return ok && va.Json == vb.Json
+	case *logspb.Value_Proto:
+		vb, ok := b.GetValue().(*logspb.Value_Proto)
+		return ok && bytes.Equal(va.Proto, vb.Proto)
+	case *logspb.Value_Bytes:
+		vb, ok := b.GetValue().(*logspb.Value_Bytes)
+		return ok && bytes.Equal(va.Bytes, vb.Bytes)
+	case *logspb.Value_MapValue:
+		vb, ok := b.GetValue().(*logspb.Value_MapValue)
+		if !ok || len(va.MapValue.GetValues()) != len(vb.MapValue.GetValues()) {
+			return false
+		}
+		for key, val := range va.MapValue.GetValues() {
+			if other, exists := vb.MapValue.GetValues()[key]; !exists || !ValueEqual(val, other) {
+				return false
+			}
+		}
+		return true
+	case *logspb.Value_Duration:
+		vb, ok := b.GetValue().(*logspb.Value_Duration)
+		return ok && va.Duration == vb.Duration
+	case *logspb.Value_Time:
+		vb, ok := b.GetValue().(*logspb.Value_Time)
+		return ok && va.Time == vb.Time
+	case *logspb.Value_Decimal:
+		vb, ok := b.GetValue().(*logspb.Value_Decimal)
+		if !ok {
+			return false
+		}
+		da, errA := ParseDecimal(va.Decimal)
+		db, errB := ParseDecimal(vb.Decimal)
+		if errA != nil || errB != nil {
+			return va.Decimal == vb.Decimal
+		}
+		return da.Cmp(db) == 0
+	}
+	ia, fa, numA := numericValue(a)
+	ib, fb, numB := numericValue(b)
+	if !numA || !numB {
+		return false
+	}
+	return ia == ib && fa == fb
+}
+
+// ValueHash computes a hash of the value consistent with ValueEqual:
+// values equal by ValueEqual have the same hash.
+func ValueHash(v *logspb.Value) uint64 {
+	h := fnv.New64a()
+	var buf [9]byte
+	switch val := v.GetValue().(type) {
+	case nil:
+		h.Write([]byte{valueHashNil})
+	case *logspb.Value_BoolValue:
+		buf[0], buf[1] = valueHashBool, 0
+		if val.BoolValue {
+			buf[1] = 1
+		}
+		h.Write(buf[:2])
+	case *logspb.Value_StrValue:
+		h.Write([]byte{valueHashStr})
+		h.Write([]byte(val.StrValue))
+	case *logspb.Value_Json:
+		h.Write([]byte{valueHashJSON})
+		h.Write([]byte(val.Json))
+	case *logspb.Value_Proto:
+		h.Write([]byte{valueHashProto})
+		h.Write(val.Proto)
+	case *logspb.Value_Bytes:
+		h.Write([]byte{valueHashBytes})
+		h.Write(val.Bytes)
+	case *logspb.Value_MapValue:
+		h.Write([]byte{valueHashMap})
+		values := val.MapValue.GetValues()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			h.Write([]byte(key))
+			binary.LittleEndian.PutUint64(buf[:8], ValueHash(values[key]))
+			h.Write(buf[:8])
+		}
+	case *logspb.Value_Duration:
+		buf[0] = valueHashDuration
+		binary.LittleEndian.PutUint64(buf[1:], uint64(val.Duration))
+		h.Write(buf[:])
+	case *logspb.Value_Time:
+		buf[0] = valueHashTime
+		binary.LittleEndian.PutUint64(buf[1:], uint64(val.Time))
+		h.Write(buf[:])
+	case *logspb.Value_Decimal:
+		h.Write([]byte{valueHashDecimal})
+		if d, err := ParseDecimal(val.Decimal); err == nil {
+			h.Write([]byte(d.RatString()))
+		} else {
+			h.Write([]byte(val.Decimal))
+		}
+	default:
+		i, f, _ := numericValue(v)
+		if f == 0 {
+			buf[0] = valueHashInt
+			binary.LittleEndian.PutUint64(buf[1:], uint64(i))
+		} else {
+			buf[0] = valueHashFloat
+			binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(f))
+		}
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// numericValue normalizes a numeric value. If the number is integral and
+// fits in int64, it's returned as the int part with zero frac part. Otherwise,
+// the int part is zero and the number is returned as the frac part (NaN is
+// returned as NaN which never equals).
+func numericValue(v *logspb.Value) (int64, float64, bool) {
+	var f float64
+	switch val := v.GetValue().(type) {
+	case *logspb.Value_IntValue:
+		return val.IntValue, 0, true
+	case *logspb.Value_FloatValue:
+		f = float64(val.FloatValue)
+	case *logspb.Value_DoubleValue:
+		f = val.DoubleValue
+	default:
+		return 0, 0, false
+	}
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), 0, true
+	}
+	return 0, f, true
+}
+
+// decimalPattern matches decimal numbers with optional exponent. The exponent
+// is limited to avoid huge numbers being expanded.
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]{1,4})?$`)
+
+// ParseDecimal parses a decimal number exactly, e.g. "-12.50" or "1.5e3".
+func ParseDecimal(str string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(str) {
+		return nil, fmt.Errorf("invalid decimal: %q", str)
+	}
+	d, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %q", str)
+	}
+	return d, nil
+}
@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"math"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestValueEqual(t *testing.T) {
+	intVal := func(v int64) *logspb.Value { return &logspb.Value{Value: &logspb.Value_IntValue{IntValue: v}} }
+	floatVal := func(v float32) *logspb.Value { return &logspb.Value{Value: &logspb.Value_FloatValue{FloatValue: v}} }
+	doubleVal := func(v float64) *logspb.Value { return &logspb.Value{Value: &logspb.Value_DoubleValue{DoubleValue: v}} }
+	strVal := func(v string) *logspb.Value { return &logspb.Value{Value: &logspb.Value_StrValue{StrValue: v}} }
+	decimalVal := func(v string) *logspb.Value { return &logspb.Value{Value: &logspb.Value_Decimal{Decimal: v}} }
+	testCases := []struct {
+		name  string
+		a, b  *logspb.Value
+		equal bool
+	}{
+		{name: "int and integral double", a: intVal(1), b: doubleVal(1), equal: true},
+		{name: "int and fractional double", a: intVal(1), b: doubleVal(1.5)},
+		{name: "int and integral float", a: intVal(-3), b: floatVal(-3), equal: true},
+		{name: "large int and inexact double", a: intVal(1<<53 + 1), b: doubleVal(1 << 53)},
+		{name: "float and exact double", a: floatVal(0.5), b: doubleVal(0.5), equal: true},
+		{name: "float and inexact double", a: floatVal(0.1), b: doubleVal(0.1)},
+		{name: "float and its double", a: floatVal(0.1), b: doubleVal(float64(float32(0.1))), equal: true},
+		{name: "NaN", a: doubleVal(math.NaN()), b: doubleVal(math.NaN())},
+		{name: "NaN float", a: floatVal(float32(math.NaN())), b: floatVal(float32(math.NaN()))},
+		{name: "infinity", a: doubleVal(math.Inf(1)), b: floatVal(float32(math.Inf(1))), equal: true},
+		{name: "decimal trailing zeros", a: decimalVal("1.50"), b: decimalVal("1.5"), equal: true},
+		{name: "decimal exponent", a: decimalVal("1.5e3"), b: decimalVal("1500"), equal: true},
+		{name: "decimal different", a: decimalVal("0.1"), b: decimalVal("0.10000000000000000001")},
+		{name: "decimal and double", a: decimalVal("1.5"), b: doubleVal(1.5)},
+		{name: "str and JSON", a: strVal("1"), b: &logspb.Value{Value: &logspb.Value_Json{Json: "1"}}},
+		{name: "str and int", a: strVal("1"), b: intVal(1)},
+		{name: "duration and int", a: &logspb.Value{Value: &logspb.Value_Duration{Duration: 1}}, b: intVal(1)},
+		{name: "nil and empty", a: nil, b: &logspb.Value{}, equal: true},
+		{name: "nil and str", a: nil, b: strVal("")},
+		{
+			name:  "maps",
+			a:     &logspb.Value{Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: map[string]*logspb.Value{"a": intVal(1), "b": decimalVal("2.0")}}}},
+			b:     &logspb.Value{Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: map[string]*logspb.Value{"a": doubleVal(1), "b": decimalVal("2")}}}},
+			equal: true,
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if equal := ValueEqual(tc.a, tc.b); equal != tc.equal {
+				t.Errorf("Expect ValueEqual(%v, %v) %v, got %v", tc.a, tc.b, tc.equal, equal)
+			}
+			if equal := ValueEqual(tc.b, tc.a); equal != tc.equal {
+				t.Errorf("Expect ValueEqual(%v, %v) %v, got %v", tc.b, tc.a, tc.equal, equal)
+			}
+			if tc.equal && ValueHash(tc.a) != ValueHash(tc.b) {
+				t.Errorf("Expect the same hashes for %v and %v", tc.a, tc.b)
+			}
+		})
+	}
+}
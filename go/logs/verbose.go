@@ -0,0 +1,17 @@
+package logs
+
+import (
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// IsVerbose determines whether the entry is emitted from a context returned by WithLevel.
+func IsVerbose(entry *logspb.LogEntry) bool {
+	return entry.GetVerbose()
+}
+
+// LevelAllowed determines whether an emitter filtering by the min level should
+// emit the entry. Verbose entries (see IsVerbose) are always allowed, as the
+// level has been lowered explicitly.
+func LevelAllowed(entry *logspb.LogEntry, minLevel logspb.LogEntry_Level) bool {
+	return entry.GetLevel() >= minLevel || IsVerbose(entry)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	fileIndexName = "index.json"
+)
+
+// FileIndex records the time range of the rotated files of a client, and is
+// persisted as index.json in the directory of the client.
+type FileIndex struct {
+	Files []FileIndexEntry `json:"files"`
+}
+
+// FileIndexEntry is the time range of a rotated file.
+type FileIndexEntry struct {
+	// Name is the filename without the directory and the compression suffix.
+	Name string `json:"name"`
+	// FirstNs is the timestamp of the first log entry.
+	FirstNs int64 `json:"first_ns"`
+	// LastNs is the timestamp of the last log entry, 0 if unknown.
+	LastNs int64 `json:"last_ns,omitempty"`
+}
+
+// ReadFileIndex reads the index in the directory of a client.
+// An empty index is returned if the index doesn't exist.
+func ReadFileIndex(dir string) (*FileIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, fileIndexName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &FileIndex{}, nil
+		}
+		return nil, err
+	}
+	var index FileIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// Write persists the index in the directory of a client, atomically replacing the existing one.
+func (x *FileIndex) Write(dir string) error {
+	data, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	fn := filepath.Join(dir, fileIndexName)
+	tmpFn := fn + ".tmp"
+	if err := os.WriteFile(tmpFn, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFn, fn)
+}
+
+// Lookup finds the entry of a file, which may be compressed after rotation.
+func (x *FileIndex) Lookup(fn string) (FileIndexEntry, bool) {
+	name := strings.TrimSuffix(filepath.Base(fn), gzipFileSuffix)
+	for _, entry := range x.Files {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return FileIndexEntry{}, false
+}
+
+// add adds or replaces the entry of a file.
+func (x *FileIndex) add(entry FileIndexEntry) {
+	for n := range x.Files {
+		if x.Files[n].Name == entry.Name {
+			x.Files[n] = entry
+			return
+		}
+	}
+	x.Files = append(x.Files, entry)
+}
+
+// remove removes the entries of the files.
+func (x *FileIndex) remove(fns ...string) {
+	names := make(map[string]bool, len(fns))
+	for _, fn := range fns {
+		names[strings.TrimSuffix(filepath.Base(fn), gzipFileSuffix)] = true
+	}
+	files := x.Files[:0]
+	for _, entry := range x.Files {
+		if !names[entry.Name] {
+			files = append(files, entry)
+		}
+	}
+	x.Files = files
+}
+
+// Overlaps determines whether the file may contain log entries in the range
+// [since, before). Zero since or before means unbounded.
+func (e FileIndexEntry) Overlaps(since, before time.Time) bool {
+	if !before.IsZero() && e.FirstNs >= before.UnixNano() {
+		return false
+	}
+	if !since.IsZero() && e.LastNs >= e.FirstNs && e.LastNs < since.UnixNano() {
+		return false
+	}
+	return true
+}
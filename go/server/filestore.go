@@ -4,16 +4,20 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
-	"google.golang.org/protobuf/proto"
-
+	"github.com/evo-cloud/logs/go/blob"
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
 )
 
 const (
@@ -21,6 +25,7 @@ const (
 	DefaultFileSizeLimit = 1 << 26 // 64M
 
 	logFileSuffix   = ".logs.blob"
+	gzipFileSuffix  = ".gz"
 	currentFileName = "current" + logFileSuffix
 	maxRecordBody   = 1 << 24 // 16M
 )
@@ -36,6 +41,24 @@ var (
 type FileStore struct {
 	BaseDir       string
 	FileSizeLimit int64
+	// ClientFileSizeLimits overrides FileSizeLimit for specific clients.
+	ClientFileSizeLimits map[string]int64
+	// RotatedFileName generates the name (without the .logs.blob suffix) of a
+	// rotated file of the client. The file starts with the log at startTime (in
+	// unix nanoseconds). If not specified, the start time in decimal is used.
+	// Leading decimal digits of the name are used to order the files, and
+	// names must be unique for the client, otherwise the existing file is replaced.
+	RotatedFileName func(name string, startTime int64) string
+	// Retention policy per client enforced by Prune, 0 means no limit.
+	// MaxTotalBytes limits the size of all files including the current one,
+	// MaxFileAge limits the time since a rotated file was last modified, and
+	// MaxFiles limits the number of rotated files.
+	MaxTotalBytes int64
+	MaxFileAge    time.Duration
+	MaxFiles      int
+	// FormatVersion is the blob format version of the records,
+	// blob.DefaultFormatVersion if 0. Records of all versions are readable.
+	FormatVersion int
 
 	writersLock sync.Mutex
 	writers     map[string]*fileBatchWriter
@@ -50,6 +73,7 @@ type fileBatchWriter struct {
 	lock      sync.Mutex
 	file      *os.File
 	startTime int64
+	lastTime  int64
 	size      int64
 }
 
@@ -77,6 +101,9 @@ func (s *FileStore) WriteBatch(ctx context.Context, name string) (BatchWriter, e
 	defer s.writersLock.Unlock()
 	w := s.writers[name]
 	if w == nil {
+		if s.writers == nil {
+			s.writers = make(map[string]*fileBatchWriter)
+		}
 		w = &fileBatchWriter{store: s, name: name, dir: filepath.Join(s.BaseDir, name)}
 		s.writers[name] = w
 	}
@@ -84,6 +111,227 @@ func (s *FileStore) WriteBatch(ctx context.Context, name string) (BatchWriter, e
 	return &fileBatchWriterRef{fileBatchWriter: w}, nil
 }
 
+// Rotate forces the current file of the client to be rotated.
+func (s *FileStore) Rotate(name string) error {
+	ref, err := s.WriteBatch(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	defer ref.Close()
+	return ref.(*fileBatchWriterRef).rotate()
+}
+
+// RotateAll rotates the current files of all clients.
+func (s *FileStore) RotateAll() error {
+	dirEntries, err := os.ReadDir(s.BaseDir)
+	if err != nil {
+		return err
+	}
+	for _, ent := range dirEntries {
+		if !ent.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.BaseDir, ent.Name(), currentFileName)); err != nil {
+			continue
+		}
+		if err := s.Rotate(ent.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes the oldest rotated files of all clients until the retention
+// policy is met. The current files are never deleted.
+func (s *FileStore) Prune(ctx context.Context) error {
+	if s.MaxTotalBytes <= 0 && s.MaxFileAge <= 0 && s.MaxFiles <= 0 {
+		return nil
+	}
+	dirEntries, err := os.ReadDir(s.BaseDir)
+	if err != nil {
+		return err
+	}
+	for _, ent := range dirEntries {
+		if !ent.IsDir() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.pruneClient(ent.Name()); err != nil {
+			return fmt.Errorf("prune %q: %w", ent.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) pruneClient(name string) error {
+	// The files are not changed by writers while pruning, as the writer
+	// (if any) rotates files with its lock held.
+	s.writersLock.Lock()
+	defer s.writersLock.Unlock()
+	if w := s.writers[name]; w != nil {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+	}
+
+	files, err := s.Files(name)
+	if err != nil {
+		return err
+	}
+	type rotatedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var rotated []rotatedFile
+	var totalBytes int64
+	for _, fn := range files {
+		info, err := os.Stat(fn)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		totalBytes += info.Size()
+		if filepath.Base(fn) != currentFileName {
+			rotated = append(rotated, rotatedFile{path: fn, size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+	now := time.Now()
+	var deleted []string
+	for ; len(rotated) > 0; rotated = rotated[1:] {
+		oldest := rotated[0]
+		if (s.MaxFiles <= 0 || len(rotated) <= s.MaxFiles) &&
+			(s.MaxTotalBytes <= 0 || totalBytes <= s.MaxTotalBytes) &&
+			(s.MaxFileAge <= 0 || now.Sub(oldest.modTime) <= s.MaxFileAge) {
+			break
+		}
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		totalBytes -= oldest.size
+		deleted = append(deleted, oldest.path)
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+	dir := filepath.Join(s.BaseDir, name)
+	index, err := ReadFileIndex(dir)
+	if err != nil {
+		return err
+	}
+	index.remove(deleted...)
+	return index.Write(dir)
+}
+
+func (s *FileStore) fileSizeLimit(name string) int64 {
+	if limit, ok := s.ClientFileSizeLimits[name]; ok {
+		return limit
+	}
+	return s.FileSizeLimit
+}
+
+func (s *FileStore) rotatedFileName(name string, startTime int64) string {
+	if fn := s.RotatedFileName; fn != nil {
+		return fn(name, startTime)
+	}
+	return strconv.FormatInt(startTime, 10)
+}
+
+// Files returns the log files of a client in time order.
+// The current file being written is the last one.
+func (s *FileStore) Files(name string) ([]string, error) {
+	return ListLogFiles(filepath.Join(s.BaseDir, name))
+}
+
+// FilesForRange returns the log files of a client in time order, which may
+// contain log entries in the range [since, before). Zero since or before means unbounded.
+// Rotated files not found in the index are always included.
+func (s *FileStore) FilesForRange(name string, since, before time.Time) ([]string, error) {
+	dir := filepath.Join(s.BaseDir, name)
+	files, err := ListLogFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	index, err := ReadFileIndex(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	selected := files[:0]
+	for _, fn := range files {
+		if filepath.Base(fn) == currentFileName {
+			if !before.IsZero() {
+				if entry, err := readFirstLogEntry(fn); err == nil && entry.GetNanoTs() >= before.UnixNano() {
+					continue
+				}
+			}
+		} else if entry, ok := index.Lookup(fn); ok && !entry.Overlaps(since, before) {
+			continue
+		}
+		selected = append(selected, fn)
+	}
+	return selected, nil
+}
+
+func readFirstLogEntry(fn string) (*logspb.LogEntry, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readRecordAndDecode(f)
+}
+
+// ListLogFiles lists log files written by FileStore in a directory in time order.
+// Rotated files, including the compressed ones (with .gz suffix), are ordered by
+// the start time in the leading digits of the filename, and the current file is the last one.
+func ListLogFiles(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type rotatedFile struct {
+		name      string
+		startTime int64
+	}
+	var rotated []rotatedFile
+	var current string
+	for _, ent := range dirEntries {
+		if ent.IsDir() {
+			continue
+		}
+		fn := ent.Name()
+		if fn == currentFileName {
+			current = fn
+			continue
+		}
+		base := strings.TrimSuffix(fn, gzipFileSuffix)
+		if !strings.HasSuffix(base, logFileSuffix) {
+			continue
+		}
+		prefix := strings.TrimSuffix(base, logFileSuffix)
+		digits := len(prefix) - len(strings.TrimLeft(prefix, "0123456789"))
+		startTime, _ := strconv.ParseInt(prefix[:digits], 10, 64)
+		rotated = append(rotated, rotatedFile{name: fn, startTime: startTime})
+	}
+	sort.SliceStable(rotated, func(i, j int) bool {
+		if rotated[i].startTime != rotated[j].startTime {
+			return rotated[i].startTime < rotated[j].startTime
+		}
+		return rotated[i].name < rotated[j].name
+	})
+	files := make([]string, 0, len(rotated)+1)
+	for _, f := range rotated {
+		files = append(files, filepath.Join(dir, f.name))
+	}
+	if current != "" {
+		files = append(files, filepath.Join(dir, current))
+	}
+	return files, nil
+}
+
 func (w *fileBatchWriterRef) WriteLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
 	writer := w.fileBatchWriter
 	if writer == nil {
@@ -103,7 +351,7 @@ func (w *fileBatchWriterRef) Close() error {
 }
 
 func (w *fileBatchWriter) writeLogEntry(entry *logspb.LogEntry) error {
-	rec, err := encodeLogEntry(entry)
+	rec, err := encodeLogEntry(entry, w.store.FormatVersion)
 	if err != nil {
 		return err
 	}
@@ -115,16 +363,19 @@ func (w *fileBatchWriter) writeLogEntry(entry *logspb.LogEntry) error {
 		if err := w.currentFile(); err != nil {
 			return err
 		}
-		if w.startTime == 0 {
-			w.startTime = entry.GetNanoTs()
-		}
 	}
 
-	if w.size+int64(recSize) > w.store.FileSizeLimit {
+	if w.size+int64(recSize) > w.store.fileSizeLimit(w.name) {
 		if err := w.rotateFile(); err != nil {
 			return err
 		}
 	}
+	if w.startTime == 0 {
+		w.startTime = entry.GetNanoTs()
+	}
+	if ts := entry.GetNanoTs(); ts > w.lastTime {
+		w.lastTime = ts
+	}
 
 	if _, err := w.file.Write(rec.head); err != nil {
 		return err
@@ -139,6 +390,7 @@ func (w *fileBatchWriter) writeLogEntry(entry *logspb.LogEntry) error {
 		return err
 	}
 	w.size += int64(recSize)
+	logs.ObserveDurable("filestore", entry)
 	return nil
 }
 
@@ -169,6 +421,11 @@ func (w *fileBatchWriter) currentFile() error {
 			return err
 		}
 		w.startTime = entry.GetNanoTs()
+		// The last time is unknown (0) if the last record is partially written.
+		w.lastTime = 0
+		if entry, err := readLastRecordAndDecode(f, info.Size()); err == nil {
+			w.lastTime = entry.GetNanoTs()
+		}
 	}
 	pos, err := f.Seek(0, os.SEEK_END)
 	if err != nil {
@@ -179,24 +436,55 @@ func (w *fileBatchWriter) currentFile() error {
 	return nil
 }
 
+func (w *fileBatchWriterRef) rotate() error {
+	writer := w.fileBatchWriter
+	if writer == nil {
+		return ErrWriterClosed
+	}
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if writer.file == nil {
+		if err := writer.currentFile(); err != nil {
+			return err
+		}
+	}
+	if writer.size == 0 {
+		return nil
+	}
+	return writer.rotateFile()
+}
+
 func (w *fileBatchWriter) rotateFile() error {
 	fn := filepath.Join(w.dir, currentFileName)
 	if w.file != nil {
 		w.file.Close()
 		w.file, w.size = nil, 0
-		rotatedFn := filepath.Join(w.dir, strconv.FormatInt(w.startTime, 10)+logFileSuffix)
+		rotatedFn := filepath.Join(w.dir, w.store.rotatedFileName(w.name, w.startTime)+logFileSuffix)
 		if err := os.Rename(fn, rotatedFn); err != nil {
 			return err
 		}
+		if err := w.addToIndex(FileIndexEntry{Name: filepath.Base(rotatedFn), FirstNs: w.startTime, LastNs: w.lastTime}); err != nil {
+			// The file is still found without the index, not failing the rotation.
+			logs.Emergent().Error(err).PrintErrf("FileStore index %q: ", w.name)
+		}
 	}
 	f, err := os.Create(fn)
 	if err != nil {
 		return err
 	}
-	w.file = f
+	w.file, w.startTime, w.lastTime = f, 0, 0
 	return nil
 }
 
+func (w *fileBatchWriter) addToIndex(entry FileIndexEntry) error {
+	index, err := ReadFileIndex(w.dir)
+	if err != nil {
+		return err
+	}
+	index.add(entry)
+	return index.Write(w.dir)
+}
+
 func (w *fileBatchWriter) deref() {
 	if atomic.AddInt32(&w.ref, -1) == 0 {
 		if w.file != nil {
@@ -211,18 +499,21 @@ func (w *fileBatchWriter) deref() {
 	}
 }
 
-func encodeLogEntry(entry *logspb.LogEntry) (*encodedRecord, error) {
-	encoded, err := proto.Marshal(entry)
+func encodeLogEntry(entry *logspb.LogEntry, version int) (*encodedRecord, error) {
+	if version == 0 {
+		version = blob.DefaultFormatVersion
+	}
+	// The records are framed as in blob files, so the files can be read as blob files.
+	rec, err := blob.EncodeToRawRecordVersion(entry, version, false)
 	if err != nil {
 		return nil, err
 	}
-	rec := &encodedRecord{
-		head: make([]byte, 4),
-		body: encoded,
-	}
-	binary.LittleEndian.PutUint32(rec.head, uint32(len(encoded)))
-	rec.tail = rec.head
-	return rec, nil
+	return &encodedRecord{head: rec.Head, body: rec.Body, tail: rec.Tail}, nil
+}
+
+// bodyPaddings returns the number of paddings after a body of the size in blob records.
+func bodyPaddings(size int64) int64 {
+	return (4 - size&3) & 3
 }
 
 func readRecord(r io.Reader) (*encodedRecord, error) {
@@ -241,21 +532,52 @@ func readRecord(r io.Reader) (*encodedRecord, error) {
 	}
 	rec.tail = rec.body[size:]
 	rec.body = rec.body[:size]
-	tailSize := int64(binary.LittleEndian.Uint32(rec.tail))
-	if tailSize != size {
+	if int64(binary.LittleEndian.Uint32(rec.tail)) == size {
+		// Not padded, written before the records are framed as in blob files.
+		return &rec, nil
+	}
+	paddings := bodyPaddings(size)
+	if paddings == 0 {
+		return nil, ErrInvalidData
+	}
+	rec.tail = append(rec.tail, make([]byte, paddings)...)
+	if _, err := io.ReadFull(r, rec.tail[4:]); err != nil {
+		return nil, err
+	}
+	if int64(binary.LittleEndian.Uint32(rec.tail[paddings:])) != size {
 		return nil, ErrInvalidData
 	}
 	return &rec, nil
 }
 
+// readLastRecordAndDecode reads the last record using the size in the tail.
+func readLastRecordAndDecode(r io.ReaderAt, fileSize int64) (*logspb.LogEntry, error) {
+	tail := make([]byte, 4)
+	if _, err := r.ReadAt(tail, fileSize-4); err != nil {
+		return nil, err
+	}
+	size := int64(binary.LittleEndian.Uint32(tail))
+	if size > maxRecordBody {
+		return nil, ErrInvalidData
+	}
+	// Try the padded record first, and then the record not padded.
+	err := ErrInvalidData
+	for _, recSize := range []int64{size + bodyPaddings(size) + 8, size + 8} {
+		if recSize > fileSize {
+			continue
+		}
+		var entry *logspb.LogEntry
+		if entry, err = readRecordAndDecode(io.NewSectionReader(r, fileSize-recSize, recSize)); err == nil {
+			return entry, nil
+		}
+	}
+	return nil, err
+}
+
 func readRecordAndDecode(r io.Reader) (*logspb.LogEntry, error) {
 	rec, err := readRecord(r)
 	if err != nil {
 		return nil, err
 	}
-	var pb logspb.LogEntry
-	if err := proto.Unmarshal(rec.body, &pb); err != nil {
-		return nil, err
-	}
-	return &pb, nil
+	return blob.DecodeBody(rec.body)
 }
@@ -0,0 +1,418 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+func TestFileStorePrune(t *testing.T) {
+	now := time.Now()
+	// Files of the client with sizes and ages, the names are deliberately not
+	// in the order of ages to make sure the start times decide the order.
+	type testFile struct {
+		name string
+		size int
+		age  time.Duration
+	}
+	files := []testFile{
+		{name: "300.logs.blob", size: 100, age: 3 * time.Hour},
+		{name: "100.logs.blob.gz", size: 50, age: 5 * time.Hour},
+		{name: "200-host.logs.blob", size: 100, age: 4 * time.Hour},
+		{name: "400.logs.blob", size: 100, age: time.Hour},
+		{name: currentFileName, size: 100, age: 100 * time.Hour},
+	}
+	testCases := []struct {
+		name          string
+		maxTotalBytes int64
+		maxFileAge    time.Duration
+		maxFiles      int
+		remaining     []string
+	}{
+		{
+			name:      "no limits",
+			remaining: []string{"100.logs.blob.gz", "200-host.logs.blob", "300.logs.blob", "400.logs.blob", currentFileName},
+		},
+		{
+			name:      "max files",
+			maxFiles:  2,
+			remaining: []string{"300.logs.blob", "400.logs.blob", currentFileName},
+		},
+		{
+			name:          "max total bytes",
+			maxTotalBytes: 300,
+			remaining:     []string{"300.logs.blob", "400.logs.blob", currentFileName},
+		},
+		{
+			name:          "max total bytes exceeded by current",
+			maxTotalBytes: 50,
+			remaining:     []string{currentFileName},
+		},
+		{
+			name:       "max file age",
+			maxFileAge: 150 * time.Minute,
+			remaining:  []string{"400.logs.blob", currentFileName},
+		},
+		{
+			name:          "combined",
+			maxTotalBytes: 400,
+			maxFileAge:    210 * time.Minute,
+			maxFiles:      3,
+			remaining:     []string{"300.logs.blob", "400.logs.blob", currentFileName},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			baseDir := t.TempDir()
+			dir := filepath.Join(baseDir, "client")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+			for _, f := range files {
+				fn := filepath.Join(dir, f.name)
+				if err := os.WriteFile(fn, make([]byte, f.size), 0644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+				modTime := now.Add(-f.age)
+				if err := os.Chtimes(fn, modTime, modTime); err != nil {
+					t.Fatalf("Chtimes: %v", err)
+				}
+			}
+			store := NewFileStore(baseDir)
+			store.MaxTotalBytes, store.MaxFileAge, store.MaxFiles = tc.maxTotalBytes, tc.maxFileAge, tc.maxFiles
+			if err := store.Prune(context.Background()); err != nil {
+				t.Fatalf("Prune: %v", err)
+			}
+			if remaining := clientFileNames(t, store, "client"); !reflect.DeepEqual(remaining, tc.remaining) {
+				t.Errorf("Expect remaining files %v, got %v", tc.remaining, remaining)
+			}
+		})
+	}
+}
+
+func TestFileStorePruneWhileWriting(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	store.MaxFiles = 1
+	ctx := context.Background()
+	w, err := store.WriteBatch(ctx, "client")
+	if err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	defer w.Close()
+	for n := 1; n <= 3; n++ {
+		if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: int64(n), Message: "entry"}); err != nil {
+			t.Fatalf("WriteLogEntry: %v", err)
+		}
+		if err := store.Rotate("client"); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+	if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: 4, Message: "entry"}); err != nil {
+		t.Fatalf("WriteLogEntry: %v", err)
+	}
+	if err := store.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	expected := []string{"3.logs.blob", currentFileName}
+	if remaining := clientFileNames(t, store, "client"); !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("Expect remaining files %v, got %v", expected, remaining)
+	}
+	if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: 5, Message: "entry"}); err != nil {
+		t.Errorf("WriteLogEntry after Prune: %v", err)
+	}
+}
+
+func clientFileNames(t *testing.T, store *FileStore, name string) []string {
+	t.Helper()
+	files, err := store.Files(name)
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, fn := range files {
+		names = append(names, filepath.Base(fn))
+	}
+	return names
+}
+
+func TestFileStoreIndex(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	ctx := context.Background()
+	// Each batch is written with a new writer to cover reopening the current file.
+	batches := [][]int64{{10, 15}, {20}, {30, 40}, {50, 60}}
+	for n, batch := range batches {
+		w, err := store.WriteBatch(ctx, "client")
+		if err != nil {
+			t.Fatalf("WriteBatch: %v", err)
+		}
+		for _, ts := range batch {
+			if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: ts, Message: "entry"}); err != nil {
+				t.Fatalf("WriteLogEntry: %v", err)
+			}
+		}
+		w.Close()
+		if n == 1 || n == 2 {
+			if err := store.Rotate("client"); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+		}
+	}
+
+	index, err := ReadFileIndex(filepath.Join(store.BaseDir, "client"))
+	if err != nil {
+		t.Fatalf("ReadFileIndex: %v", err)
+	}
+	expectedIndex := []FileIndexEntry{
+		{Name: "10.logs.blob", FirstNs: 10, LastNs: 20},
+		{Name: "30.logs.blob", FirstNs: 30, LastNs: 40},
+	}
+	if !reflect.DeepEqual(index.Files, expectedIndex) {
+		t.Errorf("Expect index %v, got %v", expectedIndex, index.Files)
+	}
+
+	testCases := []struct {
+		name     string
+		since    int64
+		before   int64
+		expected []string
+	}{
+		{name: "unbounded", expected: []string{"10.logs.blob", "30.logs.blob", currentFileName}},
+		{name: "first file", since: 12, before: 25, expected: []string{"10.logs.blob"}},
+		{name: "last entry of file", since: 20, before: 21, expected: []string{"10.logs.blob"}},
+		{name: "between files", since: 21, before: 30, expected: []string{}},
+		{name: "before excluded", since: 0, before: 30, expected: []string{"10.logs.blob"}},
+		{name: "since", since: 35, expected: []string{"30.logs.blob", currentFileName}},
+		{name: "current", since: 41, before: 100, expected: []string{currentFileName}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var since, before time.Time
+			if tc.since != 0 {
+				since = time.Unix(0, tc.since)
+			}
+			if tc.before != 0 {
+				before = time.Unix(0, tc.before)
+			}
+			files, err := store.FilesForRange("client", since, before)
+			if err != nil {
+				t.Fatalf("FilesForRange: %v", err)
+			}
+			names := make([]string, 0, len(files))
+			for _, fn := range files {
+				names = append(names, filepath.Base(fn))
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Expect files %v, got %v", tc.expected, names)
+			}
+		})
+	}
+
+	// Files not in the index are always included, and pruned files are removed from the index.
+	if err := os.WriteFile(filepath.Join(store.BaseDir, "client", "5.logs.blob"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	files, err := store.FilesForRange("client", time.Unix(0, 35), time.Time{})
+	if err != nil {
+		t.Fatalf("FilesForRange: %v", err)
+	}
+	if len(files) != 3 || filepath.Base(files[0]) != "5.logs.blob" {
+		t.Errorf("Expect unindexed file included, got %v", files)
+	}
+	store.MaxFiles = 1
+	if err := store.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if index, err = ReadFileIndex(filepath.Join(store.BaseDir, "client")); err != nil {
+		t.Fatalf("ReadFileIndex: %v", err)
+	}
+	if !reflect.DeepEqual(index.Files, expectedIndex[1:]) {
+		t.Errorf("Expect index %v after Prune, got %v", expectedIndex[1:], index.Files)
+	}
+}
+
+func TestFileStoreFormatVersion(t *testing.T) {
+	for _, version := range []int{0, 1, 2} {
+		store := NewFileStore(t.TempDir())
+		store.FormatVersion = version
+		ctx := context.Background()
+		// Each batch is written with a new writer to cover reading the first entry when reopening.
+		for _, ts := range []int64{10, 20, 30} {
+			w, err := store.WriteBatch(ctx, "client")
+			if err != nil {
+				t.Fatalf("Version %d: WriteBatch: %v", version, err)
+			}
+			if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: ts, Message: "entry"}); err != nil {
+				t.Fatalf("Version %d: WriteLogEntry: %v", version, err)
+			}
+			w.Close()
+			if ts == 20 {
+				if err := store.Rotate("client"); err != nil {
+					t.Fatalf("Version %d: Rotate: %v", version, err)
+				}
+			}
+		}
+		files, err := store.Files("client")
+		if err != nil {
+			t.Fatalf("Version %d: Files: %v", version, err)
+		}
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("Version %d: ReadFile: %v", version, err)
+		}
+		// The first byte of the body is the version byte or the tag of nano_ts.
+		expected := byte(0x08)
+		if version == 2 {
+			expected = 2
+		}
+		if len(data) < 5 || data[4] != expected {
+			t.Errorf("Version %d: expect body starting with %02x, got %x", version, expected, data)
+		}
+		r := &storeFilesReader{files: files}
+		var timestamps []int64
+		for {
+			entry, err := r.Read(ctx)
+			if err != nil {
+				t.Fatalf("Version %d: Read: %v", version, err)
+			}
+			if entry == nil {
+				break
+			}
+			timestamps = append(timestamps, entry.GetNanoTs())
+		}
+		r.Close()
+		if expected := []int64{10, 20, 30}; !reflect.DeepEqual(timestamps, expected) {
+			t.Errorf("Version %d: expect entries %v, got %v", version, expected, timestamps)
+		}
+	}
+}
+
+func TestFileStoreReadAsBlobFiles(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	ctx := context.Background()
+	// Messages of different lengths cover all paddings. Each entry is written
+	// with a new writer to cover reading the last entry when reopening.
+	var expected []int64
+	for n := 1; n <= 6; n++ {
+		w, err := store.WriteBatch(ctx, "client")
+		if err != nil {
+			t.Fatalf("WriteBatch: %v", err)
+		}
+		ts := int64(n * 10)
+		if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: ts, Message: strings.Repeat("x", n)}); err != nil {
+			t.Fatalf("WriteLogEntry: %v", err)
+		}
+		expected = append(expected, ts)
+		w.Close()
+		if n == 3 {
+			if err := store.Rotate("client"); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+		}
+	}
+	dir := filepath.Join(store.BaseDir, "client")
+	index, err := ReadFileIndex(dir)
+	if err != nil {
+		t.Fatalf("ReadFileIndex: %v", err)
+	}
+	if expectedIndex := []FileIndexEntry{{Name: "10.logs.blob", FirstNs: 10, LastNs: 30}}; !reflect.DeepEqual(index.Files, expectedIndex) {
+		t.Errorf("Expect index %v, got %v", expectedIndex, index.Files)
+	}
+
+	// Read the directory the same way as the logs command.
+	files, err := ListLogFiles(dir)
+	if err != nil {
+		t.Fatalf("ListLogFiles: %v", err)
+	}
+	r := source.NewFiles(files...)
+	defer r.Close()
+	var timestamps []int64
+	for {
+		entry, err := r.Read(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		timestamps = append(timestamps, entry.GetNanoTs())
+	}
+	if !reflect.DeepEqual(timestamps, expected) {
+		t.Errorf("Expect entries %v, got %v", expected, timestamps)
+	}
+}
+
+func TestFileStoreReadUnpaddedRecords(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	dir := filepath.Join(store.BaseDir, "client")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	// Records written before they are framed as in blob files.
+	var data []byte
+	for n := 1; n <= 3; n++ {
+		body, err := proto.Marshal(&logspb.LogEntry{NanoTs: int64(n), Message: strings.Repeat("x", n)})
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		size := binary.LittleEndian.AppendUint32(nil, uint32(len(body)))
+		data = append(append(append(data, size...), body...), size...)
+	}
+	if err := os.WriteFile(filepath.Join(dir, currentFileName), data, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// Reopening reads the first and the last entries.
+	ctx := context.Background()
+	w, err := store.WriteBatch(ctx, "client")
+	if err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: 4, Message: "x"}); err != nil {
+		t.Fatalf("WriteLogEntry: %v", err)
+	}
+	w.Close()
+	if err := store.Rotate("client"); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	index, err := ReadFileIndex(dir)
+	if err != nil {
+		t.Fatalf("ReadFileIndex: %v", err)
+	}
+	if expectedIndex := []FileIndexEntry{{Name: "1.logs.blob", FirstNs: 1, LastNs: 4}}; !reflect.DeepEqual(index.Files, expectedIndex) {
+		t.Errorf("Expect index %v, got %v", expectedIndex, index.Files)
+	}
+	files, err := store.Files("client")
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	r := &storeFilesReader{files: files}
+	defer r.Close()
+	var timestamps []int64
+	for {
+		entry, err := r.Read(ctx)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if entry == nil {
+			break
+		}
+		timestamps = append(timestamps, entry.GetNanoTs())
+	}
+	if expected := []int64{1, 2, 3, 4}; !reflect.DeepEqual(timestamps, expected) {
+		t.Errorf("Expect entries %v, got %v", expected, timestamps)
+	}
+}
@@ -3,6 +3,8 @@ package hub
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 
@@ -10,22 +12,45 @@ import (
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/source"
 )
 
 // Connector connects the hub and streams logs to the emitter.
 type Connector struct {
 	Emitter logs.LogEmitter
+	// Filters are evaluated by the hub to select the log entries sent to
+	// this connector, in the syntax of source.ParseFilters.
+	Filters []string
 }
 
 func (c *Connector) DialAndStream(network, addr string) error {
+	// Validate the filters locally, as the hub simply closes the connection on invalid filters.
+	if _, err := source.ParseFilters(c.Filters...); err != nil {
+		return err
+	}
 	conn, err := net.Dial(network, addr)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
+	if err := c.Handshake(conn); err != nil {
+		return err
+	}
 	return c.Stream(conn)
 }
 
+// Handshake sends the handshake to the hub right after connecting.
+func (c *Connector) Handshake(w io.Writer) error {
+	data, err := json.Marshal(&handshake{Filters: c.Filters})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("send handshake: %w", err)
+	}
+	return nil
+}
+
 func (c *Connector) Stream(r io.Reader) error {
 	defer func() {
 		if closer, ok := r.(io.Closer); ok {
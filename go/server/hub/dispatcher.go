@@ -1,66 +1,165 @@
 package hub
 
 import (
+	"bufio"
 	"context"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
 	"github.com/evo-cloud/logs/go/server"
+	"github.com/evo-cloud/logs/go/source"
 )
 
+const (
+	defaultDrainTimeout     = 5 * time.Second
+	defaultHandshakeTimeout = time.Second
+	defaultMaxPending       = 1024
+	maxHandshakeSize        = 1 << 16
+)
+
+// ErrDispatcherClosed is returned by Serve and WriteBatch after Shutdown.
+var ErrDispatcherClosed = errors.New("dispatcher closed")
+
 // Dispatcher dispatches logs to connected clients.
 type Dispatcher struct {
 	Emitter logs.LogEmitter
+	// DrainTimeout limits the time waiting for in-flight batches before
+	// closing the connections when Serve fails on accepting connections.
+	DrainTimeout time.Duration
+	// HandshakeTimeout limits the time waiting for the handshake from a new
+	// connection. Connections not sending a handshake in time receive all logs.
+	HandshakeTimeout time.Duration
+	// MaxPending limits the number of entries buffered for a connection.
+	// When a client can't keep up, the oldest entries are dropped.
+	MaxPending int
+	// WriteTimeout, if positive, closes a connection when writing to it is
+	// blocked longer than the timeout.
+	WriteTimeout time.Duration
 
 	connsLock sync.RWMutex
-	conns     map[net.Conn]struct{}
+	conns     map[net.Conn]*connection
+	listeners map[net.Listener]struct{}
+	shutdown  bool
+	// writers tracks the in-flight batch writers.
+	writers sync.WaitGroup
+}
+
+// ConnStats is the statistics of a connection.
+type ConnStats struct {
+	RemoteAddr string
+	// Pending is the number of entries buffered for the connection.
+	Pending int
+	// Dropped is the number of entries dropped as the client can't keep up.
+	Dropped int64
+}
+
+// connEncoding is the encoding of log entries sent to a connection.
+type connEncoding int
+
+const (
+	// encodingWire is the proto encoding prefixed by a 4-byte big-endian length.
+	encodingWire connEncoding = iota
+	// encodingJSON is the JSON encoding, one entry per write.
+	encodingJSON
+	numEncodings
+)
+
+// connection is a connected client.
+type connection struct {
+	net.Conn
+	// filter selects the log entries sent to the client, nil for all.
+	filter source.LogEntryFilter
+	// ready indicates the handshake is completed.
+	ready      bool
+	remoteAddr string
+	encoding   connEncoding
+
+	writeTimeout time.Duration
+	// pending buffers the encoded entries written by writeLoop.
+	pending   chan []byte
+	dropped   int64
+	closeOnce sync.Once
+	// closed is closed when the connection is closed.
+	closed chan struct{}
+	// flush is closed to stop writeLoop after writing all pending entries.
+	flush chan struct{}
+	// stopped is closed when writeLoop exits.
+	stopped chan struct{}
 }
 
 type batchWriter struct {
 	*Dispatcher
-	conns  []net.Conn
-	lenBuf [4]byte
+	conns  []*connection
+	closed bool
 }
 
+// handshake is sent by the client as a single line of JSON right after connecting.
+type handshake struct {
+	// Filters are parsed by source.ParseFilters.
+	Filters []string `json:"filters,omitempty"`
+}
+
+// Serve accepts connections from ln and dispatches logs to them.
+// On errors accepting connections, the dispatcher is shut down gracefully.
+// After Shutdown, it returns ErrDispatcherClosed.
 func (d *Dispatcher) Serve(ln net.Listener) error {
-	defer func() {
-		d.connsLock.Lock()
-		conns := d.conns
-		d.conns = nil
+	defer ln.Close()
+	d.connsLock.Lock()
+	if d.shutdown {
 		d.connsLock.Unlock()
-		for conn := range conns {
-			conn.Close()
-		}
-		ln.Close()
-	}()
+		return ErrDispatcherClosed
+	}
+	if d.listeners == nil {
+		d.listeners = make(map[net.Listener]struct{})
+	}
+	d.listeners[ln] = struct{}{}
+	d.connsLock.Unlock()
 	ctx := context.Background()
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
+			if d.isShutdown() {
+				return ErrDispatcherClosed
+			}
+			timeout := d.DrainTimeout
+			if timeout <= 0 {
+				timeout = defaultDrainTimeout
+			}
+			drainCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			d.Shutdown(drainCtx)
 			return err
 		}
-		d.connsLock.Lock()
-		if d.conns == nil {
-			d.conns = make(map[net.Conn]struct{})
+		c := d.addConn(conn, conn.RemoteAddr().String(), encodingWire)
+		if c == nil {
+			conn.Close()
+			return ErrDispatcherClosed
 		}
-		d.conns[conn] = struct{}{}
-		d.connsLock.Unlock()
 		go func(conn net.Conn) {
 			_, log := logs.StartSpan(ctx, "Serve", logs.Str("remote-addr", conn.RemoteAddr().String()))
 			defer log.EndSpan()
-			defer func() {
-				d.connsLock.Lock()
-				delete(d.conns, conn)
-				d.connsLock.Unlock()
-			}()
+			defer d.removeConn(log, c)
+			r := bufio.NewReaderSize(conn, maxHandshakeSize)
+			filter, err := d.readHandshake(conn, r)
+			if err != nil {
+				log.Warning(err).PrintErr("Handshake: ")
+				return
+			}
+			d.setReady(c, filter)
 			var buf [1]byte
 			for {
-				_, err := conn.Read(buf[:])
+				_, err := r.Read(buf[:])
 				if err != nil {
 					return
 				}
@@ -69,14 +168,176 @@ func (d *Dispatcher) Serve(ln net.Listener) error {
 	}
 }
 
+// addConn registers a connection and starts writing to it.
+// It returns nil if the dispatcher is shut down.
+func (d *Dispatcher) addConn(conn net.Conn, remoteAddr string, encoding connEncoding) *connection {
+	maxPending := d.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+	c := &connection{
+		Conn:         conn,
+		remoteAddr:   remoteAddr,
+		encoding:     encoding,
+		writeTimeout: d.WriteTimeout,
+		pending:      make(chan []byte, maxPending),
+		closed:       make(chan struct{}),
+		flush:        make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	d.connsLock.Lock()
+	defer d.connsLock.Unlock()
+	if d.shutdown {
+		return nil
+	}
+	if d.conns == nil {
+		d.conns = make(map[net.Conn]*connection)
+	}
+	d.conns[conn] = c
+	go c.writeLoop()
+	return c
+}
+
+// setReady starts dispatching logs to a connection after the handshake.
+func (d *Dispatcher) setReady(c *connection, filter source.LogEntryFilter) {
+	d.connsLock.Lock()
+	defer d.connsLock.Unlock()
+	if d.conns[c.Conn] == c {
+		c.filter, c.ready = filter, true
+	}
+}
+
+// removeConn unregisters and closes a connection.
+func (d *Dispatcher) removeConn(log *logs.Logger, c *connection) {
+	d.connsLock.Lock()
+	delete(d.conns, c.Conn)
+	d.connsLock.Unlock()
+	c.close()
+	if dropped := atomic.LoadInt64(&c.dropped); dropped > 0 {
+		log.Info().Printf("Dropped %d entries", dropped)
+	}
+}
+
+// readHandshake reads the handshake from a new connection and returns the filter.
+func (d *Dispatcher) readHandshake(conn net.Conn, r *bufio.Reader) (source.LogEntryFilter, error) {
+	timeout := d.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = defaultHandshakeTimeout
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	defer conn.SetReadDeadline(time.Time{})
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		var netErr net.Error
+		if len(line) == 0 && errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, nil
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("handshake exceeds %d bytes", maxHandshakeSize)
+		}
+		return nil, err
+	}
+	var hs handshake
+	if err := json.Unmarshal(line, &hs); err != nil {
+		return nil, fmt.Errorf("decode handshake: %w", err)
+	}
+	filters, err := source.ParseFilters(hs.Filters...)
+	if err != nil || len(filters) == 0 {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// Shutdown stops the dispatcher gracefully: it stops accepting connections
+// and batches, waits for the in-flight batches to complete until ctx is done,
+// and then closes the connections.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.connsLock.Lock()
+	d.shutdown = true
+	listeners := d.listeners
+	d.listeners = nil
+	d.connsLock.Unlock()
+	for ln := range listeners {
+		ln.Close()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		d.writers.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	d.connsLock.Lock()
+	conns := d.conns
+	d.conns = nil
+	d.connsLock.Unlock()
+	if err == nil {
+		for _, c := range conns {
+			close(c.flush)
+		}
+		for _, c := range conns {
+			select {
+			case <-c.stopped:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	for conn, c := range conns {
+		// Half-close first so the written entries are delivered before EOF.
+		if hc, ok := conn.(interface{ CloseWrite() error }); ok {
+			hc.CloseWrite()
+		}
+		c.close()
+	}
+	return err
+}
+
+// ConnStats returns the statistics of the connections.
+func (d *Dispatcher) ConnStats() []ConnStats {
+	d.connsLock.RLock()
+	defer d.connsLock.RUnlock()
+	stats := make([]ConnStats, 0, len(d.conns))
+	for _, c := range d.conns {
+		stats = append(stats, ConnStats{
+			RemoteAddr: c.remoteAddr,
+			Pending:    len(c.pending),
+			Dropped:    atomic.LoadInt64(&c.dropped),
+		})
+	}
+	return stats
+}
+
+func (d *Dispatcher) isShutdown() bool {
+	d.connsLock.RLock()
+	defer d.connsLock.RUnlock()
+	return d.shutdown
+}
+
+// WriteBatch implements server.LogStore.
 func (d *Dispatcher) WriteBatch(ctx context.Context, name string) (server.BatchWriter, error) {
 	w := &batchWriter{Dispatcher: d}
 	d.connsLock.RLock()
-	w.conns = make([]net.Conn, 0, len(d.conns))
-	for conn := range d.conns {
-		w.conns = append(w.conns, conn)
+	defer d.connsLock.RUnlock()
+	if d.shutdown {
+		return nil, ErrDispatcherClosed
+	}
+	d.writers.Add(1)
+	w.conns = make([]*connection, 0, len(d.conns))
+	for _, conn := range d.conns {
+		if conn.ready {
+			w.conns = append(w.conns, conn)
+		}
 	}
-	d.connsLock.RUnlock()
 	return w, nil
 }
 
@@ -84,21 +345,106 @@ func (w *batchWriter) WriteLogEntry(ctx context.Context, entry *logspb.LogEntry)
 	if emitter := w.Emitter; emitter != nil {
 		emitter.EmitLogEntry(entry)
 	}
-	if len(w.conns) == 0 {
-		return nil
-	}
-	entryPb, err := proto.Marshal(entry)
-	if err != nil {
-		return err
-	}
-	binary.BigEndian.PutUint32(w.lenBuf[:], uint32(len(entryPb)))
+	var encoded [numEncodings][]byte
 	for _, conn := range w.conns {
-		conn.Write(w.lenBuf[:])
-		conn.Write(entryPb)
+		if conn.filter != nil && !conn.filter.FilterLogEntry(entry) {
+			continue
+		}
+		// Encode only if any connection accepts the entry. The buffer is
+		// shared by the connections and must not be reused.
+		buf := encoded[conn.encoding]
+		if buf == nil {
+			var err error
+			if buf, err = encodeEntry(entry, conn.encoding); err != nil {
+				return err
+			}
+			encoded[conn.encoding] = buf
+		}
+		conn.enqueue(buf)
 	}
 	return nil
 }
 
+func encodeEntry(entry *logspb.LogEntry, encoding connEncoding) ([]byte, error) {
+	if encoding == encodingJSON {
+		return protojson.MarshalOptions{UseProtoNames: true}.Marshal(entry)
+	}
+	buf, err := proto.MarshalOptions{}.MarshalAppend([]byte{0, 0, 0, 0}, entry)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf, nil
+}
+
 func (w *batchWriter) Close() error {
+	if !w.closed {
+		w.closed = true
+		w.writers.Done()
+	}
 	return nil
 }
+
+// enqueue buffers an encoded entry without blocking, and drops the oldest
+// entry if the buffer is full.
+func (c *connection) enqueue(buf []byte) {
+	for {
+		select {
+		case c.pending <- buf:
+			return
+		case <-c.closed:
+			return
+		default:
+		}
+		select {
+		case <-c.pending:
+			atomic.AddInt64(&c.dropped, 1)
+		default:
+		}
+	}
+}
+
+// writeLoop writes the pending entries to the connection. Each entry is
+// written in a single write (a frame for WebSocket), so entries are not interleaved.
+func (c *connection) writeLoop() {
+	defer close(c.stopped)
+	for {
+		select {
+		case buf := <-c.pending:
+			if !c.write(buf) {
+				return
+			}
+		case <-c.flush:
+			for {
+				select {
+				case buf := <-c.pending:
+					if !c.write(buf) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *connection) write(buf []byte) bool {
+	if c.writeTimeout > 0 {
+		c.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	if _, err := c.Write(buf); err != nil {
+		c.close()
+		return false
+	}
+	return true
+}
+
+func (c *connection) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.Conn.Close()
+	})
+}
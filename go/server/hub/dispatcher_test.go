@@ -0,0 +1,361 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// entriesRecorder records the timestamps of the received log entries.
+type entriesRecorder struct {
+	lock       sync.Mutex
+	timestamps []int64
+}
+
+func (r *entriesRecorder) EmitLogEntry(entry *logspb.LogEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timestamps = append(r.timestamps, entry.GetNanoTs())
+}
+
+func (r *entriesRecorder) received() []int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]int64{}, r.timestamps...)
+}
+
+func startDispatcher(t *testing.T, d *Dispatcher) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go d.Serve(ln)
+	return ln.Addr().String()
+}
+
+// waitForReadyConns waits until the dispatcher has the number of connections completing handshakes.
+func waitForReadyConns(t *testing.T, d *Dispatcher, count int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.connsLock.RLock()
+		ready := 0
+		for _, conn := range d.conns {
+			if conn.ready {
+				ready++
+			}
+		}
+		d.connsLock.RUnlock()
+		if ready >= count {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expect %d ready connections, got %d", count, ready)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func writeEntries(t *testing.T, d *Dispatcher, entries ...*logspb.LogEntry) {
+	t.Helper()
+	ctx := context.Background()
+	w, err := d.WriteBatch(ctx, "client")
+	if err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	defer w.Close()
+	for _, entry := range entries {
+		if err := w.WriteLogEntry(ctx, entry); err != nil {
+			t.Fatalf("WriteLogEntry: %v", err)
+		}
+	}
+}
+
+func TestDispatcherFilter(t *testing.T) {
+	d := &Dispatcher{HandshakeTimeout: 100 * time.Millisecond}
+	addr := startDispatcher(t, d)
+
+	testCases := []struct {
+		name     string
+		filters  []string
+		legacy   bool
+		expected []int64
+	}{
+		{name: "unfiltered", expected: []int64{1, 2, 3, 4}},
+		{name: "level", filters: []string{"level=error"}, expected: []int64{2, 4}},
+		{name: "level and attribute", filters: []string{"level=error", "a:client=b"}, expected: []int64{4}},
+		{name: "no handshake", legacy: true, expected: []int64{1, 2, 3, 4}},
+	}
+	recorders := make([]*entriesRecorder, len(testCases))
+	var wg sync.WaitGroup
+	for n := range testCases {
+		tc, recorder := testCases[n], &entriesRecorder{}
+		recorders[n] = recorder
+		connector := &Connector{Emitter: recorder, Filters: tc.filters}
+		wg.Add(1)
+		if tc.legacy {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			go func() {
+				defer wg.Done()
+				connector.Stream(conn)
+			}()
+			continue
+		}
+		go func() {
+			defer wg.Done()
+			connector.DialAndStream("tcp", addr)
+		}()
+	}
+	waitForReadyConns(t, d, len(testCases))
+
+	entry := func(ts int64, level logspb.LogEntry_Level, client string) *logspb.LogEntry {
+		entry := &logspb.LogEntry{NanoTs: ts, Level: level, Attributes: make(map[string]*logspb.Value)}
+		logs.Str("client", client).SetAttributes(entry.Attributes)
+		return entry
+	}
+	writeEntries(t, d,
+		entry(1, logspb.LogEntry_INFO, "a"),
+		entry(2, logspb.LogEntry_ERROR, "a"),
+		entry(3, logspb.LogEntry_INFO, "b"),
+		entry(4, logspb.LogEntry_ERROR, "b"),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	wg.Wait()
+
+	for n := range testCases {
+		tc, recorder := testCases[n], recorders[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if received := recorder.received(); !reflect.DeepEqual(received, tc.expected) {
+				t.Errorf("Expect entries %v, got %v", tc.expected, received)
+			}
+		})
+	}
+}
+
+func TestDispatcherInvalidFilter(t *testing.T) {
+	d := &Dispatcher{}
+	addr := startDispatcher(t, d)
+	defer d.Shutdown(context.Background())
+
+	connector := &Connector{Emitter: &entriesRecorder{}, Filters: []string{"unknown=x"}}
+	if err := connector.DialAndStream("tcp", addr); err == nil {
+		t.Fatalf("Expect error on invalid filters")
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(`{"filters":["unknown=x"]}` + "\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// The hub closes the connection on invalid filters.
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var buf [1]byte
+	if _, err := conn.Read(buf[:]); err == nil || isTimeout(err) {
+		t.Errorf("Expect connection closed, got %v", err)
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// dialSlowReader connects to the hub and completes the handshake without reading.
+func dialSlowReader(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	if err := (&Connector{}).Handshake(conn); err != nil {
+		t.Fatalf("Handshake: %v", err)
+	}
+	return conn
+}
+
+func connStatsOf(d *Dispatcher, conn net.Conn) (ConnStats, bool) {
+	for _, stats := range d.ConnStats() {
+		if stats.RemoteAddr == conn.LocalAddr().String() {
+			return stats, true
+		}
+	}
+	return ConnStats{}, false
+}
+
+func largeEntries(start, count int) []*logspb.LogEntry {
+	entries := make([]*logspb.LogEntry, 0, count)
+	for n := 0; n < count; n++ {
+		entries = append(entries, &logspb.LogEntry{NanoTs: int64(start + n), Message: strings.Repeat("x", 4096)})
+	}
+	return entries
+}
+
+func TestDispatcherSlowReader(t *testing.T) {
+	const batchSize, batches = 100, 100
+	d := &Dispatcher{MaxPending: 2 * batchSize}
+	addr := startDispatcher(t, d)
+	slowConn := dialSlowReader(t, addr)
+	defer slowConn.Close()
+	recorder := &entriesRecorder{}
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		(&Connector{Emitter: recorder}).DialAndStream("tcp", addr)
+	}()
+	waitForReadyConns(t, d, 2)
+
+	for n := 0; n < batches; n++ {
+		writeEntries(t, d, largeEntries(n*batchSize, batchSize)...)
+		// The fast reader keeps up with each batch regardless of the slow reader.
+		expected := (n + 1) * batchSize
+		deadline := time.Now().Add(5 * time.Second)
+		for len(recorder.received()) < expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("Batch %d: expect %d entries received, got %d", n, expected, len(recorder.received()))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	stats, ok := connStatsOf(d, slowConn)
+	if !ok {
+		t.Fatalf("Slow reader not connected")
+	}
+	if stats.Dropped == 0 || stats.Pending > d.MaxPending {
+		t.Errorf("Expect entries dropped and pending bounded for slow reader, got %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	d.Shutdown(ctx)
+	<-streamDone
+	received := recorder.received()
+	if len(received) != batchSize*batches {
+		t.Fatalf("Expect %d entries received, got %d", batchSize*batches, len(received))
+	}
+	for n, ts := range received {
+		if ts != int64(n) {
+			t.Fatalf("Expect entry %d at %d, got %d", n, n, ts)
+		}
+	}
+}
+
+func TestDispatcherWriteTimeout(t *testing.T) {
+	d := &Dispatcher{MaxPending: 16, WriteTimeout: 100 * time.Millisecond}
+	addr := startDispatcher(t, d)
+	defer d.Shutdown(context.Background())
+	slowConn := dialSlowReader(t, addr)
+	defer slowConn.Close()
+	waitForReadyConns(t, d, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for n := 0; ; n++ {
+		if _, ok := connStatsOf(d, slowConn); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expect slow reader closed by write timeout")
+		}
+		writeEntries(t, d, largeEntries(n*16, 16)...)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDispatcherShutdownFlush(t *testing.T) {
+	const count = 2000
+	d := &Dispatcher{MaxPending: count}
+	addr := startDispatcher(t, d)
+	conn := dialSlowReader(t, addr)
+	defer conn.Close()
+	waitForReadyConns(t, d, 1)
+
+	entries := make([]*logspb.LogEntry, 0, count)
+	for n := 0; n < count; n++ {
+		entries = append(entries, &logspb.LogEntry{NanoTs: int64(n), Message: strings.Repeat("x", 16384)})
+	}
+	writeEntries(t, d, entries...)
+	// The entries not accepted by the socket remain buffered.
+	if stats, ok := connStatsOf(d, conn); !ok || stats.Pending == 0 {
+		t.Fatalf("Expect entries buffered, got %+v", stats)
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		shutdownErr <- d.Shutdown(ctx)
+	}()
+	recorder := &entriesRecorder{}
+	(&Connector{Emitter: recorder}).Stream(conn)
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	received := recorder.received()
+	if len(received) != count {
+		t.Fatalf("Expect %d entries received, got %d", count, len(received))
+	}
+	for n, ts := range received {
+		if ts != int64(n) {
+			t.Fatalf("Expect entry %d at %d, got %d", n, n, ts)
+		}
+	}
+}
+
+func TestDispatcherShutdownTimeout(t *testing.T) {
+	testCases := []struct {
+		name  string
+		setup func(t *testing.T, d *Dispatcher, addr string)
+	}{
+		{
+			name: "in-flight batch",
+			setup: func(t *testing.T, d *Dispatcher, addr string) {
+				if _, err := d.WriteBatch(context.Background(), "client"); err != nil {
+					t.Fatalf("WriteBatch: %v", err)
+				}
+			},
+		},
+		{
+			name: "blocked reader",
+			setup: func(t *testing.T, d *Dispatcher, addr string) {
+				conn := dialSlowReader(t, addr)
+				t.Cleanup(func() { conn.Close() })
+				waitForReadyConns(t, d, 1)
+				writeEntries(t, d, largeEntries(0, d.MaxPending)...)
+			},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Dispatcher{MaxPending: 4096}
+			addr := startDispatcher(t, d)
+			tc.setup(t, d, addr)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expect Shutdown to return DeadlineExceeded, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Shutdown returned after %v", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package hub
+
+import (
+	"io"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+const (
+	// WebSocketFilterParam is the repeatable query parameter of the filters
+	// in the syntax of source.ParseFilters.
+	WebSocketFilterParam = "filter"
+)
+
+// WebSocketHandler returns an HTTP handler which upgrades the requests to
+// WebSocket and streams log entries encoded in JSON, one per text message.
+// The log entries are filtered by the filter query parameters, e.g.
+// "/?filter=level=error&filter=a:client=web".
+func (d *Dispatcher) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters, err := source.ParseFilters(r.URL.Query()[WebSocketFilterParam]...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var filter source.LogEntryFilter
+		if len(filters) > 0 {
+			filter = filters
+		}
+		srv := &websocket.Server{Handler: func(ws *websocket.Conn) {
+			d.serveWebSocket(ws, filter)
+		}}
+		srv.ServeHTTP(w, r)
+	})
+}
+
+func (d *Dispatcher) serveWebSocket(ws *websocket.Conn, filter source.LogEntryFilter) {
+	ws.PayloadType = websocket.TextFrame
+	remoteAddr := ws.Request().RemoteAddr
+	c := d.addConn(ws, remoteAddr, encodingJSON)
+	if c == nil {
+		return
+	}
+	_, log := logs.StartSpan(ws.Request().Context(), "ServeWebSocket", logs.Str("remote-addr", remoteAddr))
+	defer log.EndSpan()
+	defer d.removeConn(log, c)
+	d.setReady(c, filter)
+	// Messages from the client are ignored, and reading detects the close of the connection.
+	io.Copy(io.Discard, ws)
+}
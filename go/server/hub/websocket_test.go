@@ -0,0 +1,71 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestWebSocket(t *testing.T) {
+	d := &Dispatcher{}
+	srv := httptest.NewServer(d.WebSocketHandler())
+	defer srv.Close()
+	defer d.Shutdown(context.Background())
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	query := url.Values{WebSocketFilterParam: []string{"level=error"}}
+	ws, err := websocket.Dial(wsURL+"/?"+query.Encode(), "", srv.URL)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+	waitForReadyConns(t, d, 1)
+
+	writeEntries(t, d,
+		&logspb.LogEntry{NanoTs: 1, Level: logspb.LogEntry_INFO, Message: "info"},
+		&logspb.LogEntry{NanoTs: 2, Level: logspb.LogEntry_ERROR, Message: "error1"},
+		&logspb.LogEntry{NanoTs: 3, Level: logspb.LogEntry_ERROR, Message: "error2"},
+	)
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var messages []string
+	for len(messages) < 2 {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		entry := &logspb.LogEntry{}
+		if err := protojson.Unmarshal([]byte(msg), entry); err != nil {
+			t.Fatalf("Unmarshal %q: %v", msg, err)
+		}
+		messages = append(messages, entry.GetMessage())
+	}
+	if expected := []string{"error1", "error2"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expect messages %v, got %v", expected, messages)
+	}
+}
+
+func TestWebSocketInvalidFilter(t *testing.T) {
+	d := &Dispatcher{}
+	srv := httptest.NewServer(d.WebSocketHandler())
+	defer srv.Close()
+	defer d.Shutdown(context.Background())
+
+	resp, err := http.Get(srv.URL + "/?" + url.Values{WebSocketFilterParam: []string{"unknown=x"}}.Encode())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expect status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
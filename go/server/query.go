@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/source"
+)
+
+const (
+	// DefaultMaxQueryBatchSize is the default size limit of entries in a QueryResponse,
+	// well below the default 4MB message size limit of gRPC.
+	DefaultMaxQueryBatchSize = 1 << 20
+)
+
+// QueryServer implements EgressService by reading logs from FileStore.
+type QueryServer struct {
+	Store *FileStore
+	// MaxBatchSize limits the encoded size of entries in a QueryResponse.
+	// An entry larger than the limit is sent alone.
+	// If 0, DefaultMaxQueryBatchSize is used.
+	MaxBatchSize int
+
+	logspb.UnimplementedEgressServiceServer
+}
+
+// Query implements EgressService.
+func (s *QueryServer) Query(req *logspb.QueryRequest, stream logspb.EgressService_QueryServer) error {
+	name := req.GetClient()
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return status.Errorf(codes.InvalidArgument, "invalid client name %q", name)
+	}
+	var since, before time.Time
+	if req.GetSinceNs() != 0 {
+		since = time.Unix(0, req.GetSinceNs())
+	}
+	if req.GetBeforeNs() != 0 {
+		before = time.Unix(0, req.GetBeforeNs())
+	}
+	filters, err := source.ParseFilters(req.GetFilters()...)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !since.IsZero() || !before.IsZero() {
+		filters = append(filters, source.FilterByTime(since, before))
+	}
+	if level := req.GetMinLevel(); level != logspb.LogEntry_NONE {
+		filters = append(filters, source.FilterByLevel(level))
+	}
+	if req.GetTraceId() != "" || req.GetSpanId() != "" {
+		filters = append(filters, source.FilterByTrace(req.GetTraceId()).AndSpan(req.GetSpanId()))
+	}
+
+	files, err := s.Store.FilesForRange(name, since, before)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return status.Errorf(codes.NotFound, "client %q not found", name)
+		}
+		return err
+	}
+	ctx := stream.Context()
+	filesReader := &storeFilesReader{files: files}
+	defer filesReader.Close()
+	reader := &source.FilteredReader{Reader: filesReader, Filter: filters}
+	maxBatchSize := s.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxQueryBatchSize
+	}
+	var count int64
+	var batch []*logspb.LogEntry
+	var batchSize int
+	for limit := req.GetLimit(); limit <= 0 || count < limit; count++ {
+		entry, err := reader.Read(ctx)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if entry == nil {
+			break
+		}
+		size := proto.Size(entry)
+		if len(batch) > 0 && batchSize+size > maxBatchSize {
+			if err := stream.Send(&logspb.QueryResponse{Entries: batch}); err != nil {
+				return err
+			}
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, entry)
+		batchSize += size
+	}
+	if len(batch) > 0 {
+		return stream.Send(&logspb.QueryResponse{Entries: batch})
+	}
+	return nil
+}
+
+// storeFilesReader reads log entries from files written by FileStore sequentially.
+type storeFilesReader struct {
+	files   []string
+	current io.ReadCloser
+}
+
+// Read implements source.Reader.
+func (r *storeFilesReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if r.current == nil {
+			if len(r.files) == 0 {
+				return nil, nil
+			}
+			f, err := source.OpenFile(r.files[0])
+			r.files = r.files[1:]
+			if err != nil {
+				// The file may be pruned after being listed.
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			r.current = f
+		}
+		entry, err := readRecordAndDecode(r.current)
+		if err == nil {
+			return entry, nil
+		}
+		r.current.Close()
+		r.current = nil
+		// The last record of the current file may be partially written.
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+	}
+}
+
+// Close closes the file being read.
+func (r *storeFilesReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
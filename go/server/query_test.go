@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/streamers/remote"
+)
+
+func TestIngressAndQuery(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	ln := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	logspb.RegisterIngressServiceServer(srv, &IngressServer{Store: store})
+	logspb.RegisterEgressServiceServer(srv, &QueryServer{Store: store})
+	go srv.Serve(ln)
+	defer srv.Stop()
+	dialOpts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+
+	traceA := []byte{0xa1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	traceB := []byte{0xb1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	entry := func(ts int64, traceID []byte, level logspb.LogEntry_Level) *logspb.LogEntry {
+		return &logspb.LogEntry{
+			NanoTs:  ts,
+			Level:   level,
+			Message: "message",
+			Trace:   &logspb.Trace{SpanContext: &logspb.SpanContext{TraceId: traceID, SpanId: uint64(ts)}},
+		}
+	}
+	conn, err := grpc.Dial("bufnet", dialOpts...)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+	ingress, err := logspb.NewIngressServiceClient(conn).IngressStream(metadata.AppendToOutgoingContext(ctx, remote.RemoteMetadataKeyClientName, "client"))
+	if err != nil {
+		t.Fatalf("IngressStream: %v", err)
+	}
+	batches := [][]*logspb.LogEntry{
+		{entry(1, traceA, logspb.LogEntry_INFO), entry(2, traceB, logspb.LogEntry_INFO)},
+		{entry(3, traceA, logspb.LogEntry_ERROR), entry(4, traceB, logspb.LogEntry_ERROR), entry(5, traceA, logspb.LogEntry_INFO)},
+	}
+	for n, batch := range batches {
+		if err := ingress.Send(&logspb.IngressBatch{Entries: batch, ChunkEnd: true}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if _, err := ingress.Recv(); err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		// Rotate to query across files.
+		if n == 0 {
+			if err := store.Rotate("client"); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+		}
+	}
+	ingress.CloseSend()
+
+	client := logspb.NewEgressServiceClient(conn)
+	testCases := []struct {
+		name     string
+		req      *logspb.QueryRequest
+		expected []int64
+	}{
+		{name: "trace", req: &logspb.QueryRequest{TraceId: logs.TraceIDStringFrom(&logspb.SpanContext{TraceId: traceA})}, expected: []int64{1, 3, 5}},
+		{name: "trace and level", req: &logspb.QueryRequest{TraceId: "a1", MinLevel: logspb.LogEntry_ERROR}, expected: []int64{3}},
+		{name: "time range", req: &logspb.QueryRequest{SinceNs: 2, BeforeNs: 4}, expected: []int64{2, 3}},
+		{name: "filters", req: &logspb.QueryRequest{Filters: []string{"level=error"}}, expected: []int64{3, 4}},
+		{name: "limit", req: &logspb.QueryRequest{TraceId: "b1", Limit: 1}, expected: []int64{2}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Client = "client"
+			stream, err := client.Query(ctx, tc.req)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			var timestamps []int64
+			for {
+				resp, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv: %v", err)
+				}
+				for _, entry := range resp.GetEntries() {
+					timestamps = append(timestamps, entry.GetNanoTs())
+				}
+			}
+			if len(timestamps) != len(tc.expected) {
+				t.Fatalf("Expect entries %v, got %v", tc.expected, timestamps)
+			}
+			for i, ts := range timestamps {
+				if ts != tc.expected[i] {
+					t.Errorf("Expect entries %v, got %v", tc.expected, timestamps)
+					break
+				}
+			}
+		})
+	}
+
+	for _, req := range []*logspb.QueryRequest{{Client: "unknown"}, {Client: "../client"}, {Client: "client", Filters: []string{"unknown=x"}}} {
+		stream, err := client.Query(ctx, req)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if code := status.Code(err); code != codes.NotFound && code != codes.InvalidArgument {
+			t.Errorf("Query %v: expect NotFound or InvalidArgument, got %v", req, err)
+		}
+	}
+}
+
+func TestQueryBatchSize(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	ctx := context.Background()
+	w, err := store.WriteBatch(ctx, "client")
+	if err != nil {
+		t.Fatalf("WriteBatch: %v", err)
+	}
+	message := strings.Repeat("x", 100)
+	for n := 1; n <= 10; n++ {
+		if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: int64(n), Message: message}); err != nil {
+			t.Fatalf("WriteLogEntry: %v", err)
+		}
+	}
+	// Larger than the batch size limit.
+	if err := w.WriteLogEntry(ctx, &logspb.LogEntry{NanoTs: 11, Message: strings.Repeat("x", 500)}); err != nil {
+		t.Fatalf("WriteLogEntry: %v", err)
+	}
+	w.Close()
+
+	const maxBatchSize = 300
+	ln := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	logspb.RegisterEgressServiceServer(srv, &QueryServer{Store: store, MaxBatchSize: maxBatchSize})
+	go srv.Serve(ln)
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	stream, err := logspb.NewEgressServiceClient(conn).Query(ctx, &logspb.QueryRequest{Client: "client"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var count, batches int
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		batches++
+		var size int
+		for _, entry := range resp.GetEntries() {
+			size += proto.Size(entry)
+		}
+		if len(resp.GetEntries()) > 1 && size > maxBatchSize {
+			t.Errorf("Batch of %d entries exceeds size limit: %d", len(resp.GetEntries()), size)
+		}
+		count += len(resp.GetEntries())
+	}
+	if count != 11 {
+		t.Errorf("Expect 11 entries, got %d", count)
+	}
+	// 2 entries of ~100 bytes fit in a batch, and the large entry is sent alone.
+	if batches != 6 {
+		t.Errorf("Expect 6 batches, got %d", batches)
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 // BlobReader reads log entries from a blob stream.
 type BlobReader struct {
+	// SkipErrors skips corrupted records and resyncs on the next valid one.
+	SkipErrors bool
+
 	reader *blob.Reader
 }
 
@@ -20,5 +23,11 @@ func NewBlob(in io.Reader) *BlobReader {
 
 // Read implements Reader.
 func (r *BlobReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	r.reader.SkipErrors = r.SkipErrors
 	return r.reader.Read()
 }
+
+// SkippedBytes returns the number of bytes skipped for corrupted records.
+func (r *BlobReader) SkippedBytes() int64 {
+	return r.reader.SkippedBytes
+}
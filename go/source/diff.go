@@ -0,0 +1,179 @@
+package source
+
+import (
+	"sort"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// DiffKind is the kind of a DiffOp.
+type DiffKind int
+
+// Diff kinds.
+const (
+	// DiffEqual indicates entries in both streams are aligned.
+	DiffEqual DiffKind = iota
+	// DiffDelete indicates the entry only exists in the first stream.
+	DiffDelete
+	// DiffInsert indicates the entry only exists in the second stream.
+	DiffInsert
+)
+
+// DiffOp is a single operation of the diff between two streams of log entries.
+type DiffOp struct {
+	Kind DiffKind
+	// A is the entry from the first stream, nil for DiffInsert.
+	A *logspb.LogEntry
+	// B is the entry from the second stream, nil for DiffDelete.
+	B *logspb.LogEntry
+}
+
+// DiffKey returns the key to align entries: span events are keyed by the span
+// names, and other entries are keyed by the location and the message.
+func DiffKey(entry *logspb.LogEntry) string {
+	switch ev := entry.GetTrace().GetEvent().(type) {
+	case *logspb.Trace_SpanStart_:
+		return "+" + ev.SpanStart.GetName()
+	case *logspb.Trace_SpanEnd_:
+		return "-" + entry.GetLocation()
+	case *logspb.Trace_SpanEvent_:
+		return "*" + ev.SpanEvent.GetName()
+	}
+	return entry.GetLocation() + " " + entry.GetMessage()
+}
+
+// DiffLogEntries aligns two streams of entries by the keys and returns the shortest
+// edit script (Myers' algorithm in linear space). If key is nil, DiffKey is used.
+func DiffLogEntries(a, b []*logspb.LogEntry, key func(*logspb.LogEntry) string) []DiffOp {
+	if key == nil {
+		key = DiffKey
+	}
+	keysA, keysB := make([]string, len(a)), make([]string, len(b))
+	for n, entry := range a {
+		keysA[n] = key(entry)
+	}
+	for n, entry := range b {
+		keysB[n] = key(entry)
+	}
+	return diffRange(make([]DiffOp, 0, len(a)+len(b)), a, b, keysA, keysB)
+}
+
+// diffRange appends the edit script of a and b to ops. The common prefix and
+// suffix are aligned without the search, and the rest is split at the middle
+// snake recursively, so it takes O(N+M) space.
+func diffRange(ops []DiffOp, a, b []*logspb.LogEntry, keysA, keysB []string) []DiffOp {
+	n, m := len(a), len(b)
+	prefix := 0
+	for prefix < n && prefix < m && keysA[prefix] == keysB[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && keysA[n-1-suffix] == keysB[m-1-suffix] {
+		suffix++
+	}
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, DiffOp{Kind: DiffEqual, A: a[i], B: b[i]})
+	}
+	switch midA, midB := a[prefix:n-suffix], b[prefix:m-suffix]; {
+	case len(midA) == 0:
+		for _, entry := range midB {
+			ops = append(ops, DiffOp{Kind: DiffInsert, B: entry})
+		}
+	case len(midB) == 0:
+		for _, entry := range midA {
+			ops = append(ops, DiffOp{Kind: DiffDelete, A: entry})
+		}
+	default:
+		// Both are not empty and differ on both ends, so at least 2 edits are
+		// needed and both halves are smaller.
+		midKeysA, midKeysB := keysA[prefix:n-suffix], keysB[prefix:m-suffix]
+		x, y, u, v := middleSnake(midKeysA, midKeysB)
+		ops = diffRange(ops, midA[:x], midB[:y], midKeysA[:x], midKeysB[:y])
+		for i := 0; i < u-x; i++ {
+			ops = append(ops, DiffOp{Kind: DiffEqual, A: midA[x+i], B: midB[y+i]})
+		}
+		ops = diffRange(ops, midA[u:], midB[v:], midKeysA[u:], midKeysB[v:])
+	}
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, DiffOp{Kind: DiffEqual, A: a[n-i], B: b[m-i]})
+	}
+	return ops
+}
+
+// middleSnake searches forward from the start and backward from the end at the
+// same time, and returns the snake (x, y) to (u, v) where the two searches
+// overlap. The snake is in the middle of a shortest edit script.
+func middleSnake(keysA, keysB []string) (x, y, u, v int) {
+	n, m := len(keysA), len(keysB)
+	delta := n - m
+	odd := delta&1 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	// forward[k] is the furthest reaching x on diagonal k from the start, and
+	// backward[c] is the furthest reaching distance to n on diagonal c from the end,
+	// where backward diagonal c corresponds to forward diagonal delta-c.
+	forward, backward := make([]int, 2*offset+1), make([]int, 2*offset+1)
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && keysA[u] == keysB[v] {
+				u, v = u+1, v+1
+			}
+			forward[offset+k] = u
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && u+backward[offset+c] >= n {
+				return x, y, u, v
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			var rx int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				rx = backward[offset+c+1]
+			} else {
+				rx = backward[offset+c-1] + 1
+			}
+			ry := rx - c
+			ru, rv := rx, ry
+			for ru < n && rv < m && keysA[n-1-ru] == keysB[m-1-rv] {
+				ru, rv = ru+1, rv+1
+			}
+			backward[offset+c] = ru
+			if k := delta - c; !odd && k >= -d && k <= d && ru+forward[offset+k] >= n {
+				return n - ru, m - rv, n - rx, m - ry
+			}
+		}
+	}
+	// Unreachable: the searches always overlap within limit steps.
+	return 0, 0, 0, 0
+}
+
+// AttributeDiffs returns the sorted names of attributes which are different
+// (or only present in one entry), excluding the ignored ones.
+func AttributeDiffs(a, b *logspb.LogEntry, ignore map[string]bool) []string {
+	var names []string
+	attrsA, attrsB := a.GetAttributes(), b.GetAttributes()
+	for name, valA := range attrsA {
+		if ignore[name] {
+			continue
+		}
+		if valB, ok := attrsB[name]; !ok || !logs.ValueEqual(valA, valB) {
+			names = append(names, name)
+		}
+	}
+	for name := range attrsB {
+		if ignore[name] {
+			continue
+		}
+		if _, ok := attrsA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
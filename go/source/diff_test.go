@@ -0,0 +1,150 @@
+package source
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestDiffLogEntries(t *testing.T) {
+	entries := func(msgs string) []*logspb.LogEntry {
+		var result []*logspb.LogEntry
+		for _, msg := range strings.Split(msgs, "") {
+			result = append(result, &logspb.LogEntry{Message: msg})
+		}
+		return result
+	}
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "all inserts",
+			b:        "abc",
+			expected: []string{"+a", "+b", "+c"},
+		},
+		{
+			name:     "all deletes",
+			a:        "abc",
+			expected: []string{"-a", "-b", "-c"},
+		},
+		{
+			name:     "equal",
+			a:        "abc",
+			b:        "abc",
+			expected: []string{"=a", "=b", "=c"},
+		},
+		{
+			name:     "interleaved",
+			a:        "abcabba",
+			b:        "cbabac",
+			expected: []string{"-a", "+c", "=b", "-c", "=a", "=b", "-b", "=a", "+c"},
+		},
+		{
+			name:     "common prefix and suffix",
+			a:        "xaby",
+			b:        "xcby",
+			expected: []string{"=x", "-a", "+c", "=b", "=y"},
+		},
+	}
+	key := func(entry *logspb.LogEntry) string { return entry.GetMessage() }
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := entries(tc.a), entries(tc.b)
+			var script []string
+			var restoredA, restoredB string
+			for _, op := range DiffLogEntries(a, b, key) {
+				switch op.Kind {
+				case DiffEqual:
+					script = append(script, "="+op.A.GetMessage())
+					if op.A.GetMessage() != op.B.GetMessage() {
+						t.Errorf("Aligned %q with %q", op.A.GetMessage(), op.B.GetMessage())
+					}
+					restoredA += op.A.GetMessage()
+					restoredB += op.B.GetMessage()
+				case DiffDelete:
+					script = append(script, "-"+op.A.GetMessage())
+					restoredA += op.A.GetMessage()
+				case DiffInsert:
+					script = append(script, "+"+op.B.GetMessage())
+					restoredB += op.B.GetMessage()
+				}
+			}
+			if !reflect.DeepEqual(script, tc.expected) {
+				t.Errorf("Expect %v, got %v", tc.expected, script)
+			}
+			if restoredA != tc.a || restoredB != tc.b {
+				t.Errorf("Expect script from %q to %q, got %q to %q", tc.a, tc.b, restoredA, restoredB)
+			}
+		})
+	}
+}
+
+func TestDiffLogEntriesShortest(t *testing.T) {
+	key := func(entry *logspb.LogEntry) string { return entry.GetMessage() }
+	randomEntries := func(rnd *rand.Rand) []*logspb.LogEntry {
+		result := make([]*logspb.LogEntry, rnd.Intn(30))
+		for n := range result {
+			result[n] = &logspb.LogEntry{Message: string(rune('a' + rnd.Intn(4)))}
+		}
+		return result
+	}
+	// lcs returns the length of the longest common subsequence.
+	lcs := func(a, b []*logspb.LogEntry) int {
+		lengths := make([][]int, len(a)+1)
+		for i := range lengths {
+			lengths[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				switch {
+				case a[i].GetMessage() == b[j].GetMessage():
+					lengths[i][j] = lengths[i+1][j+1] + 1
+				case lengths[i+1][j] > lengths[i][j+1]:
+					lengths[i][j] = lengths[i+1][j]
+				default:
+					lengths[i][j] = lengths[i][j+1]
+				}
+			}
+		}
+		return lengths[0][0]
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 500; n++ {
+		a, b := randomEntries(rnd), randomEntries(rnd)
+		var i, j, edits int
+		for _, op := range DiffLogEntries(a, b, key) {
+			switch op.Kind {
+			case DiffEqual:
+				if op.A != a[i] || op.B != b[j] || op.A.GetMessage() != op.B.GetMessage() {
+					t.Fatalf("Case %d: invalid alignment at %d, %d", n, i, j)
+				}
+				i, j = i+1, j+1
+			case DiffDelete:
+				if op.A != a[i] {
+					t.Fatalf("Case %d: invalid delete at %d", n, i)
+				}
+				i, edits = i+1, edits+1
+			case DiffInsert:
+				if op.B != b[j] {
+					t.Fatalf("Case %d: invalid insert at %d", n, j)
+				}
+				j, edits = j+1, edits+1
+			}
+		}
+		if i != len(a) || j != len(b) {
+			t.Fatalf("Case %d: script covers %d of %d and %d of %d entries", n, i, len(a), j, len(b))
+		}
+		if expected := len(a) + len(b) - 2*lcs(a, b); edits != expected {
+			t.Errorf("Case %d: %d edits, expect %d", n, edits, expected)
+		}
+	}
+}
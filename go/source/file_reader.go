@@ -0,0 +1,135 @@
+package source
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	gzipSuffix = ".gz"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// FilesReader reads log entries from a list of files sequentially.
+type FilesReader struct {
+	Files []string
+	// SkipErrors skips corrupted records and the files failed to open.
+	SkipErrors bool
+	// SourceAttr, if not empty, is the attribute set to the base name of the
+	// file where an entry is read from, unless the entry already has one.
+	SourceAttr string
+
+	current *StreamReader
+	source  *logspb.Value
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// OpenFile opens a file for reading log entries.
+// Gzip compressed files are detected by the .gz extension or the magic bytes
+// (like StreamReader), and decompressed transparently.
+func OpenFile(fn string) (io.ReadCloser, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader
+	var gz *gzip.Reader
+	if strings.HasSuffix(fn, gzipSuffix) {
+		gz, err = gzip.NewReader(f)
+		r = gz
+	} else {
+		r, gz, err = decompress(f)
+	}
+	switch {
+	case errors.Is(err, io.EOF):
+		// Empty file.
+		return f, nil
+	case err != nil:
+		f.Close()
+		return nil, err
+	case gz != nil:
+		return &readCloser{Reader: r, closers: []io.Closer{gz, f}}, nil
+	}
+	return &readCloser{Reader: r, closers: []io.Closer{f}}, nil
+}
+
+// NewFiles creates a FilesReader.
+func NewFiles(files ...string) *FilesReader {
+	return &FilesReader{Files: files}
+}
+
+// Read implements Reader.
+func (r *FilesReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	for {
+		if r.current == nil {
+			if len(r.Files) == 0 {
+				return nil, io.EOF
+			}
+			name := r.Files[0]
+			f, err := OpenFile(name)
+			if err != nil {
+				r.Files = r.Files[1:]
+				if r.SkipErrors {
+					continue
+				}
+				return nil, fmt.Errorf("open %s: %w", name, err)
+			}
+			r.current = &StreamReader{In: f, SkipErrors: r.SkipErrors}
+			r.source = &logspb.Value{Value: &logspb.Value_StrValue{StrValue: filepath.Base(name)}}
+			r.Files = r.Files[1:]
+		}
+		entry, err := r.current.Read(ctx)
+		if entry != nil {
+			if r.SourceAttr != "" {
+				if entry.Attributes == nil {
+					entry.Attributes = make(map[string]*logspb.Value)
+				}
+				if _, exists := entry.Attributes[r.SourceAttr]; !exists {
+					entry.Attributes[r.SourceAttr] = r.source
+				}
+			}
+			return entry, nil
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		r.current.Close()
+		r.current = nil
+	}
+}
+
+// Close implements io.Closer.
+func (r *FilesReader) Close() error {
+	if r.current != nil {
+		err := r.current.Close()
+		r.current = nil
+		return err
+	}
+	return nil
+}
+
+// Close implements io.Closer.
+func (r *readCloser) Close() error {
+	var err error
+	for _, closer := range r.closers {
+		if e := closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
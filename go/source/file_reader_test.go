@@ -0,0 +1,115 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesReaderOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	entries := streamTestEntries()
+	writeFile := func(name string, data []byte) string {
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, data, 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		return fn
+	}
+	first := writeFile("1.logs.blob", encodeBlob(t, entries[:1]))
+	// Not in gzip format.
+	corrupted := writeFile("2.logs.blob.gz", []byte("garbage"))
+	missing := filepath.Join(dir, "3.logs.blob")
+	last := writeFile("4.logs.blob", encodeBlob(t, entries[1:]))
+
+	testCases := []struct {
+		name       string
+		files      []string
+		skipErrors bool
+		expected   []string
+		failed     string
+	}{
+		{name: "missing", files: []string{first, missing, last}, expected: []string{"1.logs.blob"}, failed: missing},
+		{name: "corrupted", files: []string{first, corrupted, last}, expected: []string{"1.logs.blob"}, failed: corrupted},
+		{name: "skipped", files: []string{first, missing, corrupted, last}, skipErrors: true, expected: []string{"1.logs.blob", "4.logs.blob", "4.logs.blob"}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			r := &FilesReader{Files: tc.files, SkipErrors: tc.skipErrors, SourceAttr: "source"}
+			defer r.Close()
+			var sources []string
+			var err error
+			for {
+				entry, readErr := r.Read(context.Background())
+				if entry == nil {
+					err = readErr
+					break
+				}
+				sources = append(sources, entry.GetAttributes()["source"].GetStrValue())
+			}
+			if len(sources) != len(tc.expected) {
+				t.Fatalf("Expect entries from %v, got %v", tc.expected, sources)
+			}
+			for i := range sources {
+				if sources[i] != tc.expected[i] {
+					t.Fatalf("Expect entries from %v, got %v", tc.expected, sources)
+				}
+			}
+			if tc.failed == "" {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("Expect EOF, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), "open "+tc.failed+": ") {
+				t.Errorf("Expect error opening %s, got %v", tc.failed, err)
+			}
+			if tc.failed == missing && !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Expect error wrapping os.ErrNotExist, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	blobData := encodeBlob(t, streamTestEntries())
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected []byte
+	}{
+		{name: "plain.blob", data: blobData, expected: blobData},
+		{name: "gzip.blob", data: gzipData(t, blobData), expected: blobData},
+		{name: "suffix.blob.gz", data: gzipData(t, blobData), expected: blobData},
+		{name: "magic.txt", data: []byte{0x1f, 0x8b}, expected: []byte{0x1f, 0x8b}},
+		{name: "empty.blob"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			fn := filepath.Join(dir, tc.name)
+			if err := os.WriteFile(fn, tc.data, 0644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			f, err := OpenFile(fn)
+			if err != nil {
+				t.Fatalf("OpenFile: %v", err)
+			}
+			defer f.Close()
+			data, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(data, tc.expected) {
+				t.Errorf("Read %x, expect %x", data, tc.expected)
+			}
+		})
+	}
+}
@@ -2,17 +2,25 @@ package source
 
 import (
 	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
 )
 
+const (
+	// ClientAttr is the attribute of the client name matched by ClientFilter.
+	ClientAttr = "client"
+)
+
 var (
 	attrFilterRegexp = regexp.MustCompile(`^([^:=~<>!]+)(=|:|~|<|>|!=)(.*)$`)
+	attrExistRegexp  = regexp.MustCompile(`^(!?)([^:=~<>!]+)$`)
 )
 
 // LogEntryFilter defines the interface to filter log entries.
@@ -42,6 +50,41 @@ func (f LogEntryFilters) FilterLogEntry(entry *logspb.LogEntry) bool {
 	return true
 }
 
+// OrFilter matches entries matching any of the filters.
+type OrFilter []LogEntryFilter
+
+// FilterLogEntry implements LogEntryFilter.
+func (f OrFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	for _, filter := range f {
+		if filter.FilterLogEntry(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotFilter matches entries not matching the filter.
+type NotFilter struct {
+	// Filter is the filter to negate. A nil Filter matches all entries,
+	// so nothing is matched.
+	Filter LogEntryFilter
+}
+
+// FilterLogEntry implements LogEntryFilter.
+func (f NotFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	return f.Filter != nil && !f.Filter.FilterLogEntry(entry)
+}
+
+// Or creates an OrFilter.
+func Or(filters ...LogEntryFilter) OrFilter {
+	return OrFilter(filters)
+}
+
+// Not creates a NotFilter.
+func Not(filter LogEntryFilter) *NotFilter {
+	return &NotFilter{Filter: filter}
+}
+
 // TimeRangeFilter filters logs by start and end time.
 // Both Since/Before are optional (ignored if IsZero is true).
 type TimeRangeFilter struct {
@@ -156,6 +199,69 @@ func FilterByTrace(traceIDContains string) *TraceSpanFilter {
 	return &TraceSpanFilter{TraceIDContains: traceIDContains}
 }
 
+// SpanKindFilter filters logs by the kind of the span.
+// As the kind is only available in span start events, the kinds of spans are
+// recorded from the span start events as the entries are filtered in order, and
+// the logs in a span are matched only if the span start event has been filtered.
+type SpanKindFilter struct {
+	Kind logspb.Span_Kind
+
+	lock  sync.Mutex
+	spans map[uint64]logspb.Span_Kind
+}
+
+// FilterLogEntry implements LogEntryFilter.
+func (f *SpanKindFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	spanCtx := entry.GetTrace().GetSpanContext()
+	if spanCtx == nil {
+		return false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if start := entry.GetTrace().GetSpanStart(); start != nil {
+		if f.spans == nil {
+			f.spans = make(map[uint64]logspb.Span_Kind)
+		}
+		f.spans[spanCtx.GetSpanId()] = start.GetKind()
+		return start.GetKind() == f.Kind
+	}
+	kind, ok := f.spans[spanCtx.GetSpanId()]
+	if entry.GetTrace().GetSpanEnd() != nil {
+		delete(f.spans, spanCtx.GetSpanId())
+	}
+	return ok && kind == f.Kind
+}
+
+// FilterBySpanKind creates a SpanKindFilter.
+func FilterBySpanKind(kind logspb.Span_Kind) *SpanKindFilter {
+	return &SpanKindFilter{Kind: kind}
+}
+
+// ParseSpanKind parses a span kind case-insensitively, e.g. server.
+func ParseSpanKind(str string) (logspb.Span_Kind, error) {
+	kind, ok := logspb.Span_Kind_value[strings.ToUpper(str)]
+	if !ok {
+		return logspb.Span_UNSPECIFIED, fmt.Errorf("unknown span kind %q, expect one of internal, server, client, producer, consumer", str)
+	}
+	return logspb.Span_Kind(kind), nil
+}
+
+// ClientFilter filters logs by the name of the client emitting the logs.
+// LogEntry doesn't carry the client name, so it matches the ClientAttr attribute.
+type ClientFilter struct {
+	Name string
+}
+
+// FilterLogEntry implements LogEntryFilter.
+func (f ClientFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	return entry.GetAttributes()[ClientAttr].GetStrValue() == f.Name
+}
+
+// FilterByClient creates a ClientFilter.
+func FilterByClient(name string) *ClientFilter {
+	return &ClientFilter{Name: name}
+}
+
 // LocationFilter filter logs by location.
 type LocationFilter struct {
 	// ContainsAny specifies the substrings to be contained in the location.
@@ -165,14 +271,19 @@ type LocationFilter struct {
 	ContainsAll []string
 	// NotContains specifies the substrings none should be matched.
 	NotContains []string
+	// Matches specifies the regular expressions must all be matched.
+	Matches []*regexp.Regexp
+	// NotMatches specifies the regular expressions none should be matched.
+	NotMatches []*regexp.Regexp
 }
 
 // FilterLogEntry implements LogEntryFilter.
 func (f LocationFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	loc := entry.GetLocation()
 	if len(f.ContainsAny) > 0 {
 		matched := false
 		for _, str := range f.ContainsAny {
-			if strings.Contains(entry.GetLocation(), str) {
+			if strings.Contains(loc, str) {
 				matched = true
 				break
 			}
@@ -181,23 +292,42 @@ func (f LocationFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
 			return false
 		}
 	}
-	if len(f.ContainsAll) > 0 {
-		for _, str := range f.ContainsAny {
-			if !strings.Contains(entry.GetLocation(), str) {
-				return false
-			}
+	for _, str := range f.ContainsAll {
+		if !strings.Contains(loc, str) {
+			return false
 		}
 	}
-	if len(f.NotContains) > 0 {
-		for _, str := range f.ContainsAny {
-			if strings.Contains(entry.GetLocation(), str) {
-				return false
-			}
+	for _, str := range f.NotContains {
+		if strings.Contains(loc, str) {
+			return false
+		}
+	}
+	for _, re := range f.Matches {
+		if !re.MatchString(loc) {
+			return false
+		}
+	}
+	for _, re := range f.NotMatches {
+		if re.MatchString(loc) {
+			return false
 		}
 	}
 	return true
 }
 
+// LocationMatches creates a LocationFilter matching the location with a regular expression.
+// If negate is true, the filter matches locations not matching the regular expression.
+func LocationMatches(pattern string, negate bool) (*LocationFilter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid location regexp %q: %w", pattern, err)
+	}
+	if negate {
+		return &LocationFilter{NotMatches: []*regexp.Regexp{re}}, nil
+	}
+	return &LocationFilter{Matches: []*regexp.Regexp{re}}, nil
+}
+
 // SpanEventFilter filter logs by matching span events.
 type SpanEventFilter struct {
 	// Exclude excludes entries representing span events.
@@ -217,6 +347,28 @@ func ExcludeSpanEvents() *SpanEventFilter {
 	return &SpanEventFilter{Exclude: true}
 }
 
+// ErroredFilter matches entries at level ERROR or above, or carrying an error attribute,
+// e.g. the span end entry with an error status, or ended by a panic.
+type ErroredFilter struct{}
+
+// FilterLogEntry implements LogEntryFilter.
+func (f ErroredFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
+	if entry.GetLevel() >= logspb.LogEntry_ERROR {
+		return true
+	}
+	attrs := entry.GetAttributes()
+	if _, ok := attrs[logs.ErrorAttr]; ok {
+		return true
+	}
+	_, ok := attrs[logs.PanicAttr]
+	return ok
+}
+
+// Errored returns an ErroredFilter.
+func Errored() *ErroredFilter {
+	return &ErroredFilter{}
+}
+
 // AttributeFilter implements LogEntryFilter.
 type AttributeFilter struct {
 	// Name is the attribute name.
@@ -226,10 +378,38 @@ type AttributeFilter struct {
 }
 
 func (f AttributeFilter) FilterLogEntry(entry *logspb.LogEntry) bool {
-	return f.Matcher(entry.GetAttributes()[f.Name])
+	return f.Matcher(lookupAttribute(entry.GetAttributes(), f.Name))
 }
 
+// lookupAttribute finds the attribute by name, and a dotted name refers to
+// a nested attribute, e.g. http.method refers to method in the map of http.
+func lookupAttribute(attrs map[string]*logspb.Value, name string) *logspb.Value {
+	if val, ok := attrs[name]; ok {
+		return val
+	}
+	for n := 0; n < len(name); n++ {
+		if name[n] != '.' {
+			continue
+		}
+		if nested := attrs[name[:n]].GetMapValue(); nested != nil {
+			if val := lookupAttribute(nested.GetValues(), name[n+1:]); val != nil {
+				return val
+			}
+		}
+	}
+	return nil
+}
+
+// ParseAttributeFilter parses NAME OP VALUE, or NAME for the existence of the
+// attribute regardless of the value, and !NAME for the absence.
 func ParseAttributeFilter(str string) (*AttributeFilter, error) {
+	if matches := attrExistRegexp.FindStringSubmatch(str); matches != nil {
+		absent := matches[1] != ""
+		return &AttributeFilter{
+			Name:    matches[2],
+			Matcher: func(v *logspb.Value) bool { return (v != nil) != absent },
+		}, nil
+	}
 	matches := attrFilterRegexp.FindAllStringSubmatch(str, -1)
 	if len(matches) != 1 || len(matches[0]) != 4 {
 		return nil, fmt.Errorf("invalid attribute filter: %s", str)
@@ -341,9 +521,29 @@ func ordinalCompare[T string | float64 | uint64 | int64](v1, v2 T, op string) bo
 	return false
 }
 
+func decimalCompare(val string, dec *big.Rat, str, op string) bool {
+	d, err := logs.ParseDecimal(val)
+	if err != nil || dec == nil {
+		return (op == "=" || op == "!=") && ordinalCompare(val, str, op)
+	}
+	return ordinalCompare(int64(d.Cmp(dec)), 0, op)
+}
+
 func ordinalMatcher(str, op string) func(*logspb.Value) bool {
 	strVals := parseStrValues(str)
+	strDec, _ := logs.ParseDecimal(str)
 	equalCmp := op == "=" || op == "!="
+	// Numeric and decimal equalities are decided by logs.ValueEqual.
+	var numVal, decVal *logspb.Value
+	if equalCmp {
+		switch {
+		case strVals.iVal != nil:
+			numVal = &logspb.Value{Value: &logspb.Value_IntValue{IntValue: *strVals.iVal}}
+		case strVals.fVal != nil:
+			numVal = &logspb.Value{Value: &logspb.Value_DoubleValue{DoubleValue: *strVals.fVal}}
+		}
+		decVal = &logspb.Value{Value: &logspb.Value_Decimal{Decimal: str}}
+	}
 	return func(v *logspb.Value) bool {
 		if v == nil && equalCmp {
 			return ordinalCompare("", str, op)
@@ -360,13 +560,37 @@ func ordinalMatcher(str, op string) func(*logspb.Value) bool {
 			}
 			return false
 		case *logspb.Value_DoubleValue:
+			if numVal != nil {
+				return logs.ValueEqual(v, numVal) == (op == "=")
+			}
 			return strVals.floatCompare(val.DoubleValue, op)
 		case *logspb.Value_FloatValue:
+			if numVal != nil {
+				return logs.ValueEqual(v, numVal) == (op == "=")
+			}
 			return strVals.floatCompare(float64(val.FloatValue), op)
 		case *logspb.Value_IntValue:
+			if numVal != nil {
+				return logs.ValueEqual(v, numVal) == (op == "=")
+			}
 			return strVals.intCompare(val.IntValue, op)
 		case *logspb.Value_StrValue:
 			return ordinalCompare(val.StrValue, str, op)
+		case *logspb.Value_Duration:
+			if d, err := time.ParseDuration(str); err == nil {
+				return ordinalCompare(val.Duration, int64(d), op)
+			}
+			return strVals.intCompare(val.Duration, op)
+		case *logspb.Value_Time:
+			if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+				return ordinalCompare(val.Time, t.UnixNano(), op)
+			}
+			return strVals.intCompare(val.Time, op)
+		case *logspb.Value_Decimal:
+			if decVal != nil {
+				return logs.ValueEqual(v, decVal) == (op == "=")
+			}
+			return decimalCompare(val.Decimal, strDec, str, op)
 		}
 		return false
 	}
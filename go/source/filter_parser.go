@@ -28,16 +28,49 @@ func ParseFilters(strs ...string) (LogEntryFilters, error) {
 }
 
 // ParseFilter parses a string into a LogEntryFilter.
+// A leading ! negates the filter, and filters separated by | in parentheses
+// are combined with OR, e.g. "(level=error|a:urgent=true)" and "!(loc=a,b|failed)".
+// The groups can be nested, and | is only a separator at the top level of
+// a group, so a regexp with | in a group must be wrapped as "(loc~(a|b)|failed)".
+// Levels can be ranges, inclusive on both ends, e.g. "level=warning..error",
+// or comparisons, e.g. "level>=warning,<critical".
+// Note a nil filter is returned if it matches all entries.
 func ParseFilter(str string) (LogEntryFilter, error) {
+	if strings.HasPrefix(str, "!") {
+		f, err := ParseFilter(str[1:])
+		if err != nil {
+			return nil, err
+		}
+		return Not(f), nil
+	}
+	if strings.HasPrefix(str, "(") && strings.HasSuffix(str, ")") {
+		return parseOrFilter(str[1 : len(str)-1])
+	}
 	if strings.HasPrefix(str, "a:") {
 		return ParseAttributeFilter(str[2:])
 	}
+	for _, prefix := range []string{"location", "loc"} {
+		if strings.HasPrefix(str, prefix+"!~") {
+			return LocationMatches(str[len(prefix)+2:], true)
+		}
+		if strings.HasPrefix(str, prefix+"~") {
+			return LocationMatches(str[len(prefix)+1:], false)
+		}
+	}
+	for _, prefix := range []string{"level", "lv", "l"} {
+		if strings.HasPrefix(str, prefix+"<") || strings.HasPrefix(str, prefix+">") {
+			return parseLevelComparisons(str[len(prefix):])
+		}
+	}
 
 	tokens := strings.SplitN(str, "=", 2)
 
 	if len(tokens) == 1 {
-		if tokens[0] == "" {
+		switch tokens[0] {
+		case "":
 			return nil, nil
+		case "errored":
+			return Errored(), nil
 		}
 		return MessageContains(tokens[0]), nil
 	}
@@ -57,6 +90,9 @@ func ParseFilter(str string) (LogEntryFilter, error) {
 		}
 		return FilterBefore(t), nil
 	case "l", "lv", "level":
+		if strings.Contains(val, "..") {
+			return parseLevelRange(val)
+		}
 		level, err := logs.ParseLevel(val)
 		if err != nil {
 			return nil, err
@@ -74,17 +110,25 @@ func ParseFilter(str string) (LogEntryFilter, error) {
 			if item == "" {
 				continue
 			}
-			if strings.HasPrefix(val, "!") || strings.HasPrefix(val, "~") {
-				filter.NotContains = append(filter.NotContains, val[1:])
+			if strings.HasPrefix(item, "!") || strings.HasPrefix(item, "~") {
+				filter.NotContains = append(filter.NotContains, item[1:])
 				continue
 			}
-			if strings.HasPrefix(val, "+") {
-				filter.ContainsAll = append(filter.ContainsAll, val[1:])
+			if strings.HasPrefix(item, "+") {
+				filter.ContainsAll = append(filter.ContainsAll, item[1:])
 				continue
 			}
 			filter.ContainsAny = append(filter.ContainsAny, item)
 		}
 		return filter, nil
+	case "kind", "span-kind":
+		kind, err := ParseSpanKind(val)
+		if err != nil {
+			return nil, err
+		}
+		return FilterBySpanKind(kind), nil
+	case "client":
+		return FilterByClient(val), nil
 	case "span-events", "span-event", "event", "se", "ev":
 		switch strings.ToLower(val) {
 		case "", "no", "none":
@@ -96,6 +140,127 @@ func ParseFilter(str string) (LogEntryFilter, error) {
 	}
 }
 
+func parseOrFilter(str string) (LogEntryFilter, error) {
+	var items []string
+	var depth, start int
+	for n, ch := range str {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses: (%s)", str)
+			}
+		case '|':
+			if depth == 0 {
+				items = append(items, str[start:n])
+				start = n + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses: (%s)", str)
+	}
+	items = append(items, str[start:])
+	filters := make([]LogEntryFilter, 0, len(items))
+	for _, item := range items {
+		f, err := ParseFilter(item)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			// Matches all entries.
+			return nil, nil
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return Or(filters...), nil
+}
+
+// parseLevelRange parses MIN..MAX, inclusive on both ends, either can be
+// omitted for no limit.
+func parseLevelRange(str string) (LogEntryFilter, error) {
+	bounds := strings.SplitN(str, "..", 2)
+	minLevel, err := logs.ParseLevel(bounds[0])
+	if err != nil {
+		return nil, err
+	}
+	maxLevel, err := logs.ParseLevel(bounds[1])
+	if err != nil {
+		return nil, err
+	}
+	if maxLevel == logspb.LogEntry_NONE {
+		return levelRangeFilter(minLevel, logspb.LogEntry_NONE)
+	}
+	if minLevel > maxLevel {
+		return nil, fmt.Errorf("invalid level range %s: %s is above %s", str, minLevel, maxLevel)
+	}
+	return levelRangeFilter(minLevel, levelAbove(maxLevel))
+}
+
+// parseLevelComparisons parses comma separated comparisons, e.g. >=warning,<critical.
+func parseLevelComparisons(str string) (LogEntryFilter, error) {
+	var minLevel, maxLevel logspb.LogEntry_Level
+	var hasMin, hasMax bool
+	for _, item := range strings.Split(str, ",") {
+		op := item[:len(item)-len(strings.TrimLeft(item, "<>="))]
+		if item[len(op):] == "" {
+			return nil, fmt.Errorf("missing level in comparison: %s", item)
+		}
+		level, err := logs.ParseLevel(item[len(op):])
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case ">=", ">":
+			if hasMin {
+				return nil, fmt.Errorf("duplicated minimal level: %s", item)
+			}
+			hasMin, minLevel = true, level
+			if op == ">" {
+				if minLevel = levelAbove(level); minLevel == logspb.LogEntry_NONE {
+					return nil, fmt.Errorf("no level above %s", level)
+				}
+			}
+		case "<=", "<":
+			if hasMax {
+				return nil, fmt.Errorf("duplicated maximal level: %s", item)
+			}
+			hasMax, maxLevel = true, level
+			if op == "<=" {
+				maxLevel = levelAbove(level)
+			} else if level == logspb.LogEntry_NONE {
+				return nil, fmt.Errorf("no level below %s", level)
+			}
+		default:
+			return nil, fmt.Errorf("invalid level comparison: %s", item)
+		}
+	}
+	if maxLevel != logspb.LogEntry_NONE && maxLevel <= minLevel {
+		return nil, fmt.Errorf("invalid level range %s: no level matches", str)
+	}
+	return levelRangeFilter(minLevel, maxLevel)
+}
+
+// levelRangeFilter creates a LevelFilter, or nil if it matches all levels.
+func levelRangeFilter(minLevel, maxLevel logspb.LogEntry_Level) (LogEntryFilter, error) {
+	if minLevel == logspb.LogEntry_NONE && maxLevel == logspb.LogEntry_NONE {
+		return nil, nil
+	}
+	return FilterByLevel(minLevel).AndBelow(maxLevel), nil
+}
+
+// levelAbove returns the next level, or NONE (no limit) for the highest level.
+func levelAbove(level logspb.LogEntry_Level) logspb.LogEntry_Level {
+	if level >= logspb.LogEntry_FATAL {
+		return logspb.LogEntry_NONE
+	}
+	return level + 1
+}
+
 func parseTime(str string) (time.Time, error) {
 	nanos, err := strconv.ParseInt(str, 10, 64)
 	if err == nil {
@@ -1,7 +1,10 @@
 package source
 
 import (
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 	"github.com/evo-cloud/logs/go/logs"
@@ -13,6 +16,10 @@ func logEntryWith(setters ...logs.AttributeSetter) *logspb.LogEntry {
 	return entry
 }
 
+func logEntryAt(loc string) *logspb.LogEntry {
+	return &logspb.LogEntry{Location: loc}
+}
+
 func TestFilters(t *testing.T) {
 	testCases := []struct {
 		filter string
@@ -33,6 +40,66 @@ func TestFilters(t *testing.T) {
 			entry:  logEntryWith(logs.Str("key", "a")),
 			match:  true,
 		},
+		{
+			filter: "a:http.method=GET",
+			entry:  logEntryWith(logs.Map("http", logs.Str("method", "GET"), logs.Int("status", 200))),
+			match:  true,
+		},
+		{
+			filter: "a:http.status>=400",
+			entry:  logEntryWith(logs.Map("http", logs.Str("method", "GET"), logs.Int("status", 200))),
+		},
+		{
+			filter: "a:elapsed>1s",
+			entry:  logEntryWith(logs.Duration("elapsed", 1200*time.Millisecond)),
+			match:  true,
+		},
+		{
+			filter: "a:elapsed<1000",
+			entry:  logEntryWith(logs.Duration("elapsed", time.Microsecond)),
+		},
+		{
+			filter: "a:at>=2022-01-01T00:00:00Z",
+			entry:  logEntryWith(logs.Time("at", time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC))),
+			match:  true,
+		},
+		{
+			filter: "a:n=1",
+			entry:  logEntryWith(logs.Double("n", 1)),
+			match:  true,
+		},
+		{
+			filter: "a:n=9007199254740993",
+			entry:  logEntryWith(logs.Double("n", 9007199254740992)),
+		},
+		{
+			filter: "a:n!=0.1",
+			entry:  logEntryWith(logs.Float("n", 0.1)),
+			match:  true,
+		},
+		{
+			filter: "a:amount=0.3",
+			entry:  logEntryWith(logs.Decimal("amount", "0.30")),
+			match:  true,
+		},
+		{
+			filter: "a:amount!=1.5",
+			entry:  logEntryWith(logs.Decimal("amount", "1.50")),
+		},
+		{
+			filter: "a:amount>0.1",
+			entry:  logEntryWith(logs.Decimal("amount", "0.10000000000000000001")),
+			match:  true,
+		},
+		{
+			filter: "a:amount<99999999999999999.99",
+			entry:  logEntryWith(logs.Decimal("amount", "99999999999999999.98")),
+			match:  true,
+		},
+		{
+			filter: "a:amount>=1e3",
+			entry:  logEntryWith(logs.Decimal("amount", "999.999")),
+		},
 		{
 			filter: "a:key>=a",
 			entry:  logEntryWith(logs.Str("key", "a")),
@@ -246,6 +313,58 @@ func TestFilters(t *testing.T) {
 			filter: "a:key>1",
 			entry:  logEntryWith(logs.Int("key", -1)),
 		},
+		// locations.
+		{
+			filter: "loc=server/,logs/",
+			entry:  logEntryAt("server/filestore.go:10"),
+			match:  true,
+		},
+		{
+			filter: "loc=server/,!_test.go",
+			entry:  logEntryAt("server/filestore_test.go:10"),
+		},
+		{
+			filter: "loc=+server/,+store",
+			entry:  logEntryAt("server/ingress.go:10"),
+		},
+		{
+			filter: `loc~^server/.*_test\.go:`,
+			entry:  logEntryAt("server/filestore_test.go:10"),
+			match:  true,
+		},
+		{
+			filter: `loc~^server/.*_test\.go:`,
+			entry:  logEntryAt("logs/server/filestore_test.go:10"),
+		},
+		{
+			filter: `location!~\.pb\.go:`,
+			entry:  logEntryAt("gen/proto/logs/log.pb.go:10"),
+		},
+		{
+			filter: `location!~\.pb\.go:`,
+			entry:  logEntryAt("server/ingress.go:10"),
+			match:  true,
+		},
+		// errored entries.
+		{
+			filter: "errored",
+			entry:  &logspb.LogEntry{Level: logspb.LogEntry_CRITICAL},
+			match:  true,
+		},
+		{
+			filter: "errored",
+			entry:  logEntryWith(logs.Str(logs.ErrorAttr, "not found")),
+			match:  true,
+		},
+		{
+			filter: "errored",
+			entry:  logEntryWith(logs.Bool(logs.PanicAttr, true)),
+			match:  true,
+		},
+		{
+			filter: "errored",
+			entry:  &logspb.LogEntry{Level: logspb.LogEntry_WARNING},
+		},
 	}
 	for n := range testCases {
 		tc := testCases[n]
@@ -261,3 +380,303 @@ func TestFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalidLocationRegexp(t *testing.T) {
+	if _, err := ParseFilter("loc~server/(.*"); err == nil {
+		t.Errorf("Expect error for invalid regexp")
+	}
+}
+
+func TestCombinedFilters(t *testing.T) {
+	errorEntry := &logspb.LogEntry{Level: logspb.LogEntry_ERROR, Location: "server/ingress.go:10", Message: "failed"}
+	urgentEntry := logEntryWith(logs.Bool("urgent", true))
+	urgentEntry.Location = "server/filestore.go:10"
+	infoEntry := &logspb.LogEntry{Level: logspb.LogEntry_INFO, Location: "server/ingress.go:20", Message: "ok"}
+	testCases := []struct {
+		filters []string
+		entry   *logspb.LogEntry
+		match   bool
+	}{
+		{
+			filters: []string{"(level=error|a:urgent=true)"},
+			entry:   errorEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(level=error|a:urgent=true)"},
+			entry:   urgentEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(level=error|a:urgent=true)"},
+			entry:   infoEntry,
+		},
+		{
+			filters: []string{"!level=error"},
+			entry:   errorEntry,
+		},
+		{
+			filters: []string{"!level=error"},
+			entry:   infoEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(level=error|a:urgent=true)", "loc=ingress"},
+			entry:   errorEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(level=error|a:urgent=true)", "loc=ingress"},
+			entry:   urgentEntry,
+		},
+		{
+			filters: []string{"!(level=error|a:urgent=true)", "loc=ingress"},
+			entry:   infoEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(failed|!(a:urgent=true|loc=server/))"},
+			entry:   urgentEntry,
+		},
+		{
+			filters: []string{"(failed|!(a:urgent=true|loc=server/))"},
+			entry:   errorEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(loc=filestore,ingress)"},
+			entry:   infoEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(loc=filestore,ingress)"},
+			entry:   &logspb.LogEntry{Location: "server/query.go:10", Message: "ingress"},
+		},
+		{
+			filters: []string{"!(loc=filestore,ingress)"},
+			entry:   &logspb.LogEntry{Location: "server/query.go:10", Message: "ingress"},
+			match:   true,
+		},
+		{
+			filters: []string{"((loc=filestore,ingress)|level=none)"},
+			entry:   &logspb.LogEntry{Location: "server/query.go:10", Message: "ingress"},
+			match:   true,
+		},
+		{
+			filters: []string{"(level>=warning,<critical|a:urgent=true)"},
+			entry:   errorEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"(level>=warning,<critical|a:urgent=true)"},
+			entry:   &logspb.LogEntry{Level: logspb.LogEntry_CRITICAL, Message: "critical"},
+		},
+		{
+			filters: []string{"(loc~(filestore|ingress)|failed)"},
+			entry:   infoEntry,
+			match:   true,
+		},
+		{
+			filters: []string{"!!failed"},
+			entry:   errorEntry,
+			match:   true,
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(strings.Join(tc.filters, " "), func(t *testing.T) {
+			f, err := ParseFilters(tc.filters...)
+			if err != nil {
+				t.Fatalf("parse filters %q: %v", tc.filters, err)
+			}
+			if match := f.FilterLogEntry(tc.entry); match != tc.match {
+				t.Errorf("Expect match=%v, got %v", tc.match, match)
+			}
+		})
+	}
+}
+
+func TestInvalidCombinedFilters(t *testing.T) {
+	for _, str := range []string{"((level=error)", "(a)|(b)", "!(level=bad|a:key=1)"} {
+		if _, err := ParseFilter(str); err == nil {
+			t.Errorf("Expect error for %q", str)
+		}
+	}
+}
+
+func TestSpanKindFilter(t *testing.T) {
+	spanCtx := func(id uint64) *logspb.SpanContext {
+		return &logspb.SpanContext{TraceId: make([]byte, 16), SpanId: id}
+	}
+	entries := []*logspb.LogEntry{
+		{Trace: &logspb.Trace{SpanContext: spanCtx(1), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Kind: logspb.Span_SERVER}}}},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(2), Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Kind: logspb.Span_CLIENT}}}},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(1)}},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(2)}},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(3)}},
+		{},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(1), Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}},
+		{Trace: &logspb.Trace{SpanContext: spanCtx(1)}},
+	}
+	f, err := ParseFilter("kind=Server")
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+	expected := []bool{true, false, true, false, false, false, true, false}
+	for n, entry := range entries {
+		if match := f.FilterLogEntry(entry); match != expected[n] {
+			t.Errorf("entry %d: expect match=%v, got %v", n, expected[n], match)
+		}
+	}
+}
+
+func TestClientFilter(t *testing.T) {
+	f, err := ParseFilter("client=my-service")
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+	if !f.FilterLogEntry(logEntryWith(logs.Str(ClientAttr, "my-service"))) {
+		t.Errorf("Expect match of the same client")
+	}
+	if f.FilterLogEntry(logEntryWith(logs.Str(ClientAttr, "other"))) {
+		t.Errorf("Expect no match of other client")
+	}
+	if f.FilterLogEntry(logEntryWith()) {
+		t.Errorf("Expect no match without client")
+	}
+}
+
+func TestInvalidSpanKind(t *testing.T) {
+	_, err := ParseFilter("kind=backend")
+	if err == nil {
+		t.Fatalf("Expect error for unknown span kind")
+	}
+	if !strings.Contains(err.Error(), `unknown span kind "backend"`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParseLevelFilter(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected *LevelFilter
+	}{
+		{filter: "level=warning", expected: &LevelFilter{MinLevel: logspb.LogEntry_WARNING}},
+		{filter: "level=none"},
+		{filter: "level=warning..error", expected: &LevelFilter{MinLevel: logspb.LogEntry_WARNING, MaxLevel: logspb.LogEntry_CRITICAL}},
+		{filter: "lv=e..e", expected: &LevelFilter{MinLevel: logspb.LogEntry_ERROR, MaxLevel: logspb.LogEntry_CRITICAL}},
+		{filter: "level=..warning", expected: &LevelFilter{MaxLevel: logspb.LogEntry_ERROR}},
+		{filter: "level=error..", expected: &LevelFilter{MinLevel: logspb.LogEntry_ERROR}},
+		{filter: "level=info..fatal", expected: &LevelFilter{MinLevel: logspb.LogEntry_INFO}},
+		{filter: "level=..", expected: nil},
+		{filter: "level>=warning,<critical", expected: &LevelFilter{MinLevel: logspb.LogEntry_WARNING, MaxLevel: logspb.LogEntry_CRITICAL}},
+		{filter: "level>info", expected: &LevelFilter{MinLevel: logspb.LogEntry_WARNING}},
+		{filter: "l<=warning", expected: &LevelFilter{MaxLevel: logspb.LogEntry_ERROR}},
+		{filter: "level<info", expected: &LevelFilter{MaxLevel: logspb.LogEntry_INFO}},
+		{filter: "level>=none"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.filter, func(t *testing.T) {
+			f, err := ParseFilter(tc.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter: %v", err)
+			}
+			if tc.expected == nil {
+				if f != nil {
+					t.Errorf("Expect nil filter, got %#v", f)
+				}
+				return
+			}
+			if lf, ok := f.(*LevelFilter); !ok || *lf != *tc.expected {
+				t.Errorf("Expect %#v, got %#v", tc.expected, f)
+			}
+		})
+	}
+}
+
+func TestParseLevelFilterRange(t *testing.T) {
+	f, err := ParseFilter("level=warning..error")
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	for level, match := range map[logspb.LogEntry_Level]bool{
+		logspb.LogEntry_INFO:     false,
+		logspb.LogEntry_WARNING:  true,
+		logspb.LogEntry_ERROR:    true,
+		logspb.LogEntry_CRITICAL: false,
+	} {
+		if f.FilterLogEntry(&logspb.LogEntry{Level: level}) != match {
+			t.Errorf("Expect match=%v for %s", match, level)
+		}
+	}
+}
+
+func TestInvalidLevelFilters(t *testing.T) {
+	for _, str := range []string{
+		"level=error..warning",
+		"level=warning..bad",
+		"level=bad..error",
+		"level>=error,<warning",
+		"level>=error,<error",
+		"level>fatal",
+		"level<none",
+		"level>=info,>=warning",
+		"level<=error,<fatal",
+		"level=>warning",
+		"level>=bad",
+		"level>",
+		"level>=warning,<",
+		"(level>=warning,<)",
+	} {
+		if _, err := ParseFilter(str); err == nil {
+			t.Errorf("Expect error for %q", str)
+		}
+	}
+}
+
+func TestAttributeExistenceFilter(t *testing.T) {
+	entries := map[string]*logspb.LogEntry{
+		"with":     logEntryWith(logs.Str("user", "alice")),
+		"empty":    logEntryWith(logs.Str("user", "")),
+		"false":    logEntryWith(logs.Bool("user", false)),
+		"nested":   logEntryWith(logs.Map("http", logs.Int("status", 200))),
+		"other":    logEntryWith(logs.Str("client", "web")),
+		"no attrs": {Message: "user"},
+	}
+	testCases := []struct {
+		filter  string
+		matches []string
+	}{
+		{filter: "a:user", matches: []string{"empty", "false", "with"}},
+		{filter: "a:!user", matches: []string{"nested", "no attrs", "other"}},
+		{filter: "a:http.status", matches: []string{"nested"}},
+		{filter: "a:!http.status", matches: []string{"empty", "false", "no attrs", "other", "with"}},
+		// Empty value matching treats absent attributes as empty strings.
+		{filter: "a:user=", matches: []string{"empty", "nested", "no attrs", "other"}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.filter, func(t *testing.T) {
+			f, err := ParseFilter(tc.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter: %v", err)
+			}
+			var matches []string
+			for name, entry := range entries {
+				if f.FilterLogEntry(entry) {
+					matches = append(matches, name)
+				}
+			}
+			sort.Strings(matches)
+			if strings.Join(matches, ",") != strings.Join(tc.matches, ",") {
+				t.Errorf("Expect matches %v, got %v", tc.matches, matches)
+			}
+		})
+	}
+	for _, str := range []string{"a:", "a:!", "a:!!user"} {
+		if _, err := ParseFilter(str); err == nil {
+			t.Errorf("Expect error for %q", str)
+		}
+	}
+}
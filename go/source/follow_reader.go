@@ -0,0 +1,147 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	defaultFollowMinBackoff = 100 * time.Millisecond
+	defaultFollowMaxBackoff = time.Second
+)
+
+// ErrRotated indicates the followed file is rotated or truncated, and
+// should be reopened from the beginning.
+var ErrRotated = errors.New("file rotated")
+
+// FollowFile reads a file like `tail -f`: on EOF, it waits for more data
+// instead of returning io.EOF. When the file at Path is replaced (inode
+// changed) or truncated, Read returns ErrRotated after all data in the
+// opened file is consumed.
+type FollowFile struct {
+	Path       string
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	file   *os.File
+	offset int64
+	ctx    context.Context
+}
+
+// OpenFollowFile opens a file for following.
+func OpenFollowFile(path string) (*FollowFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FollowFile{Path: path, file: f}, nil
+}
+
+// SetContext sets the context for cancelling the waiting in Read.
+func (f *FollowFile) SetContext(ctx context.Context) {
+	f.ctx = ctx
+}
+
+// Read implements io.Reader.
+func (f *FollowFile) Read(p []byte) (int, error) {
+	backoff := f.MinBackoff
+	if backoff <= 0 {
+		backoff = defaultFollowMinBackoff
+	}
+	maxBackoff := f.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultFollowMaxBackoff
+	}
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if f.rotated() {
+			return 0, ErrRotated
+		}
+		ctx := f.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// rotated checks if the file at Path is no longer the opened file,
+// or the opened file is truncated.
+func (f *FollowFile) rotated() bool {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		// The file may be removed and not yet recreated during rotation.
+		return false
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(info, current) || current.Size() < f.offset
+}
+
+// Close implements io.Closer.
+func (f *FollowFile) Close() error {
+	return f.file.Close()
+}
+
+// FollowReader reads log entries from a file like `tail -f`, and reopens
+// the file when it's rotated.
+type FollowReader struct {
+	Path       string
+	SkipErrors bool
+
+	file   *FollowFile
+	reader *StreamReader
+}
+
+// NewFollow creates a FollowReader.
+func NewFollow(path string) *FollowReader {
+	return &FollowReader{Path: path}
+}
+
+// Read implements Reader.
+func (r *FollowReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	for {
+		if r.file == nil {
+			f, err := OpenFollowFile(r.Path)
+			if err != nil {
+				return nil, err
+			}
+			r.file = f
+			r.reader = &StreamReader{In: f, SkipErrors: r.SkipErrors}
+		}
+		r.file.SetContext(ctx)
+		entry, err := r.reader.Read(ctx)
+		if err == nil || !errors.Is(err, ErrRotated) {
+			return entry, err
+		}
+		r.Close()
+	}
+}
+
+// Close implements io.Closer.
+func (r *FollowReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	err := r.reader.Close()
+	r.file, r.reader = nil, nil
+	return err
+}
@@ -0,0 +1,92 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestFollowReader(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "current.logs.blob")
+	entries := streamTestEntries()
+	// appendData doesn't fail the test itself, so it can be called from other goroutines.
+	appendData := func(data []byte) error {
+		f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(data)
+		return err
+	}
+	appendEntries := func(t *testing.T, entries ...*logspb.LogEntry) {
+		if err := appendData(encodeBlob(t, entries)); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	appendEntries(t, entries[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := NewFollow(fn)
+	defer r.Close()
+	expect := func(t *testing.T, ctx context.Context, expected *logspb.LogEntry) {
+		entry, err := r.Read(ctx)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if !proto.Equal(entry, expected) {
+			t.Errorf("read %v, expect %v", entry, expected)
+		}
+	}
+	expect(t, ctx, entries[0])
+
+	// Entries appended after EOF.
+	data := encodeBlob(t, entries[1:2])
+	appendCtx, cancelAppend := context.WithCancel(ctx)
+	defer cancelAppend()
+	appendErr := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		err := appendData(data)
+		if err != nil {
+			// Stop waiting for the entry.
+			cancelAppend()
+		}
+		appendErr <- err
+	}()
+	entry, err := r.Read(appendCtx)
+	if err := <-appendErr; err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !proto.Equal(entry, entries[1]) {
+		t.Errorf("read %v, expect %v", entry, entries[1])
+	}
+
+	// Rotated file is reopened.
+	if err := os.Rename(fn, fn+".1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendEntries(t, entries[2])
+	expect(t, ctx, entries[2])
+
+	// Waiting is cancelled by the context.
+	cancelCtx, cancelRead := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancelRead()
+	}()
+	if _, err := r.Read(cancelCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Read after cancel: %v, expect context.Canceled", err)
+	}
+}
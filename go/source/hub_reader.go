@@ -0,0 +1,63 @@
+package source
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+const (
+	maxHubRecordSize = 1 << 24 // 16M
+)
+
+// HubStreamReader reads log entries from the hub wire format: each entry is
+// encoded in proto, prefixed by a 4-byte big-endian length, e.g. captured
+// from the hub egress stream.
+type HubStreamReader struct {
+	SkipErrors bool
+
+	in  io.Reader
+	buf []byte
+}
+
+// NewHubStream creates a HubStreamReader.
+func NewHubStream(in io.Reader) *HubStreamReader {
+	return &HubStreamReader{in: in}
+}
+
+// Read implements Reader.
+func (r *HubStreamReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	for {
+		var head [4]byte
+		if _, err := io.ReadFull(r.in, head[:]); err != nil {
+			return nil, err
+		}
+		size := binary.BigEndian.Uint32(head[:])
+		if size > maxHubRecordSize {
+			return nil, fmt.Errorf("record size %d exceeds limit %d", size, maxHubRecordSize)
+		}
+		if cap(r.buf) < int(size) {
+			r.buf = make([]byte, size)
+		}
+		data := r.buf[:size]
+		if _, err := io.ReadFull(r.in, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		entry := &logspb.LogEntry{}
+		if err := proto.Unmarshal(data, entry); err != nil {
+			if r.SkipErrors {
+				continue
+			}
+			return nil, err
+		}
+		return entry, nil
+	}
+}
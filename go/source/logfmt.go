@@ -0,0 +1,136 @@
+package source
+
+import (
+	"strconv"
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// Default limits of LogfmtExtractor.
+const (
+	DefaultLogfmtMaxPairs      = 32
+	DefaultLogfmtMaxMessageLen = 4096
+)
+
+// LogfmtExtractor extracts logfmt style key=value pairs embedded in the
+// message into attributes, e.g. for legacy logs without structured attributes.
+// It's heuristic: tokens which are not in the form of key=value are ignored,
+// and existing attributes are never overwritten. The message is kept as is.
+type LogfmtExtractor struct {
+	// MaxPairs limits the number of pairs extracted from a single message.
+	// DefaultLogfmtMaxPairs is used if zero.
+	MaxPairs int
+	// MaxMessageLen skips messages longer than this.
+	// DefaultLogfmtMaxMessageLen is used if zero.
+	MaxMessageLen int
+}
+
+// TransformLogEntry implements LogEntryTransformer.
+func (x *LogfmtExtractor) TransformLogEntry(entry *logspb.LogEntry) {
+	maxPairs, maxLen := x.MaxPairs, x.MaxMessageLen
+	if maxPairs <= 0 {
+		maxPairs = DefaultLogfmtMaxPairs
+	}
+	if maxLen <= 0 {
+		maxLen = DefaultLogfmtMaxMessageLen
+	}
+	msg := entry.GetMessage()
+	if len(msg) > maxLen || !strings.Contains(msg, "=") {
+		return
+	}
+	for _, pair := range ParseLogfmt(msg, maxPairs) {
+		if _, exists := entry.GetAttributes()[pair.Key]; exists {
+			continue
+		}
+		if entry.Attributes == nil {
+			entry.Attributes = make(map[string]*logspb.Value)
+		}
+		entry.Attributes[pair.Key] = logfmtValue(pair.Value, pair.Quoted)
+	}
+}
+
+// LogfmtPair is a key=value pair parsed from text.
+type LogfmtPair struct {
+	Key   string
+	Value string
+	// Quoted indicates the value is a double quoted string.
+	Quoted bool
+}
+
+// ParseLogfmt parses at most maxPairs key=value pairs from text. Values can be
+// double quoted with Go escape sequences. Malformed tokens are skipped.
+func ParseLogfmt(text string, maxPairs int) []LogfmtPair {
+	var pairs []LogfmtPair
+	for text != "" && len(pairs) < maxPairs {
+		text = strings.TrimLeft(text, " \t\r\n")
+		keyLen := 0
+		for keyLen < len(text) && isLogfmtKeyChar(text[keyLen], keyLen == 0) {
+			keyLen++
+		}
+		if keyLen == 0 || keyLen >= len(text) || text[keyLen] != '=' {
+			text = skipLogfmtToken(text)
+			continue
+		}
+		pair := LogfmtPair{Key: text[:keyLen]}
+		text = text[keyLen+1:]
+		if strings.HasPrefix(text, `"`) {
+			prefix, err := strconv.QuotedPrefix(text)
+			if err != nil {
+				text = skipLogfmtToken(text)
+				continue
+			}
+			pair.Value, _ = strconv.Unquote(prefix)
+			pair.Quoted = true
+			text = text[len(prefix):]
+			if text != "" && !isLogfmtSpace(text[0]) {
+				text = skipLogfmtToken(text)
+				continue
+			}
+		} else {
+			end := strings.IndexAny(text, " \t\r\n")
+			if end < 0 {
+				end = len(text)
+			}
+			pair.Value, text = text[:end], text[end:]
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+func isLogfmtKeyChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return !first
+	}
+	return false
+}
+
+func isLogfmtSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func skipLogfmtToken(text string) string {
+	if end := strings.IndexAny(text, " \t\r\n"); end >= 0 {
+		return text[end:]
+	}
+	return ""
+}
+
+func logfmtValue(str string, quoted bool) *logspb.Value {
+	if !quoted {
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return &logspb.Value{Value: &logspb.Value_IntValue{IntValue: i}}
+		}
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return &logspb.Value{Value: &logspb.Value_DoubleValue{DoubleValue: f}}
+		}
+		if b, err := strconv.ParseBool(str); err == nil && (str == "true" || str == "false") {
+			return &logspb.Value{Value: &logspb.Value_BoolValue{BoolValue: b}}
+		}
+	}
+	return &logspb.Value{Value: &logspb.Value_StrValue{StrValue: str}}
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// ErrNoLogfmtPairs indicates a line doesn't contain any key=value pairs.
+var ErrNoLogfmtPairs = errors.New("no key=value pairs")
+
+// LogfmtReader reads log entries in logfmt (a line of key=value pairs per
+// entry) from a stream. The well-known keys are mapped to the entry fields:
+//   - ts, time: the timestamp in RFC3339 or Unix seconds with optional fractions;
+//   - level, lvl: the level, see logs.ParseLevel;
+//   - msg, message: the message.
+//
+// Other pairs, as well as the well-known ones failed to parse, are attributes
+// with values detected as bool, int, float or string. Quoted values are always strings.
+type LogfmtReader struct {
+	SkipErrors bool
+
+	reader *bufio.Reader
+	err    error
+}
+
+// NewLogfmt creates a LogfmtReader.
+func NewLogfmt(in io.Reader) *LogfmtReader {
+	return &LogfmtReader{reader: bufio.NewReader(in)}
+}
+
+// Read implements Reader.
+func (r *LogfmtReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			r.err = err
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, perr := ParseLogfmtEntry(line)
+		if perr != nil {
+			if r.SkipErrors {
+				continue
+			}
+			return nil, perr
+		}
+		return entry, nil
+	}
+}
+
+// ParseLogfmtEntry parses a line of logfmt into a log entry.
+func ParseLogfmtEntry(line string) (*logspb.LogEntry, error) {
+	pairs := ParseLogfmt(line, len(line))
+	if len(pairs) == 0 {
+		return nil, ErrNoLogfmtPairs
+	}
+	entry := &logspb.LogEntry{}
+	for _, pair := range pairs {
+		switch pair.Key {
+		case "ts", "time":
+			if ts, ok := parseLogfmtTime(pair.Value); ok {
+				entry.NanoTs = ts
+				continue
+			}
+		case "level", "lvl":
+			if level, err := logs.ParseLevel(pair.Value); err == nil {
+				entry.Level = level
+				continue
+			}
+		case "msg", "message":
+			entry.Message = pair.Value
+			continue
+		}
+		if entry.Attributes == nil {
+			entry.Attributes = make(map[string]*logspb.Value)
+		}
+		entry.Attributes[pair.Key] = logfmtValue(pair.Value, pair.Quoted)
+	}
+	return entry, nil
+}
+
+func parseLogfmtTime(str string) (int64, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t.UnixNano(), true
+	}
+	if secs, err := strconv.ParseFloat(str, 64); err == nil && secs > 0 {
+		whole, frac := math.Modf(secs)
+		return int64(whole)*int64(time.Second) + int64(math.Round(frac*1e6))*int64(time.Microsecond), true
+	}
+	return 0, false
+}
@@ -0,0 +1,155 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestParseLogfmtEntry(t *testing.T) {
+	ts := time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC)
+	testCases := []struct {
+		line     string
+		expected *logspb.LogEntry
+	}{
+		{
+			line:     `ts=2023-11-14T22:13:20.123Z level=info msg="request done" user=alice`,
+			expected: entryWithAttrs(&logspb.LogEntry{NanoTs: ts.UnixNano(), Level: logspb.LogEntry_INFO, Message: "request done"}, logs.Str("user", "alice")),
+		},
+		{
+			line:     `time=1700000000.123 lvl=WARN message=retry`,
+			expected: &logspb.LogEntry{NanoTs: ts.UnixNano(), Level: logspb.LogEntry_WARNING, Message: "retry"},
+		},
+		{
+			line:     `level=e msg="failed: \"quoted\"\ttab" err="connection refused"`,
+			expected: entryWithAttrs(&logspb.LogEntry{Level: logspb.LogEntry_ERROR, Message: "failed: \"quoted\"\ttab"}, logs.Str("err", "connection refused")),
+		},
+		{
+			line:     `msg=done count=3 ratio=0.5 ok=true quoted="42"`,
+			expected: entryWithAttrs(&logspb.LogEntry{Message: "done"}, logs.Int("count", 3), logs.Double("ratio", 0.5), logs.Bool("ok", true), logs.Str("quoted", "42")),
+		},
+		{
+			line:     `level=debug ts=yesterday msg=kept`,
+			expected: entryWithAttrs(&logspb.LogEntry{Message: "kept"}, logs.Str("level", "debug"), logs.Str("ts", "yesterday")),
+		},
+		{
+			line:     `level=critical msg= empty=""`,
+			expected: entryWithAttrs(&logspb.LogEntry{Level: logspb.LogEntry_CRITICAL}, logs.Str("empty", "")),
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.line, func(t *testing.T) {
+			entry, err := ParseLogfmtEntry(tc.line)
+			if err != nil {
+				t.Fatalf("ParseLogfmtEntry: %v", err)
+			}
+			if !proto.Equal(entry, tc.expected) {
+				t.Errorf("Expect %v, got %v", tc.expected, entry)
+			}
+		})
+	}
+	if _, err := ParseLogfmtEntry("not logfmt"); !errors.Is(err, ErrNoLogfmtPairs) {
+		t.Errorf("Expect ErrNoLogfmtPairs, got %v", err)
+	}
+}
+
+func TestLogfmtReader(t *testing.T) {
+	input := "level=info msg=first\n\nplain text\nlevel=error msg=second"
+	readAll := func(r Reader) ([]string, error) {
+		var messages []string
+		for {
+			entry, err := r.Read(context.Background())
+			if err != nil {
+				return messages, err
+			}
+			messages = append(messages, entry.GetMessage())
+		}
+	}
+
+	r := NewLogfmt(strings.NewReader(input))
+	if messages, err := readAll(r); !errors.Is(err, ErrNoLogfmtPairs) || len(messages) != 1 {
+		t.Errorf("Expect ErrNoLogfmtPairs after 1 entry, got %v, %v", messages, err)
+	}
+	r = NewLogfmt(strings.NewReader(input))
+	r.SkipErrors = true
+	if messages, err := readAll(r); !errors.Is(err, io.EOF) || strings.Join(messages, ",") != "first,second" {
+		t.Errorf("Expect entries first,second, got %v, %v", messages, err)
+	}
+}
+
+func TestStreamReaderLogfmt(t *testing.T) {
+	// A blob record of size 0x3d61 starts with "a=" followed by zeros.
+	blobLike := &logspb.LogEntry{NanoTs: 1, Message: strings.Repeat("x", 0x3d00)}
+	blobLike.Message += strings.Repeat("x", 0x3d61-blobBodySize(t, blobLike))
+	if size := blobBodySize(t, blobLike); size != 0x3d61 {
+		t.Fatalf("Expect blob body size 0x3d61, got %#x", size)
+	}
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected []*logspb.LogEntry
+	}{
+		{
+			name: "logfmt",
+			data: []byte("\n  level=info msg=first\nlevel=error msg=\"second line\"\n"),
+			expected: []*logspb.LogEntry{
+				{Level: logspb.LogEntry_INFO, Message: "first"},
+				{Level: logspb.LogEntry_ERROR, Message: "second line"},
+			},
+		},
+		{
+			name:     "logfmt empty value",
+			data:     []byte("msg= k=v"),
+			expected: []*logspb.LogEntry{entryWithAttrs(&logspb.LogEntry{}, logs.Str("k", "v"))},
+		},
+		{
+			name:     "blob starting with key=",
+			data:     encodeBlob(t, []*logspb.LogEntry{blobLike}),
+			expected: []*logspb.LogEntry{blobLike},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			r := &StreamReader{In: bytes.NewReader(tc.data)}
+			for i, expected := range tc.expected {
+				entry, err := r.Read(context.Background())
+				if err != nil {
+					t.Fatalf("Read %d: %v", i, err)
+				}
+				if !proto.Equal(entry, expected) {
+					t.Errorf("Read %d: expect %v, got %v", i, expected, entry)
+				}
+			}
+			if _, err := r.Read(context.Background()); !errors.Is(err, io.EOF) {
+				t.Errorf("Expect EOF, got %v", err)
+			}
+		})
+	}
+}
+
+// blobBodySize returns the size of the encoded blob record body of an entry.
+func blobBodySize(t *testing.T, entry *logspb.LogEntry) int {
+	rec, err := blob.EncodeToRawRecord(entry)
+	if err != nil {
+		t.Fatalf("EncodeToRawRecord: %v", err)
+	}
+	return len(rec.Body)
+}
+
+func entryWithAttrs(entry *logspb.LogEntry, setters ...logs.AttributeSetter) *logspb.LogEntry {
+	entry.Attributes = make(map[string]*logspb.Value)
+	logs.AttributeSetters(setters).SetAttributes(entry.Attributes)
+	return entry
+}
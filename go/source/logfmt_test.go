@@ -0,0 +1,57 @@
+package source
+
+import (
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func TestLogfmtExtractor(t *testing.T) {
+	testCases := []struct {
+		msg    string
+		filter string
+		match  bool
+	}{
+		{msg: "request done user=alice status=200", filter: "a:user=alice", match: true},
+		{msg: "request done user=alice status=200", filter: "a:status=200", match: true},
+		{msg: "request done user=alice status=200", filter: "a:status>=400"},
+		{msg: `failed err="connection refused" retry=true`, filter: "a:err=connection refused", match: true},
+		{msg: `failed err="connection refused" retry=true`, filter: "a:retry=true", match: true},
+		{msg: "ratio=0.5", filter: "a:ratio>0.4", match: true},
+		{msg: "x = 1", filter: "a:x=1"},
+		{msg: `broken="unterminated key=value`, filter: "a:key=value", match: true},
+		{msg: "1key=value", filter: "a:1key=value"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.msg+" "+tc.filter, func(t *testing.T) {
+			filter, err := ParseFilters(tc.filter)
+			if err != nil {
+				t.Fatalf("ParseFilters error: %v", err)
+			}
+			entry := &logspb.LogEntry{Message: tc.msg}
+			(&LogfmtExtractor{}).TransformLogEntry(entry)
+			if match := filter.FilterLogEntry(entry); match != tc.match {
+				t.Errorf("match %v, expect %v, attrs %v", match, tc.match, entry.Attributes)
+			}
+		})
+	}
+}
+
+func TestLogfmtExtractorLimits(t *testing.T) {
+	entry := logEntryWith(logs.Str("user", "bob"))
+	entry.Message = "a=1 b=2 c=3 user=alice"
+	(&LogfmtExtractor{MaxPairs: 2}).TransformLogEntry(entry)
+	if _, ok := entry.Attributes["c"]; ok {
+		t.Errorf("unexpected attribute c beyond MaxPairs")
+	}
+	(&LogfmtExtractor{}).TransformLogEntry(entry)
+	if val := entry.Attributes["user"].GetStrValue(); val != "bob" {
+		t.Errorf("existing attribute overwritten: %q", val)
+	}
+	entry = &logspb.LogEntry{Message: "a=1 b=2"}
+	(&LogfmtExtractor{MaxMessageLen: 4}).TransformLogEntry(entry)
+	if len(entry.Attributes) != 0 {
+		t.Errorf("unexpected attributes from long message: %v", entry.Attributes)
+	}
+}
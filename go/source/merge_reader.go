@@ -0,0 +1,124 @@
+package source
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"io"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// MergeReader merges entries from multiple readers ordered by timestamps.
+// Each reader is expected to produce entries in timestamp order, and only the
+// next entry of each reader is buffered. Entries with the same timestamp are
+// ordered by the readers.
+type MergeReader struct {
+	Readers []Reader
+
+	started bool
+	heads   mergeHeads
+}
+
+type mergeHead struct {
+	entry  *logspb.LogEntry
+	reader Reader
+	index  int
+}
+
+type mergeHeads []*mergeHead
+
+// NewMerge creates a MergeReader.
+func NewMerge(readers ...Reader) *MergeReader {
+	return &MergeReader{Readers: readers}
+}
+
+// Read implements Reader.
+func (r *MergeReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	if !r.started {
+		r.started = true
+		for n, reader := range r.Readers {
+			head := &mergeHead{reader: reader, index: n}
+			ok, err := head.next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				r.heads = append(r.heads, head)
+			}
+		}
+		heap.Init(&r.heads)
+	}
+	if len(r.heads) == 0 {
+		return nil, io.EOF
+	}
+	head := r.heads[0]
+	entry := head.entry
+	ok, err := head.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		heap.Fix(&r.heads, 0)
+	} else {
+		heap.Pop(&r.heads)
+	}
+	return entry, nil
+}
+
+// Close implements io.Closer.
+func (r *MergeReader) Close() error {
+	var err error
+	for _, reader := range r.Readers {
+		if closer, ok := reader.(io.Closer); ok {
+			if e := closer.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+func (h *mergeHead) next(ctx context.Context) (bool, error) {
+	entry, err := h.reader.Read(ctx)
+	if entry != nil {
+		h.entry = entry
+		return true, nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	h.entry = nil
+	return false, nil
+}
+
+// Len implements heap.Interface.
+func (h mergeHeads) Len() int {
+	return len(h)
+}
+
+// Less implements heap.Interface.
+func (h mergeHeads) Less(i, j int) bool {
+	if tsI, tsJ := h[i].entry.GetNanoTs(), h[j].entry.GetNanoTs(); tsI != tsJ {
+		return tsI < tsJ
+	}
+	return h[i].index < h[j].index
+}
+
+// Swap implements heap.Interface.
+func (h mergeHeads) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+// Push implements heap.Interface.
+func (h *mergeHeads) Push(x interface{}) {
+	*h = append(*h, x.(*mergeHead))
+}
+
+// Pop implements heap.Interface.
+func (h *mergeHeads) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,150 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// sliceReader reads entries from a slice, and returns err (io.EOF if nil) at the end.
+type sliceReader struct {
+	entries []*logspb.LogEntry
+	err     error
+	closed  bool
+}
+
+func (r *sliceReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	if len(r.entries) == 0 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, io.EOF
+	}
+	entry := r.entries[0]
+	r.entries = r.entries[1:]
+	return entry, nil
+}
+
+func (r *sliceReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+// mergeEntries creates entries with the timestamps, and the messages are
+// the name followed by the timestamps.
+func mergeEntries(name string, timestamps ...int64) []*logspb.LogEntry {
+	entries := make([]*logspb.LogEntry, 0, len(timestamps))
+	for _, ts := range timestamps {
+		entries = append(entries, &logspb.LogEntry{NanoTs: ts, Message: fmt.Sprintf("%s%d", name, ts)})
+	}
+	return entries
+}
+
+func TestMergeReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		readers  [][]*logspb.LogEntry
+		expected []string
+	}{
+		{
+			name:     "ordering",
+			readers:  [][]*logspb.LogEntry{mergeEntries("a", 1, 4, 5), mergeEntries("b", 2, 3, 6)},
+			expected: []string{"a1", "b2", "b3", "a4", "a5", "b6"},
+		},
+		{
+			name:     "ties",
+			readers:  [][]*logspb.LogEntry{mergeEntries("a", 1, 2, 2), mergeEntries("b", 1, 2), mergeEntries("c", 2)},
+			expected: []string{"a1", "b1", "a2", "a2", "b2", "c2"},
+		},
+		{
+			name:     "empty readers",
+			readers:  [][]*logspb.LogEntry{nil, mergeEntries("b", 1, 2), nil},
+			expected: []string{"b1", "b2"},
+		},
+		{
+			name:    "all empty",
+			readers: [][]*logspb.LogEntry{nil, nil},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			readers := make([]Reader, 0, len(tc.readers))
+			for _, entries := range tc.readers {
+				readers = append(readers, &sliceReader{entries: entries})
+			}
+			r := NewMerge(readers...)
+			var messages []string
+			for {
+				entry, err := r.Read(context.Background())
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				messages = append(messages, entry.GetMessage())
+			}
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Errorf("Expect %v, got %v", tc.expected, messages)
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+			for i, reader := range readers {
+				if !reader.(*sliceReader).closed {
+					t.Errorf("Reader %d not closed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeReaderErrors(t *testing.T) {
+	errRead := errors.New("read error")
+	testCases := []struct {
+		name     string
+		readers  []*sliceReader
+		expected []string
+	}{
+		{
+			name:    "first read",
+			readers: []*sliceReader{{entries: mergeEntries("a", 1)}, {err: errRead}},
+		},
+		{
+			name:     "later read",
+			readers:  []*sliceReader{{entries: mergeEntries("a", 1, 4)}, {entries: mergeEntries("b", 2), err: errRead}},
+			expected: []string{"a1"},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			readers := make([]Reader, 0, len(tc.readers))
+			for _, reader := range tc.readers {
+				readers = append(readers, reader)
+			}
+			r := NewMerge(readers...)
+			var messages []string
+			var err error
+			for {
+				var entry *logspb.LogEntry
+				if entry, err = r.Read(context.Background()); err != nil {
+					break
+				}
+				messages = append(messages, entry.GetMessage())
+			}
+			if !errors.Is(err, errRead) {
+				t.Errorf("Expect %v, got %v", errRead, err)
+			}
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Errorf("Expect %v, got %v", tc.expected, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+	"github.com/evo-cloud/logs/go/stackdriver"
+)
+
+// StackdriverReader reads log entries from newline-separated Stackdriver JSON,
+// e.g. the output of stackdriver.JSONEmitter or exports from Cloud Logging.
+type StackdriverReader struct {
+	SkipErrors bool
+
+	reader *bufio.Reader
+	err    error
+}
+
+// stackdriverRecord contains both the fields in stackdriver.JSONPayload and
+// the fields of an exported Cloud Logging LogEntry which wraps the payload.
+type stackdriverRecord struct {
+	Timestamp      json.RawMessage            `json:"timestamp"`
+	Severity       string                     `json:"severity"`
+	Message        string                     `json:"message"`
+	Labels         map[string]json.RawMessage `json:"logging.googleapis.com/labels"`
+	SourceLocation *stackdriverSourceLocation `json:"logging.googleapis.com/sourceLocation"`
+	TraceID        string                     `json:"logging.googleapis.com/trace"`
+	SpanID         string                     `json:"logging.googleapis.com/spanId"`
+	TraceSampled   bool                       `json:"logging.googleapis.com/trace_sampled"`
+	Raw            json.RawMessage            `json:"raw"`
+
+	// Fields in exported LogEntry.
+	JSONPayload          *stackdriverRecord         `json:"jsonPayload"`
+	TextPayload          string                     `json:"textPayload"`
+	Trace                string                     `json:"trace"`
+	SpanIDExported       string                     `json:"spanId"`
+	TraceSampledExported bool                       `json:"traceSampled"`
+	ExportedLabels       map[string]json.RawMessage `json:"labels"`
+	ExportedSourceLoc    *stackdriverSourceLocation `json:"sourceLocation"`
+}
+
+// stackdriverSourceLocation accepts line as either a number or a string.
+type stackdriverSourceLocation struct {
+	File string      `json:"file"`
+	Line json.Number `json:"line"`
+}
+
+// NewStackdriver creates a StackdriverReader.
+func NewStackdriver(in io.Reader) *StackdriverReader {
+	return &StackdriverReader{reader: bufio.NewReader(in)}
+}
+
+// Read implements Reader.
+func (r *StackdriverReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				r.err = err
+				return nil, err
+			}
+			continue
+		}
+		entry, parseErr := ParseStackdriverJSON(line)
+		if parseErr != nil {
+			if r.SkipErrors {
+				if err != nil {
+					r.err = err
+					return nil, err
+				}
+				continue
+			}
+			return nil, parseErr
+		}
+		if err != nil {
+			r.err = err
+		}
+		return entry, nil
+	}
+}
+
+// ParseStackdriverJSON parses a single Stackdriver JSON record into a log entry.
+// If the record carries the original entry in the "raw" field, it's used directly.
+// Otherwise, the entry is reconstructed from the Stackdriver fields.
+func ParseStackdriverJSON(data []byte) (*logspb.LogEntry, error) {
+	var rec stackdriverRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	outer := &rec
+	if rec.JSONPayload != nil {
+		payload := rec.JSONPayload
+		if len(payload.Timestamp) == 0 {
+			payload.Timestamp = rec.Timestamp
+		}
+		if payload.Severity == "" {
+			payload.Severity = rec.Severity
+		}
+		if payload.TraceID == "" {
+			payload.TraceID = rec.Trace
+		}
+		if payload.SpanID == "" {
+			payload.SpanID = rec.SpanIDExported
+		}
+		payload.TraceSampled = payload.TraceSampled || rec.TraceSampledExported
+		if payload.SourceLocation == nil {
+			payload.SourceLocation = rec.ExportedSourceLoc
+		}
+		if len(payload.Labels) == 0 {
+			payload.Labels = rec.ExportedLabels
+		}
+		outer = payload
+	} else if rec.Message == "" && rec.TextPayload != "" {
+		rec.Message = rec.TextPayload
+		rec.TraceID, rec.SpanID = rec.Trace, rec.SpanIDExported
+		rec.TraceSampled = rec.TraceSampledExported
+		rec.SourceLocation, rec.Labels = rec.ExportedSourceLoc, rec.ExportedLabels
+	}
+
+	if raw := bytes.TrimSpace(outer.Raw); len(raw) > 0 && raw[0] == '{' {
+		entry := &logspb.LogEntry{}
+		if err := protojson.Unmarshal(raw, entry); err == nil {
+			return entry, nil
+		}
+	}
+
+	entry := &logspb.LogEntry{
+		Level:      stackdriver.LevelFromSeverity(outer.Severity),
+		Message:    outer.Message,
+		Attributes: make(map[string]*logspb.Value),
+	}
+	ts, err := parseStackdriverTimestamp(outer.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	entry.NanoTs = ts
+	if loc := outer.SourceLocation; loc != nil && loc.File != "" {
+		entry.Location = loc.File
+		if line := loc.Line.String(); line != "" {
+			entry.Location += ":" + line
+		}
+	}
+	if traceID := outer.TraceID; traceID != "" {
+		if pos := strings.LastIndex(traceID, "/traces/"); pos >= 0 {
+			traceID = traceID[pos+8:]
+		}
+		if info := logs.BuildSpanInfoFrom(traceID, outer.SpanID, ""); info.Context != nil {
+			if outer.TraceSampled {
+				info.Context.Flags = logs.TraceFlagSampled
+			}
+			entry.Trace = &logspb.Trace{SpanContext: info.Context}
+		}
+	}
+	for key, val := range outer.Labels {
+		if v := valueFromJSON(val); v != nil {
+			entry.Attributes[key] = v
+		}
+	}
+	return entry, nil
+}
+
+func parseStackdriverTimestamp(data json.RawMessage) (int64, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return 0, nil
+	}
+	if data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return 0, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", str, err)
+		}
+		return t.UnixNano(), nil
+	}
+	var ts stackdriver.Timestamp
+	if err := json.Unmarshal(data, &ts); err != nil {
+		return 0, err
+	}
+	return ts.UnixNano(), nil
+}
+
+func valueFromJSON(data json.RawMessage) *logspb.Value {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case 'n':
+		return nil
+	case 't', 'f':
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil
+		}
+		return &logspb.Value{Value: &logspb.Value_BoolValue{BoolValue: b}}
+	case '"':
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return nil
+		}
+		return &logspb.Value{Value: &logspb.Value_StrValue{StrValue: str}}
+	case '{', '[':
+		return &logspb.Value{Value: &logspb.Value_Json{Json: string(data)}}
+	}
+	if i, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		return &logspb.Value{Value: &logspb.Value_IntValue{IntValue: i}}
+	}
+	if f, err := strconv.ParseFloat(string(data), 64); err == nil && !math.IsInf(f, 0) {
+		return &logspb.Value{Value: &logspb.Value_DoubleValue{DoubleValue: f}}
+	}
+	return nil
+}
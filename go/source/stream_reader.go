@@ -2,25 +2,41 @@ package source
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"io"
 	"strings"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/evo-cloud/logs/go/blob"
 	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
 )
 
 const (
 	whiteSpaces = " \t\r\n"
 	maxPreRead  = 4096
+	// maxLogfmtKeyDetect limits the length of the key read for detecting logfmt.
+	maxLogfmtKeyDetect = 256
 )
 
+// gzipDeflate is the compression method following gzipMagic. It's also checked
+// for detecting gzip streams, as the first 2 bytes can be a blob record length.
+const gzipDeflate = 0x08
+
 // StreamReader auto detects content from a stream to decode log entries.
+// Gzip compressed content is decompressed transparently.
 type StreamReader struct {
 	In         io.Reader
 	SkipErrors bool
 
 	preRead bytes.Buffer
 	reader  Reader
+	// raw is the original In once compression is detected, and gz is the
+	// decompressor if In is gzip compressed.
+	raw io.Reader
+	gz  *gzip.Reader
 }
 
 // Read implements Reader.
@@ -28,6 +44,13 @@ func (r *StreamReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
 	if r.reader != nil {
 		return r.reader.Read(ctx)
 	}
+	if r.raw == nil {
+		in, gz, err := decompress(r.In)
+		if err != nil {
+			return nil, err
+		}
+		r.raw, r.gz, r.In = r.In, gz, in
+	}
 	for {
 		b := []byte{0}
 		_, err := r.In.Read(b)
@@ -45,17 +68,130 @@ func (r *StreamReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
 			jsonReader := NewJSON(io.MultiReader(bytes.NewBuffer(b), r.In))
 			jsonReader.SkipErrors = r.SkipErrors
 			r.reader = jsonReader
+		} else if b[0] == 0 && r.preRead.Len() == 1 && r.detectHubStream() {
+			hubReader := NewHubStream(io.MultiReader(&r.preRead, r.In))
+			hubReader.SkipErrors = r.SkipErrors
+			r.reader = hubReader
+		} else if isLogfmtKeyChar(b[0], true) && r.detectLogfmt() {
+			logfmtReader := NewLogfmt(io.MultiReader(&r.preRead, r.In))
+			logfmtReader.SkipErrors = r.SkipErrors
+			r.reader = logfmtReader
 		} else {
-			r.reader = NewBlob(io.MultiReader(&r.preRead, r.In))
+			blobReader := NewBlob(io.MultiReader(&r.preRead, r.In))
+			blobReader.SkipErrors = r.SkipErrors
+			r.reader = blobReader
 		}
 		break
 	}
 	return r.reader.Read(ctx)
 }
 
+// detectHubStream reads the rest of the 4-byte length prefix and reports whether
+// it's the big-endian length of the hub wire format. The blob format uses
+// little-endian length which always has the highest byte zero for valid
+// records, so a zero first byte with a non-zero last byte indicates big-endian.
+// When both bytes are zero, e.g. a hub record of 256 bytes, the record is read
+// ahead and validated in both formats.
+func (r *StreamReader) detectHubStream() bool {
+	var rest [3]byte
+	n, _ := io.ReadFull(r.In, rest[:])
+	r.preRead.Write(rest[:n])
+	if n != len(rest) {
+		return false
+	}
+	if rest[2] != 0 {
+		return true
+	}
+	head := r.preRead.Bytes()
+	hubSize := int(binary.BigEndian.Uint32(head))
+	blobSize := int(binary.LittleEndian.Uint32(head))
+	if hubSize > maxHubRecordSize {
+		return false
+	}
+	// The blob record includes the padding and the tail length.
+	blobEnd := blobSize
+	if rem := blobEnd & 3; rem != 0 {
+		blobEnd += 4 - rem
+	}
+	blobEnd += 4
+	readAhead := hubSize
+	if blobSize > 0 && blobEnd > readAhead && blobEnd <= maxHubRecordSize+8 {
+		readAhead = blobEnd
+	}
+	data := make([]byte, readAhead)
+	n, _ = io.ReadFull(r.In, data)
+	r.preRead.Write(data[:n])
+	data = data[:n]
+	if blobSize > 0 && blobEnd <= len(data) && int(binary.LittleEndian.Uint32(data[blobEnd-4:])) == blobSize {
+		if _, err := blob.DecodeBody(data[:blobSize]); err == nil {
+			return false
+		}
+	}
+	return hubSize <= len(data) && proto.Unmarshal(data[:hubSize], &logspb.LogEntry{}) == nil
+}
+
+// detectLogfmt reads the rest of the key and the first byte of the value, and
+// reports whether it's logfmt. A blob record may also start with the key and
+// '=' as the length, however, the length is followed by zeros for valid records,
+// which are never seen in text.
+func (r *StreamReader) detectLogfmt() bool {
+	b := []byte{0}
+	for n := 1; n < maxLogfmtKeyDetect; n++ {
+		if _, err := io.ReadFull(r.In, b); err != nil {
+			return false
+		}
+		r.preRead.Write(b)
+		if b[0] == '=' {
+			break
+		}
+		if !isLogfmtKeyChar(b[0], false) {
+			return false
+		}
+	}
+	if b[0] != '=' {
+		return false
+	}
+	if _, err := io.ReadFull(r.In, b); err != nil {
+		return err == io.EOF
+	}
+	r.preRead.Write(b)
+	return b[0] >= ' ' || strings.IndexByte(whiteSpaces, b[0]) >= 0
+}
+
+// decompress detects gzip compressed content by gzipMagic followed by the
+// compression method, and returns the decompressed content, or the content as is
+// with gz nil. The rest of the header is only read if the first byte matches, so
+// it doesn't block on streams with less data.
+func decompress(in io.Reader) (out io.Reader, gz *gzip.Reader, err error) {
+	head := make([]byte, len(gzipMagic)+1)
+	if _, err := io.ReadFull(in, head[:1]); err != nil {
+		return nil, nil, err
+	}
+	n := 1
+	if head[0] == gzipMagic[0] {
+		rest, _ := io.ReadFull(in, head[1:])
+		n += rest
+	}
+	out = io.MultiReader(bytes.NewReader(head[:n]), in)
+	if n < len(head) || !bytes.Equal(head[:len(gzipMagic)], gzipMagic) || head[len(gzipMagic)] != gzipDeflate {
+		return out, nil, nil
+	}
+	if gz, err = gzip.NewReader(out); err != nil {
+		return nil, nil, err
+	}
+	return gz, gz, nil
+}
+
 // Close implements io.Closer.
 func (r *StreamReader) Close() error {
-	if closer, ok := r.In.(io.Closer); ok {
+	in := r.In
+	if r.raw != nil {
+		in = r.raw
+	}
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	if closer, ok := in.(io.Closer); ok {
 		return closer.Close()
 	}
 	return nil
@@ -0,0 +1,218 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/evo-cloud/logs/go/blob"
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+func streamTestEntries() []*logspb.LogEntry {
+	return []*logspb.LogEntry{
+		logEntryWith(logs.Str("key", "value")),
+		{NanoTs: 2, Level: logspb.LogEntry_WARNING, Location: "a.go:1", Message: "second"},
+		{NanoTs: 3, Message: "third"},
+	}
+}
+
+func encodeBlob(t *testing.T, entries []*logspb.LogEntry) []byte {
+	var buf bytes.Buffer
+	w := &blob.Writer{W: &buf}
+	for _, entry := range entries {
+		if err := w.WriteLogEntry(entry); err != nil {
+			t.Fatalf("encode blob: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func encodeJSON(t *testing.T, entries []*logspb.LogEntry) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(entry)
+		if err != nil {
+			t.Fatalf("encode JSON: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStreamReaderGzip(t *testing.T) {
+	entries := streamTestEntries()
+	blobData, jsonData := encodeBlob(t, entries), encodeJSON(t, entries)
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "blob", data: blobData},
+		{name: "gzip blob", data: gzipData(t, blobData)},
+		{name: "json", data: jsonData},
+		{name: "gzip json", data: gzipData(t, jsonData)},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			r := &StreamReader{In: bytes.NewReader(tc.data)}
+			var decoded []*logspb.LogEntry
+			for {
+				entry, err := r.Read(context.Background())
+				if err != nil && !errors.Is(err, io.EOF) {
+					t.Fatalf("Read: %v", err)
+				}
+				if entry == nil {
+					break
+				}
+				decoded = append(decoded, entry)
+			}
+			if len(decoded) != len(entries) {
+				t.Fatalf("decoded %d entries, expect %d", len(decoded), len(entries))
+			}
+			for i, entry := range decoded {
+				if !proto.Equal(entry, entries[i]) {
+					t.Errorf("entry %d: decoded %v, expect %v", i, entry, entries[i])
+				}
+			}
+		})
+	}
+}
+
+func encodeHub(t *testing.T, entries []*logspb.LogEntry) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := proto.Marshal(entry)
+		if err != nil {
+			t.Fatalf("encode hub: %v", err)
+		}
+		var head [4]byte
+		binary.BigEndian.PutUint32(head[:], uint32(len(data)))
+		buf.Write(head[:])
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// entryOfSize returns an entry encoded in the specified size.
+func entryOfSize(t *testing.T, size int) *logspb.LogEntry {
+	entry := &logspb.LogEntry{NanoTs: 1}
+	for n := 0; n < size; n++ {
+		entry.Message = strings.Repeat("x", n)
+		if proto.Size(entry) == size {
+			return entry
+		}
+	}
+	t.Fatalf("no entry of size %d", size)
+	return nil
+}
+
+func readAll(t *testing.T, r Reader) []*logspb.LogEntry {
+	var decoded []*logspb.LogEntry
+	for {
+		entry, err := r.Read(context.Background())
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatalf("Read: %v", err)
+		}
+		if entry == nil {
+			return decoded
+		}
+		decoded = append(decoded, entry)
+	}
+}
+
+func TestStreamReaderDetectHub(t *testing.T) {
+	entries := streamTestEntries()
+	// Sizes with the lowest byte zero in little-endian or big-endian,
+	// considering the checksum header of 5 bytes in blob records.
+	hub256 := append([]*logspb.LogEntry{entryOfSize(t, 256)}, entries...)
+	hub512 := append([]*logspb.LogEntry{entryOfSize(t, 512)}, entries...)
+	blob256 := append([]*logspb.LogEntry{entryOfSize(t, 256-5)}, entries...)
+	blob512 := append([]*logspb.LogEntry{entryOfSize(t, 512-5)}, entries...)
+	testCases := []struct {
+		name    string
+		data    []byte
+		entries []*logspb.LogEntry
+		hub     bool
+	}{
+		{name: "hub", data: encodeHub(t, entries), entries: entries, hub: true},
+		{name: "gzip hub", data: gzipData(t, encodeHub(t, entries)), entries: entries, hub: true},
+		{name: "hub size 256", data: encodeHub(t, hub256), entries: hub256, hub: true},
+		{name: "hub size 512", data: encodeHub(t, hub512), entries: hub512, hub: true},
+		{name: "hub single record of 256", data: encodeHub(t, hub256[:1]), entries: hub256[:1], hub: true},
+		{name: "blob", data: encodeBlob(t, entries), entries: entries},
+		{name: "blob size 256", data: encodeBlob(t, blob256), entries: blob256},
+		{name: "blob size 512", data: encodeBlob(t, blob512), entries: blob512},
+		{name: "blob single record of 256", data: encodeBlob(t, blob256[:1]), entries: blob256[:1]},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			r := &StreamReader{In: bytes.NewReader(tc.data)}
+			decoded := readAll(t, r)
+			if _, isHub := r.reader.(*HubStreamReader); isHub != tc.hub {
+				t.Errorf("Expect hub format %v, got reader %T", tc.hub, r.reader)
+			}
+			if len(decoded) != len(tc.entries) {
+				t.Fatalf("decoded %d entries, expect %d", len(decoded), len(tc.entries))
+			}
+			for i, entry := range decoded {
+				if !proto.Equal(entry, tc.entries[i]) {
+					t.Errorf("entry %d: decoded %v, expect %v", i, entry, tc.entries[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHubStreamReader(t *testing.T) {
+	entries := streamTestEntries()
+	decoded := readAll(t, NewHubStream(bytes.NewReader(encodeHub(t, entries))))
+	if len(decoded) != len(entries) {
+		t.Fatalf("decoded %d entries, expect %d", len(decoded), len(entries))
+	}
+	for i, entry := range decoded {
+		if !proto.Equal(entry, entries[i]) {
+			t.Errorf("entry %d: decoded %v, expect %v", i, entry, entries[i])
+		}
+	}
+
+	// A record not decodable as LogEntry (field 1 as length-delimited).
+	bad := []byte{0, 0, 0, 2, 0x0a, 0x05}
+	data := append(append([]byte(nil), bad...), encodeHub(t, entries[:1])...)
+	if _, err := NewHubStream(bytes.NewReader(data)).Read(context.Background()); err == nil {
+		t.Errorf("Expect error decoding bad record")
+	}
+	r := NewHubStream(bytes.NewReader(data))
+	r.SkipErrors = true
+	if decoded := readAll(t, r); len(decoded) != 1 || !proto.Equal(decoded[0], entries[0]) {
+		t.Errorf("Expect bad record skipped, got %v", decoded)
+	}
+
+	if _, err := NewHubStream(bytes.NewReader([]byte{2, 0, 0, 0})).Read(context.Background()); err == nil {
+		t.Errorf("Expect error for oversized record")
+	}
+	if _, err := NewHubStream(bytes.NewReader([]byte{0, 0, 0, 4, 8})).Read(context.Background()); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expect %v for truncated record, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
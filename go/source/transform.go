@@ -0,0 +1,118 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// LogEntryTransformer transforms a log entry in place.
+type LogEntryTransformer interface {
+	TransformLogEntry(*logspb.LogEntry)
+}
+
+// LogEntryTransformerFunc is the func form of LogEntryTransformer.
+type LogEntryTransformerFunc func(*logspb.LogEntry)
+
+// TransformLogEntry implements LogEntryTransformer.
+func (f LogEntryTransformerFunc) TransformLogEntry(entry *logspb.LogEntry) {
+	f(entry)
+}
+
+// LogEntryTransformers applies a list of transformers in order.
+type LogEntryTransformers []LogEntryTransformer
+
+// TransformLogEntry implements LogEntryTransformer.
+func (t LogEntryTransformers) TransformLogEntry(entry *logspb.LogEntry) {
+	for _, transformer := range t {
+		transformer.TransformLogEntry(entry)
+	}
+}
+
+// TransformReader transforms the entries read from the underlying reader.
+type TransformReader struct {
+	Reader
+	Transformer LogEntryTransformer
+}
+
+// Read implements Reader.
+func (r *TransformReader) Read(ctx context.Context) (*logspb.LogEntry, error) {
+	entry, err := r.Reader.Read(ctx)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+	if t := r.Transformer; t != nil {
+		t.TransformLogEntry(entry)
+	}
+	return entry, nil
+}
+
+// RenameAttributes renames attribute keys from the map keys to the map values.
+// An existing attribute with the new name is overwritten.
+type RenameAttributes map[string]string
+
+// TransformLogEntry implements LogEntryTransformer.
+func (r RenameAttributes) TransformLogEntry(entry *logspb.LogEntry) {
+	renamed := make(map[string]*logspb.Value)
+	for from, to := range r {
+		if val, ok := entry.GetAttributes()[from]; ok && from != to {
+			delete(entry.Attributes, from)
+			renamed[to] = val
+		}
+	}
+	for key, val := range renamed {
+		entry.Attributes[key] = val
+	}
+}
+
+// ParseRenameAttributes parses the rename rules in the form of OLD=NEW.
+func ParseRenameAttributes(rules ...string) (RenameAttributes, error) {
+	r := make(RenameAttributes)
+	for _, rule := range rules {
+		from, to, ok := strings.Cut(rule, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid rename rule %q, expect OLD=NEW", rule)
+		}
+		r[from] = to
+	}
+	return r, nil
+}
+
+// SnakeCaseAttributes normalizes attribute keys into snake_case, e.g. userId to user_id.
+var SnakeCaseAttributes LogEntryTransformer = LogEntryTransformerFunc(snakeCaseAttributes)
+
+func snakeCaseAttributes(entry *logspb.LogEntry) {
+	renamed := make(map[string]*logspb.Value)
+	for key, val := range entry.GetAttributes() {
+		if normalized := snakeCase(key); normalized != key {
+			delete(entry.Attributes, key)
+			renamed[normalized] = val
+		}
+	}
+	for key, val := range renamed {
+		entry.Attributes[key] = val
+	}
+}
+
+func snakeCase(str string) string {
+	var sb strings.Builder
+	runes := []rune(str)
+	for n, r := range runes {
+		if r == '-' || r == ' ' {
+			sb.WriteByte('_')
+			continue
+		}
+		if unicode.IsUpper(r) {
+			if n > 0 && (unicode.IsLower(runes[n-1]) || unicode.IsDigit(runes[n-1]) ||
+				(n+1 < len(runes) && unicode.IsLower(runes[n+1]) && unicode.IsUpper(runes[n-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package stackdriver
+
+import (
+	"strings"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// Timestamp defines the timestamp of the log.
+type Timestamp struct {
+	Seconds int64 `json:"seconds"`
+	Nanos   int64 `json:"nanos"`
+}
+
+var (
+	severityMap = map[logspb.LogEntry_Level]string{
+		logspb.LogEntry_INFO:     "NOTICE",
+		logspb.LogEntry_WARNING:  "WARNING",
+		logspb.LogEntry_ERROR:    "ERROR",
+		logspb.LogEntry_CRITICAL: "CRITICAL",
+		logspb.LogEntry_FATAL:    "EMERGENCY",
+	}
+)
+
+// TimestampFromNanos converts unix nanoseconds to Timestamp.
+func TimestampFromNanos(nanos int64) (ts Timestamp) {
+	ts.Seconds = nanos / 1e9
+	ts.Nanos = nanos % 1e9
+	return
+}
+
+// UnixNano returns the timestamp in unix nanoseconds.
+func (ts Timestamp) UnixNano() int64 {
+	return ts.Seconds*1e9 + ts.Nanos
+}
+
+// SeverityFromLevel converts log level to Stackdriver severity.
+func SeverityFromLevel(level logspb.LogEntry_Level) string {
+	if s, ok := severityMap[level]; ok {
+		return s
+	}
+	return "DEFAULT"
+}
+
+// LevelFromSeverity converts Stackdriver severity back to log level.
+func LevelFromSeverity(severity string) logspb.LogEntry_Level {
+	switch strings.ToUpper(severity) {
+	case "DEBUG", "INFO", "NOTICE":
+		return logspb.LogEntry_INFO
+	case "WARNING":
+		return logspb.LogEntry_WARNING
+	case "ERROR":
+		return logspb.LogEntry_ERROR
+	case "CRITICAL":
+		return logspb.LogEntry_CRITICAL
+	case "ALERT", "EMERGENCY":
+		return logspb.LogEntry_FATAL
+	}
+	return logspb.LogEntry_NONE
+}
@@ -0,0 +1,32 @@
+package stackdriver
+
+import (
+	"testing"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+func TestSeverityRoundTrip(t *testing.T) {
+	for level := logspb.LogEntry_INFO; level <= logspb.LogEntry_FATAL; level++ {
+		if converted := LevelFromSeverity(SeverityFromLevel(level)); converted != level {
+			t.Errorf("Level %v converted back to %v", level, converted)
+		}
+	}
+	if severity := SeverityFromLevel(logspb.LogEntry_NONE); severity != "DEFAULT" {
+		t.Errorf("Expect DEFAULT for NONE, got %q", severity)
+	}
+	if level := LevelFromSeverity("debug"); level != logspb.LogEntry_INFO {
+		t.Errorf("Expect INFO for debug, got %v", level)
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	const nanos = 1234567890123456789
+	ts := TimestampFromNanos(nanos)
+	if ts.Seconds != 1234567890 || ts.Nanos != 123456789 {
+		t.Errorf("Unexpected timestamp %+v", ts)
+	}
+	if converted := ts.UnixNano(); converted != nanos {
+		t.Errorf("Expect %d, got %d", nanos, converted)
+	}
+}
@@ -3,10 +3,11 @@ package elasticsearch
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"os"
 	"strings"
@@ -20,6 +21,14 @@ import (
 
 const (
 	bulkThreshold = 32
+	latencySink   = "elasticsearch"
+
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = 100 * time.Millisecond
+	maxRetryDelay      = 10 * time.Second
+
+	// DeadLetterReasonAttr is the attribute of dead-letter entries with the rejection reason.
+	DeadLetterReasonAttr = "dead_letter.reason"
 )
 
 // Streamer streams logs to remote server.
@@ -29,6 +38,22 @@ type Streamer struct {
 	ServerURL  string
 	Client     *http.Client
 	Verbose    bool
+	// Username and Password authenticate using basic auth.
+	Username string
+	Password string
+	// APIKey is the base64 encoded API key authenticating using ApiKey.
+	// It's preferred over Username and Password.
+	APIKey string
+	// MaxAttempts is the max number of bulk requests for a batch, including
+	// retries on 429 and 5xx. Only the failed entries are retried when the
+	// others are accepted. Values less than 2 disable retries.
+	MaxAttempts int
+	// RetryDelay is the base delay of the exponential backoff between retries.
+	RetryDelay time.Duration
+	// DeadLetter receives the entries permanently rejected by ElasticSearch (e.g. mapping
+	// conflicts), or still failing after MaxAttempts, with the reason in DeadLetterReasonAttr,
+	// so they are neither retried forever nor lost. If nil, the failures fail the bulk request.
+	DeadLetter logs.LogEmitter
 
 	traceAPI bool
 }
@@ -36,11 +61,13 @@ type Streamer struct {
 // NewStreamer creates a Streamer.
 func NewStreamer(clientName, dataStream, serverURL string) *Streamer {
 	return &Streamer{
-		ClientName: clientName,
-		DataStream: dataStream,
-		ServerURL:  serverURL,
-		Client:     http.DefaultClient,
-		traceAPI:   os.Getenv("ES_TRACE_API") != "",
+		ClientName:  clientName,
+		DataStream:  dataStream,
+		ServerURL:   serverURL,
+		Client:      http.DefaultClient,
+		MaxAttempts: defaultMaxAttempts,
+		RetryDelay:  defaultRetryDelay,
+		traceAPI:    os.Getenv("ES_TRACE_API") != "",
 	}
 }
 
@@ -51,28 +78,16 @@ func (s *Streamer) Close() error {
 
 // StreamLogEntries implements logs.LogStreamer.
 func (s *Streamer) StreamLogEntries(ctx context.Context, entries []*logspb.LogEntry) error {
-	payload := &bytes.Buffer{}
-	encoder := json.NewEncoder(payload)
-	for _, entry := range entries {
-		payload.WriteString(`{"create":{}}` + "\n")
-		rec := entryToRecord(entry)
-		rec.Client = s.ClientName
-		if err := encoder.Encode(rec); err != nil {
-			return err
-		}
-	}
-	if s.traceAPI {
-		str := payload.String()
-		payload = bytes.NewBufferString(str)
+	if err := s.bulk(ctx, entries); err != nil {
+		return err
 	}
-	return s.bulk(payload)
+	logs.ObserveDurable(latencySink, entries...)
+	return nil
 }
 
 // StartStreamInChunk implements ChunkedStreamer.
 func (s *Streamer) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo) (logs.ChunkedLogStreamer, error) {
-	st := &stream{streamer: s, info: info}
-	st.encoder = json.NewEncoder(&st.payload)
-	return st, nil
+	return &stream{streamer: s, info: info}, nil
 }
 
 // BulkReply defines the reply of bulk call.
@@ -99,15 +114,93 @@ type BulkError struct {
 	Reason string `json:"reason"`
 }
 
-func (s *Streamer) bulk(payload io.Reader) error {
-	req, err := http.NewRequest(http.MethodPost, s.ServerURL+"/"+s.DataStream+"/_bulk", payload)
+// bulk sends the entries, and retries the failed ones with backoff.
+// The errors of the entries not retryable are collected from all attempts.
+func (s *Streamer) bulk(ctx context.Context, entries []*logspb.LogEntry) error {
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		retries, retryErr, err := s.bulkOnce(ctx, entries)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(retries) == 0 {
+			return combineErrors(errs)
+		}
+		if attempt >= s.MaxAttempts {
+			if s.DeadLetter == nil {
+				return combineErrors(append(errs, retryErr))
+			}
+			reason := fmt.Sprintf("failed after %d attempts: %v", attempt, retryErr)
+			for _, entry := range retries {
+				s.DeadLetter.EmitLogEntry(deadLetterEntry(entry, reason))
+			}
+			if s.Verbose {
+				logs.Emergent().Errorf("ES failed %d entries after %d attempts, sent to dead letter", len(retries), attempt)
+			}
+			return combineErrors(errs)
+		}
+		if s.Verbose {
+			logs.Emergent().Error(retryErr).PrintErrf("Bulk attempt %d, retrying %d entries: ", attempt, len(retries))
+		}
+		timer := time.NewTimer(s.retryDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return combineErrors(append(errs, fmt.Errorf("%w, last error: %v", ctx.Err(), retryErr)))
+		case <-timer.C:
+		}
+		entries = retries
+	}
+}
+
+// combineErrors combines the errors into one, or returns nil if there's none.
+func combineErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	msgs := make([]string, len(errs))
+	for n, err := range errs {
+		msgs[n] = err.Error()
+	}
+	return fmt.Errorf("%w\n%s", errs[0], strings.Join(msgs[1:], "\n"))
+}
+
+// retryDelay returns the exponential backoff with jitter before the retry
+// following the attempt, which is between half and full of the backoff.
+func (s *Streamer) retryDelay(attempt int) time.Duration {
+	delay := s.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	for n := 1; n < attempt && delay < maxRetryDelay; n++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// bulkOnce sends the entries in a single bulk request, and returns the
+// entries to retry with the error of them, and the error of the others
+// not retryable.
+func (s *Streamer) bulkOnce(ctx context.Context, entries []*logspb.LogEntry) (retries []*logspb.LogEntry, retryErr error, err error) {
+	payload, err := s.encodeBulk(entries)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.ServerURL+"/"+s.DataStream+"/_bulk", payload)
+	if err != nil {
+		return nil, nil, err
 	}
 	req.Header.Add("Content-type", "application/x-ndjson")
+	s.setAuth(req)
 	resp, err := s.Client.Do(req)
 	if err != nil {
-		return err
+		return entries, err, nil
 	}
 	defer resp.Body.Close()
 	var replyJSON string
@@ -120,67 +213,132 @@ func (s *Streamer) bulk(payload io.Reader) error {
 		logs.Emergent().Infof("ES bulk reply:\n%s", string(replyJSON))
 	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bulk error: %d %s", resp.StatusCode, replyJSON)
+		err := fmt.Errorf("bulk error: %d %s", resp.StatusCode, replyJSON)
+		if isRetryable(resp.StatusCode) {
+			return entries, err, nil
+		}
+		return nil, nil, err
 	}
-	if reply.Errors {
-		var msgs []string
-		for n, item := range reply.Items {
-			if item.Create == nil || item.Create.Error == nil {
-				continue
-			}
-			msgs = append(msgs, fmt.Sprintf("[%d] %s: %s", n, item.Create.Error.Type, item.Create.Error.Reason))
+	if !reply.Errors {
+		return nil, nil, nil
+	}
+	var msgs, retryMsgs []string
+	var deadLetters int
+	for n, item := range reply.Items {
+		if item.Create == nil || item.Create.Error == nil {
+			continue
+		}
+		msg := fmt.Sprintf("[%d] %s: %s", n, item.Create.Error.Type, item.Create.Error.Reason)
+		if n < len(entries) && isRetryable(item.Create.Status) {
+			retries = append(retries, entries[n])
+			retryMsgs = append(retryMsgs, msg)
+			continue
 		}
-		if len(msgs) > 0 {
-			return fmt.Errorf("errors: %s", strings.Join(msgs, "\n"))
+		if s.DeadLetter != nil && n < len(entries) && isPermanentRejection(item.Create.Status) {
+			s.DeadLetter.EmitLogEntry(deadLetterEntry(entries[n], item.Create.Error.Type+": "+item.Create.Error.Reason))
+			deadLetters++
+			continue
 		}
+		msgs = append(msgs, msg)
 	}
-	return nil
+	if deadLetters > 0 && s.Verbose {
+		logs.Emergent().Errorf("ES rejected %d entries, sent to dead letter", deadLetters)
+	}
+	if len(retryMsgs) > 0 {
+		retryErr = fmt.Errorf("errors: %s", strings.Join(retryMsgs, "\n"))
+	}
+	if len(msgs) > 0 {
+		err = fmt.Errorf("errors: %s", strings.Join(msgs, "\n"))
+	}
+	return retries, retryErr, err
+}
+
+// encodeBulk encodes the entries as the payload of a bulk request.
+func (s *Streamer) encodeBulk(entries []*logspb.LogEntry) (*bytes.Buffer, error) {
+	payload := &bytes.Buffer{}
+	encoder := json.NewEncoder(payload)
+	for _, entry := range entries {
+		payload.WriteString(`{"create":{}}` + "\n")
+		rec := entryToRecord(entry)
+		rec.Client = s.ClientName
+		if err := encoder.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// isRetryable determines whether a request or an entry failed with the status
+// may succeed on retries.
+func isRetryable(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// setAuth sets the Authorization header, preferring the API key.
+func (s *Streamer) setAuth(req *http.Request) {
+	switch {
+	case s.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+	case s.Username != "" || s.Password != "":
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+// isPermanentRejection determines whether an entry rejected with the status
+// never succeeds on retries.
+func isPermanentRejection(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400 && status < 500
+}
+
+// deadLetterEntry returns a copy of the entry with the rejection reason.
+func deadLetterEntry(entry *logspb.LogEntry, reason string) *logspb.LogEntry {
+	return logs.CopyLogEntryWith(entry, DeadLetterReasonAttr, &logspb.Value{Value: &logspb.Value_StrValue{StrValue: reason}})
 }
 
 type stream struct {
 	streamer          *Streamer
 	info              logs.ChunkInfo
-	payload           bytes.Buffer
-	encoder           *json.Encoder
-	entryCount        int
+	entries           []*logspb.LogEntry
 	lastNanoTSEncoded int64
 	lastNanoTS        int64
 }
 
 func (s *stream) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
-	s.payload.WriteString(`{"create":{}}` + "\n")
-	rec := entryToRecord(entry)
-	rec.Client = s.streamer.ClientName
-	if err := s.encoder.Encode(rec); err != nil {
-		return err
-	}
 	if ts := entry.GetNanoTs(); ts > s.lastNanoTSEncoded {
 		s.lastNanoTSEncoded = ts
 	}
-	s.entryCount++
-	if s.entryCount >= bulkThreshold {
-		s.flush()
+	s.entries = append(s.entries, entry)
+	if len(s.entries) >= bulkThreshold {
+		s.flush(ctx)
 	}
 	return nil
 }
 
 func (s *stream) StreamEnd(ctx context.Context) (int64, error) {
-	s.flush()
+	s.flush(ctx)
 	return s.lastNanoTS, nil
 }
 
-func (s *stream) flush() {
-	s.entryCount = 0
+func (s *stream) flush(ctx context.Context) {
+	entries := s.entries
+	s.entries = nil
 	encodedLastNanoTS := s.lastNanoTSEncoded
 	s.lastNanoTSEncoded = 0
-	err := s.streamer.bulk(&s.payload)
-	s.payload.Reset()
+	if len(entries) == 0 {
+		return
+	}
+	err := s.streamer.bulk(ctx, entries)
 	if err != nil {
 		if s.streamer.Verbose {
 			logs.Emergent().Error(err).PrintErr("Bulk: ")
 		}
 		return
 	}
+	logs.ObserveDurable(latencySink, entries...)
 	if encodedLastNanoTS > s.lastNanoTS {
 		s.lastNanoTS = encodedLastNanoTS
 	}
@@ -210,36 +368,57 @@ type timestamp struct {
 	Seconds int64 `json:"s"`
 }
 
+const esTimeFormat = "2006-01-02T15:04:05.999999999Z"
+
+func attrsToObject(attrs map[string]*logspb.Value) map[string]interface{} {
+	obj := make(map[string]interface{}, len(attrs))
+	for key, val := range attrs {
+		switch v := val.GetValue().(type) {
+		case *logspb.Value_BoolValue:
+			obj[key] = v.BoolValue
+		case *logspb.Value_IntValue:
+			obj[key] = v.IntValue
+		case *logspb.Value_FloatValue:
+			obj[key] = v.FloatValue
+		case *logspb.Value_DoubleValue:
+			obj[key] = v.DoubleValue
+		case *logspb.Value_StrValue:
+			obj[key] = v.StrValue
+		case *logspb.Value_Json:
+			obj[key] = v.Json
+		case *logspb.Value_Proto:
+			obj[key] = v.Proto
+		case *logspb.Value_Bytes:
+			// Hex encoded to be distinguished from protos which are base64 encoded.
+			obj[key] = hex.EncodeToString(v.Bytes)
+		case *logspb.Value_Duration:
+			// ElasticSearch doesn't have a duration type, use nanoseconds as a long.
+			obj[key] = v.Duration
+		case *logspb.Value_Time:
+			// Detected as date by dynamic mapping.
+			obj[key] = time.Unix(0, v.Time).UTC().Format(esTimeFormat)
+		case *logspb.Value_Decimal:
+			// Kept as a string to avoid rounding, mapped as a string unless
+			// the index defines a numeric mapping (e.g. scaled_float).
+			obj[key] = v.Decimal
+		case *logspb.Value_MapValue:
+			// Indexed as an object.
+			obj[key] = attrsToObject(v.MapValue.GetValues())
+		}
+	}
+	return obj
+}
+
 func entryToRecord(entry *logspb.LogEntry) *record {
 	r := &record{
-		Timestamp: time.Unix(0, entry.GetNanoTs()).UTC().Format("2006-01-02T15:04:05.999999999Z"),
+		Timestamp: time.Unix(0, entry.GetNanoTs()).UTC().Format(esTimeFormat),
 		TS:        &timestamp{Nanos: entry.GetNanoTs() % 1e9, Seconds: entry.GetNanoTs() / 1e9},
 		Message:   entry.GetMessage(),
 		Location:  entry.GetLocation(),
 		LogJSON:   protojson.MarshalOptions{UseProtoNames: true}.Format(entry),
 	}
 	if attrs := entry.GetAttributes(); len(attrs) > 0 {
-		r.Attrs = make(map[string]interface{})
-		for key, val := range attrs {
-			switch v := val.GetValue().(type) {
-			case *logspb.Value_BoolValue:
-				r.Attrs[key] = v.BoolValue
-			case *logspb.Value_IntValue:
-				r.Attrs[key] = v.IntValue
-			case *logspb.Value_FloatValue:
-				r.Attrs[key] = v.FloatValue
-			case *logspb.Value_DoubleValue:
-				r.Attrs[key] = v.DoubleValue
-			case *logspb.Value_StrValue:
-				r.Attrs[key] = v.StrValue
-			case *logspb.Value_Json:
-				r.Attrs[key] = v.Json
-			case *logspb.Value_Proto:
-				r.Attrs[key] = v.Proto
-			default:
-				continue
-			}
-		}
+		r.Attrs = attrsToObject(attrs)
 	}
 	if level := entry.GetLevel(); level != logspb.LogEntry_NONE {
 		r.Level = level.String()
@@ -256,6 +435,10 @@ func entryToRecord(entry *logspb.LogEntry) *record {
 		if ev := tr.GetSpanEnd(); ev != nil {
 			r.Trace.Event = "span-end"
 		}
+		if ev := tr.GetSpanEvent(); ev != nil {
+			r.Trace.Name = ev.GetName()
+			r.Trace.Event = "span-event"
+		}
 	}
 	return r
 }
@@ -0,0 +1,288 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// roundTripperFunc is a stub http.RoundTripper.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestStreamerAuth(t *testing.T) {
+	testCases := []struct {
+		name     string
+		username string
+		password string
+		apiKey   string
+		expected string
+	}{
+		{name: "none"},
+		{name: "basic", username: "elastic", password: "secret", expected: "Basic ZWxhc3RpYzpzZWNyZXQ="},
+		{name: "api key", apiKey: "VnVhQ2ZHY0JDZGJrUW0tZTVhT3g6dWkybHAyYXhUTm1zeWFrdzl0dk5udw==", expected: "ApiKey VnVhQ2ZHY0JDZGJrUW0tZTVhT3g6dWkybHAyYXhUTm1zeWFrdzl0dk5udw=="},
+		{name: "api key preferred", username: "elastic", password: "secret", apiKey: "a2V5", expected: "ApiKey a2V5"},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var reqs []*http.Request
+			s := NewStreamer("client", "logs", "http://localhost:9200")
+			s.Username, s.Password, s.APIKey = tc.username, tc.password, tc.apiKey
+			s.Client = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				reqs = append(reqs, req)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"errors":false,"items":[{"create":{"status":201}}]}`)),
+					Request:    req,
+				}, nil
+			})}
+			entries := []*logspb.LogEntry{{NanoTs: 1, Message: "hello"}}
+			if err := s.StreamLogEntries(context.Background(), entries); err != nil {
+				t.Fatalf("StreamLogEntries: %v", err)
+			}
+			if len(reqs) != 1 {
+				t.Fatalf("Expect 1 request, got %d", len(reqs))
+			}
+			if auth := reqs[0].Header.Get("Authorization"); auth != tc.expected {
+				t.Errorf("Expect Authorization %q, got %q", tc.expected, auth)
+			}
+		})
+	}
+}
+
+// stubReply is a stub bulk reply.
+type stubReply struct {
+	status int
+	body   string
+}
+
+func TestStreamerRetry(t *testing.T) {
+	const accepted = `{"create":{"status":201}}`
+	entries := []*logspb.LogEntry{{NanoTs: 1, Message: "a"}, {NanoTs: 2, Message: "b"}, {NanoTs: 3, Message: "c"}}
+	testCases := []struct {
+		name     string
+		replies  []stubReply
+		failed   bool
+		requests []int
+		errors   []string
+	}{
+		{
+			name:     "too many requests",
+			replies:  []stubReply{{status: 429}, {status: 200, body: `{"errors":false}`}},
+			requests: []int{3, 3},
+		},
+		{
+			name:     "server errors exhausted",
+			replies:  []stubReply{{status: 503}, {status: 502}, {status: 500}},
+			failed:   true,
+			requests: []int{3, 3, 3},
+		},
+		{
+			name:     "not retryable",
+			replies:  []stubReply{{status: 400}},
+			failed:   true,
+			requests: []int{3},
+		},
+		{
+			name: "partial failures",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[` + accepted + `,{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` + accepted + `]}`},
+				{status: 200, body: `{"errors":false,"items":[` + accepted + `]}`},
+			},
+			requests: []int{3, 1},
+		},
+		{
+			name: "partial permanent failures",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}},` + accepted + `,` + accepted + `]}`},
+			},
+			failed:   true,
+			requests: []int{3},
+		},
+		{
+			name: "permanent and retried failures",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}},{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` + accepted + `]}`},
+				{status: 200, body: `{"errors":false,"items":[` + accepted + `]}`},
+			},
+			failed:   true,
+			requests: []int{3, 1},
+			errors:   []string{"mapper_parsing_exception"},
+		},
+		{
+			name: "permanent and exhausted failures",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}},{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` + accepted + `]}`},
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":503,"error":{"type":"unavailable_shards_exception"}}}]}`},
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`},
+			},
+			failed:   true,
+			requests: []int{3, 1, 1},
+			errors:   []string{"mapper_parsing_exception", "es_rejected_execution_exception"},
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []int
+			s := NewStreamer("client", "logs", "http://localhost:9200")
+			s.RetryDelay = time.Millisecond
+			s.Client = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				data, _ := io.ReadAll(req.Body)
+				// Each entry is encoded in 2 lines: the action and the document.
+				requests = append(requests, strings.Count(string(data), "\n")/2)
+				reply := tc.replies[len(requests)-1]
+				return &http.Response{
+					StatusCode: reply.status,
+					Body:       io.NopCloser(strings.NewReader(reply.body)),
+					Request:    req,
+				}, nil
+			})}
+			err := s.StreamLogEntries(context.Background(), entries)
+			if failed := err != nil; failed != tc.failed {
+				t.Errorf("Expect failed %v, got error %v", tc.failed, err)
+			}
+			if !reflect.DeepEqual(requests, tc.requests) {
+				t.Errorf("Expect requests with entries %v, got %v", tc.requests, requests)
+			}
+			for _, str := range tc.errors {
+				if err == nil || !strings.Contains(err.Error(), str) {
+					t.Errorf("Expect %q in error %v", str, err)
+				}
+			}
+		})
+	}
+}
+
+type recordingEmitter struct {
+	entries []*logspb.LogEntry
+}
+
+func (e *recordingEmitter) EmitLogEntry(entry *logspb.LogEntry) {
+	e.entries = append(e.entries, entry)
+}
+
+func TestStreamerDeadLetter(t *testing.T) {
+	const accepted = `{"create":{"status":201}}`
+	attrs := map[string]*logspb.Value{
+		"b": {Value: &logspb.Value_StrValue{StrValue: "1"}},
+		"a": {Value: &logspb.Value_StrValue{StrValue: "2"}},
+	}
+	testCases := []struct {
+		name        string
+		replies     []stubReply
+		failed      bool
+		deadLetters map[string]string
+	}{
+		{
+			name: "permanent rejection",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}},` + accepted + `,` + accepted + `]}`},
+			},
+			deadLetters: map[string]string{"a": "mapper_parsing_exception: bad field"},
+		},
+		{
+			name: "retries exhausted",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[` + accepted + `,{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` + accepted + `]}`},
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`},
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":503,"error":{"type":"unavailable_shards_exception"}}}]}`},
+			},
+			deadLetters: map[string]string{"b": "failed after 3 attempts"},
+		},
+		{
+			name:        "server errors exhausted",
+			replies:     []stubReply{{status: 503}, {status: 502}, {status: 500}},
+			deadLetters: map[string]string{"a": "failed after 3 attempts", "b": "failed after 3 attempts", "c": "failed after 3 attempts"},
+		},
+		{
+			name: "permanent and exhausted",
+			replies: []stubReply{
+				{status: 200, body: `{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}},{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` + accepted + `]}`},
+				{status: 503},
+				{status: 503},
+			},
+			deadLetters: map[string]string{"a": "mapper_parsing_exception", "b": "failed after 3 attempts"},
+		},
+		{
+			name:    "not retryable",
+			replies: []stubReply{{status: 401}},
+			failed:  true,
+		},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			entries := []*logspb.LogEntry{
+				{NanoTs: 1, Message: "a", Attributes: attrs, AttributeOrder: []string{"b", "a"}},
+				{NanoTs: 2, Message: "b", Attributes: attrs, AttributeOrder: []string{"b", "a"}},
+				{NanoTs: 3, Message: "c", Attributes: attrs, AttributeOrder: []string{"b", "a"}},
+			}
+			var requests int
+			deadLetter := &recordingEmitter{}
+			s := NewStreamer("client", "logs", "http://localhost:9200")
+			s.RetryDelay = time.Millisecond
+			s.DeadLetter = deadLetter
+			s.Client = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				reply := tc.replies[requests]
+				requests++
+				return &http.Response{
+					StatusCode: reply.status,
+					Body:       io.NopCloser(strings.NewReader(reply.body)),
+					Request:    req,
+				}, nil
+			})}
+			err := s.StreamLogEntries(context.Background(), entries)
+			if failed := err != nil; failed != tc.failed {
+				t.Errorf("Expect failed %v, got error %v", tc.failed, err)
+			}
+			if len(deadLetter.entries) != len(tc.deadLetters) {
+				t.Fatalf("Expect %d dead letters, got %d", len(tc.deadLetters), len(deadLetter.entries))
+			}
+			for _, entry := range deadLetter.entries {
+				reason := entry.GetAttributes()[DeadLetterReasonAttr].GetStrValue()
+				if expected, ok := tc.deadLetters[entry.GetMessage()]; !ok || !strings.HasPrefix(reason, expected) {
+					t.Errorf("Unexpected dead letter %q with reason %q", entry.GetMessage(), reason)
+				}
+				if order := entry.GetAttributeOrder(); !reflect.DeepEqual(order, []string{"b", "a", DeadLetterReasonAttr}) {
+					t.Errorf("Unexpected attribute order %v", order)
+				}
+			}
+			for _, entry := range entries {
+				if _, ok := entry.GetAttributes()[DeadLetterReasonAttr]; ok {
+					t.Errorf("Entry %q is modified", entry.GetMessage())
+				}
+			}
+		})
+	}
+}
+
+func TestStreamerRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var requests int
+	s := NewStreamer("client", "logs", "http://localhost:9200")
+	s.RetryDelay = time.Hour
+	s.Client = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		cancel()
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})}
+	err := s.StreamLogEntries(ctx, []*logspb.LogEntry{{NanoTs: 1, Message: "a"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expect canceled, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expect 1 request, got %d", requests)
+	}
+}
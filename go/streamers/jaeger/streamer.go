@@ -16,11 +16,21 @@ import (
 	"github.com/evo-cloud/logs/go/logs"
 )
 
+const (
+	defaultMaxOpenSpans = 10000
+	defaultSpanTTL      = time.Hour
+)
+
 // Reporter implements logs.ChunkedStreamer.
 type Reporter struct {
+	// ProcessTags are attached to the Process of every batch, e.g. hostname, version.
+	ProcessTags map[string]*logspb.Value
+
 	name      string
 	conn      *grpc.ClientConn
 	assembler logs.SpanAssembler
+	// evicted keeps the evicted partial spans to be reported in the current batch.
+	evicted logs.EvictedSpans
 }
 
 type batchStreamer struct {
@@ -41,7 +51,18 @@ func New(clientName, serverAddr string, tlsConf *tls.Config) (*Reporter, error)
 	if err != nil {
 		return nil, err
 	}
-	return &Reporter{name: clientName, conn: conn}, nil
+	r := &Reporter{name: clientName, conn: conn}
+	r.assembler.MaxOpenSpans = defaultMaxOpenSpans
+	r.assembler.TTL = defaultSpanTTL
+	r.assembler.OnEvict = r.evicted.Add
+	return r, nil
+}
+
+// SetProcessTags sets the attributes as ProcessTags.
+func (r *Reporter) SetProcessTags(attrs ...logs.AttributeSetter) *Reporter {
+	r.ProcessTags = make(map[string]*logspb.Value)
+	logs.AttributeSetters(attrs).SetAttributes(r.ProcessTags)
+	return r
 }
 
 // StartStreamInChunk implements logs.ChunkedStreamer.
@@ -51,6 +72,7 @@ func (r *Reporter) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo)
 		batch: jaegerpb.Batch{
 			Process: &jaegerpb.Process{
 				ServiceName: r.name,
+				Tags:        attrsToKVs(r.ProcessTags),
 			},
 		},
 	}, nil
@@ -59,79 +81,97 @@ func (r *Reporter) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo)
 // StreamLogEntry implements logs.ChunkedLogStreamer.
 func (s *batchStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
 	s.lastNanoTS = entry.NanoTs
-	span := s.reporter.assembler.AddLogEntry(entry)
-	if span != nil {
-		tid, sid, err := parseIDs(span.GetContext())
+	if span := s.reporter.assembler.AddLogEntry(entry); span != nil {
+		s.addSpan(span)
+	}
+	for _, span := range s.reporter.evicted.Take() {
+		s.addSpan(span)
+	}
+	return nil
+}
+
+func (s *batchStreamer) addSpan(span *logspb.Span) {
+	tid, sid, err := parseIDs(span.GetContext())
+	if err != nil {
+		logs.Emergent().Error(err).PrintErr("Jaeger: invalid TraceID or SpanID: ")
+		return
+	}
+	jspan := &jaegerpb.Span{
+		TraceID:       tid,
+		SpanID:        sid,
+		OperationName: span.GetName(),
+		StartTime:     time.Unix(0, span.StartNs),
+		Duration:      time.Duration(span.Duration) * time.Nanosecond,
+		Tags:          attrsToKVs(span.Attributes),
+		Flags:         jaegerpb.Flags(span.GetContext().GetFlags()),
+	}
+	for _, link := range span.Links {
+		ltid, lsid, err := parseIDs(link.GetSpanContext())
 		if err != nil {
-			logs.Emergent().Error(err).PrintErr("Jaeger: invalid TraceID or SpanID: ")
-			return nil
+			continue
+		}
+		ref := jaegerpb.SpanRef{
+			TraceID: ltid,
+			SpanID:  lsid,
+		}
+		switch link.GetType() {
+		case logspb.Link_CHILD_OF:
+			ref.RefType = jaegerpb.SpanRefType_CHILD_OF
+		case logspb.Link_FOLLOW:
+			ref.RefType = jaegerpb.SpanRefType_FOLLOWS_FROM
 		}
-		jspan := &jaegerpb.Span{
-			TraceID:       tid,
-			SpanID:        sid,
-			OperationName: span.GetName(),
-			StartTime:     time.Unix(0, span.StartNs),
-			Duration:      time.Duration(span.Duration) * time.Nanosecond,
-			Tags:          attrsToKVs(span.Attributes),
+		jspan.References = append(jspan.References, ref)
+	}
+	for _, entry := range span.Logs {
+		switch entry.GetTrace().GetEvent().(type) {
+		case *logspb.Trace_SpanStart_, *logspb.Trace_SpanEnd_:
+			continue
 		}
-		for _, link := range span.Links {
-			ltid, lsid, err := parseIDs(link.GetSpanContext())
-			if err != nil {
-				continue
-			}
-			ref := jaegerpb.SpanRef{
-				TraceID: ltid,
-				SpanID:  lsid,
-			}
-			switch link.GetType() {
-			case logspb.Link_CHILD_OF:
-				ref.RefType = jaegerpb.SpanRefType_CHILD_OF
-			case logspb.Link_FOLLOW:
-				ref.RefType = jaegerpb.SpanRefType_FOLLOWS_FROM
-			}
-			jspan.References = append(jspan.References, ref)
+		l := jaegerpb.Log{
+			Timestamp: time.Unix(0, entry.NanoTs),
+			Fields:    attrsToKVs(entry.Attributes),
 		}
-		for _, entry := range span.Logs {
-			switch entry.GetTrace().GetEvent().(type) {
-			case *logspb.Trace_SpanStart_, *logspb.Trace_SpanEnd_:
-				continue
-			}
-			l := jaegerpb.Log{
-				Timestamp: time.Unix(0, entry.NanoTs),
-				Fields:    attrsToKVs(entry.Attributes),
-			}
-			if entry.Level != logspb.LogEntry_NONE {
-				l.Fields = append(l.Fields, jaegerpb.KeyValue{
-					Key:   "level",
-					VType: jaegerpb.ValueType_STRING,
-					VStr:  entry.Level.String(),
-				})
-			}
-			if entry.Location != "" {
-				l.Fields = append(l.Fields, jaegerpb.KeyValue{
-					Key:   "source",
-					VType: jaegerpb.ValueType_STRING,
-					VStr:  entry.Location,
-				})
-			}
+		if entry.Level != logspb.LogEntry_NONE {
+			l.Fields = append(l.Fields, jaegerpb.KeyValue{
+				Key:   "level",
+				VType: jaegerpb.ValueType_STRING,
+				VStr:  entry.Level.String(),
+			})
+		}
+		if entry.Location != "" {
+			l.Fields = append(l.Fields, jaegerpb.KeyValue{
+				Key:   "source",
+				VType: jaegerpb.ValueType_STRING,
+				VStr:  entry.Location,
+			})
+		}
+		if ev := entry.GetTrace().GetSpanEvent(); ev != nil {
+			l.Fields = append(l.Fields, jaegerpb.KeyValue{
+				Key:   "event",
+				VType: jaegerpb.ValueType_STRING,
+				VStr:  ev.GetName(),
+			})
+		} else {
 			l.Fields = append(l.Fields, jaegerpb.KeyValue{
 				Key:   "message",
 				VType: jaegerpb.ValueType_STRING,
 				VStr:  entry.Message,
 			})
-			jspan.Logs = append(jspan.Logs, l)
 		}
-		s.batch.Spans = append(s.batch.Spans, jspan)
+		jspan.Logs = append(jspan.Logs, l)
 	}
-	return nil
+	s.batch.Spans = append(s.batch.Spans, jspan)
 }
 
 // StreamEnd implements logs.ChunkedLogStreamer.
+// The entries are acknowledged even if posting the spans fails, as the spans
+// already assembled can't be assembled again from the same entries, but the
+// error is returned to be reported, e.g. to a circuit breaker.
 func (s *batchStreamer) StreamEnd(ctx context.Context) (int64, error) {
 	if len(s.batch.Spans) > 0 {
 		client := jaegerapi.NewCollectorServiceClient(s.reporter.conn)
 		if _, err := client.PostSpans(ctx, &jaegerapi.PostSpansRequest{Batch: s.batch}); err != nil {
-			logs.Emergent().Error(err).PrintErr("Post: ")
+			return s.lastNanoTS, fmt.Errorf("post spans: %w", err)
 		}
 	}
 	return s.lastNanoTS, nil
@@ -154,9 +194,14 @@ func parseIDs(ctx *logspb.SpanContext) (tid jaegerpb.TraceID, sid jaegerpb.SpanI
 }
 
 func attrsToKVs(attrs map[string]*logspb.Value) []jaegerpb.KeyValue {
-	kvs := make([]jaegerpb.KeyValue, 0, len(attrs))
+	return appendKVs(make([]jaegerpb.KeyValue, 0, len(attrs)), "", attrs)
+}
+
+// appendKVs appends attributes as KeyValues, and nested attributes are flattened
+// using dotted keys.
+func appendKVs(kvs []jaegerpb.KeyValue, prefix string, attrs map[string]*logspb.Value) []jaegerpb.KeyValue {
 	for key, attr := range attrs {
-		kv := jaegerpb.KeyValue{Key: key}
+		kv := jaegerpb.KeyValue{Key: prefix + key}
 		switch v := attr.GetValue().(type) {
 		case *logspb.Value_BoolValue:
 			kv.VType, kv.VBool = jaegerpb.ValueType_BOOL, v.BoolValue
@@ -172,6 +217,17 @@ func attrsToKVs(attrs map[string]*logspb.Value) []jaegerpb.KeyValue {
 			kv.VType, kv.VStr = jaegerpb.ValueType_STRING, v.Json
 		case *logspb.Value_Proto:
 			kv.VType, kv.VBinary = jaegerpb.ValueType_BINARY, v.Proto
+		case *logspb.Value_Bytes:
+			kv.VType, kv.VBinary = jaegerpb.ValueType_BINARY, v.Bytes
+		case *logspb.Value_MapValue:
+			kvs = appendKVs(kvs, kv.Key+".", v.MapValue.GetValues())
+			continue
+		case *logspb.Value_Duration:
+			kv.VType, kv.VStr = jaegerpb.ValueType_STRING, time.Duration(v.Duration).String()
+		case *logspb.Value_Time:
+			kv.VType, kv.VStr = jaegerpb.ValueType_STRING, time.Unix(0, v.Time).UTC().Format(time.RFC3339Nano)
+		case *logspb.Value_Decimal:
+			kv.VType, kv.VStr = jaegerpb.ValueType_STRING, v.Decimal
 		default:
 			continue
 		}
@@ -0,0 +1,133 @@
+package jaeger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	jaegerpb "github.com/jaegertracing/jaeger/model"
+	jaegerapi "github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+// collectorRecorder records the received PostSpans requests.
+type collectorRecorder struct {
+	lock sync.Mutex
+	reqs []*jaegerapi.PostSpansRequest
+	err  error
+}
+
+func (r *collectorRecorder) PostSpans(ctx context.Context, req *jaegerapi.PostSpansRequest) (*jaegerapi.PostSpansResponse, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reqs = append(r.reqs, req)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &jaegerapi.PostSpansResponse{}, nil
+}
+
+func TestProcessTags(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	recorder := &collectorRecorder{}
+	srv := grpc.NewServer()
+	jaegerapi.RegisterCollectorServiceServer(srv, recorder)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	reporter, err := New("client", ln.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	reporter.SetProcessTags(logs.Str("hostname", "host1"), logs.Str("env", "prod"))
+
+	spanCtx := &logspb.SpanContext{TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, SpanId: 1}
+	ctx := context.Background()
+	for n := 0; n < 2; n++ {
+		s, err := reporter.StartStreamInChunk(ctx, logs.ChunkInfo{NumEntries: 2})
+		if err != nil {
+			t.Fatalf("StartStreamInChunk: %v", err)
+		}
+		s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 1, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span"}}}})
+		s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 2, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}})
+		if _, err := s.StreamEnd(ctx); err != nil {
+			t.Fatalf("StreamEnd: %v", err)
+		}
+	}
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if len(recorder.reqs) != 2 {
+		t.Fatalf("Expect 2 PostSpans requests, got %d", len(recorder.reqs))
+	}
+	expected := map[string]string{"hostname": "host1", "env": "prod"}
+	for _, req := range recorder.reqs {
+		process := req.Batch.Process
+		if process.GetServiceName() != "client" {
+			t.Errorf("Expect service name client, got %q", process.GetServiceName())
+		}
+		tags := make(map[string]string)
+		for _, tag := range process.GetTags() {
+			if tag.VType != jaegerpb.ValueType_STRING {
+				t.Errorf("Expect tag %s of type string, got %v", tag.Key, tag.VType)
+			}
+			tags[tag.Key] = tag.VStr
+		}
+		if !reflect.DeepEqual(tags, expected) {
+			t.Errorf("Expect process tags %v, got %v", expected, tags)
+		}
+	}
+}
+
+func TestPostSpansErrorOpensBreaker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	recorder := &collectorRecorder{err: status.Error(codes.Unavailable, "unavailable")}
+	srv := grpc.NewServer()
+	jaegerapi.RegisterCollectorServiceServer(srv, recorder)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	reporter, err := New("client", ln.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	breaker := &logs.CircuitBreaker{Name: "jaeger", Threshold: 1, Cooldown: time.Hour}
+	streamer := &logs.BreakerChunkedStreamer{Streamer: reporter, Breaker: breaker}
+
+	spanCtx := &logspb.SpanContext{TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, SpanId: 1}
+	ctx := context.Background()
+	s, err := streamer.StartStreamInChunk(ctx, logs.ChunkInfo{NumEntries: 2})
+	if err != nil {
+		t.Fatalf("StartStreamInChunk: %v", err)
+	}
+	s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 1, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span"}}}})
+	s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 2, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}})
+	lastTS, err := s.StreamEnd(ctx)
+	if status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Fatalf("Expect Unavailable from StreamEnd, got %v", err)
+	}
+	if lastTS != 2 {
+		t.Errorf("Expect entries acknowledged up to 2, got %d", lastTS)
+	}
+	if state := breaker.State(); state != logs.BreakerOpen {
+		t.Errorf("Expect breaker open, got %v", state)
+	}
+	if _, err := streamer.StartStreamInChunk(ctx, logs.ChunkInfo{}); !errors.Is(err, logs.ErrCircuitOpen) {
+		t.Errorf("Expect ErrCircuitOpen, got %v", err)
+	}
+}
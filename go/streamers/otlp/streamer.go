@@ -0,0 +1,283 @@
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+const (
+	// ScopeName is the instrumentation scope name of exported spans.
+	ScopeName = "github.com/evo-cloud/logs"
+	// RefTypeAttr is the attribute of a span link indicating the link type,
+	// following the convention of the OpenTracing shim.
+	RefTypeAttr = "opentracing.ref_type"
+
+	defaultMaxOpenSpans = 10000
+	defaultSpanTTL      = time.Hour
+)
+
+// Exporter implements logs.ChunkedStreamer.
+type Exporter struct {
+	name      string
+	conn      *grpc.ClientConn
+	assembler logs.SpanAssembler
+	// evicted keeps the evicted partial spans to be exported in the current batch.
+	evicted logs.EvictedSpans
+}
+
+type batchStreamer struct {
+	exporter   *Exporter
+	lastNanoTS int64
+	spans      []*tracepb.Span
+}
+
+// New creates an Exporter with an OTLP gRPC client.
+func New(clientName, serverAddr string, tlsConf *tls.Config) (*Exporter, error) {
+	var options []grpc.DialOption
+	if tlsConf != nil {
+		options = append(options, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
+	} else {
+		options = append(options, grpc.WithInsecure())
+	}
+	conn, err := grpc.Dial(serverAddr, options...)
+	if err != nil {
+		return nil, err
+	}
+	e := &Exporter{name: clientName, conn: conn}
+	e.assembler.MaxOpenSpans = defaultMaxOpenSpans
+	e.assembler.TTL = defaultSpanTTL
+	e.assembler.OnEvict = e.evicted.Add
+	return e, nil
+}
+
+// Close closes the underlying gRPC connection.
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
+
+// StartStreamInChunk implements logs.ChunkedStreamer.
+func (e *Exporter) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo) (logs.ChunkedLogStreamer, error) {
+	return &batchStreamer{exporter: e}, nil
+}
+
+// StreamLogEntry implements logs.ChunkedLogStreamer.
+func (s *batchStreamer) StreamLogEntry(ctx context.Context, entry *logspb.LogEntry) error {
+	s.lastNanoTS = entry.NanoTs
+	if span := s.exporter.assembler.AddLogEntry(entry); span != nil {
+		s.addSpan(span)
+	}
+	for _, span := range s.exporter.evicted.Take() {
+		s.addSpan(span)
+	}
+	return nil
+}
+
+func (s *batchStreamer) addSpan(span *logspb.Span) {
+	ospan, err := ConvertSpan(span)
+	if err != nil {
+		logs.Emergent().Error(err).PrintErr("OTLP: ")
+		return
+	}
+	s.spans = append(s.spans, ospan)
+}
+
+// StreamEnd implements logs.ChunkedLogStreamer.
+// The entries are acknowledged even if exporting the spans fails, as the spans
+// already assembled can't be assembled again from the same entries, but the
+// error is returned to be reported, e.g. to a circuit breaker.
+func (s *batchStreamer) StreamEnd(ctx context.Context) (int64, error) {
+	if len(s.spans) > 0 {
+		client := collectorpb.NewTraceServiceClient(s.exporter.conn)
+		if _, err := client.Export(ctx, s.exporter.exportRequest(s.spans)); err != nil {
+			return s.lastNanoTS, fmt.Errorf("export spans: %w", err)
+		}
+	}
+	return s.lastNanoTS, nil
+}
+
+func (e *Exporter) exportRequest(spans []*tracepb.Span) *collectorpb.ExportTraceServiceRequest {
+	return &collectorpb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{
+			{
+				Resource: &resourcepb.Resource{
+					Attributes: []*commonpb.KeyValue{strKV("service.name", e.name)},
+				},
+				ScopeSpans: []*tracepb.ScopeSpans{
+					{
+						Scope: &commonpb.InstrumentationScope{Name: ScopeName},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// ConvertSpan converts an assembled span into an OTLP span.
+// The first CHILD_OF link is the parent, and the other links are kept as
+// OTLP links with the link type in RefTypeAttr.
+func ConvertSpan(span *logspb.Span) (*tracepb.Span, error) {
+	traceID, spanID, err := convertIDs(span.GetContext())
+	if err != nil {
+		return nil, err
+	}
+	ospan := &tracepb.Span{
+		TraceId:           traceID,
+		SpanId:            spanID,
+		Flags:             convertFlags(span.GetContext()),
+		Name:              span.GetName(),
+		Kind:              convertKind(span.GetKind()),
+		StartTimeUnixNano: uint64(span.GetStartNs()),
+		EndTimeUnixNano:   uint64(span.GetStartNs() + span.GetDuration()),
+		Attributes:        attrsToKVs(span.GetAttributes()),
+	}
+	for _, link := range span.GetLinks() {
+		ltid, lsid, err := convertIDs(link.GetSpanContext())
+		if err != nil {
+			continue
+		}
+		if link.GetType() == logspb.Link_CHILD_OF && ospan.ParentSpanId == nil {
+			ospan.ParentSpanId = lsid
+			continue
+		}
+		olink := &tracepb.Span_Link{
+			TraceId:    ltid,
+			SpanId:     lsid,
+			Flags:      convertFlags(link.GetSpanContext()),
+			Attributes: attrsToKVs(link.GetAttributes()),
+		}
+		switch link.GetType() {
+		case logspb.Link_CHILD_OF:
+			olink.Attributes = append(olink.Attributes, strKV(RefTypeAttr, "child_of"))
+		case logspb.Link_FOLLOW:
+			olink.Attributes = append(olink.Attributes, strKV(RefTypeAttr, "follows_from"))
+		}
+		ospan.Links = append(ospan.Links, olink)
+	}
+	for _, entry := range span.GetLogs() {
+		switch entry.GetTrace().GetEvent().(type) {
+		case *logspb.Trace_SpanStart_, *logspb.Trace_SpanEnd_:
+			continue
+		}
+		event := &tracepb.Span_Event{
+			TimeUnixNano: uint64(entry.GetNanoTs()),
+			Name:         entry.GetMessage(),
+			Attributes:   attrsToKVs(entry.GetAttributes()),
+		}
+		if ev := entry.GetTrace().GetSpanEvent(); ev != nil {
+			event.Name = ev.GetName()
+		}
+		if entry.Level != logspb.LogEntry_NONE {
+			event.Attributes = append(event.Attributes, strKV("level", entry.Level.String()))
+		}
+		if entry.Location != "" {
+			event.Attributes = append(event.Attributes, strKV("source", entry.Location))
+		}
+		ospan.Events = append(ospan.Events, event)
+	}
+	return ospan, nil
+}
+
+func convertIDs(ctx *logspb.SpanContext) (traceID, spanID []byte, err error) {
+	if !logs.IsTraceIDValid(ctx.GetTraceId()) {
+		return nil, nil, fmt.Errorf("invalid trace ID")
+	}
+	if ctx.GetSpanId() == 0 {
+		return nil, nil, fmt.Errorf("invalid span ID")
+	}
+	spanID = make([]byte, 8)
+	binary.BigEndian.PutUint64(spanID, ctx.GetSpanId())
+	return ctx.GetTraceId(), spanID, nil
+}
+
+// convertFlags converts trace flags into W3C trace flags, which only define the sampled bit.
+func convertFlags(ctx *logspb.SpanContext) uint32 {
+	if logs.IsSampled(ctx) {
+		return logs.TraceFlagSampled
+	}
+	return 0
+}
+
+func convertKind(kind logspb.Span_Kind) tracepb.Span_SpanKind {
+	switch kind {
+	case logspb.Span_INTERNAL:
+		return tracepb.Span_SPAN_KIND_INTERNAL
+	case logspb.Span_SERVER:
+		return tracepb.Span_SPAN_KIND_SERVER
+	case logspb.Span_CLIENT:
+		return tracepb.Span_SPAN_KIND_CLIENT
+	case logspb.Span_PRODUCER:
+		return tracepb.Span_SPAN_KIND_PRODUCER
+	case logspb.Span_CONSUMER:
+		return tracepb.Span_SPAN_KIND_CONSUMER
+	}
+	return tracepb.Span_SPAN_KIND_UNSPECIFIED
+}
+
+func strKV(key, val string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: val}}}
+}
+
+// attrsToKVs converts attributes into KeyValues sorted by keys, and nested
+// attributes are kept as KeyValueLists.
+func attrsToKVs(attrs map[string]*logspb.Value) []*commonpb.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kvs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		if val := convertValue(attrs[key]); val != nil {
+			kvs = append(kvs, &commonpb.KeyValue{Key: key, Value: val})
+		}
+	}
+	return kvs
+}
+
+func convertValue(attr *logspb.Value) *commonpb.AnyValue {
+	switch v := attr.GetValue().(type) {
+	case *logspb.Value_BoolValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.BoolValue}}
+	case *logspb.Value_IntValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.IntValue}}
+	case *logspb.Value_FloatValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v.FloatValue)}}
+	case *logspb.Value_DoubleValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.DoubleValue}}
+	case *logspb.Value_StrValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.StrValue}}
+	case *logspb.Value_Json:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Json}}
+	case *logspb.Value_Proto:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v.Proto}}
+	case *logspb.Value_Bytes:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v.Bytes}}
+	case *logspb.Value_MapValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: attrsToKVs(v.MapValue.GetValues())}}}
+	case *logspb.Value_Duration:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: time.Duration(v.Duration).String()}}
+	case *logspb.Value_Time:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: time.Unix(0, v.Time).UTC().Format(time.RFC3339Nano)}}
+	case *logspb.Value_Decimal:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Decimal}}
+	}
+	return nil
+}
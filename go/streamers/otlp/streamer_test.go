@@ -0,0 +1,200 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+	"github.com/evo-cloud/logs/go/logs"
+)
+
+var (
+	testTraceID = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	testOtherID = []byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+)
+
+func TestConvertSpanKind(t *testing.T) {
+	testCases := []struct {
+		kind     logspb.Span_Kind
+		expected tracepb.Span_SpanKind
+	}{
+		{kind: logspb.Span_UNSPECIFIED, expected: tracepb.Span_SPAN_KIND_UNSPECIFIED},
+		{kind: logspb.Span_INTERNAL, expected: tracepb.Span_SPAN_KIND_INTERNAL},
+		{kind: logspb.Span_SERVER, expected: tracepb.Span_SPAN_KIND_SERVER},
+		{kind: logspb.Span_CLIENT, expected: tracepb.Span_SPAN_KIND_CLIENT},
+		{kind: logspb.Span_PRODUCER, expected: tracepb.Span_SPAN_KIND_PRODUCER},
+		{kind: logspb.Span_CONSUMER, expected: tracepb.Span_SPAN_KIND_CONSUMER},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.kind.String(), func(t *testing.T) {
+			span, err := ConvertSpan(&logspb.Span{
+				Context: &logspb.SpanContext{TraceId: testTraceID, SpanId: 1},
+				Kind:    tc.kind,
+			})
+			if err != nil {
+				t.Fatalf("ConvertSpan: %v", err)
+			}
+			if span.GetKind() != tc.expected {
+				t.Errorf("Expect kind %v, got %v", tc.expected, span.GetKind())
+			}
+		})
+	}
+}
+
+func TestConvertSpan(t *testing.T) {
+	span, err := ConvertSpan(&logspb.Span{
+		Context:  &logspb.SpanContext{TraceId: testTraceID, SpanId: 0x0102030405060708, Flags: logs.TraceFlagSampled | logs.TraceFlagDebug},
+		Name:     "handle",
+		Kind:     logspb.Span_SERVER,
+		StartNs:  1000,
+		Duration: 500,
+		Attributes: map[string]*logspb.Value{
+			"method":  {Value: &logspb.Value_StrValue{StrValue: "GET"}},
+			"status":  {Value: &logspb.Value_IntValue{IntValue: 200}},
+			"elapsed": {Value: &logspb.Value_Duration{Duration: int64(time.Second)}},
+			"req": {Value: &logspb.Value_MapValue{MapValue: &logspb.MapValue{Values: map[string]*logspb.Value{
+				"id": {Value: &logspb.Value_BoolValue{BoolValue: true}},
+			}}}},
+		},
+		Links: []*logspb.Link{
+			{SpanContext: &logspb.SpanContext{TraceId: testTraceID, SpanId: 2, Flags: logs.TraceFlagSampled}, Type: logspb.Link_CHILD_OF},
+			{SpanContext: &logspb.SpanContext{TraceId: testOtherID, SpanId: 3}, Type: logspb.Link_FOLLOW},
+			{SpanContext: &logspb.SpanContext{TraceId: testOtherID, SpanId: 4}, Type: logspb.Link_CHILD_OF},
+			{SpanContext: &logspb.SpanContext{SpanId: 5}, Type: logspb.Link_FOLLOW},
+		},
+		Logs: []*logspb.LogEntry{
+			{NanoTs: 1000, Trace: &logspb.Trace{Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "handle"}}}},
+			{NanoTs: 1100, Level: logspb.LogEntry_INFO, Location: "main.go:10", Message: "hello"},
+			{NanoTs: 1200, Trace: &logspb.Trace{Event: &logspb.Trace_SpanEvent_{SpanEvent: &logspb.Trace_SpanEvent{Name: "retry"}}}},
+			{NanoTs: 1500, Trace: &logspb.Trace{Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ConvertSpan: %v", err)
+	}
+	expected := &tracepb.Span{
+		TraceId:           testTraceID,
+		SpanId:            []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		ParentSpanId:      []byte{0, 0, 0, 0, 0, 0, 0, 2},
+		Flags:             1,
+		Name:              "handle",
+		Kind:              tracepb.Span_SPAN_KIND_SERVER,
+		StartTimeUnixNano: 1000,
+		EndTimeUnixNano:   1500,
+		Attributes: []*commonpb.KeyValue{
+			strKV("elapsed", "1s"),
+			strKV("method", "GET"),
+			{Key: "req", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: []*commonpb.KeyValue{
+				{Key: "id", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: true}}},
+			}}}}},
+			{Key: "status", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 200}}},
+		},
+		Links: []*tracepb.Span_Link{
+			{TraceId: testOtherID, SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 3}, Attributes: []*commonpb.KeyValue{strKV(RefTypeAttr, "follows_from")}},
+			{TraceId: testOtherID, SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 4}, Attributes: []*commonpb.KeyValue{strKV(RefTypeAttr, "child_of")}},
+		},
+		Events: []*tracepb.Span_Event{
+			{TimeUnixNano: 1100, Name: "hello", Attributes: []*commonpb.KeyValue{strKV("level", "INFO"), strKV("source", "main.go:10")}},
+			{TimeUnixNano: 1200, Name: "retry"},
+		},
+	}
+	if !proto.Equal(span, expected) {
+		t.Errorf("Expect span:\n%v\ngot:\n%v", expected, span)
+	}
+}
+
+func TestConvertSpanInvalidIDs(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  *logspb.SpanContext
+	}{
+		{name: "no trace ID", ctx: &logspb.SpanContext{SpanId: 1}},
+		{name: "short trace ID", ctx: &logspb.SpanContext{TraceId: testTraceID[:8], SpanId: 1}},
+		{name: "no span ID", ctx: &logspb.SpanContext{TraceId: testTraceID}},
+	}
+	for n := range testCases {
+		tc := testCases[n]
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ConvertSpan(&logspb.Span{Context: tc.ctx}); err == nil {
+				t.Errorf("Expect error")
+			}
+		})
+	}
+}
+
+func TestExportRequest(t *testing.T) {
+	e := &Exporter{name: "client"}
+	req := e.exportRequest([]*tracepb.Span{{Name: "span"}})
+	rs := req.GetResourceSpans()
+	if len(rs) != 1 || len(rs[0].GetScopeSpans()) != 1 {
+		t.Fatalf("Expect 1 resource span with 1 scope span, got %v", req)
+	}
+	if attrs := rs[0].GetResource().GetAttributes(); len(attrs) != 1 || !proto.Equal(attrs[0], strKV("service.name", "client")) {
+		t.Errorf("Expect service.name in resource, got %v", attrs)
+	}
+	if spans := rs[0].GetScopeSpans()[0].GetSpans(); len(spans) != 1 || spans[0].GetName() != "span" {
+		t.Errorf("Expect the span exported, got %v", spans)
+	}
+}
+
+// failingTraceService fails all Export requests.
+type failingTraceService struct {
+	collectorpb.UnimplementedTraceServiceServer
+}
+
+func (s *failingTraceService) Export(ctx context.Context, req *collectorpb.ExportTraceServiceRequest) (*collectorpb.ExportTraceServiceResponse, error) {
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestExportErrorOpensBreaker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	collectorpb.RegisterTraceServiceServer(srv, &failingTraceService{})
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	exporter, err := New("client", ln.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer exporter.Close()
+	breaker := &logs.CircuitBreaker{Name: "otlp", Threshold: 1, Cooldown: time.Hour}
+	streamer := &logs.BreakerChunkedStreamer{Streamer: exporter, Breaker: breaker}
+
+	spanCtx := &logspb.SpanContext{TraceId: testTraceID, SpanId: 1}
+	ctx := context.Background()
+	s, err := streamer.StartStreamInChunk(ctx, logs.ChunkInfo{NumEntries: 2})
+	if err != nil {
+		t.Fatalf("StartStreamInChunk: %v", err)
+	}
+	s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 1, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanStart_{SpanStart: &logspb.Trace_SpanStart{Name: "span"}}}})
+	s.StreamLogEntry(ctx, &logspb.LogEntry{NanoTs: 2, Trace: &logspb.Trace{SpanContext: spanCtx, Event: &logspb.Trace_SpanEnd_{SpanEnd: &logspb.Trace_SpanEnd{}}}})
+	lastTS, err := s.StreamEnd(ctx)
+	if status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Fatalf("Expect Unavailable from StreamEnd, got %v", err)
+	}
+	if lastTS != 2 {
+		t.Errorf("Expect entries acknowledged up to 2, got %d", lastTS)
+	}
+	if state := breaker.State(); state != logs.BreakerOpen {
+		t.Errorf("Expect breaker open, got %v", state)
+	}
+	if _, err := streamer.StartStreamInChunk(ctx, logs.ChunkInfo{}); !errors.Is(err, logs.ErrCircuitOpen) {
+		t.Errorf("Expect ErrCircuitOpen, got %v", err)
+	}
+}
@@ -2,8 +2,11 @@ package remote
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -15,17 +18,33 @@ import (
 const (
 	// RemoteMetadataKeyClientName specifies the key in gRPC context for client name.
 	RemoteMetadataKeyClientName = "logs-client"
+
+	// DefaultMinReconnectDelay is the default delay before the second reconnect attempt.
+	DefaultMinReconnectDelay = 100 * time.Millisecond
+	// DefaultMaxReconnectDelay is the default cap of the reconnect delay.
+	DefaultMaxReconnectDelay = 30 * time.Second
 )
 
+// ErrDisconnected indicates the stream is broken and the reconnect is backing off.
+var ErrDisconnected = errors.New("disconnected")
+
 // Streamer streams logs to remote server.
+// A broken stream is re-established on the next send, and consecutive
+// failures back off exponentially between MinReconnectDelay and MaxReconnectDelay.
 type Streamer struct {
-	Verbose bool
+	Verbose           bool
+	MinReconnectDelay time.Duration
+	MaxReconnectDelay time.Duration
 
 	clientName string
 	conn       *grpc.ClientConn
 
-	streamLock sync.Mutex
-	stream     logspb.IngressService_IngressStreamClient
+	streamLock  sync.Mutex
+	stream      logspb.IngressService_IngressStreamClient
+	established bool
+	failures    int
+	nextAttempt time.Time
+	lastErr     error
 }
 
 // NewStreamer creates a Streamer.
@@ -35,8 +54,10 @@ func NewStreamer(clientName, serverAddr string, grpcOpts ...grpc.DialOption) (*S
 		return nil, err
 	}
 	return &Streamer{
-		clientName: clientName,
-		conn:       conn,
+		MinReconnectDelay: DefaultMinReconnectDelay,
+		MaxReconnectDelay: DefaultMaxReconnectDelay,
+		clientName:        clientName,
+		conn:              conn,
 	}, nil
 }
 
@@ -56,39 +77,100 @@ func (s *Streamer) StreamLogEntries(ctx context.Context, entries []*logspb.LogEn
 		return err
 	}
 	err = stream.Send(&logspb.IngressBatch{Entries: entries, ChunkEnd: true})
-	if err != nil && s.Verbose {
-		return logs.Emergent().Error(err).PrintErr("Send: ")
+	if err != nil {
+		s.streamBroken(stream, err)
+		if s.Verbose {
+			return logs.Emergent().Error(err).PrintErr("Send: ")
+		}
 	}
 	return err
 }
 
+// Connected determines whether the stream to the remote server is established.
+func (s *Streamer) Connected() bool {
+	s.streamLock.Lock()
+	defer s.streamLock.Unlock()
+	return s.stream != nil
+}
+
 func (s *Streamer) ensureIngressStreamClient(ctx context.Context) (logspb.IngressService_IngressStreamClient, error) {
+	// The lock is held while establishing the stream, so concurrent sends
+	// wait for the single attempt rather than reconnecting on their own.
 	s.streamLock.Lock()
 	defer s.streamLock.Unlock()
 	if s.stream != nil {
 		return s.stream, nil
 	}
+	if wait := time.Until(s.nextAttempt); wait > 0 {
+		return nil, fmt.Errorf("%w, reconnect in %v, last error: %v", ErrDisconnected, wait.Round(time.Millisecond), s.lastErr)
+	}
+	if s.established && s.Verbose {
+		logs.Emergent().Error(s.lastErr).Printf("IngressStream: reconnecting, attempt %d", s.failures)
+	}
 	ctx = metadata.AppendToOutgoingContext(ctx, RemoteMetadataKeyClientName, s.clientName)
 	stream, err := logspb.NewIngressServiceClient(s.conn).IngressStream(ctx)
 	if err != nil {
+		s.failed(err)
 		return nil, err
 	}
 	go func() {
 		for {
 			if _, err := stream.Recv(); err != nil {
-				break
+				s.streamBroken(stream, err)
+				return
 			}
-		}
-		s.streamLock.Lock()
-		defer s.streamLock.Unlock()
-		if s.stream == stream {
-			s.stream = nil
+			s.acknowledged(stream)
 		}
 	}()
-	s.stream = stream
+	s.stream, s.established = stream, true
 	return stream, nil
 }
 
+// streamBroken drops the stream if it's still the current one.
+func (s *Streamer) streamBroken(stream logspb.IngressService_IngressStreamClient, err error) {
+	s.streamLock.Lock()
+	defer s.streamLock.Unlock()
+	if s.stream == stream {
+		s.stream = nil
+		s.failed(err)
+	}
+}
+
+// acknowledged resets the backoff once the remote server accepts the entries.
+func (s *Streamer) acknowledged(stream logspb.IngressService_IngressStreamClient) {
+	s.streamLock.Lock()
+	defer s.streamLock.Unlock()
+	if s.stream == stream {
+		s.failures, s.lastErr = 0, nil
+	}
+}
+
+// failed records a failure and schedules the next attempt. It must be called with streamLock held.
+// The first reconnect is attempted immediately, as a healthy stream may be broken by a server restart.
+func (s *Streamer) failed(err error) {
+	s.failures++
+	s.lastErr = err
+	s.nextAttempt = time.Now().Add(s.reconnectDelay(s.failures))
+}
+
+func (s *Streamer) reconnectDelay(failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	maxDelay := s.MaxReconnectDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxReconnectDelay
+	}
+	delay := s.MinReconnectDelay
+	for n := 2; n < failures && delay < maxDelay; n++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
 // StartStreamInChunk implements ChunkedStreamer.
 func (s *Streamer) StartStreamInChunk(ctx context.Context, info logs.ChunkInfo) (logs.ChunkedLogStreamer, error) {
 	ctx = metadata.AppendToOutgoingContext(ctx, RemoteMetadataKeyClientName, s.clientName)
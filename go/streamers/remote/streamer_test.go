@@ -0,0 +1,130 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	logspb "github.com/evo-cloud/logs/go/gen/proto/logs"
+)
+
+// ingressRecorder acknowledges and records the received log entries.
+type ingressRecorder struct {
+	lock    sync.Mutex
+	entries []*logspb.LogEntry
+
+	logspb.UnimplementedIngressServiceServer
+}
+
+func (r *ingressRecorder) IngressStream(stream logspb.IngressService_IngressStreamServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		r.lock.Lock()
+		r.entries = append(r.entries, msg.GetEntries()...)
+		r.lock.Unlock()
+		if err := stream.Send(&logspb.IngressEvent{LastNanoTs: msg.GetEntries()[len(msg.GetEntries())-1].GetNanoTs()}); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *ingressRecorder) numEntries() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.entries)
+}
+
+// restartableServer serves ingressRecorder on a bufconn listener which is replaced on restart.
+type restartableServer struct {
+	lock     sync.Mutex
+	ln       *bufconn.Listener
+	srv      *grpc.Server
+	recorder *ingressRecorder
+}
+
+func (s *restartableServer) start() *ingressRecorder {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ln, s.srv, s.recorder = bufconn.Listen(1<<16), grpc.NewServer(), &ingressRecorder{}
+	logspb.RegisterIngressServiceServer(s.srv, s.recorder)
+	go s.srv.Serve(s.ln)
+	return s.recorder
+}
+
+func (s *restartableServer) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.srv.Stop()
+}
+
+func (s *restartableServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+	s.lock.Lock()
+	ln := s.ln
+	s.lock.Unlock()
+	return ln.DialContext(ctx)
+}
+
+func TestStreamerReconnect(t *testing.T) {
+	server := &restartableServer{}
+	recorder := server.start()
+	defer server.stop()
+
+	s, err := NewStreamer("client", "bufnet",
+		grpc.WithContextDialer(server.dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond}}),
+	)
+	if err != nil {
+		t.Fatalf("NewStreamer: %v", err)
+	}
+	defer s.Close()
+	s.MinReconnectDelay, s.MaxReconnectDelay = 50*time.Millisecond, 100*time.Millisecond
+
+	ctx := context.Background()
+	if err := s.StreamLogEntries(ctx, []*logspb.LogEntry{{NanoTs: 1, Message: "before"}}); err != nil {
+		t.Fatalf("StreamLogEntries: %v", err)
+	}
+	waitFor(t, "entry received", func() bool { return recorder.numEntries() == 1 })
+
+	server.stop()
+	waitFor(t, "disconnected", func() bool { return !s.Connected() })
+	err = s.StreamLogEntries(ctx, []*logspb.LogEntry{{NanoTs: 2, Message: "down"}})
+	if err == nil {
+		t.Fatalf("Expect error when server is down")
+	}
+	err = s.StreamLogEntries(ctx, []*logspb.LogEntry{{NanoTs: 2, Message: "down"}})
+	if !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Expect backing off with ErrDisconnected, got %v", err)
+	}
+
+	recorder = server.start()
+	waitFor(t, "reconnected", func() bool {
+		s.StreamLogEntries(ctx, []*logspb.LogEntry{{NanoTs: 3, Message: "after"}})
+		return recorder.numEntries() > 0
+	})
+	if !s.Connected() {
+		t.Errorf("Expect connected after reconnect")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}